@@ -0,0 +1,528 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+	ctyfunction "github.com/zclconf/go-cty/cty/function"
+	"github.com/zclconf/go-cty/cty/function/stdlib"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+	"gopkg.in/yaml.v3"
+
+	"terraform-provider-sops/internal/sopsencrypt"
+)
+
+// contentModel holds the plaintext inputs of an encrypted resource. Exactly
+// one of Content, ContentObject, ContentSources and ContentTemplate is set.
+type contentModel struct {
+	Content         types.String  `tfsdk:"content"`
+	ContentObject   types.Dynamic `tfsdk:"content_object"`
+	ContentType     types.String  `tfsdk:"content_type"`
+	ContentSources  types.List    `tfsdk:"content_sources"`
+	ContentTemplate types.String  `tfsdk:"content_template"`
+	Vars            types.Dynamic `tfsdk:"vars"`
+}
+
+// validate checks that exactly one content input is set and that
+// content_type is known and applies to it.
+func (m contentModel) validate(diags *diag.Diagnostics) {
+	if m.Content.IsUnknown() || m.ContentObject.IsUnknown() || m.ContentSources.IsUnknown() || m.ContentTemplate.IsUnknown() {
+		return
+	}
+	set := 0
+	for _, null := range []bool{m.Content.IsNull(), m.ContentObject.IsNull(), m.ContentSources.IsNull(), m.ContentTemplate.IsNull()} {
+		if !null {
+			set++
+		}
+	}
+	switch {
+	case set == 0:
+		diags.AddAttributeError(path.Root("content"),
+			"Missing content",
+			"Set content, content_object, content_sources or content_template.")
+	case set > 1:
+		diags.AddAttributeError(path.Root("content"),
+			"Conflicting content",
+			"Only one of content, content_object, content_sources and content_template can be set.")
+	case !m.ContentSources.IsNull() && len(m.ContentSources.Elements()) == 0:
+		diags.AddAttributeError(path.Root("content_sources"),
+			"Missing content",
+			"content_sources must contain at least one document.")
+	case !m.ContentTemplate.IsNull():
+		if _, d := hclsyntax.ParseTemplate([]byte(m.ContentTemplate.ValueString()), "content_template", hcl.InitialPos); d.HasErrors() {
+			diags.AddAttributeError(path.Root("content_template"), "Invalid content_template",
+				sopsencrypt.Redact(d.Error(), m.ContentTemplate.ValueString()))
+		}
+	}
+	if !m.Vars.IsNull() && m.ContentTemplate.IsNull() {
+		diags.AddAttributeError(path.Root("vars"),
+			"Conflicting vars",
+			"vars only applies to content_template.")
+	}
+	switch m.ContentType.ValueString() {
+	case "", "json":
+	case "yaml":
+		if !m.ContentObject.IsNull() {
+			diags.AddAttributeError(path.Root("content_type"),
+				"Conflicting content_type",
+				"content_type does not apply to content_object, which is always encoded as JSON.")
+		}
+	default:
+		diags.AddAttributeError(path.Root("content_type"),
+			"Invalid content_type",
+			fmt.Sprintf("content_type must be \"json\" or \"yaml\", got %q.", m.ContentType.ValueString()))
+	}
+}
+
+// attr returns the path of the content input that is set.
+func (m contentModel) attr() path.Path {
+	switch {
+	case !m.ContentObject.IsNull():
+		return path.Root("content_object")
+	case !m.ContentSources.IsNull():
+		return path.Root("content_sources")
+	case !m.ContentTemplate.IsNull():
+		return path.Root("content_template")
+	}
+	return path.Root("content")
+}
+
+// planContent reports a document over max_content_size or already
+// SOPS-encrypted at plan time, once the content inputs are known. Content
+// that fails to render is left to Create, which reports why.
+func (m contentModel) planContent(ctx context.Context, pd *sopsProviderData, diags *diag.Diagnostics) {
+	if !m.known() {
+		return
+	}
+	document, inputYAML, d := m.document(ctx)
+	if !d.HasError() {
+		pd.checkContentSize(m.attr(), len(document), diags)
+		checkPlaintext(m.attr(), document, inputYAML, diags)
+	}
+}
+
+// planKeyDiff warns which top-level keys differ between the document of
+// prior, the content inputs in state, and that of m, once both render.
+func (m contentModel) planKeyDiff(ctx context.Context, prior contentModel, diags *diag.Diagnostics) {
+	if !m.known() {
+		return
+	}
+	oldDocument, oldYAML, d := prior.document(ctx)
+	if d.HasError() {
+		return
+	}
+	newDocument, newYAML, d := m.document(ctx)
+	if d.HasError() || newDocument == oldDocument {
+		return
+	}
+	warnKeyDiff(m.attr(), oldDocument, oldYAML, newDocument, newYAML, diags)
+}
+
+// warnKeyDiff warns which top-level keys a planned change of the document at
+// attr adds, removes or changes, so that a replacement forced by a sensitive
+// input can be reviewed. Only key names are reported, never values.
+// Documents that do not parse are left to encryption, which reports why.
+func warnKeyDiff(attr path.Path, oldContent string, oldYAML bool, newContent string, newYAML bool, diags *diag.Diagnostics) {
+	diff, err := sopsencrypt.DiffKeys(oldContent, oldYAML, newContent, newYAML)
+	if err != nil || diff.Empty() {
+		return
+	}
+	var detail strings.Builder
+	detail.WriteString("The new document changes these top-level keys (values are not shown):")
+	for _, change := range []struct {
+		label string
+		keys  []string
+	}{{"added", diff.Added}, {"removed", diff.Removed}, {"changed", diff.Changed}} {
+		if len(change.keys) > 0 {
+			fmt.Fprintf(&detail, "\n  %s: %s", change.label, strings.Join(change.keys, ", "))
+		}
+	}
+	diags.AddAttributeWarning(attr, "Document keys changed", detail.String())
+}
+
+// known reports whether every content input is known, so that document can
+// be rendered at plan time.
+func (m contentModel) known() bool {
+	return !m.Content.IsUnknown() && !m.ContentObject.IsUnknown() && !m.ContentSources.IsUnknown() &&
+		!m.ContentTemplate.IsUnknown() && !m.Vars.IsUnknown()
+}
+
+// document returns the plaintext document to encrypt and whether it is YAML:
+// content as given, content_object encoded as JSON, content_sources
+// deep-merged into a JSON document, or content_template rendered with vars.
+// Its diagnostics never contain any part of the content inputs.
+func (m contentModel) document(ctx context.Context) (string, bool, diag.Diagnostics) {
+	document, inputYAML, diags := m.render(ctx)
+	if !diags.HasError() {
+		return document, inputYAML, diags
+	}
+	return document, inputYAML, redactDiagnostics(diags, m.plaintexts(ctx)...)
+}
+
+// plaintexts returns every content input as a string, for redaction.
+func (m contentModel) plaintexts(ctx context.Context) []string {
+	var out []string
+	for _, v := range []types.String{m.Content, m.ContentTemplate} {
+		if v.ValueString() != "" {
+			out = append(out, v.ValueString())
+		}
+	}
+	var sources []string
+	if !m.ContentSources.IsNull() && !m.ContentSources.ElementsAs(ctx, &sources, false).HasError() {
+		out = append(out, sources...)
+	}
+	for _, v := range []types.Dynamic{m.ContentObject, m.Vars} {
+		if v.IsNull() || v.IsUnknown() {
+			continue
+		}
+		if jv, err := dynamicValue(ctx, v); err == nil {
+			out = appendLeaves(out, jv)
+		}
+	}
+	return out
+}
+
+// appendLeaves appends the string and number leaves of a value returned by
+// jsonValue to out.
+func appendLeaves(out []string, v any) []string {
+	switch v := v.(type) {
+	case string:
+		return append(out, v)
+	case json.Number:
+		return append(out, v.String())
+	case map[string]any:
+		for _, e := range v {
+			out = appendLeaves(out, e)
+		}
+	case []any:
+		for _, e := range v {
+			out = appendLeaves(out, e)
+		}
+	}
+	return out
+}
+
+// render builds the document for document.
+func (m contentModel) render(ctx context.Context) (string, bool, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	inputYAML := m.ContentType.ValueString() == "yaml"
+	switch {
+	case !m.ContentObject.IsNull():
+		v, err := dynamicValue(ctx, m.ContentObject)
+		if err == nil {
+			var out []byte
+			if out, err = json.Marshal(v); err == nil {
+				return string(out), false, diags
+			}
+		}
+		diags.AddAttributeError(path.Root("content_object"), "Invalid content_object", err.Error())
+		return "", false, diags
+	case !m.ContentSources.IsNull():
+		var sources []string
+		diags.Append(m.ContentSources.ElementsAs(ctx, &sources, false)...)
+		if diags.HasError() {
+			return "", false, diags
+		}
+		merged, err := mergeDocuments(sources, inputYAML)
+		if err != nil {
+			diags.AddAttributeError(path.Root("content_sources"), "Invalid content_sources", err.Error())
+			return "", false, diags
+		}
+		return merged, false, diags
+	case !m.ContentTemplate.IsNull():
+		rendered, err := renderTemplate(ctx, m.ContentTemplate.ValueString(), m.Vars)
+		if err != nil {
+			diags.AddAttributeError(path.Root("content_template"), "Invalid content_template", err.Error())
+			return "", false, diags
+		}
+		return rendered, inputYAML, diags
+	default:
+		return m.Content.ValueString(), inputYAML, diags
+	}
+}
+
+// renderTemplate renders a Terraform string template with vars, as
+// templatefile() does. jsonencode() is available for embedding values in a
+// JSON document.
+func renderTemplate(ctx context.Context, template string, vars types.Dynamic) (string, error) {
+	expr, d := hclsyntax.ParseTemplate([]byte(template), "content_template", hcl.InitialPos)
+	if d.HasErrors() {
+		return "", d
+	}
+	evalCtx := &hcl.EvalContext{
+		Variables: map[string]cty.Value{},
+		Functions: map[string]ctyfunction.Function{"jsonencode": stdlib.JSONEncodeFunc},
+	}
+	if !vars.IsNull() {
+		v, err := dynamicValue(ctx, vars)
+		if err != nil {
+			return "", fmt.Errorf("vars: %w", err)
+		}
+		if _, ok := v.(map[string]any); !ok {
+			return "", fmt.Errorf("vars must be an object or map")
+		}
+		// Round-trip through JSON to get the cty value templates evaluate.
+		b, err := json.Marshal(v)
+		if err != nil {
+			return "", fmt.Errorf("vars: %w", err)
+		}
+		typ, err := ctyjson.ImpliedType(b)
+		if err != nil {
+			return "", fmt.Errorf("vars: %w", err)
+		}
+		obj, err := ctyjson.Unmarshal(b, typ)
+		if err != nil {
+			return "", fmt.Errorf("vars: %w", err)
+		}
+		evalCtx.Variables = obj.AsValueMap()
+	}
+	val, d := expr.Value(evalCtx)
+	if d.HasErrors() {
+		return "", d
+	}
+	val, err := convert.Convert(val, cty.String)
+	if err != nil || val.IsNull() {
+		return "", fmt.Errorf("template did not render to a string")
+	}
+	return val.AsString(), nil
+}
+
+// mergeDocuments deep-merges JSON (or YAML) object documents into a single
+// JSON document. Later sources win: nested objects are merged key by key,
+// any other value, including a list, replaces the earlier one. Keys keep the
+// order of the source that introduced them, followed by keys added by later
+// sources, so the encrypted output lines up with the sources in a diff.
+// Each source must hold exactly one object; data after it is an error rather
+// than dropped.
+func mergeDocuments(sources []string, inputYAML bool) (string, error) {
+	merged := newOrderedObject()
+	for i, src := range sources {
+		var (
+			doc any
+			err error
+		)
+		if inputYAML {
+			var node yaml.Node
+			dec := yaml.NewDecoder(strings.NewReader(src))
+			if err = dec.Decode(&node); err == nil || errors.Is(err, io.EOF) {
+				doc, err = orderedYAML(&node)
+			}
+			if err == nil {
+				err = expectEOF(dec.Decode(&yaml.Node{}), "second YAML document")
+			}
+			if err != nil {
+				return "", fmt.Errorf("content_sources[%d]: parsing YAML: %w", i, err)
+			}
+		} else {
+			dec := json.NewDecoder(strings.NewReader(src))
+			dec.UseNumber()
+			if doc, err = orderedJSON(dec); err == nil {
+				_, err = dec.Token()
+				err = expectEOF(err, "data after the JSON value")
+			}
+			if err != nil {
+				return "", fmt.Errorf("content_sources[%d]: parsing JSON: %w", i, err)
+			}
+		}
+		obj, ok := doc.(*orderedObject)
+		if !ok {
+			return "", fmt.Errorf("content_sources[%d] must be an object", i)
+		}
+		deepMerge(merged, obj)
+	}
+	out, err := json.Marshal(merged)
+	if err != nil {
+		return "", fmt.Errorf("encoding merged content_sources as JSON: %w", err)
+	}
+	return string(out), nil
+}
+
+// expectEOF returns nil for io.EOF, the error of reading past the end of a
+// source. Any other error is returned as is, and a successful read is
+// reported as unexpected, naming what was found.
+func expectEOF(err error, found string) error {
+	switch {
+	case errors.Is(err, io.EOF):
+		return nil
+	case err != nil:
+		return err
+	}
+	return fmt.Errorf("unexpected %s", found)
+}
+
+// deepMerge merges src into dst, recursing into objects present in both.
+// Replaced values keep their position in dst.
+func deepMerge(dst, src *orderedObject) {
+	for _, k := range src.keys {
+		v := src.values[k]
+		if srcObj, ok := v.(*orderedObject); ok {
+			if dstObj, ok := dst.values[k].(*orderedObject); ok {
+				deepMerge(dstObj, srcObj)
+				continue
+			}
+		}
+		dst.set(k, v)
+	}
+}
+
+// orderedObject is a JSON object that keeps its keys in the order they were
+// first set.
+type orderedObject struct {
+	keys   []string
+	values map[string]any
+}
+
+func newOrderedObject() *orderedObject { return &orderedObject{values: map[string]any{}} }
+
+// set sets key to v, appending key unless it is already present.
+func (o *orderedObject) set(key string, v any) {
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = v
+}
+
+func (o *orderedObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, k := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(k)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(o.values[k])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// orderedJSON decodes the next JSON value from dec, with objects as
+// *orderedObject.
+func orderedJSON(dec *json.Decoder) (any, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok {
+	case json.Delim('{'):
+		obj := newOrderedObject()
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			v, err := orderedJSON(dec)
+			if err != nil {
+				return nil, err
+			}
+			obj.set(key.(string), v)
+		}
+		_, err := dec.Token()
+		return obj, err
+	case json.Delim('['):
+		list := []any{}
+		for dec.More() {
+			v, err := orderedJSON(dec)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		_, err := dec.Token()
+		return list, err
+	}
+	return tok, nil
+}
+
+// orderedYAML converts a YAML node to the value yaml.Unmarshal would decode,
+// with mappings as *orderedObject. Merge keys ("<<") add the keys of the
+// merged mappings that the mapping does not set itself.
+func orderedYAML(n *yaml.Node) (any, error) {
+	switch n.Kind {
+	case yaml.DocumentNode:
+		if len(n.Content) == 0 {
+			return nil, nil
+		}
+		return orderedYAML(n.Content[0])
+	case yaml.AliasNode:
+		return orderedYAML(n.Alias)
+	case yaml.SequenceNode:
+		list := make([]any, 0, len(n.Content))
+		for _, c := range n.Content {
+			v, err := orderedYAML(c)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		return list, nil
+	case yaml.MappingNode:
+		obj := newOrderedObject()
+		explicit := map[string]bool{}
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			if n.Content[i].Tag != "!!merge" {
+				explicit[n.Content[i].Value] = true
+			}
+		}
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			k, v := n.Content[i], n.Content[i+1]
+			if k.Tag == "!!merge" {
+				merged, err := orderedYAML(v)
+				if err != nil {
+					return nil, err
+				}
+				sources := []any{merged}
+				if list, ok := merged.([]any); ok {
+					sources = list
+				}
+				for _, src := range sources {
+					srcObj, ok := src.(*orderedObject)
+					if !ok {
+						return nil, fmt.Errorf("line %d: merge key must refer to a mapping", k.Line)
+					}
+					for _, mk := range srcObj.keys {
+						if _, set := obj.values[mk]; !set && !explicit[mk] {
+							obj.set(mk, srcObj.values[mk])
+						}
+					}
+				}
+				continue
+			}
+			var key string
+			if err := k.Decode(&key); err != nil {
+				return nil, err
+			}
+			value, err := orderedYAML(v)
+			if err != nil {
+				return nil, err
+			}
+			obj.set(key, value)
+		}
+		return obj, nil
+	default:
+		var v any
+		err := n.Decode(&v)
+		return v, err
+	}
+}
@@ -0,0 +1,115 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"terraform-provider-sops/internal/sopsencrypt"
+)
+
+var _ datasource.DataSource = &creationRuleMatchDataSource{}
+
+// creationRuleMatchDataSource reports which creation rule of a .sops.yaml
+// sops applies to a file, to debug configs with many rules.
+type creationRuleMatchDataSource struct{}
+
+type creationRuleMatchModel struct {
+	ID        types.String `tfsdk:"id"`
+	Content   types.String `tfsdk:"content"`
+	Path      types.String `tfsdk:"path"`
+	Matched   types.Bool   `tfsdk:"matched"`
+	RuleIndex types.Int64  `tfsdk:"rule_index"`
+	PathRegex types.String `tfsdk:"path_regex"`
+	KeyURIs   types.List   `tfsdk:"key_uris"`
+}
+
+func NewCreationRuleMatchDataSource() datasource.DataSource { return &creationRuleMatchDataSource{} }
+
+func (d *creationRuleMatchDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_creation_rule_match"
+}
+
+func (d *creationRuleMatchDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: `Reports which creation rule of a .sops.yaml the SOPS CLI applies to a file,
+and the keys it encrypts the file with. sops uses the first rule without a
+path_regex or whose path_regex matches the path. No Vault access is needed.
+
+    data "sops_creation_rule_match" "db" {
+      content = file("${path.module}/.sops.yaml")
+      path    = "secrets/prod/db.enc.yaml"
+    }`,
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:    true,
+				Description: "SHA-256 of the content and path.",
+			},
+			"content": schema.StringAttribute{
+				Required:    true,
+				Description: "Content of the .sops.yaml file.",
+			},
+			"path": schema.StringAttribute{
+				Required: true,
+				Description: `Path of the file, relative to the directory of the .sops.yaml file, as
+sops matches it against path_regex.`,
+			},
+			"matched": schema.BoolAttribute{
+				Computed:    true,
+				Description: "Whether a rule applies. sops refuses to encrypt a file no rule applies to.",
+			},
+			"rule_index": schema.Int64Attribute{
+				Computed:    true,
+				Description: "Index of the rule in creation_rules, or null if no rule applies.",
+			},
+			"path_regex": schema.StringAttribute{
+				Computed:    true,
+				Description: "path_regex of the rule, or null for a rule without one and if no rule applies.",
+			},
+			"key_uris": schema.ListAttribute{
+				ElementType: types.StringType,
+				Computed:    true,
+				Description: `Keys of the rule as written in the config: age recipients, PGP
+fingerprints, KMS ARNs, GCP KMS resource IDs, HuaweiCloud KMS key IDs, Azure
+Key Vault key URLs and Vault Transit URIs, in the order sops adds them, key
+group after key group. Empty if no rule applies.`,
+			},
+		},
+	}
+}
+
+func (d *creationRuleMatchDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data creationRuleMatchModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	match, ok, err := sopsencrypt.MatchCreationRule(data.Content.ValueString(), data.Path.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("content"), "Invalid SOPS config", err.Error())
+		return
+	}
+
+	data.ID = sha256Hex(data.Content.ValueString() + "\x00" + data.Path.ValueString())
+	data.Matched = types.BoolValue(ok)
+	data.RuleIndex = types.Int64Null()
+	data.PathRegex = types.StringNull()
+	if ok {
+		data.RuleIndex = types.Int64Value(int64(match.Index))
+		if match.PathRegex != "" {
+			data.PathRegex = types.StringValue(match.PathRegex)
+		}
+	}
+	keys := match.Keys
+	if keys == nil {
+		keys = []string{}
+	}
+	keyURIs, diags := types.ListValueFrom(ctx, types.StringType, keys)
+	resp.Diagnostics.Append(diags...)
+	data.KeyURIs = keyURIs
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
@@ -0,0 +1,74 @@
+package provider_test
+
+import (
+	"os"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+// TestAccCreationRuleMatchDataSource verifies that the first matching rule is
+// reported, that a path no rule matches is reported as unmatched, and that an
+// invalid path_regex fails the plan.
+func TestAccCreationRuleMatchDataSource(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+provider "sops" {
+  mock = true
+}
+
+locals {
+  sops_yaml = <<-YAML
+    creation_rules:
+      - path_regex: ^secrets/.*\.yaml$
+        hc_vault_transit_uri: https://vault.example.com/v1/transit/keys/broad
+      - path_regex: ^secrets/prod/
+        hc_vault_transit_uri: https://vault.example.com/v1/transit/keys/prod
+  YAML
+}
+
+data "sops_creation_rule_match" "shadowed" {
+  content = local.sops_yaml
+  path    = "secrets/prod/db.yaml"
+}
+
+data "sops_creation_rule_match" "none" {
+  content = local.sops_yaml
+  path    = "config/app.json"
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.sops_creation_rule_match.shadowed", "matched", "true"),
+					resource.TestCheckResourceAttr("data.sops_creation_rule_match.shadowed", "rule_index", "0"),
+					resource.TestCheckResourceAttr("data.sops_creation_rule_match.shadowed", "path_regex", `^secrets/.*\.yaml$`),
+					resource.TestCheckResourceAttr("data.sops_creation_rule_match.shadowed", "key_uris.#", "1"),
+					resource.TestCheckResourceAttr("data.sops_creation_rule_match.shadowed", "key_uris.0", "https://vault.example.com/v1/transit/keys/broad"),
+					resource.TestCheckResourceAttr("data.sops_creation_rule_match.none", "matched", "false"),
+					resource.TestCheckNoResourceAttr("data.sops_creation_rule_match.none", "rule_index"),
+					resource.TestCheckResourceAttr("data.sops_creation_rule_match.none", "key_uris.#", "0"),
+				),
+			},
+			{
+				Config: `
+provider "sops" {
+  mock = true
+}
+
+data "sops_creation_rule_match" "test" {
+  content = "creation_rules:\n  - path_regex: '('\n"
+  path    = "app.yaml"
+}
+`,
+				ExpectError: regexp.MustCompile(`Invalid SOPS config`),
+			},
+		},
+	})
+}
@@ -0,0 +1,286 @@
+package provider
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"terraform-provider-sops/internal/sopsencrypt"
+)
+
+var (
+	_ datasource.DataSource                   = &decryptedDataSource{}
+	_ datasource.DataSourceWithConfigure      = &decryptedDataSource{}
+	_ datasource.DataSourceWithValidateConfig = &decryptedDataSource{}
+)
+
+type decryptedDataSource struct{ pd *sopsProviderData }
+
+type decryptedModel struct {
+	ID              types.String             `tfsdk:"id"`
+	Ciphertext      types.String             `tfsdk:"ciphertext"`
+	Source          types.String             `tfsdk:"source"`
+	InputType       types.String             `tfsdk:"input_type"`
+	Plaintext       types.String             `tfsdk:"plaintext"`
+	PlaintextBase64 types.String             `tfsdk:"plaintext_base64"`
+	Env             types.Map                `tfsdk:"env"`
+	Git             *gitSourceModel          `tfsdk:"git"`
+	Timeouts        *dataSourceTimeoutsModel `tfsdk:"timeouts"`
+}
+
+// gitSourceModel is the git block of sops_decrypted. A nil pointer means the
+// block is absent.
+type gitSourceModel struct {
+	URL  types.String `tfsdk:"url"`
+	Ref  types.String `tfsdk:"ref"`
+	Path types.String `tfsdk:"path"`
+}
+
+func NewDecryptedDataSource() datasource.DataSource { return &decryptedDataSource{} }
+
+func (d *decryptedDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_decrypted"
+}
+
+func (d *decryptedDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: `Decrypts a SOPS document of any format, like sops -d --input-type. The
+data key is unwrapped with Vault Transit, using the engine and key recorded
+in the document. Requires Vault; not available in mock or age-only mode.
+
+    data "sops_decrypted" "app" {
+      ciphertext = file("secrets.enc.env")
+      input_type = "dotenv"
+    }
+
+Documents published to S3 or Google Cloud Storage, or committed to a git
+repository, can be read directly:
+
+    data "sops_decrypted" "app" {
+      source = "s3://artifacts/app/secrets.enc.yaml"
+    }
+
+    data "sops_decrypted" "shared" {
+      git {
+        url  = "https://github.com/example/platform-secrets.git"
+        ref  = "v1.4.0"
+        path = "prod/database.enc.yaml"
+      }
+    }`,
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:    true,
+				Description: "SHA-256 of the ciphertext.",
+			},
+			"ciphertext": schema.StringAttribute{
+				Optional:    true,
+				Computed:    true,
+				Description: "The SOPS-encrypted document. Exactly one of ciphertext, source and the git block must be set; with the others, the document read.",
+			},
+			"source": schema.StringAttribute{
+				Optional:    true,
+				Description: "URL of the SOPS-encrypted document in object storage, s3://<bucket>/<key> or gs://<bucket>/<object>, e.g. as written by sops publish. Read with the ambient AWS or Google Cloud credentials.",
+			},
+			"input_type": schema.StringAttribute{
+				Optional:    true,
+				Computed:    true,
+				Description: "Format of the document: json, yaml, dotenv, ini or binary. Defaults to the format of the source or git path extension, else json if the document starts with '{', yaml otherwise.",
+			},
+			"plaintext": schema.StringAttribute{
+				Computed:    true,
+				Sensitive:   true,
+				Description: "The decrypted document in its input format. For binary, the original file content; null if it is not valid UTF-8.",
+			},
+			"plaintext_base64": schema.StringAttribute{
+				Computed:    true,
+				Sensitive:   true,
+				Description: "The decrypted document, base64-encoded.",
+			},
+			"env": schema.MapAttribute{
+				Computed:    true,
+				Sensitive:   true,
+				ElementType: types.StringType,
+				Description: "The decrypted document flattened into environment variables, like sops exec-env: nested keys are joined with '_' in UPPER_SNAKE_CASE, so {\"db\": {\"host\": \"x\"}} becomes DB_HOST = \"x\". Null, with a warning, when two keys map to the same variable.",
+			},
+		},
+		Blocks: map[string]schema.Block{
+			"git": schema.SingleNestedBlock{
+				Description: "Reads the SOPS-encrypted document from a git repository with the git CLI, which must be installed. Credentials come from the SSH agent, credential helpers and the git configuration.",
+				Attributes: map[string]schema.Attribute{
+					"url": schema.StringAttribute{
+						Optional:    true,
+						Description: "Repository URL, as accepted by git clone. Required in the block.",
+					},
+					"ref": schema.StringAttribute{
+						Optional:    true,
+						Description: "Branch, tag or commit SHA to read from. Defaults to the remote HEAD.",
+					},
+					"path": schema.StringAttribute{
+						Optional:    true,
+						Description: "Path of the document within the repository. Required in the block.",
+					},
+				},
+			},
+			"timeouts": dataSourceTimeoutsBlock(),
+		},
+	}
+}
+
+func (d *decryptedDataSource) Configure(_ context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	pd, ok := req.ProviderData.(*sopsProviderData)
+	if !ok {
+		resp.Diagnostics.AddError("Unexpected provider data type",
+			fmt.Sprintf("Expected *sopsProviderData, got %T", req.ProviderData))
+		return
+	}
+	d.pd = pd
+}
+
+// ValidateConfig requires exactly one of ciphertext, source and the git
+// block, a complete git block, and a known input_type.
+func (d *decryptedDataSource) ValidateConfig(ctx context.Context, req datasource.ValidateConfigRequest, resp *datasource.ValidateConfigResponse) {
+	var data decryptedModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if !data.Ciphertext.IsUnknown() && !data.Source.IsUnknown() {
+		set := 0
+		for _, isSet := range []bool{!data.Ciphertext.IsNull(), !data.Source.IsNull(), data.Git != nil} {
+			if isSet {
+				set++
+			}
+		}
+		if set != 1 {
+			resp.Diagnostics.AddAttributeError(path.Root("ciphertext"),
+				"Invalid document source",
+				"Exactly one of ciphertext, source and the git block must be set.")
+		}
+	}
+	if data.Git != nil {
+		if data.Git.URL.IsNull() {
+			resp.Diagnostics.AddAttributeError(path.Root("git").AtName("url"),
+				"Incomplete git block", "The git block requires url.")
+		}
+		if data.Git.Path.IsNull() {
+			resp.Diagnostics.AddAttributeError(path.Root("git").AtName("path"),
+				"Incomplete git block", "The git block requires path.")
+		}
+	}
+	validateInputType(&resp.Diagnostics, data.InputType)
+}
+
+// validateInputType rejects an input_type that is not one of
+// sopsencrypt.InputTypes.
+func validateInputType(diags *diag.Diagnostics, inputType types.String) {
+	if inputType.IsNull() || inputType.IsUnknown() {
+		return
+	}
+	if !slices.Contains(sopsencrypt.InputTypes, inputType.ValueString()) {
+		diags.AddAttributeError(path.Root("input_type"),
+			"Invalid input_type",
+			fmt.Sprintf("Expected one of %s, got %q.", strings.Join(sopsencrypt.InputTypes, ", "), inputType.ValueString()))
+	}
+}
+
+func (d *decryptedDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data decryptedModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	client, err := d.pd.newVaultClient(data.Timeouts.read())
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to create Vault client", err.Error())
+		return
+	}
+	if client == nil {
+		resp.Diagnostics.AddError("Vault not configured",
+			"sops_decrypted unwraps data keys with Vault and is not available in mock or age-only mode.")
+		return
+	}
+	ctx = maskSecrets(ctx, clientToken(client))
+	fetchCtx := ctx
+	if timeout := data.Timeouts.read(); timeout > 0 {
+		var cancel context.CancelFunc
+		fetchCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	ciphertext, attr, name := data.Ciphertext.ValueString(), path.Root("ciphertext"), ""
+	switch {
+	case !data.Source.IsNull():
+		attr, name = path.Root("source"), data.Source.ValueString()
+		tflog.Debug(ctx, "Reading SOPS document", map[string]any{"source": name})
+		b, err := sopsencrypt.FetchRemote(fetchCtx, name)
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(attr, "Failed to read SOPS document", err.Error())
+			return
+		}
+		ciphertext = string(b)
+	case data.Git != nil:
+		attr, name = path.Root("git"), data.Git.Path.ValueString()
+		tflog.Debug(ctx, "Reading SOPS document from git", map[string]any{
+			"ref":  data.Git.Ref.ValueString(),
+			"path": name,
+		})
+		b, err := sopsencrypt.FetchGit(fetchCtx, data.Git.URL.ValueString(), data.Git.Ref.ValueString(), name)
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(attr, "Failed to read SOPS document", err.Error())
+			return
+		}
+		ciphertext = string(b)
+	}
+	inputType := data.InputType.ValueString()
+	if inputType == "" {
+		inputType = sopsencrypt.DetectInputTypeForPath(name, ciphertext)
+	}
+
+	release, err := d.pd.acquireVault(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to decrypt SOPS document", err.Error())
+		return
+	}
+	defer release()
+	start := time.Now()
+	plaintext, err := sopsencrypt.Decrypt(client, ciphertext, inputType)
+	logResult(ctx, "Decryption", start, err)
+	if err != nil {
+		addVaultError(&resp.Diagnostics, attr, "Failed to decrypt SOPS document", err)
+		return
+	}
+
+	data.ID = sha256Hex(ciphertext)
+	data.Ciphertext = types.StringValue(ciphertext)
+	data.InputType = types.StringValue(inputType)
+	data.Plaintext = types.StringNull()
+	if utf8.Valid(plaintext) {
+		data.Plaintext = types.StringValue(string(plaintext))
+	}
+	data.PlaintextBase64 = types.StringValue(base64.StdEncoding.EncodeToString(plaintext))
+	data.Env = types.MapNull(types.StringType)
+	if env, err := sopsencrypt.Env(plaintext, inputType); err != nil {
+		resp.Diagnostics.AddAttributeWarning(path.Root("env"),
+			"Document not convertible to environment variables",
+			sopsencrypt.Redact(err.Error(), string(plaintext)))
+	} else {
+		m, diags := types.MapValueFrom(ctx, types.StringType, env)
+		resp.Diagnostics.Append(diags...)
+		data.Env = m
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
@@ -0,0 +1,163 @@
+package provider_test
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+// TestAccDecryptedDataSource_RoundTrip verifies that a document encrypted by
+// sops_encrypted_yaml decrypts back to its content.
+func TestAccDecryptedDataSource_RoundTrip(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+	vaultAddr := requireEnv(t, "VAULT_ADDR")
+	vaultToken := requireEnv(t, "VAULT_TOKEN")
+	keyName := envOrDefault("SOPS_VAULT_KEY", "sops-test")
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccDecrypted(vaultAddr, vaultToken, keyName, ""),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.sops_decrypted.test", "input_type", "yaml"),
+					resource.TestCheckResourceAttr("data.sops_decrypted.test", "plaintext", "username: admin\npassword: secret\n"),
+					resource.TestCheckResourceAttrSet("data.sops_decrypted.test", "plaintext_base64"),
+					resource.TestCheckResourceAttr("data.sops_decrypted.test", "env.USERNAME", "admin"),
+					resource.TestCheckResourceAttr("data.sops_decrypted.test", "env.PASSWORD", "secret"),
+				),
+			},
+		},
+	})
+}
+
+// TestAccDecryptedDataSource_InvalidInputType verifies that an unknown
+// input_type fails validation.
+func TestAccDecryptedDataSource_InvalidInputType(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+provider "sops" {
+  mock = true
+}
+
+data "sops_decrypted" "test" {
+  ciphertext = "{}"
+  input_type = "toml"
+}
+`,
+				ExpectError: regexp.MustCompile(`Invalid input_type`),
+			},
+		},
+	})
+}
+
+// TestAccDecryptedDataSource_Source verifies that ciphertext and source are
+// mutually exclusive and that only object storage URLs are read.
+func TestAccDecryptedDataSource_Source(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+	vaultAddr := requireEnv(t, "VAULT_ADDR")
+	vaultToken := requireEnv(t, "VAULT_TOKEN")
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+provider "sops" {
+  mock = true
+}
+
+data "sops_decrypted" "test" {
+  ciphertext = "{}"
+  source     = "s3://bucket/secrets.enc.json"
+}
+`,
+				ExpectError: regexp.MustCompile(`Exactly one of ciphertext, source and the git block`),
+			},
+			{
+				Config: fmt.Sprintf(`
+provider "sops" {
+  vault_address = %q
+
+  auth {
+    token = %q
+  }
+}
+
+data "sops_decrypted" "test" {
+  source = "https://example.com/secrets.enc.json"
+}
+`, vaultAddr, vaultToken),
+				ExpectError: regexp.MustCompile(`unsupported source URL`),
+			},
+		},
+	})
+}
+
+// TestAccDecryptedDataSource_GitIncomplete verifies that a git block without
+// a path fails validation.
+func TestAccDecryptedDataSource_GitIncomplete(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+provider "sops" {
+  mock = true
+}
+
+data "sops_decrypted" "test" {
+  git {
+    url = "https://github.com/example/platform-secrets.git"
+  }
+}
+`,
+				ExpectError: regexp.MustCompile(`The git block requires path`),
+			},
+		},
+	})
+}
+
+func testAccDecrypted(vaultAddr, vaultToken, keyName, inputType string) string {
+	inputTypeArg := ""
+	if inputType != "" {
+		inputTypeArg = fmt.Sprintf("input_type = %q", inputType)
+	}
+	return fmt.Sprintf(`
+provider "sops" {
+  vault_address = %q
+
+  auth {
+    token = %q
+  }
+}
+
+resource "sops_encrypted_yaml" "test" {
+  content        = "username: admin\npassword: secret\n"
+  vault_key_name = %q
+}
+
+data "sops_decrypted" "test" {
+  ciphertext = sops_encrypted_yaml.test.ciphertext_yaml
+  %s
+}
+`, vaultAddr, vaultToken, keyName, inputTypeArg)
+}
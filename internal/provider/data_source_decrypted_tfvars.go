@@ -0,0 +1,216 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"terraform-provider-sops/internal/sopsencrypt"
+)
+
+var (
+	_ datasource.DataSource                   = &decryptedTFVarsDataSource{}
+	_ datasource.DataSourceWithConfigure      = &decryptedTFVarsDataSource{}
+	_ datasource.DataSourceWithValidateConfig = &decryptedTFVarsDataSource{}
+)
+
+// decryptedTFVarsDataSource decrypts a SOPS document, typically one written
+// by sops_encrypted_tfvars, into typed values, so that one workspace can
+// consume the secrets of another without sops exec-file.
+type decryptedTFVarsDataSource struct{ pd *sopsProviderData }
+
+type decryptedTFVarsModel struct {
+	ID         types.String             `tfsdk:"id"`
+	Ciphertext types.String             `tfsdk:"ciphertext"`
+	InputType  types.String             `tfsdk:"input_type"`
+	Variables  types.Dynamic            `tfsdk:"variables"`
+	Timeouts   *dataSourceTimeoutsModel `tfsdk:"timeouts"`
+}
+
+func NewDecryptedTFVarsDataSource() datasource.DataSource { return &decryptedTFVarsDataSource{} }
+
+func (d *decryptedTFVarsDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_decrypted_tfvars"
+}
+
+func (d *decryptedTFVarsDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: `Decrypts a SOPS-encrypted .tfvars.json, or any JSON or YAML document with
+a mapping at the top level, and exposes each top-level key as a typed value.
+The data key is unwrapped with Vault Transit. Requires Vault; not available
+in mock or age-only mode.
+
+    data "sops_decrypted_tfvars" "shared" {
+      ciphertext = file("secrets.tfvars.json")
+    }
+
+    module "db" {
+      source   = "./db"
+      password = data.sops_decrypted_tfvars.shared.variables.db_password
+      port     = data.sops_decrypted_tfvars.shared.variables.port
+    }`,
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:    true,
+				Description: "SHA-256 of the ciphertext.",
+			},
+			"ciphertext": schema.StringAttribute{
+				Required:    true,
+				Description: "The SOPS-encrypted document.",
+			},
+			"input_type": schema.StringAttribute{
+				Optional:    true,
+				Computed:    true,
+				Description: "Format of the document: json, yaml, dotenv, ini or binary. Defaults to json if the document starts with '{', yaml otherwise.",
+			},
+			"variables": schema.DynamicAttribute{
+				Computed:  true,
+				Sensitive: true,
+				Description: `Object of the decrypted top-level keys. Strings, numbers and booleans keep
+their type, mappings become objects and sequences tuples. A null value
+becomes a null string.`,
+			},
+		},
+		Blocks: map[string]schema.Block{
+			"timeouts": dataSourceTimeoutsBlock(),
+		},
+	}
+}
+
+func (d *decryptedTFVarsDataSource) Configure(_ context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	pd, ok := req.ProviderData.(*sopsProviderData)
+	if !ok {
+		resp.Diagnostics.AddError("Unexpected provider data type",
+			fmt.Sprintf("Expected *sopsProviderData, got %T", req.ProviderData))
+		return
+	}
+	d.pd = pd
+}
+
+func (d *decryptedTFVarsDataSource) ValidateConfig(ctx context.Context, req datasource.ValidateConfigRequest, resp *datasource.ValidateConfigResponse) {
+	var data decryptedTFVarsModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	validateInputType(&resp.Diagnostics, data.InputType)
+}
+
+func (d *decryptedTFVarsDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data decryptedTFVarsModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	client, err := d.pd.newVaultClient(data.Timeouts.read())
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to create Vault client", err.Error())
+		return
+	}
+	if client == nil {
+		resp.Diagnostics.AddError("Vault not configured",
+			"sops_decrypted_tfvars unwraps data keys with Vault and is not available in mock or age-only mode.")
+		return
+	}
+	ctx = maskSecrets(ctx, clientToken(client))
+	ciphertext := data.Ciphertext.ValueString()
+	inputType := data.InputType.ValueString()
+	if inputType == "" {
+		inputType = sopsencrypt.DetectInputType(ciphertext)
+	}
+
+	release, err := d.pd.acquireVault(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to decrypt SOPS document", err.Error())
+		return
+	}
+	defer release()
+	start := time.Now()
+	plaintext, err := sopsencrypt.Decrypt(client, ciphertext, inputType)
+	logResult(ctx, "Decryption", start, err)
+	if err != nil {
+		addVaultError(&resp.Diagnostics, path.Root("ciphertext"), "Failed to decrypt SOPS document", err)
+		return
+	}
+
+	vars, err := sopsencrypt.Variables(plaintext, inputType)
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("ciphertext"), "Invalid tfvars document",
+			sopsencrypt.Redact(err.Error(), string(plaintext)))
+		return
+	}
+	value, err := jsonAttrValue(vars)
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("ciphertext"), "Invalid tfvars document",
+			sopsencrypt.Redact(err.Error(), string(plaintext)))
+		return
+	}
+
+	data.ID = sha256Hex(ciphertext)
+	data.InputType = types.StringValue(inputType)
+	data.Variables = types.DynamicValue(value)
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// jsonAttrValue converts a value decoded from JSON with UseNumber into a
+// framework value, the inverse of jsonValue. A null becomes a null string,
+// as nested values cannot be dynamic.
+func jsonAttrValue(v any) (attr.Value, error) {
+	switch v := v.(type) {
+	case nil:
+		return types.StringNull(), nil
+	case string:
+		return types.StringValue(v), nil
+	case bool:
+		return types.BoolValue(v), nil
+	case json.Number:
+		f, _, err := big.ParseFloat(v.String(), 10, 512, big.ToNearestEven)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %s: %w", v, err)
+		}
+		return types.NumberValue(f), nil
+	case []any:
+		elems := make([]attr.Value, len(v))
+		elemTypes := make([]attr.Type, len(v))
+		for i, e := range v {
+			ev, err := jsonAttrValue(e)
+			if err != nil {
+				return nil, err
+			}
+			elems[i], elemTypes[i] = ev, ev.Type(context.Background())
+		}
+		tuple, diags := types.TupleValue(elemTypes, elems)
+		if diags.HasError() {
+			return nil, fmt.Errorf("building tuple: %v", diags)
+		}
+		return tuple, nil
+	case map[string]any:
+		attrs := make(map[string]attr.Value, len(v))
+		attrTypes := make(map[string]attr.Type, len(v))
+		for k, e := range v {
+			ev, err := jsonAttrValue(e)
+			if err != nil {
+				return nil, fmt.Errorf("%q: %w", k, err)
+			}
+			attrs[k], attrTypes[k] = ev, ev.Type(context.Background())
+		}
+		obj, diags := types.ObjectValue(attrTypes, attrs)
+		if diags.HasError() {
+			return nil, fmt.Errorf("building object: %v", diags)
+		}
+		return obj, nil
+	}
+	return nil, fmt.Errorf("unsupported value of type %T", v)
+}
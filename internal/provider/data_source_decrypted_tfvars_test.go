@@ -0,0 +1,90 @@
+package provider_test
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+// TestAccDecryptedTFVarsDataSource_RoundTrip verifies that the variables
+// encrypted by sops_encrypted_tfvars decrypt back with their types.
+func TestAccDecryptedTFVarsDataSource_RoundTrip(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+	vaultAddr := requireEnv(t, "VAULT_ADDR")
+	vaultToken := requireEnv(t, "VAULT_TOKEN")
+	keyName := envOrDefault("SOPS_VAULT_KEY", "sops-test")
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+provider "sops" {
+  vault_address = %q
+
+  auth {
+    token = %q
+  }
+}
+
+resource "sops_encrypted_tfvars" "test" {
+  variables = {
+    db_password = "s3cret"
+    db_port     = 5432
+    tls         = true
+    zones       = ["a", "b"]
+  }
+  vault_key_name = %q
+}
+
+data "sops_decrypted_tfvars" "test" {
+  ciphertext = sops_encrypted_tfvars.test.ciphertext
+}
+
+output "port_plus_one" {
+  value     = data.sops_decrypted_tfvars.test.variables.db_port + 1
+  sensitive = true
+}
+`, vaultAddr, vaultToken, keyName),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.sops_decrypted_tfvars.test", "input_type", "json"),
+					resource.TestCheckResourceAttr("data.sops_decrypted_tfvars.test", "variables.db_password", "s3cret"),
+					resource.TestCheckResourceAttr("data.sops_decrypted_tfvars.test", "variables.tls", "true"),
+					resource.TestCheckResourceAttr("data.sops_decrypted_tfvars.test", "variables.zones.#", "2"),
+					resource.TestCheckOutput("port_plus_one", "5433"),
+				),
+			},
+		},
+	})
+}
+
+// TestAccDecryptedTFVarsDataSource_RequiresVault verifies that the data
+// source fails in mock mode, where there is no Vault to unwrap data keys.
+func TestAccDecryptedTFVarsDataSource_RequiresVault(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+provider "sops" {
+  mock = true
+}
+
+data "sops_decrypted_tfvars" "test" {
+  ciphertext = "{}"
+}
+`,
+				ExpectError: regexp.MustCompile(`Vault not configured`),
+			},
+		},
+	})
+}
@@ -0,0 +1,163 @@
+package provider
+
+import (
+	"context"
+	"os"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"terraform-provider-sops/internal/sopsencrypt"
+)
+
+var (
+	_ datasource.DataSource                   = &metadataDataSource{}
+	_ datasource.DataSourceWithValidateConfig = &metadataDataSource{}
+)
+
+// metadataDataSource is the data source counterpart of the metadata
+// function, for Terraform versions without provider functions and for
+// documents read from disk.
+type metadataDataSource struct{}
+
+type metadataDataSourceModel struct {
+	ID         types.String `tfsdk:"id"`
+	Ciphertext types.String `tfsdk:"ciphertext"`
+	Filename   types.String `tfsdk:"filename"`
+	InputType  types.String `tfsdk:"input_type"`
+	sopsMetadataModel
+}
+
+func NewMetadataDataSource() datasource.DataSource { return &metadataDataSource{} }
+
+func (d *metadataDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_metadata"
+}
+
+func (d *metadataDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: `Reads the sops metadata block of an encrypted document without decrypting
+it: the key sources that wrap the data key, the version, the modification
+time and the scope options. No key material or Vault access is needed.
+
+    data "sops_metadata" "app" {
+      filename = "secrets.enc.yaml"
+    }`,
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:    true,
+				Description: "SHA-256 of the document.",
+			},
+			"ciphertext": schema.StringAttribute{
+				Optional:    true,
+				Description: "The SOPS-encrypted document. Exactly one of ciphertext and filename must be set.",
+			},
+			"filename": schema.StringAttribute{
+				Optional:    true,
+				Description: "Path of a SOPS-encrypted file to read. Exactly one of ciphertext and filename must be set.",
+			},
+			"input_type": schema.StringAttribute{
+				Optional:    true,
+				Computed:    true,
+				Description: "Format of the document: json, yaml, dotenv, ini or binary. Defaults to json if the document starts with '{', yaml otherwise.",
+			},
+			"version": schema.StringAttribute{
+				Computed:    true,
+				Description: "SOPS version that wrote the document.",
+			},
+			"lastmodified": schema.StringAttribute{
+				Computed:    true,
+				Description: "Last modification time, RFC 3339.",
+			},
+			"unencrypted_suffix": schema.StringAttribute{
+				Computed:    true,
+				Description: "unencrypted_suffix recorded in the document, or null.",
+			},
+			"encrypted_suffix": schema.StringAttribute{
+				Computed:    true,
+				Description: "encrypted_suffix recorded in the document, or null.",
+			},
+			"unencrypted_regex": schema.StringAttribute{
+				Computed:    true,
+				Description: "unencrypted_regex recorded in the document, or null.",
+			},
+			"encrypted_regex": schema.StringAttribute{
+				Computed:    true,
+				Description: "encrypted_regex recorded in the document, or null.",
+			},
+			"key_sources": schema.ListNestedAttribute{
+				Computed:    true,
+				Description: "Master keys that wrap the data key, one entry per key.",
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"type": schema.StringAttribute{
+							Computed:    true,
+							Description: "Key backend as named in the metadata block, e.g. hc_vault or age.",
+						},
+						"id": schema.StringAttribute{
+							Computed:    true,
+							Description: "Key identifier: the Transit key URI for hc_vault, the recipient for age.",
+						},
+						"group": schema.Int64Attribute{
+							Computed:    true,
+							Description: "Index of the key group the key belongs to.",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// ValidateConfig requires exactly one of ciphertext and filename, and a known
+// input_type.
+func (d *metadataDataSource) ValidateConfig(ctx context.Context, req datasource.ValidateConfigRequest, resp *datasource.ValidateConfigResponse) {
+	var data metadataDataSourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if !data.Ciphertext.IsUnknown() && !data.Filename.IsUnknown() &&
+		data.Ciphertext.IsNull() == data.Filename.IsNull() {
+		resp.Diagnostics.AddAttributeError(path.Root("ciphertext"),
+			"Invalid document source",
+			"Exactly one of ciphertext and filename must be set.")
+	}
+	validateInputType(&resp.Diagnostics, data.InputType)
+}
+
+func (d *metadataDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data metadataDataSourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	document, attr := data.Ciphertext.ValueString(), path.Root("ciphertext")
+	if !data.Filename.IsNull() {
+		attr = path.Root("filename")
+		b, err := os.ReadFile(data.Filename.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(attr, "Failed to read SOPS file", err.Error())
+			return
+		}
+		document = string(b)
+	}
+	inputType := data.InputType.ValueString()
+	if inputType == "" {
+		inputType = sopsencrypt.DetectInputType(document)
+	}
+
+	md, err := sopsencrypt.ParseMetadataAs(document, inputType)
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(attr, "Failed to parse SOPS metadata", err.Error())
+		return
+	}
+
+	data.ID = sha256Hex(document)
+	data.InputType = types.StringValue(inputType)
+	data.sopsMetadataModel = newSOPSMetadataModel(md)
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
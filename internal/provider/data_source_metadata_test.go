@@ -0,0 +1,88 @@
+package provider_test
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+// TestAccMetadataDataSource_File verifies that the metadata of a file on disk
+// is read without Vault access.
+func TestAccMetadataDataSource_File(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+	filename := filepath.Join(t.TempDir(), "secrets.enc.yaml")
+	if err := os.WriteFile(filename, []byte(testAccSOPSYAMLDocument), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+provider "sops" {
+  mock = true
+}
+
+data "sops_metadata" "test" {
+  filename = "` + filepath.ToSlash(filename) + `"
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.sops_metadata.test", "input_type", "yaml"),
+					resource.TestCheckResourceAttr("data.sops_metadata.test", "version", "3.12.1"),
+					resource.TestCheckResourceAttr("data.sops_metadata.test", "lastmodified", "2024-01-01T00:00:00Z"),
+					resource.TestCheckResourceAttr("data.sops_metadata.test", "encrypted_regex", "^password$"),
+					resource.TestCheckNoResourceAttr("data.sops_metadata.test", "unencrypted_suffix"),
+					resource.TestCheckResourceAttr("data.sops_metadata.test", "key_sources.#", "1"),
+					resource.TestCheckResourceAttr("data.sops_metadata.test", "key_sources.0.type", "hc_vault"),
+					resource.TestCheckResourceAttr("data.sops_metadata.test", "key_sources.0.id", "https://vault.example.com/v1/transit/keys/app"),
+				),
+			},
+		},
+	})
+}
+
+// TestAccMetadataDataSource_Plaintext verifies that a document without a sops
+// block fails the plan, as does setting both document sources.
+func TestAccMetadataDataSource_Plaintext(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+provider "sops" {
+  mock = true
+}
+
+data "sops_metadata" "test" {
+  ciphertext = jsonencode({ password = "plaintext" })
+}
+`,
+				ExpectError: regexp.MustCompile(`Failed to parse SOPS metadata`),
+			},
+			{
+				Config: `
+provider "sops" {
+  mock = true
+}
+
+data "sops_metadata" "test" {
+  ciphertext = "{}"
+  filename   = "secrets.enc.json"
+}
+`,
+				ExpectError: regexp.MustCompile(`Exactly one of ciphertext and filename`),
+			},
+		},
+	})
+}
@@ -2,11 +2,14 @@ package provider
 
 import (
 	"context"
-	"crypto/sha256"
 	"fmt"
+	"sort"
 
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
 	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"terraform-provider-sops/internal/sopsencrypt"
 )
@@ -20,10 +23,81 @@ type sopsConfigDataSource struct{ pd *sopsProviderData }
 
 type sopsConfigModel struct {
 	ID                 types.String `tfsdk:"id"`
-	VaultKeyName       types.String `tfsdk:"vault_key_name"`
 	VaultTransitEngine types.String `tfsdk:"vault_transit_engine"`
 	PathRegexes        types.List   `tfsdk:"path_regexes"`
-	Content            types.String `tfsdk:"content"`
+	PathVaultKeys      types.Map    `tfsdk:"path_vault_keys"`
+	ruleModel
+	CreationRules    []creationRuleModel    `tfsdk:"creation_rule"`
+	DestinationRules []destinationRuleModel `tfsdk:"destination_rules"`
+	ExtraRulesYAML   types.String           `tfsdk:"extra_rules_yaml"`
+	Content          types.String           `tfsdk:"content"`
+}
+
+// ruleModel holds the keys and options of a creation rule. The top-level
+// shorthand applies it to every generated rule; a creation_rule block applies
+// it to one.
+type ruleModel struct {
+	VaultKeyName types.String `tfsdk:"vault_key_name"`
+	keySourcesModel
+	KeyGroups         []keyGroupModel `tfsdk:"key_groups"`
+	ShamirThreshold   types.Int64     `tfsdk:"shamir_threshold"`
+	UnencryptedSuffix types.String    `tfsdk:"unencrypted_suffix"`
+	EncryptedSuffix   types.String    `tfsdk:"encrypted_suffix"`
+	UnencryptedRegex  types.String    `tfsdk:"unencrypted_regex"`
+	EncryptedRegex    types.String    `tfsdk:"encrypted_regex"`
+	MACOnlyEncrypted  types.Bool      `tfsdk:"mac_only_encrypted"`
+}
+
+type creationRuleModel struct {
+	PathRegex types.String `tfsdk:"path_regex"`
+	ruleModel
+}
+
+// keySourcesModel holds the non-Vault key sources, which are configured the
+// same way for a whole creation rule and for a single key group.
+type keySourcesModel struct {
+	AgeRecipients   types.List     `tfsdk:"age_recipients"`
+	PGPFingerprints types.List     `tfsdk:"pgp_fingerprints"`
+	KMSARNs         types.List     `tfsdk:"kms_arns"`
+	KMSRole         types.String   `tfsdk:"kms_role"`
+	KMSContext      types.Map      `tfsdk:"kms_context"`
+	KMSKeys         []kmsKeyModel  `tfsdk:"kms_keys"`
+	GCPKMSIDs       types.List     `tfsdk:"gcp_kms_ids"`
+	AzureKV         []azureKVModel `tfsdk:"azure_kv"`
+}
+
+type keyGroupModel struct {
+	VaultKeyName types.String `tfsdk:"vault_key_name"`
+	keySourcesModel
+}
+
+// kmsKeyModel is an entry of kms_keys: an AWS KMS key with options of its
+// own, unlike the keys of kms_arns, which share kms_role and kms_context.
+type kmsKeyModel struct {
+	ARN               types.String `tfsdk:"arn"`
+	RoleARN           types.String `tfsdk:"role_arn"`
+	Profile           types.String `tfsdk:"profile"`
+	EncryptionContext types.Map    `tfsdk:"encryption_context"`
+	ReplicaRegions    types.List   `tfsdk:"replica_regions"`
+}
+
+type azureKVModel struct {
+	VaultURL types.String `tfsdk:"vault_url"`
+	Key      types.String `tfsdk:"key"`
+	Version  types.String `tfsdk:"version"`
+}
+
+type destinationRuleModel struct {
+	PathRegex        types.String `tfsdk:"path_regex"`
+	S3Bucket         types.String `tfsdk:"s3_bucket"`
+	S3Prefix         types.String `tfsdk:"s3_prefix"`
+	GCSBucket        types.String `tfsdk:"gcs_bucket"`
+	GCSPrefix        types.String `tfsdk:"gcs_prefix"`
+	VaultPath        types.String `tfsdk:"vault_path"`
+	VaultAddress     types.String `tfsdk:"vault_address"`
+	VaultKVMountName types.String `tfsdk:"vault_kv_mount_name"`
+	VaultKVVersion   types.Int64  `tfsdk:"vault_kv_version"`
+	OmitExtensions   types.Bool   `tfsdk:"omit_extensions"`
 }
 
 func NewSOPSConfigDataSource() datasource.DataSource { return &sopsConfigDataSource{} }
@@ -32,7 +106,261 @@ func (d *sopsConfigDataSource) Metadata(_ context.Context, req datasource.Metada
 	resp.TypeName = req.ProviderTypeName + "_config"
 }
 
+// keySourceAttributes returns the schema for keySourcesModel. target names
+// what the keys are added to, for the descriptions.
+func keySourceAttributes(target string) map[string]schema.Attribute {
+	return map[string]schema.Attribute{
+		"age_recipients": schema.ListAttribute{
+			ElementType: types.StringType,
+			Optional:    true,
+			Description: "age public keys added to " + target + " as the age field.",
+		},
+		"pgp_fingerprints": schema.ListAttribute{
+			ElementType: types.StringType,
+			Optional:    true,
+			Description: "PGP key fingerprints added to " + target + " as the pgp field.",
+		},
+		"kms_arns": schema.ListAttribute{
+			ElementType: types.StringType,
+			Optional:    true,
+			Description: "AWS KMS key ARNs added to " + target + " as the kms field.",
+		},
+		"kms_role": schema.StringAttribute{
+			Optional:    true,
+			Description: "IAM role ARN assumed before calling KMS. Applied to every entry in kms_arns.",
+		},
+		"kms_context": schema.MapAttribute{
+			ElementType: types.StringType,
+			Optional:    true,
+			Description: `Encryption context applied to every entry in kms_arns. SOPS only reads
+an encryption context from key_groups, so when set outside key_groups the
+rule's key sources are rendered as a single key group instead of the flat
+fields.`,
+		},
+		"kms_keys": schema.ListNestedAttribute{
+			Optional: true,
+			Description: `AWS KMS keys with options of their own, added to ` + target + ` after the
+keys in kms_arns. kms_role and kms_context only apply to kms_arns: entries
+here keep exactly the options they set and inherit nothing, so an ARN listed
+in both is written twice, once per set of options. A profile or an
+encryption context on any key, from kms_context or from an entry here,
+renders all of the rule's key sources as a single key group, as SOPS only
+reads those per key; otherwise the keys are written to the flat kms field as
+arn+role.`,
+			NestedObject: schema.NestedAttributeObject{
+				Attributes: map[string]schema.Attribute{
+					"arn": schema.StringAttribute{
+						Required:    true,
+						Description: "KMS key ARN.",
+					},
+					"role_arn": schema.StringAttribute{
+						Optional:    true,
+						Description: "IAM role ARN assumed before calling KMS with this key.",
+					},
+					"profile": schema.StringAttribute{
+						Optional:    true,
+						Description: "Profile of the AWS shared config to call KMS with, written as aws_profile.",
+					},
+					"encryption_context": schema.MapAttribute{
+						ElementType: types.StringType,
+						Optional:    true,
+						Description: "Encryption context bound to the data key wrapped with this key.",
+					},
+					"replica_regions": schema.ListAttribute{
+						ElementType: types.StringType,
+						Optional:    true,
+						Description: `Regions of replicas of arn, a multi-region key (key/mrk-…). Each replica
+is added as a further key with the same options, so that the data key can
+be unwrapped in any of the regions.`,
+					},
+				},
+			},
+		},
+		"gcp_kms_ids": schema.ListAttribute{
+			ElementType: types.StringType,
+			Optional:    true,
+			Description: `GCP KMS crypto key resource IDs
+(projects/<project>/locations/<location>/keyRings/<ring>/cryptoKeys/<key>)
+added to ` + target + ` as the gcp_kms field. sops calls GCP KMS with the
+credentials of its environment (GOOGLE_CREDENTIALS, GOOGLE_OAUTH_ACCESS_TOKEN,
+then Application Default Credentials), which apply to every key.`,
+		},
+		"azure_kv": schema.ListNestedAttribute{
+			Optional: true,
+			Description: "Azure Key Vault keys added to " + target + " as the azure_keyvault field. " +
+				"sops authenticates with the Azure default credential chain of its environment (client secret, " +
+				"workload identity, managed identity or Azure CLI), one identity for every key.",
+			NestedObject: schema.NestedAttributeObject{
+				Attributes: map[string]schema.Attribute{
+					"vault_url": schema.StringAttribute{
+						Required:    true,
+						Description: "Key Vault URL, e.g. https://my-vault.vault.azure.net.",
+					},
+					"key": schema.StringAttribute{
+						Required:    true,
+						Description: "Name of the key in the vault.",
+					},
+					"version": schema.StringAttribute{
+						Optional:    true,
+						Description: "Key version. When omitted, SOPS uses the latest version at encryption time.",
+					},
+				},
+			},
+		},
+	}
+}
+
+// ruleAttributes returns the schema for ruleModel. target names the rules
+// the attributes apply to, for the descriptions.
+func ruleAttributes(target string) map[string]schema.Attribute {
+	attrs := keySourceAttributes(target + ", alongside the other key sources,")
+	attrs["vault_key_name"] = schema.StringAttribute{
+		Optional:    true,
+		Description: "Name of the Vault Transit key referenced in " + target + ". Defaults to the provider-level default_vault_key_name unless key_groups is set.",
+	}
+	attrs["key_groups"] = schema.ListNestedAttribute{
+		Optional: true,
+		Description: `Key groups written to ` + target + ` as key_groups. The data key is
+split across the groups with Shamir's secret sharing, so decryption needs a key
+from shamir_threshold groups. Mutually exclusive with the other key sources.`,
+		NestedObject: schema.NestedAttributeObject{
+			Attributes: func() map[string]schema.Attribute {
+				group := keySourceAttributes("the key group")
+				group["vault_key_name"] = schema.StringAttribute{
+					Optional:    true,
+					Description: "Name of a Vault Transit key added to the key group as the hc_vault field.",
+				}
+				return group
+			}(),
+		},
+	}
+	attrs["shamir_threshold"] = schema.Int64Attribute{
+		Optional:    true,
+		Description: "Number of key groups required to decrypt. Requires key_groups. Defaults to all groups.",
+	}
+	attrs["unencrypted_suffix"] = schema.StringAttribute{
+		Optional:    true,
+		Description: "unencrypted_suffix written to " + target + ". Mutually exclusive with other scope options. Inherits the provider default when no scope option is set.",
+	}
+	attrs["encrypted_suffix"] = schema.StringAttribute{
+		Optional:    true,
+		Description: "encrypted_suffix written to " + target + ". Mutually exclusive with other scope options. Inherits the provider default when no scope option is set.",
+	}
+	attrs["unencrypted_regex"] = schema.StringAttribute{
+		Optional:    true,
+		Description: "unencrypted_regex written to " + target + ". Mutually exclusive with other scope options. Inherits the provider default when no scope option is set.",
+		Validators:  []validator.String{validRegex()},
+	}
+	attrs["encrypted_regex"] = schema.StringAttribute{
+		Optional:    true,
+		Description: "encrypted_regex written to " + target + ". Mutually exclusive with other scope options. Inherits the provider default when no scope option is set.",
+		Validators:  []validator.String{validRegex()},
+	}
+	attrs["mac_only_encrypted"] = schema.BoolAttribute{
+		Optional: true,
+		Description: `Writes mac_only_encrypted to ` + target + `, so the MAC covers only
+encrypted values and plaintext values left by a scope option can change without
+failing MAC verification.`,
+	}
+	return attrs
+}
+
 func (d *sopsConfigDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	attributes := map[string]schema.Attribute{
+		"id": schema.StringAttribute{
+			Computed:    true,
+			Description: "SHA-256 of the rendered content.",
+		},
+		"vault_transit_engine": schema.StringAttribute{
+			Optional:    true,
+			Description: "Vault Transit mount path for this data source. Overrides the provider-level vault_transit_engine. Defaults to 'transit'.",
+		},
+		"path_regexes": schema.ListAttribute{
+			ElementType: types.StringType,
+			Optional:    true,
+			Description: `Path regexes for which the Vault Transit key is applied. Each regex
+becomes one creation_rule entry in the output. When omitted, a single
+catch-all creation_rule is generated with no path_regex, matching all files.`,
+		},
+		"path_vault_keys": schema.MapAttribute{
+			ElementType: types.StringType,
+			Optional:    true,
+			Description: `Map of path regex to Vault Transit key name. Each entry becomes one
+creation_rule, in lexicographic order of the regexes. When vault_key_name (or
+the provider default) is also set, a trailing catch-all rule uses it for files
+that match no regex. Mutually exclusive with path_regexes and key_groups.`,
+		},
+		"destination_rules": schema.ListNestedAttribute{
+			Optional: true,
+			Description: `Destination rules written as destination_rules, used by sops publish. Each
+rule sets exactly one of s3_bucket, gcs_bucket or vault_path. Published files
+keep their existing keys.`,
+			NestedObject: schema.NestedAttributeObject{
+				Attributes: map[string]schema.Attribute{
+					"path_regex": schema.StringAttribute{
+						Optional:    true,
+						Description: "Files matching this regex are published to the rule's destination. When omitted, the rule matches all files.",
+					},
+					"s3_bucket": schema.StringAttribute{
+						Optional:    true,
+						Description: "S3 bucket to publish to.",
+					},
+					"s3_prefix": schema.StringAttribute{
+						Optional:    true,
+						Description: "Key prefix within s3_bucket.",
+					},
+					"gcs_bucket": schema.StringAttribute{
+						Optional:    true,
+						Description: "GCS bucket to publish to.",
+					},
+					"gcs_prefix": schema.StringAttribute{
+						Optional:    true,
+						Description: "Object prefix within gcs_bucket.",
+					},
+					"vault_path": schema.StringAttribute{
+						Optional:    true,
+						Description: "Vault KV path to publish to. Published files are decrypted and stored as KV secrets.",
+					},
+					"vault_address": schema.StringAttribute{
+						Optional:    true,
+						Description: "Vault address for vault_path. Defaults to the provider-level vault_address.",
+					},
+					"vault_kv_mount_name": schema.StringAttribute{
+						Optional:    true,
+						Description: "KV mount for vault_path. SOPS defaults to 'secret/'.",
+					},
+					"vault_kv_version": schema.Int64Attribute{
+						Optional:    true,
+						Description: "KV engine version for vault_path. SOPS defaults to 2.",
+					},
+					"omit_extensions": schema.BoolAttribute{
+						Optional:    true,
+						Description: "Publish files without their file extension.",
+					},
+				},
+			},
+		},
+		"extra_rules_yaml": schema.StringAttribute{
+			Optional: true,
+			Description: `YAML list of further creation rules, appended as written after the
+generated ones. Use it for sops options this data source does not model. sops
+uses the first matching rule, so a generated catch-all rule takes precedence.`,
+		},
+		"content": schema.StringAttribute{
+			Computed:    true,
+			Description: "Rendered .sops.yaml YAML content.",
+		},
+	}
+	for name, a := range ruleAttributes("every creation rule") {
+		attributes[name] = a
+	}
+
+	creationRule := ruleAttributes("the creation rule")
+	creationRule["path_regex"] = schema.StringAttribute{
+		Optional:    true,
+		Description: "Files matching this regex use the rule. When omitted, the rule matches all files.",
+	}
+
 	resp.Schema = schema.Schema{
 		Description: `Renders the content of a ` + "`.sops.yaml`" + ` configuration file for the
 HashiCorp Vault Transit backend.
@@ -41,6 +369,9 @@ Each entry in path_regexes becomes one creation_rule that points to the
 named Vault Transit key. When path_regexes is omitted, a single catch-all
 creation_rule is emitted with no path_regex, which matches all files.
 
+For files that need different keys or options per path, use creation_rule
+blocks instead; each block becomes one creation_rule, in order.
+
 Use the output with the ` + "`local_file`" + ` resource to write the file to disk:
 
     data "sops_config" "example" {
@@ -50,30 +381,20 @@ Use the output with the ` + "`local_file`" + ` resource to write the file to dis
     resource "local_file" "sops_yaml" {
       content  = data.sops_config.example.content
       filename = "${path.module}/.sops.yaml"
-    }`,
-		Attributes: map[string]schema.Attribute{
-			"id": schema.StringAttribute{
-				Computed:    true,
-				Description: "SHA-256 of the rendered content.",
-			},
-			"vault_key_name": schema.StringAttribute{
-				Required:    true,
-				Description: "Name of the Vault Transit key referenced in every creation rule.",
-			},
-			"vault_transit_engine": schema.StringAttribute{
-				Optional:    true,
-				Description: "Vault Transit mount path for this data source. Overrides the provider-level vault_transit_engine. Defaults to 'transit'.",
-			},
-			"path_regexes": schema.ListAttribute{
-				ElementType: types.StringType,
-				Optional:    true,
-				Description: `Path regexes for which the Vault Transit key is applied. Each regex
-becomes one creation_rule entry in the output. When omitted, a single
-catch-all creation_rule is generated with no path_regex, matching all files.`,
-			},
-			"content": schema.StringAttribute{
-				Computed:    true,
-				Description: "Rendered .sops.yaml YAML content.",
+    }
+
+The sops_config_file resource accepts the same arguments and writes the file
+itself.`,
+		Attributes: attributes,
+		Blocks: map[string]schema.Block{
+			"creation_rule": schema.ListNestedBlock{
+				Description: `One creation_rule, rendered in block order. SOPS uses the first rule whose
+path_regex matches, so catch-all rules belong last. Mutually exclusive with
+the top-level shorthand (path_regexes, path_vault_keys, and the key sources and
+options applied to every rule).`,
+				NestedObject: schema.NestedBlockObject{
+					Attributes: creationRule,
+				},
 			},
 		},
 	}
@@ -99,33 +420,330 @@ func (d *sopsConfigDataSource) Read(ctx context.Context, req datasource.ReadRequ
 		return
 	}
 
-	var pathRegexes []string
-	if !data.PathRegexes.IsNull() && !data.PathRegexes.IsUnknown() {
-		resp.Diagnostics.Append(data.PathRegexes.ElementsAs(ctx, &pathRegexes, false)...)
-		if resp.Diagnostics.HasError() {
-			return
-		}
+	content, diags := d.pd.renderConfig(ctx, data)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
 	}
 
+	data.ID = sha256Hex(content)
+	data.Content = types.StringValue(content)
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// renderConfig renders the .sops.yaml content described by data, shared by
+// the sops_config data source and the sops_config_file resource.
+func (pd *sopsProviderData) renderConfig(ctx context.Context, data sopsConfigModel) (string, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
 	transitEngine := data.VaultTransitEngine.ValueString()
 	if transitEngine == "" {
-		transitEngine = d.pd.vaultTransitEngine
+		transitEngine = pd.vaultTransitEngine
+	}
+
+	var rules []sopsencrypt.CreationRule
+	if len(data.CreationRules) > 0 {
+		if data.configured() || !data.PathRegexes.IsNull() || !data.PathVaultKeys.IsNull() {
+			diags.AddAttributeError(path.Root("creation_rule"),
+				"Conflicting creation rules",
+				"creation_rule blocks cannot be combined with the top-level shorthand; move path_regexes, path_vault_keys, key sources and rule options into the blocks.")
+			return "", diags
+		}
+		for i, cr := range data.CreationRules {
+			rule, ruleDiags := pd.creationRule(ctx, cr.ruleModel, path.Root("creation_rule").AtListIndex(i), transitEngine, false)
+			diags.Append(ruleDiags...)
+			if diags.HasError() {
+				return "", diags
+			}
+			rule.PathRegex = cr.PathRegex.ValueString()
+			rules = append(rules, rule)
+		}
+	} else {
+		rules = pd.shorthandRules(ctx, data, transitEngine, &diags)
+		if diags.HasError() {
+			return "", diags
+		}
+	}
+
+	file := sopsencrypt.ConfigFile{CreationRules: rules, ExtraCreationRules: data.ExtraRulesYAML.ValueString()}
+	if file.ExtraCreationRules != "" {
+		extra, err := sopsencrypt.ParseExtraCreationRules(file.ExtraCreationRules)
+		if err != nil {
+			diags.AddAttributeError(path.Root("extra_rules_yaml"), "Invalid extra_rules_yaml", err.Error())
+			return "", diags
+		}
+		if len(extra) > 0 && len(rules) > 0 && rules[len(rules)-1].PathRegex == "" {
+			diags.AddAttributeWarning(path.Root("extra_rules_yaml"),
+				"Unreachable extra rules",
+				"The last generated creation rule has no path_regex and matches every file, so sops never reaches the rules in extra_rules_yaml. Set path_regexes or give the last creation_rule block a path_regex.")
+		}
+	}
+	for _, r := range data.DestinationRules {
+		dest := sopsencrypt.DestinationRule{
+			PathRegex:        r.PathRegex.ValueString(),
+			S3Bucket:         r.S3Bucket.ValueString(),
+			S3Prefix:         r.S3Prefix.ValueString(),
+			GCSBucket:        r.GCSBucket.ValueString(),
+			GCSPrefix:        r.GCSPrefix.ValueString(),
+			VaultPath:        r.VaultPath.ValueString(),
+			VaultAddress:     r.VaultAddress.ValueString(),
+			VaultKVMountName: r.VaultKVMountName.ValueString(),
+			VaultKVVersion:   int(r.VaultKVVersion.ValueInt64()),
+			OmitExtensions:   r.OmitExtensions.ValueBool(),
+		}
+		if dest.VaultPath != "" && dest.VaultAddress == "" {
+			dest.VaultAddress = pd.vaultAddress
+		}
+		file.DestinationRules = append(file.DestinationRules, dest)
 	}
 
-	content, err := sopsencrypt.GenerateSOPSConfig(
-		d.pd.vaultAddress,
-		transitEngine,
-		data.VaultKeyName.ValueString(),
-		pathRegexes,
-	)
+	content, err := sopsencrypt.GenerateSOPSConfigFile(file)
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to generate SOPS config", err.Error())
-		return
+		diags.AddError("Failed to generate SOPS config", err.Error())
 	}
+	return content, diags
+}
 
-	h := sha256.Sum256([]byte(content))
-	data.ID = types.StringValue(fmt.Sprintf("%x", h))
-	data.Content = types.StringValue(content)
+// shorthandRules expands the top-level attributes into creation rules: one
+// per path_vault_keys entry, then one per path_regexes entry or a single
+// catch-all.
+func (pd *sopsProviderData) shorthandRules(ctx context.Context, data sopsConfigModel, transitEngine string, diags *diag.Diagnostics) []sopsencrypt.CreationRule {
+	var pathRegexes []string
+	if !data.PathRegexes.IsNull() && !data.PathRegexes.IsUnknown() {
+		diags.Append(data.PathRegexes.ElementsAs(ctx, &pathRegexes, false)...)
+	}
+	var pathVaultKeys map[string]string
+	if !data.PathVaultKeys.IsNull() && !data.PathVaultKeys.IsUnknown() {
+		diags.Append(data.PathVaultKeys.ElementsAs(ctx, &pathVaultKeys, false)...)
+	}
+	if diags.HasError() {
+		return nil
+	}
 
-	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+	if len(pathVaultKeys) > 0 {
+		switch {
+		case len(pathRegexes) > 0:
+			diags.AddAttributeError(path.Root("path_vault_keys"),
+				"Conflicting path options",
+				"path_vault_keys and path_regexes cannot both be set; add the regexes to path_vault_keys instead.")
+			return nil
+		case len(data.KeyGroups) > 0:
+			diags.AddAttributeError(path.Root("key_groups"),
+				"Conflicting key sources",
+				"key_groups cannot be combined with path_vault_keys; use creation_rule blocks instead.")
+			return nil
+		case !pd.requireVaultAddress(diags):
+			return nil
+		}
+	}
+
+	// The Vault key comes from path_vault_keys, so the shared rule may be
+	// otherwise empty.
+	scope, ruleDiags := pd.creationRule(ctx, data.ruleModel, path.Empty(), transitEngine, len(pathVaultKeys) > 0)
+	diags.Append(ruleDiags...)
+	if diags.HasError() {
+		return nil
+	}
+
+	var rules []sopsencrypt.CreationRule
+	if len(pathVaultKeys) > 0 {
+		regexes := make([]string, 0, len(pathVaultKeys))
+		for re := range pathVaultKeys {
+			regexes = append(regexes, re)
+		}
+		sort.Strings(regexes)
+		for _, re := range regexes {
+			rule := scope
+			rule.PathRegex = re
+			rule.Keys.VaultTransitURI = sopsencrypt.VaultTransitURI(pd.vaultAddress, transitEngine, pathVaultKeys[re])
+			rules = append(rules, rule)
+		}
+	}
+
+	switch {
+	case len(pathRegexes) > 0:
+		for _, re := range pathRegexes {
+			rule := scope
+			rule.PathRegex = re
+			rules = append(rules, rule)
+		}
+	case len(pathVaultKeys) == 0 || scope.Keys.VaultTransitURI != "":
+		// A catch-all rule, which follows any path_vault_keys rules so that
+		// they take precedence.
+		rules = append(rules, scope)
+	}
+	return rules
+}
+
+// creationRule converts a ruleModel to a creation rule without a path_regex,
+// resolving the Vault key and scope options against the provider defaults.
+// base is the path of the model in the configuration, for diagnostics. When
+// allowNoKeys is false, a rule without any key source is an error.
+func (pd *sopsProviderData) creationRule(ctx context.Context, m ruleModel, base path.Path, transitEngine string, allowNoKeys bool) (sopsencrypt.CreationRule, diag.Diagnostics) {
+	rule := pd.ruleScope(m.UnencryptedSuffix, m.EncryptedSuffix, m.UnencryptedRegex, m.EncryptedRegex)
+	rule.MACOnlyEncrypted = m.MACOnlyEncrypted.ValueBool()
+
+	keys, diags := m.keySources(ctx, base)
+	if diags.HasError() {
+		return rule, diags
+	}
+
+	if len(m.KeyGroups) > 0 {
+		if isSet(m.VaultKeyName) || !keys.Empty() {
+			diags.AddAttributeError(base.AtName("key_groups"),
+				"Conflicting key sources",
+				"key_groups cannot be combined with vault_key_name or the other key sources; move them into a key group.")
+			return rule, diags
+		}
+		for i, g := range m.KeyGroups {
+			groupPath := base.AtName("key_groups").AtListIndex(i)
+			group, groupDiags := g.keySources(ctx, groupPath)
+			diags.Append(groupDiags...)
+			if diags.HasError() {
+				return rule, diags
+			}
+			if isSet(g.VaultKeyName) {
+				if !pd.requireVaultAddress(&diags) {
+					return rule, diags
+				}
+				group.VaultTransitURI = sopsencrypt.VaultTransitURI(pd.vaultAddress, transitEngine, g.VaultKeyName.ValueString())
+			}
+			pd.checkFIPSKeys(group, groupPath, &diags)
+			if diags.HasError() {
+				return rule, diags
+			}
+			if group.Empty() {
+				diags.AddAttributeError(groupPath,
+					"Empty key group",
+					"Each key group needs at least one key source.")
+				return rule, diags
+			}
+			rule.KeyGroups = append(rule.KeyGroups, group)
+		}
+		rule.ShamirThreshold = int(m.ShamirThreshold.ValueInt64())
+		return rule, diags
+	}
+
+	if !m.ShamirThreshold.IsNull() {
+		diags.AddAttributeError(base.AtName("shamir_threshold"),
+			"Missing key groups",
+			"shamir_threshold applies to key_groups, which is not set.")
+		return rule, diags
+	}
+
+	pd.checkFIPSKeys(keys, base, &diags)
+	if diags.HasError() {
+		return rule, diags
+	}
+	if keyName := pd.vaultKeyName(m.VaultKeyName); keyName != "" {
+		if !pd.requireVaultAddress(&diags) {
+			return rule, diags
+		}
+		keys.VaultTransitURI = sopsencrypt.VaultTransitURI(pd.vaultAddress, transitEngine, keyName)
+	}
+	if keys.Empty() && !allowNoKeys {
+		diags.AddAttributeError(base.AtName("vault_key_name"),
+			"Missing key source",
+			"Set vault_key_name, age_recipients, pgp_fingerprints, kms_arns, kms_keys, gcp_kms_ids, azure_kv or key_groups, or default_vault_key_name on the provider.")
+		return rule, diags
+	}
+	rule.Keys = keys
+	return rule, diags
+}
+
+// configured reports whether any attribute of the model is set.
+func (m ruleModel) configured() bool {
+	return !m.VaultKeyName.IsNull() || m.keySourcesModel.configured() ||
+		len(m.KeyGroups) > 0 || !m.ShamirThreshold.IsNull() ||
+		!m.UnencryptedSuffix.IsNull() || !m.EncryptedSuffix.IsNull() ||
+		!m.UnencryptedRegex.IsNull() || !m.EncryptedRegex.IsNull() ||
+		!m.MACOnlyEncrypted.IsNull()
+}
+
+// configured reports whether any attribute of the model is set.
+func (m keySourcesModel) configured() bool {
+	return !m.AgeRecipients.IsNull() || !m.PGPFingerprints.IsNull() ||
+		!m.KMSARNs.IsNull() || !m.KMSRole.IsNull() || !m.KMSContext.IsNull() ||
+		len(m.KMSKeys) > 0 || !m.GCPKMSIDs.IsNull() || len(m.AzureKV) > 0
+}
+
+// requireVaultAddress reports whether Vault Transit URIs can be rendered,
+// adding an error if the provider has no Vault address.
+func (pd *sopsProviderData) requireVaultAddress(diags *diag.Diagnostics) bool {
+	if pd.vaultAddress == "" && !pd.mock {
+		diags.AddError("Missing Vault address",
+			"Vault Transit creation rules require vault_address on the provider.")
+		return false
+	}
+	return true
+}
+
+// keySources converts the model to sopsencrypt key sources. base is the
+// path of the model in the configuration, for diagnostics.
+func (m keySourcesModel) keySources(ctx context.Context, base path.Path) (sopsencrypt.KeySources, diag.Diagnostics) {
+	var keys sopsencrypt.KeySources
+	var diags diag.Diagnostics
+
+	if !m.AgeRecipients.IsNull() && !m.AgeRecipients.IsUnknown() {
+		diags.Append(m.AgeRecipients.ElementsAs(ctx, &keys.AgeRecipients, false)...)
+	}
+	if !m.PGPFingerprints.IsNull() && !m.PGPFingerprints.IsUnknown() {
+		diags.Append(m.PGPFingerprints.ElementsAs(ctx, &keys.PGPFingerprints, false)...)
+	}
+	if !m.GCPKMSIDs.IsNull() && !m.GCPKMSIDs.IsUnknown() {
+		diags.Append(m.GCPKMSIDs.ElementsAs(ctx, &keys.GCPKMSIDs, false)...)
+	}
+	for _, kv := range m.AzureKV {
+		keys.AzureKVKeys = append(keys.AzureKVKeys, sopsencrypt.AzureKVKey{
+			VaultURL: kv.VaultURL.ValueString(),
+			Key:      kv.Key.ValueString(),
+			Version:  kv.Version.ValueString(),
+		})
+	}
+
+	if !m.KMSARNs.IsNull() && !m.KMSARNs.IsUnknown() {
+		var arns []string
+		diags.Append(m.KMSARNs.ElementsAs(ctx, &arns, false)...)
+		var kmsContext map[string]string
+		if !m.KMSContext.IsNull() && !m.KMSContext.IsUnknown() {
+			diags.Append(m.KMSContext.ElementsAs(ctx, &kmsContext, false)...)
+		}
+		for _, arn := range arns {
+			keys.KMSKeys = append(keys.KMSKeys, sopsencrypt.KMSKey{
+				ARN:     arn,
+				Role:    m.KMSRole.ValueString(),
+				Context: kmsContext,
+			})
+		}
+	} else if isSet(m.KMSRole) || !m.KMSContext.IsNull() {
+		diags.AddAttributeError(base.AtName("kms_arns"),
+			"Missing KMS ARNs",
+			"kms_role and kms_context apply to the keys in kms_arns, which is not set.")
+	}
+	for i, key := range m.KMSKeys {
+		kmsKey := sopsencrypt.KMSKey{
+			ARN:     key.ARN.ValueString(),
+			Role:    key.RoleARN.ValueString(),
+			Profile: key.Profile.ValueString(),
+		}
+		if !key.EncryptionContext.IsNull() && !key.EncryptionContext.IsUnknown() {
+			diags.Append(key.EncryptionContext.ElementsAs(ctx, &kmsKey.Context, false)...)
+		}
+		if key.ReplicaRegions.IsNull() || key.ReplicaRegions.IsUnknown() {
+			keys.KMSKeys = append(keys.KMSKeys, kmsKey)
+			continue
+		}
+		var regions []string
+		diags.Append(key.ReplicaRegions.ElementsAs(ctx, &regions, false)...)
+		replicas, err := kmsKey.Replicas(regions)
+		if err != nil {
+			diags.AddAttributeError(base.AtName("kms_keys").AtListIndex(i).AtName("replica_regions"),
+				"Invalid replica_regions", err.Error())
+			continue
+		}
+		keys.KMSKeys = append(keys.KMSKeys, replicas...)
+	}
+
+	return keys, diags
 }
@@ -3,6 +3,7 @@ package provider_test
 import (
 	"fmt"
 	"os"
+	"regexp"
 	"strings"
 	"testing"
 
@@ -81,7 +82,10 @@ func testAccSOPSConfigDefault(vaultAddr, vaultToken, keyName string) string {
 	return fmt.Sprintf(`
 provider "sops" {
   vault_address = %q
-  vault_token   = %q
+
+  auth {
+    token = %q
+  }
 }
 
 data "sops_config" "test" {
@@ -94,7 +98,10 @@ func testAccSOPSConfigCustom(vaultAddr, vaultToken, keyName string) string {
 	return fmt.Sprintf(`
 provider "sops" {
   vault_address = %q
-  vault_token   = %q
+
+  auth {
+    token = %q
+  }
 }
 
 data "sops_config" "test" {
@@ -103,3 +110,608 @@ data "sops_config" "test" {
 }
 `, vaultAddr, vaultToken, keyName)
 }
+
+// TestAccSOPSConfigDataSource_AgeRecipients verifies that age recipients are
+// rendered alongside the Vault Transit URI.
+func TestAccSOPSConfigDataSource_AgeRecipients(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+	vaultAddr := requireEnv(t, "VAULT_ADDR")
+	vaultToken := requireEnv(t, "VAULT_TOKEN")
+	keyName := envOrDefault("SOPS_VAULT_KEY", "sops-test")
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccSOPSConfigAge(vaultAddr, vaultToken, keyName),
+				Check: resource.TestCheckResourceAttrWith("data.sops_config.test", "content",
+					func(v string) error {
+						for _, want := range []string{"hc_vault_transit_uri:", "age: age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p"} {
+							if !strings.Contains(v, want) {
+								return fmt.Errorf("content missing %q; got:\n%s", want, v)
+							}
+						}
+						return nil
+					}),
+			},
+		},
+	})
+}
+
+func testAccSOPSConfigAge(vaultAddr, vaultToken, keyName string) string {
+	return fmt.Sprintf(`
+provider "sops" {
+  vault_address = %q
+
+  auth {
+    token = %q
+  }
+}
+
+data "sops_config" "test" {
+  vault_key_name = %q
+  age_recipients = ["age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p"]
+}
+`, vaultAddr, vaultToken, keyName)
+}
+
+// TestAccSOPSConfigDataSource_PGPFingerprints verifies that PGP fingerprints
+// are rendered as the pgp field without requiring Vault.
+func TestAccSOPSConfigDataSource_PGPFingerprints(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccSOPSConfigPGP(),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrWith("data.sops_config.test", "content",
+						func(v string) error {
+							if !strings.Contains(v, "pgp: FBC7B9E2A4F9289AC0C1D4843D16CEE4A27381B4") {
+								return fmt.Errorf("content missing pgp field; got:\n%s", v)
+							}
+							if strings.Contains(v, "hc_vault_transit_uri") {
+								return fmt.Errorf("content must not reference Vault; got:\n%s", v)
+							}
+							return nil
+						}),
+				),
+			},
+		},
+	})
+}
+
+func testAccSOPSConfigPGP() string {
+	return `
+provider "sops" {
+  age_recipients = ["age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p"]
+}
+
+data "sops_config" "test" {
+  pgp_fingerprints = ["FBC7B9E2A4F9289AC0C1D4843D16CEE4A27381B4"]
+}
+`
+}
+
+// TestAccSOPSConfigDataSource_KMS verifies that KMS ARNs are rendered with
+// their role, and that an encryption context switches the rule to key_groups.
+func TestAccSOPSConfigDataSource_KMS(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccSOPSConfigKMS(""),
+				Check: resource.TestCheckResourceAttrWith("data.sops_config.test", "content",
+					func(v string) error {
+						if !strings.Contains(v, "kms: arn:aws:kms:us-east-1:111122223333:key/a+arn:aws:iam::111122223333:role/sops") {
+							return fmt.Errorf("content missing kms field; got:\n%s", v)
+						}
+						return nil
+					}),
+			},
+			{
+				Config: testAccSOPSConfigKMS(`kms_context = { app = "web" }`),
+				Check: resource.TestCheckResourceAttrWith("data.sops_config.test", "content",
+					func(v string) error {
+						for _, want := range []string{"key_groups:", "app: web"} {
+							if !strings.Contains(v, want) {
+								return fmt.Errorf("content missing %q; got:\n%s", want, v)
+							}
+						}
+						return nil
+					}),
+			},
+		},
+	})
+}
+
+func testAccSOPSConfigKMS(extra string) string {
+	return fmt.Sprintf(`
+provider "sops" {
+  age_recipients = ["age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p"]
+}
+
+data "sops_config" "test" {
+  kms_arns = ["arn:aws:kms:us-east-1:111122223333:key/a"]
+  kms_role = "arn:aws:iam::111122223333:role/sops"
+  %s
+}
+`, extra)
+}
+
+// TestAccSOPSConfigDataSource_KMSKeys verifies that kms_keys entries keep
+// their own role, profile and encryption context.
+func TestAccSOPSConfigDataSource_KMSKeys(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+provider "sops" {
+  age_recipients = ["age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p"]
+}
+
+data "sops_config" "test" {
+  kms_keys = [
+    {
+      arn      = "arn:aws:kms:us-east-1:111122223333:key/a"
+      role_arn = "arn:aws:iam::111122223333:role/sops"
+    },
+    {
+      arn                = "arn:aws:kms:eu-west-1:444455556666:key/b"
+      profile            = "shared-services"
+      encryption_context = { team = "platform" }
+    },
+  ]
+}
+`,
+				Check: resource.TestCheckResourceAttrWith("data.sops_config.test", "content",
+					func(v string) error {
+						for _, want := range []string{"key_groups:", "role: arn:aws:iam::111122223333:role/sops", "aws_profile: shared-services", "team: platform"} {
+							if !strings.Contains(v, want) {
+								return fmt.Errorf("content missing %q; got:\n%s", want, v)
+							}
+						}
+						return nil
+					}),
+			},
+		},
+	})
+}
+
+// TestAccSOPSConfigDataSource_KMSRuleOptionsAndKeys verifies that kms_role
+// and kms_context apply to kms_arns only, and that the context switches the
+// kms_keys entries into the same key group.
+func TestAccSOPSConfigDataSource_KMSRuleOptionsAndKeys(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+provider "sops" {
+  age_recipients = ["age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p"]
+}
+
+data "sops_config" "test" {
+  kms_arns    = ["arn:aws:kms:us-east-1:111122223333:key/a"]
+  kms_role    = "arn:aws:iam::111122223333:role/rule"
+  kms_context = { app = "web" }
+  kms_keys = [{
+    arn      = "arn:aws:kms:eu-west-1:444455556666:key/b"
+    role_arn = "arn:aws:iam::444455556666:role/key"
+  }]
+}
+`,
+				Check: resource.TestCheckResourceAttr("data.sops_config.test", "content",
+					"creation_rules:\n"+
+						"  - key_groups:\n"+
+						"      - kms:\n"+
+						"          - arn: arn:aws:kms:us-east-1:111122223333:key/a\n"+
+						"            role: arn:aws:iam::111122223333:role/rule\n"+
+						"            context:\n"+
+						"              app: web\n"+
+						"          - arn: arn:aws:kms:eu-west-1:444455556666:key/b\n"+
+						"            role: arn:aws:iam::444455556666:role/key\n"),
+			},
+		},
+	})
+}
+
+// TestAccSOPSConfigDataSource_KMSReplicaRegions verifies that a
+// multi-region key is expanded into one ARN per region, and that
+// replica_regions is rejected for a single-region key.
+func TestAccSOPSConfigDataSource_KMSReplicaRegions(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+
+	const config = `
+provider "sops" {
+  age_recipients = ["age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p"]
+}
+
+data "sops_config" "test" {
+  kms_keys = [{
+    arn             = "arn:aws:kms:us-east-1:111122223333:key/%s"
+    replica_regions = ["eu-west-1"]
+  }]
+}
+`
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(config, "mrk-1234abcd12ab34cd56ef1234567890ab"),
+				Check: resource.TestCheckResourceAttrWith("data.sops_config.test", "content",
+					func(v string) error {
+						want := "kms: arn:aws:kms:us-east-1:111122223333:key/mrk-1234abcd12ab34cd56ef1234567890ab,arn:aws:kms:eu-west-1:111122223333:key/mrk-1234abcd12ab34cd56ef1234567890ab"
+						if !strings.Contains(v, want) {
+							return fmt.Errorf("content missing replica ARNs; got:\n%s", v)
+						}
+						return nil
+					}),
+			},
+			{
+				Config:      fmt.Sprintf(config, "1234abcd-12ab-34cd-56ef-1234567890ab"),
+				ExpectError: regexp.MustCompile(`Invalid replica_regions`),
+			},
+		},
+	})
+}
+
+// TestAccSOPSConfigDataSource_GCPKMS verifies that GCP KMS resource IDs are
+// rendered as the gcp_kms field.
+func TestAccSOPSConfigDataSource_GCPKMS(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccSOPSConfigGCPKMS(),
+				Check: resource.TestCheckResourceAttrWith("data.sops_config.test", "content",
+					func(v string) error {
+						if !strings.Contains(v, "gcp_kms: projects/p/locations/global/keyRings/r/cryptoKeys/k") {
+							return fmt.Errorf("content missing gcp_kms field; got:\n%s", v)
+						}
+						return nil
+					}),
+			},
+		},
+	})
+}
+
+func testAccSOPSConfigGCPKMS() string {
+	return `
+provider "sops" {
+  age_recipients = ["age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p"]
+}
+
+data "sops_config" "test" {
+  gcp_kms_ids = ["projects/p/locations/global/keyRings/r/cryptoKeys/k"]
+}
+`
+}
+
+// TestAccSOPSConfigDataSource_AzureKV verifies that Azure Key Vault keys are
+// rendered as key URLs in the azure_keyvault field.
+func TestAccSOPSConfigDataSource_AzureKV(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccSOPSConfigAzureKV(),
+				Check: resource.TestCheckResourceAttrWith("data.sops_config.test", "content",
+					func(v string) error {
+						if !strings.Contains(v, "azure_keyvault: https://v.vault.azure.net/keys/sops/1234") {
+							return fmt.Errorf("content missing azure_keyvault field; got:\n%s", v)
+						}
+						return nil
+					}),
+			},
+		},
+	})
+}
+
+func testAccSOPSConfigAzureKV() string {
+	return `
+provider "sops" {
+  age_recipients = ["age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p"]
+}
+
+data "sops_config" "test" {
+  azure_kv = [{
+    vault_url = "https://v.vault.azure.net"
+    key       = "sops"
+    version   = "1234"
+  }]
+}
+`
+}
+
+// TestAccSOPSConfigDataSource_Scope verifies that a scope option is written to
+// every creation rule, and that the provider default applies otherwise.
+func TestAccSOPSConfigDataSource_Scope(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccSOPSConfigScope(`
+  encrypted_regex    = "^(data|stringData)$"
+  mac_only_encrypted = true`),
+				Check: resource.TestCheckResourceAttrWith("data.sops_config.test", "content",
+					func(v string) error {
+						if strings.Count(v, "encrypted_regex: ^(data|stringData)$") != 2 {
+							return fmt.Errorf("want encrypted_regex on both rules; got:\n%s", v)
+						}
+						if strings.Count(v, "mac_only_encrypted: true") != 2 {
+							return fmt.Errorf("want mac_only_encrypted on both rules; got:\n%s", v)
+						}
+						if strings.Contains(v, "unencrypted_regex") {
+							return fmt.Errorf("provider default must not combine with the data source scope; got:\n%s", v)
+						}
+						return nil
+					}),
+			},
+			{
+				Config: testAccSOPSConfigScope(""),
+				Check: resource.TestCheckResourceAttrWith("data.sops_config.test", "content",
+					func(v string) error {
+						if strings.Count(v, "unencrypted_regex: ^metadata$") != 2 {
+							return fmt.Errorf("want provider default unencrypted_regex on both rules; got:\n%s", v)
+						}
+						return nil
+					}),
+			},
+		},
+	})
+}
+
+func testAccSOPSConfigScope(scope string) string {
+	return fmt.Sprintf(`
+provider "sops" {
+  age_recipients            = ["age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p"]
+  default_unencrypted_regex = "^metadata$"
+}
+
+data "sops_config" "test" {
+  age_recipients = ["age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p"]
+  path_regexes   = ["^secrets/", "^config/"]
+  %s
+}
+`, scope)
+}
+
+// TestAccSOPSConfigDataSource_KeyGroups verifies that key groups and the
+// Shamir threshold are rendered into every creation rule.
+func TestAccSOPSConfigDataSource_KeyGroups(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+	vaultAddr := requireEnv(t, "VAULT_ADDR")
+	vaultToken := requireEnv(t, "VAULT_TOKEN")
+	keyName := envOrDefault("SOPS_VAULT_KEY", "sops-test")
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccSOPSConfigKeyGroups(vaultAddr, vaultToken, keyName),
+				Check: resource.TestCheckResourceAttrWith("data.sops_config.test", "content",
+					func(v string) error {
+						for _, want := range []string{"key_groups:", "hc_vault:", "- age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p", "shamir_threshold: 2"} {
+							if !strings.Contains(v, want) {
+								return fmt.Errorf("content missing %q; got:\n%s", want, v)
+							}
+						}
+						return nil
+					}),
+			},
+		},
+	})
+}
+
+func testAccSOPSConfigKeyGroups(vaultAddr, vaultToken, keyName string) string {
+	return fmt.Sprintf(`
+provider "sops" {
+  vault_address = %q
+
+  auth {
+    token = %q
+  }
+}
+
+data "sops_config" "test" {
+  key_groups = [
+    { vault_key_name = %q },
+    { age_recipients = ["age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p"] },
+  ]
+  shamir_threshold = 2
+}
+`, vaultAddr, vaultToken, keyName)
+}
+
+// TestAccSOPSConfigDataSource_PathVaultKeys verifies that each path regex
+// gets its own Vault key, followed by a catch-all rule for vault_key_name.
+func TestAccSOPSConfigDataSource_PathVaultKeys(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+	vaultAddr := requireEnv(t, "VAULT_ADDR")
+	vaultToken := requireEnv(t, "VAULT_TOKEN")
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccSOPSConfigPathVaultKeys(vaultAddr, vaultToken),
+				Check: resource.TestCheckResourceAttrWith("data.sops_config.test", "content",
+					func(v string) error {
+						dev := strings.Index(v, "/keys/dev")
+						prod := strings.Index(v, "/keys/prod")
+						shared := strings.Index(v, "/keys/shared")
+						if dev < 0 || prod < 0 || shared < 0 {
+							return fmt.Errorf("content missing a key; got:\n%s", v)
+						}
+						if !(dev < prod && prod < shared) {
+							return fmt.Errorf("want dev, prod, then catch-all shared rule; got:\n%s", v)
+						}
+						return nil
+					}),
+			},
+		},
+	})
+}
+
+func testAccSOPSConfigPathVaultKeys(vaultAddr, vaultToken string) string {
+	return fmt.Sprintf(`
+provider "sops" {
+  vault_address = %q
+
+  auth {
+    token = %q
+  }
+}
+
+data "sops_config" "test" {
+  vault_key_name = "shared"
+  path_vault_keys = {
+    "^secrets/prod/" = "prod"
+    "^secrets/dev/"  = "dev"
+  }
+}
+`, vaultAddr, vaultToken)
+}
+
+// TestAccSOPSConfigDataSource_DestinationRules verifies that destination rules
+// are rendered, with vault_address defaulting to the provider address.
+func TestAccSOPSConfigDataSource_DestinationRules(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+	vaultAddr := requireEnv(t, "VAULT_ADDR")
+	vaultToken := requireEnv(t, "VAULT_TOKEN")
+	keyName := envOrDefault("SOPS_VAULT_KEY", "sops-test")
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccSOPSConfigDestinationRules(vaultAddr, vaultToken, keyName),
+				Check: resource.TestCheckResourceAttrWith("data.sops_config.test", "content",
+					func(v string) error {
+						for _, want := range []string{
+							"destination_rules:",
+							"s3_bucket: published-secrets",
+							"vault_path: app",
+							"vault_address: " + vaultAddr,
+						} {
+							if !strings.Contains(v, want) {
+								return fmt.Errorf("content missing %q; got:\n%s", want, v)
+							}
+						}
+						return nil
+					}),
+			},
+		},
+	})
+}
+
+func testAccSOPSConfigDestinationRules(vaultAddr, vaultToken, keyName string) string {
+	return fmt.Sprintf(`
+provider "sops" {
+  vault_address = %q
+
+  auth {
+    token = %q
+  }
+}
+
+data "sops_config" "test" {
+  vault_key_name = %q
+
+  destination_rules = [
+    {
+      path_regex = "^s3/"
+      s3_bucket  = "published-secrets"
+    },
+    {
+      path_regex = "^vault/"
+      vault_path = "app"
+    },
+  ]
+}
+`, vaultAddr, vaultToken, keyName)
+}
+
+// TestAccSOPSConfigDataSource_CreationRuleBlocks verifies that creation_rule
+// blocks render one rule each, in order, with their own keys and options.
+func TestAccSOPSConfigDataSource_CreationRuleBlocks(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccSOPSConfigCreationRules(),
+				Check: resource.TestCheckResourceAttr("data.sops_config.test", "content",
+					"creation_rules:\n"+
+						"  - path_regex: ^k8s/\n"+
+						"    age: age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p\n"+
+						"    encrypted_regex: ^(data|stringData)$\n"+
+						"  - pgp: FBC7B9E2A4F9289AC0C1D4843D16CEE4A27381B4\n"+
+						"    mac_only_encrypted: true\n"),
+			},
+		},
+	})
+}
+
+func testAccSOPSConfigCreationRules() string {
+	return `
+provider "sops" {
+  age_recipients = ["age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p"]
+}
+
+data "sops_config" "test" {
+  creation_rule {
+    path_regex      = "^k8s/"
+    age_recipients  = ["age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p"]
+    encrypted_regex = "^(data|stringData)$"
+  }
+
+  creation_rule {
+    pgp_fingerprints   = ["FBC7B9E2A4F9289AC0C1D4843D16CEE4A27381B4"]
+    mac_only_encrypted = true
+  }
+}
+`
+}
@@ -0,0 +1,179 @@
+package provider
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"terraform-provider-sops/internal/sopsencrypt"
+)
+
+var (
+	_ datasource.DataSource              = &transitDecryptedStringDataSource{}
+	_ datasource.DataSourceWithConfigure = &transitDecryptedStringDataSource{}
+)
+
+type transitDecryptedStringDataSource struct{ pd *sopsProviderData }
+
+type transitDecryptedStringModel struct {
+	ID                 types.String             `tfsdk:"id"`
+	Ciphertext         types.String             `tfsdk:"ciphertext"`
+	Context            types.String             `tfsdk:"context"`
+	VaultKeyName       types.String             `tfsdk:"vault_key_name"`
+	VaultAddress       types.String             `tfsdk:"vault_address"`
+	VaultTransitEngine types.String             `tfsdk:"vault_transit_engine"`
+	Plaintext          types.String             `tfsdk:"plaintext"`
+	PlaintextBase64    types.String             `tfsdk:"plaintext_base64"`
+	KeyVersion         types.Int64              `tfsdk:"key_version"`
+	Timeouts           *dataSourceTimeoutsModel `tfsdk:"timeouts"`
+}
+
+func NewTransitDecryptedStringDataSource() datasource.DataSource {
+	return &transitDecryptedStringDataSource{}
+}
+
+func (d *transitDecryptedStringDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_transit_decrypted_string"
+}
+
+func (d *transitDecryptedStringDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: `Decrypts a single Vault Transit ciphertext (vault:vN:...), such as the
+ciphertext of sops_transit_encrypted_string or a value written by another
+system with the same key, and exposes the plaintext as a sensitive value.
+Requires Vault; not available in mock or age-only mode.
+
+    data "sops_transit_decrypted_string" "db_password" {
+      ciphertext     = var.db_password_ciphertext
+      vault_key_name = "app-secrets"
+    }`,
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:    true,
+				Description: "SHA-256 of the ciphertext.",
+			},
+			"ciphertext": schema.StringAttribute{
+				Required:    true,
+				Description: "Transit ciphertext to decrypt, e.g. vault:v1:....",
+			},
+			"context": schema.StringAttribute{
+				Optional:    true,
+				Description: "Base64-encoded key derivation context the value was encrypted with, required by Transit keys created with derived=true.",
+				Validators:  []validator.String{validBase64()},
+			},
+			"vault_key_name": schema.StringAttribute{
+				Optional:    true,
+				Computed:    true,
+				Description: "Name of the Vault Transit key that encrypted the value. Defaults to the provider-level default_vault_key_name.",
+			},
+			"vault_address": schema.StringAttribute{
+				Optional:    true,
+				Description: "Vault server to decrypt with. Overrides the provider-level vault_address. The provider's Vault token must be valid there too.",
+			},
+			"vault_transit_engine": schema.StringAttribute{
+				Optional:    true,
+				Description: "Vault Transit mount path. Overrides the provider-level vault_transit_engine. Defaults to 'transit'.",
+			},
+			"plaintext": schema.StringAttribute{
+				Computed:    true,
+				Sensitive:   true,
+				Description: "The decrypted value; null if it is not valid UTF-8.",
+			},
+			"plaintext_base64": schema.StringAttribute{
+				Computed:    true,
+				Sensitive:   true,
+				Description: "The decrypted value, base64-encoded.",
+			},
+			"key_version": schema.Int64Attribute{
+				Computed:    true,
+				Description: "Version of the Transit key that encrypted the value, parsed from the vault:vN: prefix of ciphertext.",
+			},
+		},
+		Blocks: map[string]schema.Block{
+			"timeouts": dataSourceTimeoutsBlock(),
+		},
+	}
+}
+
+func (d *transitDecryptedStringDataSource) Configure(_ context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	pd, ok := req.ProviderData.(*sopsProviderData)
+	if !ok {
+		resp.Diagnostics.AddError("Unexpected provider data type",
+			fmt.Sprintf("Expected *sopsProviderData, got %T", req.ProviderData))
+		return
+	}
+	d.pd = pd
+}
+
+func (d *transitDecryptedStringDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data transitDecryptedStringModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ciphertext := data.Ciphertext.ValueString()
+	version, err := sopsencrypt.TransitCiphertextVersion(ciphertext)
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("ciphertext"), "Invalid Transit ciphertext", err.Error())
+		return
+	}
+	keyName := d.pd.vaultKeyName(data.VaultKeyName)
+	if keyName == "" {
+		resp.Diagnostics.AddAttributeError(path.Root("vault_key_name"),
+			"Missing Vault key name",
+			"Set vault_key_name on the data source or default_vault_key_name on the provider.")
+		return
+	}
+	var keyContext []byte
+	if isSet(data.Context) {
+		// Checked by validBase64.
+		keyContext, _ = base64.StdEncoding.DecodeString(data.Context.ValueString())
+	}
+
+	client, err := d.pd.newVaultClientAt(data.VaultAddress.ValueString(), data.Timeouts.read())
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to create Vault client", err.Error())
+		return
+	}
+	if client == nil {
+		resp.Diagnostics.AddError("Vault not configured",
+			"sops_transit_decrypted_string decrypts with Vault Transit and is not available in mock or age-only mode.")
+		return
+	}
+	transitEngine := resolveStringDefault(data.VaultTransitEngine, d.pd.vaultTransitEngine)
+	ctx = d.pd.logContext(ctx, client, d.pd.vaultAddressFor(data.VaultAddress), transitEngine, keyName)
+	release, err := d.pd.acquireVault(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError("Vault Transit decryption failed", err.Error())
+		return
+	}
+	defer release()
+	start := time.Now()
+	plaintext, err := sopsencrypt.TransitDecrypt(client, transitEngine, keyName, ciphertext, keyContext)
+	logResult(ctx, "Transit decryption", start, err)
+	if err != nil {
+		addVaultError(&resp.Diagnostics, path.Root("vault_key_name"), "Vault Transit decryption failed", err)
+		return
+	}
+
+	data.ID = sha256Hex(ciphertext)
+	data.VaultKeyName = types.StringValue(keyName)
+	data.KeyVersion = types.Int64Value(version)
+	data.Plaintext = types.StringNull()
+	if utf8.Valid(plaintext) {
+		data.Plaintext = types.StringValue(string(plaintext))
+	}
+	data.PlaintextBase64 = types.StringValue(base64.StdEncoding.EncodeToString(plaintext))
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
@@ -0,0 +1,76 @@
+package provider_test
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+func TestAccTransitDecryptedStringDataSource(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+	vaultAddr := requireEnv(t, "VAULT_ADDR")
+	vaultToken := requireEnv(t, "VAULT_TOKEN")
+	keyName := envOrDefault("SOPS_VAULT_KEY", "sops-test")
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+provider "sops" {
+  vault_address = %q
+
+  auth {
+    token = %q
+  }
+}
+
+resource "sops_transit_encrypted_string" "test" {
+  plaintext      = "s3cret"
+  vault_key_name = %[3]q
+}
+
+data "sops_transit_decrypted_string" "test" {
+  ciphertext     = sops_transit_encrypted_string.test.ciphertext
+  vault_key_name = %[3]q
+}
+`, vaultAddr, vaultToken, keyName),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.sops_transit_decrypted_string.test", "plaintext", "s3cret"),
+					resource.TestCheckResourceAttrPair("data.sops_transit_decrypted_string.test", "key_version",
+						"sops_transit_encrypted_string.test", "key_version"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccTransitDecryptedStringDataSource_InvalidCiphertext(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+provider "sops" {
+  mock = true
+}
+
+data "sops_transit_decrypted_string" "test" {
+  ciphertext     = "not-a-transit-value"
+  vault_key_name = "sops-test"
+}
+`,
+				ExpectError: regexp.MustCompile(`Invalid Transit ciphertext`),
+			},
+		},
+	})
+}
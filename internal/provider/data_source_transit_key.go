@@ -0,0 +1,171 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"terraform-provider-sops/internal/sopsencrypt"
+)
+
+var (
+	_ datasource.DataSource              = &transitKeyDataSource{}
+	_ datasource.DataSourceWithConfigure = &transitKeyDataSource{}
+)
+
+type transitKeyDataSource struct{ pd *sopsProviderData }
+
+type transitKeyModel struct {
+	ID                   types.String             `tfsdk:"id"`
+	VaultKeyName         types.String             `tfsdk:"vault_key_name"`
+	VaultTransitEngine   types.String             `tfsdk:"vault_transit_engine"`
+	Type                 types.String             `tfsdk:"type"`
+	LatestVersion        types.Int64              `tfsdk:"latest_version"`
+	MinDecryptionVersion types.Int64              `tfsdk:"min_decryption_version"`
+	MinEncryptionVersion types.Int64              `tfsdk:"min_encryption_version"`
+	DeletionAllowed      types.Bool               `tfsdk:"deletion_allowed"`
+	Exportable           types.Bool               `tfsdk:"exportable"`
+	SupportsEncryption   types.Bool               `tfsdk:"supports_encryption"`
+	SupportsDecryption   types.Bool               `tfsdk:"supports_decryption"`
+	Timeouts             *dataSourceTimeoutsModel `tfsdk:"timeouts"`
+}
+
+func NewTransitKeyDataSource() datasource.DataSource { return &transitKeyDataSource{} }
+
+func (d *transitKeyDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_transit_key"
+}
+
+func (d *transitKeyDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: `Reads the metadata of a Vault Transit key, so configurations can assert
+that the key exists and is suitable before encrypting against it. No key
+material is read. Requires Vault; not available in mock or age-only mode.
+
+    data "sops_transit_key" "app" {
+      vault_key_name = "app-secrets"
+    }`,
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:    true,
+				Description: "Vault path of the key, <vault_transit_engine>/keys/<vault_key_name>.",
+			},
+			"vault_key_name": schema.StringAttribute{
+				Optional:    true,
+				Computed:    true,
+				Description: "Name of the Transit key. Defaults to the provider-level default_vault_key_name.",
+			},
+			"vault_transit_engine": schema.StringAttribute{
+				Optional:    true,
+				Description: "Vault Transit mount path. Overrides the provider-level vault_transit_engine. Defaults to 'transit'.",
+			},
+			"type": schema.StringAttribute{
+				Computed:    true,
+				Description: "Key type, e.g. aes256-gcm96.",
+			},
+			"latest_version": schema.Int64Attribute{
+				Computed:    true,
+				Description: "Latest version of the key, used for new encryptions.",
+			},
+			"min_decryption_version": schema.Int64Attribute{
+				Computed:    true,
+				Description: "Oldest key version that may still decrypt. Data keys wrapped with an older version can no longer be unwrapped.",
+			},
+			"min_encryption_version": schema.Int64Attribute{
+				Computed:    true,
+				Description: "Oldest key version that may encrypt; 0 means the latest version.",
+			},
+			"deletion_allowed": schema.BoolAttribute{
+				Computed:    true,
+				Description: "Whether the key can be deleted.",
+			},
+			"exportable": schema.BoolAttribute{
+				Computed:    true,
+				Description: "Whether the key material can be exported.",
+			},
+			"supports_encryption": schema.BoolAttribute{
+				Computed:    true,
+				Description: "Whether the key type supports encryption, which SOPS needs to wrap data keys.",
+			},
+			"supports_decryption": schema.BoolAttribute{
+				Computed:    true,
+				Description: "Whether the key type supports decryption.",
+			},
+		},
+		Blocks: map[string]schema.Block{
+			"timeouts": dataSourceTimeoutsBlock(),
+		},
+	}
+}
+
+func (d *transitKeyDataSource) Configure(_ context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	pd, ok := req.ProviderData.(*sopsProviderData)
+	if !ok {
+		resp.Diagnostics.AddError("Unexpected provider data type",
+			fmt.Sprintf("Expected *sopsProviderData, got %T", req.ProviderData))
+		return
+	}
+	d.pd = pd
+}
+
+func (d *transitKeyDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data transitKeyModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	client, err := d.pd.newVaultClient(data.Timeouts.read())
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to create Vault client", err.Error())
+		return
+	}
+	if client == nil {
+		resp.Diagnostics.AddError("Vault not configured",
+			"sops_transit_key reads from Vault and is not available in mock or age-only mode.")
+		return
+	}
+	keyName := d.pd.vaultKeyName(data.VaultKeyName)
+	if keyName == "" {
+		resp.Diagnostics.AddAttributeError(path.Root("vault_key_name"),
+			"Missing Vault key name",
+			"Set vault_key_name on the data source or default_vault_key_name on the provider.")
+		return
+	}
+	transitEngine := resolveStringDefault(data.VaultTransitEngine, d.pd.vaultTransitEngine)
+
+	ctx = d.pd.logContext(ctx, client, d.pd.vaultAddress, transitEngine, keyName)
+	release, err := d.pd.acquireVault(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to read Transit key", err.Error())
+		return
+	}
+	defer release()
+	start := time.Now()
+	key, err := sopsencrypt.ReadTransitKey(client, transitEngine, keyName)
+	logResult(ctx, "Transit key read", start, err)
+	if err != nil {
+		addVaultError(&resp.Diagnostics, path.Root("vault_key_name"), "Failed to read Transit key", err)
+		return
+	}
+
+	data.ID = types.StringValue(transitEngine + "/keys/" + keyName)
+	data.VaultKeyName = types.StringValue(keyName)
+	data.Type = types.StringValue(key.Type)
+	data.LatestVersion = types.Int64Value(key.LatestVersion)
+	data.MinDecryptionVersion = types.Int64Value(key.MinDecryptionVersion)
+	data.MinEncryptionVersion = types.Int64Value(key.MinEncryptionVersion)
+	data.DeletionAllowed = types.BoolValue(key.DeletionAllowed)
+	data.Exportable = types.BoolValue(key.Exportable)
+	data.SupportsEncryption = types.BoolValue(key.SupportsEncryption)
+	data.SupportsDecryption = types.BoolValue(key.SupportsDecryption)
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
@@ -0,0 +1,72 @@
+package provider_test
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+// TestAccTransitKeyDataSource_Basic verifies that the metadata of an existing
+// Transit key is read.
+func TestAccTransitKeyDataSource_Basic(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+	vaultAddr := requireEnv(t, "VAULT_ADDR")
+	vaultToken := requireEnv(t, "VAULT_TOKEN")
+	keyName := envOrDefault("SOPS_VAULT_KEY", "sops-test")
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccTransitKey(vaultAddr, vaultToken, keyName),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.sops_transit_key.test", "id", "transit/keys/"+keyName),
+					resource.TestCheckResourceAttrSet("data.sops_transit_key.test", "type"),
+					resource.TestCheckResourceAttrSet("data.sops_transit_key.test", "latest_version"),
+					resource.TestCheckResourceAttr("data.sops_transit_key.test", "supports_encryption", "true"),
+				),
+			},
+		},
+	})
+}
+
+// TestAccTransitKeyDataSource_Missing verifies that a missing key fails the
+// plan.
+func TestAccTransitKeyDataSource_Missing(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+	vaultAddr := requireEnv(t, "VAULT_ADDR")
+	vaultToken := requireEnv(t, "VAULT_TOKEN")
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config:      testAccTransitKey(vaultAddr, vaultToken, "sops-test-does-not-exist"),
+				ExpectError: regexp.MustCompile(`Failed to read Transit key`),
+			},
+		},
+	})
+}
+
+func testAccTransitKey(vaultAddr, vaultToken, keyName string) string {
+	return fmt.Sprintf(`
+provider "sops" {
+  vault_address = %q
+
+  auth {
+    token = %q
+  }
+}
+
+data "sops_transit_key" "test" {
+  vault_key_name = %q
+}
+`, vaultAddr, vaultToken, keyName)
+}
@@ -0,0 +1,170 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"terraform-provider-sops/internal/sopsencrypt"
+)
+
+var (
+	_ datasource.DataSource                   = &validateDataSource{}
+	_ datasource.DataSourceWithConfigure      = &validateDataSource{}
+	_ datasource.DataSourceWithValidateConfig = &validateDataSource{}
+)
+
+type validateDataSource struct{ pd *sopsProviderData }
+
+type validateModel struct {
+	ID              types.String             `tfsdk:"id"`
+	Ciphertext      types.String             `tfsdk:"ciphertext"`
+	InputType       types.String             `tfsdk:"input_type"`
+	Valid           types.Bool               `tfsdk:"valid"`
+	Decryptable     types.Bool               `tfsdk:"decryptable"`
+	MACValid        types.Bool               `tfsdk:"mac_valid"`
+	ScopeConsistent types.Bool               `tfsdk:"scope_consistent"`
+	Reasons         []types.String           `tfsdk:"reasons"`
+	Timeouts        *dataSourceTimeoutsModel `tfsdk:"timeouts"`
+}
+
+func NewValidateDataSource() datasource.DataSource { return &validateDataSource{} }
+
+func (d *validateDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_validate"
+}
+
+func (d *validateDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: `Verifies a SOPS document without exposing its plaintext: that its data key
+can be unwrapped with the provider's Vault credentials, that its MAC matches
+its values, and that its scope metadata agrees with which values are
+encrypted. Failed checks are reported as false attributes with reasons rather
+than errors, for use in check blocks.
+
+    check "secrets" {
+      data "sops_validate" "app" {
+        ciphertext = file("secrets.enc.yaml")
+      }
+      assert {
+        condition     = data.sops_validate.app.valid
+        error_message = join("; ", data.sops_validate.app.reasons)
+      }
+    }`,
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:    true,
+				Description: "SHA-256 of the ciphertext.",
+			},
+			"ciphertext": schema.StringAttribute{
+				Required:    true,
+				Description: "The SOPS-encrypted document.",
+			},
+			"input_type": schema.StringAttribute{
+				Optional:    true,
+				Computed:    true,
+				Description: "Format of the document: json, yaml, dotenv, ini or binary. Defaults to json if the document starts with '{', yaml otherwise.",
+			},
+			"valid": schema.BoolAttribute{
+				Computed:    true,
+				Description: "Whether every check passed.",
+			},
+			"decryptable": schema.BoolAttribute{
+				Computed:    true,
+				Description: "Whether a Vault Transit key of the document could unwrap its data key. Always false in mock and age-only mode.",
+			},
+			"mac_valid": schema.BoolAttribute{
+				Computed:    true,
+				Description: "Whether the values match the MAC, i.e. the document was not modified after encryption. False when the data key could not be unwrapped.",
+			},
+			"scope_consistent": schema.BoolAttribute{
+				Computed:    true,
+				Description: "Whether at most one scope option is recorded and every value is encrypted exactly when it selects the value.",
+			},
+			"reasons": schema.ListAttribute{
+				Computed:    true,
+				ElementType: types.StringType,
+				Description: "Why the failed checks failed; empty when the document is valid. Reasons never contain document values.",
+			},
+		},
+		Blocks: map[string]schema.Block{
+			"timeouts": dataSourceTimeoutsBlock(),
+		},
+	}
+}
+
+func (d *validateDataSource) Configure(_ context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	pd, ok := req.ProviderData.(*sopsProviderData)
+	if !ok {
+		resp.Diagnostics.AddError("Unexpected provider data type",
+			fmt.Sprintf("Expected *sopsProviderData, got %T", req.ProviderData))
+		return
+	}
+	d.pd = pd
+}
+
+// ValidateConfig rejects an unknown input_type.
+func (d *validateDataSource) ValidateConfig(ctx context.Context, req datasource.ValidateConfigRequest, resp *datasource.ValidateConfigResponse) {
+	var inputType types.String
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("input_type"), &inputType)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	validateInputType(&resp.Diagnostics, inputType)
+}
+
+func (d *validateDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data validateModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	client, err := d.pd.newVaultClient(data.Timeouts.read())
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to create Vault client", err.Error())
+		return
+	}
+	ciphertext := data.Ciphertext.ValueString()
+	inputType := data.InputType.ValueString()
+	if inputType == "" {
+		inputType = sopsencrypt.DetectInputType(ciphertext)
+	}
+
+	ctx = maskSecrets(ctx, clientToken(client))
+	if client != nil {
+		release, err := d.pd.acquireVault(ctx)
+		if err != nil {
+			resp.Diagnostics.AddError("Failed to validate SOPS document", err.Error())
+			return
+		}
+		defer release()
+	}
+	start := time.Now()
+	v, err := sopsencrypt.Validate(client, ciphertext, inputType)
+	logResult(ctx, "Validation", start, err)
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("ciphertext"), "Failed to parse SOPS document", err.Error())
+		return
+	}
+
+	data.ID = sha256Hex(ciphertext)
+	data.InputType = types.StringValue(inputType)
+	data.Valid = types.BoolValue(v.Valid())
+	data.Decryptable = types.BoolValue(v.Decryptable)
+	data.MACValid = types.BoolValue(v.MACValid)
+	data.ScopeConsistent = types.BoolValue(v.ScopeConsistent)
+	data.Reasons = []types.String{}
+	for _, r := range v.Reasons() {
+		data.Reasons = append(data.Reasons, types.StringValue(r))
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
@@ -0,0 +1,85 @@
+package provider_test
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+// TestAccValidateDataSource_Valid verifies that a document encrypted by
+// sops_encrypted_json passes every check.
+func TestAccValidateDataSource_Valid(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+	vaultAddr := requireEnv(t, "VAULT_ADDR")
+	vaultToken := requireEnv(t, "VAULT_TOKEN")
+	keyName := envOrDefault("SOPS_VAULT_KEY", "sops-test")
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+provider "sops" {
+  vault_address = %q
+
+  auth {
+    token = %q
+  }
+}
+
+resource "sops_encrypted_json" "test" {
+  content        = jsonencode({ password = "secret", region_unencrypted = "eu" })
+  vault_key_name = %q
+}
+
+data "sops_validate" "test" {
+  ciphertext = sops_encrypted_json.test.ciphertext_json
+}
+`, vaultAddr, vaultToken, keyName),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.sops_validate.test", "valid", "true"),
+					resource.TestCheckResourceAttr("data.sops_validate.test", "decryptable", "true"),
+					resource.TestCheckResourceAttr("data.sops_validate.test", "mac_valid", "true"),
+					resource.TestCheckResourceAttr("data.sops_validate.test", "scope_consistent", "true"),
+					resource.TestCheckResourceAttr("data.sops_validate.test", "reasons.#", "0"),
+				),
+			},
+		},
+	})
+}
+
+// TestAccValidateDataSource_Mock verifies that in mock mode only the scope
+// check passes, without failing the read.
+func TestAccValidateDataSource_Mock(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+provider "sops" {
+  mock = true
+}
+
+data "sops_validate" "test" {
+  ciphertext = <<-EOT
+` + testAccSOPSYAMLDocument + `EOT
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.sops_validate.test", "valid", "false"),
+					resource.TestCheckResourceAttr("data.sops_validate.test", "decryptable", "false"),
+					resource.TestCheckResourceAttr("data.sops_validate.test", "scope_consistent", "true"),
+					resource.TestCheckResourceAttr("data.sops_validate.test", "reasons.#", "2"),
+				),
+			},
+		},
+	})
+}
@@ -0,0 +1,96 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	vaultapi "github.com/hashicorp/vault/api"
+
+	"terraform-provider-sops/internal/sopsencrypt"
+)
+
+// addVaultError reports err from an operation that calls Vault. A failed
+// Vault request with a status that has a usual cause (403, 404, 503) gets a
+// diagnostic naming the request and what to check, attached to attr, the
+// attribute that selected the request. Any other error is reported as is
+// under summary.
+func addVaultError(diags *diag.Diagnostics, attr path.Path, summary string, err error) {
+	var respErr *vaultapi.ResponseError
+	if !errors.As(err, &respErr) {
+		diags.AddError(summary, err.Error())
+		return
+	}
+	requestPath := vaultRequestPath(respErr.URL)
+	request := respErr.HTTPMethod + " " + requestPath
+
+	switch respErr.StatusCode {
+	case http.StatusForbidden:
+		capability := "update"
+		if respErr.HTTPMethod == http.MethodGet {
+			capability = "read"
+		}
+		diags.AddAttributeError(attr, "Vault permission denied", fmt.Sprintf(
+			"Vault denied %s. Grant the token's policy the %q capability on %q, and check that the token has not expired.\n\n%s",
+			request, capability, requestPath, err))
+	case http.StatusNotFound:
+		if slices.ContainsFunc(respErr.Errors, func(e string) bool { return strings.Contains(e, "no handler for route") }) {
+			diags.AddAttributeError(path.Root("vault_transit_engine"), "Vault Transit engine not found", fmt.Sprintf(
+				"No secrets engine answers %s. Check vault_transit_engine, or enable a Transit engine at that path "+
+					"with `vault secrets enable -path=<path> transit`.\n\n%s", request, err))
+			return
+		}
+		diags.AddAttributeError(attr, "Vault Transit key not found", fmt.Sprintf(
+			"Vault returned 404 for %s. Check vault_key_name, or set create_key_if_missing on the provider to create missing keys.\n\n%s",
+			request, err))
+	case http.StatusServiceUnavailable:
+		diags.AddAttributeError(attr, "Vault sealed or unavailable", fmt.Sprintf(
+			"Vault returned 503 for %s: it is sealed, in maintenance, or a standby node without a reachable active node. "+
+				"Unseal Vault or retry once it is available.\n\n%s", request, err))
+	default:
+		diags.AddAttributeError(attr, summary, err.Error())
+	}
+}
+
+// vaultRequestPath returns the Vault API path of a request URL, without the
+// /v1/ prefix, as used in policies.
+func vaultRequestPath(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	return strings.TrimPrefix(u.Path, "/v1/")
+}
+
+// redactDiagnostics returns diags with the plaintexts, and any fragment of
+// them a parser quoted, removed from every summary and detail. It is applied
+// to all diagnostics raised while building or encrypting a document, so that
+// no error path can echo content into the plan output.
+func redactDiagnostics(diags diag.Diagnostics, plaintexts ...string) diag.Diagnostics {
+	out := make(diag.Diagnostics, 0, len(diags))
+	for _, d := range diags {
+		summary := sopsencrypt.Redact(d.Summary(), plaintexts...)
+		detail := sopsencrypt.Redact(d.Detail(), plaintexts...)
+		if summary == d.Summary() && detail == d.Detail() {
+			out = append(out, d)
+			continue
+		}
+		withPath, ok := d.(diag.DiagnosticWithPath)
+		switch {
+		case ok && d.Severity() == diag.SeverityError:
+			out = append(out, diag.NewAttributeErrorDiagnostic(withPath.Path(), summary, detail))
+		case ok:
+			out = append(out, diag.NewAttributeWarningDiagnostic(withPath.Path(), summary, detail))
+		case d.Severity() == diag.SeverityError:
+			out = append(out, diag.NewErrorDiagnostic(summary, detail))
+		default:
+			out = append(out, diag.NewWarningDiagnostic(summary, detail))
+		}
+	}
+	return out
+}
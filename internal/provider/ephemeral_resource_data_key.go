@@ -0,0 +1,130 @@
+package provider
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"terraform-provider-sops/internal/sopsencrypt"
+)
+
+var (
+	_ ephemeral.EphemeralResource              = &dataKeyEphemeralResource{}
+	_ ephemeral.EphemeralResourceWithConfigure = &dataKeyEphemeralResource{}
+)
+
+type dataKeyEphemeralResource struct{ pd *sopsProviderData }
+
+type dataKeyModel struct {
+	VaultKeyName       types.String `tfsdk:"vault_key_name"`
+	VaultTransitEngine types.String `tfsdk:"vault_transit_engine"`
+	DataKey            types.String `tfsdk:"data_key"`
+	WrappedDataKey     types.String `tfsdk:"wrapped_data_key"`
+}
+
+func NewDataKeyEphemeralResource() ephemeral.EphemeralResource { return &dataKeyEphemeralResource{} }
+
+func (r *dataKeyEphemeralResource) Metadata(_ context.Context, req ephemeral.MetadataRequest, resp *ephemeral.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_data_key"
+}
+
+func (r *dataKeyEphemeralResource) Schema(_ context.Context, _ ephemeral.SchemaRequest, resp *ephemeral.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: `Generates a SOPS data key and wraps it with a Vault Transit key. The
+plaintext key is never stored in state or plan, so it can be passed to
+write-only arguments to encrypt several documents with one key. Requires
+Vault; in mock mode the key is all zeros and is not wrapped.
+
+    ephemeral "sops_data_key" "shared" {
+      vault_key_name = "app-secrets"
+    }`,
+		Attributes: map[string]schema.Attribute{
+			"vault_key_name": schema.StringAttribute{
+				Optional:    true,
+				Computed:    true,
+				Description: "Name of the Transit key that wraps the data key. Defaults to the provider-level default_vault_key_name.",
+			},
+			"vault_transit_engine": schema.StringAttribute{
+				Optional:    true,
+				Description: "Vault Transit mount path. Overrides the provider-level vault_transit_engine. Defaults to 'transit'.",
+			},
+			"data_key": schema.StringAttribute{
+				Computed:    true,
+				Sensitive:   true,
+				Description: "Base64-encoded 32-byte AES-256 data key.",
+			},
+			"wrapped_data_key": schema.StringAttribute{
+				Computed:    true,
+				Description: "The data key encrypted with the Transit key, e.g. vault:v1:..., as recorded in the enc field of a SOPS document.",
+			},
+		},
+	}
+}
+
+func (r *dataKeyEphemeralResource) Configure(_ context.Context, req ephemeral.ConfigureRequest, resp *ephemeral.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	pd, ok := req.ProviderData.(*sopsProviderData)
+	if !ok {
+		resp.Diagnostics.AddError("Unexpected provider data type",
+			fmt.Sprintf("Expected *sopsProviderData, got %T", req.ProviderData))
+		return
+	}
+	r.pd = pd
+}
+
+func (r *dataKeyEphemeralResource) Open(ctx context.Context, req ephemeral.OpenRequest, resp *ephemeral.OpenResponse) {
+	var data dataKeyModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if !r.pd.usesVault() {
+		resp.Diagnostics.AddError("Vault not configured",
+			"sops_data_key wraps the data key with Vault Transit and is not available in age-only mode.")
+		return
+	}
+	keyName := r.pd.vaultKeyName(data.VaultKeyName)
+	if keyName == "" {
+		resp.Diagnostics.AddAttributeError(path.Root("vault_key_name"),
+			"Missing Vault key name",
+			"Set vault_key_name on the ephemeral resource or default_vault_key_name on the provider.")
+		return
+	}
+	transitEngine := resolveStringDefault(data.VaultTransitEngine, r.pd.vaultTransitEngine)
+
+	client, err := r.pd.newVaultClient(0)
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to create Vault client", err.Error())
+		return
+	}
+	ctx = r.pd.logContext(ctx, client, r.pd.vaultAddress, transitEngine, keyName)
+	var release func()
+	if client != nil {
+		if release, err = r.pd.acquireVault(ctx); err != nil {
+			resp.Diagnostics.AddError("Failed to generate data key", err.Error())
+			return
+		}
+		defer release()
+	}
+	start := time.Now()
+	dk, err := sopsencrypt.NewDataKey(client, transitEngine, keyName, r.pd.createKeyType, r.pd.mock)
+	logResult(ctx, "Data key generation", start, err)
+	if err != nil {
+		addVaultError(&resp.Diagnostics, path.Root("vault_key_name"), "Failed to generate data key", err)
+		return
+	}
+
+	data.VaultKeyName = types.StringValue(keyName)
+	data.DataKey = types.StringValue(base64.StdEncoding.EncodeToString(dk.Key))
+	data.WrappedDataKey = types.StringValue(dk.Wrapped)
+
+	resp.Diagnostics.Append(resp.Result.Set(ctx, &data)...)
+}
@@ -0,0 +1,95 @@
+package provider_test
+
+import (
+	"os"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-testing/echoprovider"
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/tfversion"
+	"terraform-provider-sops/internal/provider"
+)
+
+// testAccEchoProviderFactories add the echo provider, which copies an
+// ephemeral value into state where test checks can see it.
+var testAccEchoProviderFactories = map[string]func() (tfprotov6.ProviderServer, error){
+	"sops": providerserver.NewProtocol6WithError(provider.New("test")()),
+	"echo": echoprovider.NewProviderServer(),
+}
+
+// TestAccDataKeyEphemeralResource_Mock verifies the fixed key and wrapped
+// placeholder of mock mode.
+func TestAccDataKeyEphemeralResource_Mock(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+
+	resource.Test(t, resource.TestCase{
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(tfversion.Version1_10_0),
+		},
+		ProtoV6ProviderFactories: testAccEchoProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+provider "sops" {
+  mock = true
+}
+
+ephemeral "sops_data_key" "test" {
+  vault_key_name = "sops-test"
+}
+
+provider "echo" {
+  data = ephemeral.sops_data_key.test
+}
+
+resource "echo" "test" {}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("echo.test", "data.vault_key_name", "sops-test"),
+					resource.TestCheckResourceAttr("echo.test", "data.data_key", "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA="),
+					resource.TestCheckResourceAttr("echo.test", "data.wrapped_data_key", "vault:v0:mock"),
+				),
+			},
+		},
+	})
+}
+
+// TestAccDataKeyEphemeralResource_AgeOnly verifies that the data key cannot
+// be generated without Vault.
+func TestAccDataKeyEphemeralResource_AgeOnly(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+
+	resource.Test(t, resource.TestCase{
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(tfversion.Version1_10_0),
+		},
+		ProtoV6ProviderFactories: testAccEchoProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+provider "sops" {
+  age_recipients = ["age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p"]
+}
+
+ephemeral "sops_data_key" "test" {
+  vault_key_name = "sops-test"
+}
+
+provider "echo" {
+  data = ephemeral.sops_data_key.test
+}
+
+resource "echo" "test" {}
+`,
+				ExpectError: regexp.MustCompile(`not available in age-only mode`),
+			},
+		},
+	})
+}
@@ -0,0 +1,70 @@
+package provider
+
+import (
+	"crypto/fips140"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+
+	"terraform-provider-sops/internal/sopsencrypt"
+)
+
+// fipsEnabled reports whether the provider binary runs its cryptography in
+// FIPS 140-3 mode: built with GOFIPS140 or run with GODEBUG=fips140=on, or
+// built with GOEXPERIMENT=boringcrypto.
+func fipsEnabled() bool {
+	return fips140.Enabled() || boringEnabled()
+}
+
+// fipsExcludedKeyTypes are the TransitKeyTypes whose cipher is not FIPS
+// 140-3 approved.
+var fipsExcludedKeyTypes = []string{"chacha20-poly1305"}
+
+// checkFIPS rejects, in fips_mode, a binary without FIPS 140-3 crypto and
+// the provider options whose cryptography is not approved: mock mode, age
+// recipients and ChaCha20-Poly1305 Transit keys. Vault Transit and the cloud
+// KMS key sources stay allowed, as their keys never leave the service.
+func (pd *sopsProviderData) checkFIPS(diags *diag.Diagnostics) {
+	if !fipsEnabled() {
+		diags.AddAttributeError(path.Root("fips_mode"),
+			"FIPS 140-3 mode unavailable",
+			"fips_mode requires a provider binary whose cryptography runs in FIPS 140-3 mode. Build it with "+
+				"GOFIPS140=v1.0.0 (make build-fips) or GOEXPERIMENT=boringcrypto, or run Terraform with GODEBUG=fips140=on.")
+	}
+	if pd.mock {
+		diags.AddAttributeError(path.Root("mock"),
+			"Non-FIPS key source",
+			"mock emits placeholder ciphertext without encrypting anything, which fips_mode does not allow.")
+	}
+	if len(pd.ageRecipients) > 0 {
+		diags.AddAttributeError(path.Root("age_recipients"),
+			"Non-FIPS key source",
+			"age wraps data keys with X25519 and ChaCha20-Poly1305, which are not FIPS 140-3 approved. "+
+				"Remove age_recipients and unset SOPS_AGE_RECIPIENTS, or disable fips_mode.")
+	}
+	for _, keyType := range fipsExcludedKeyTypes {
+		if pd.createKeyType == keyType {
+			diags.AddAttributeError(path.Root("create_key_type"),
+				"Non-FIPS key source",
+				keyType+" Transit keys are not FIPS 140-3 approved. Use aes256-gcm96 or an RSA key type.")
+		}
+	}
+}
+
+// checkFIPSKeys rejects, in fips_mode, the key sources of a creation rule
+// at base whose cryptography is not FIPS 140-3 approved: age and PGP.
+func (pd *sopsProviderData) checkFIPSKeys(keys sopsencrypt.KeySources, base path.Path, diags *diag.Diagnostics) {
+	if !pd.fips {
+		return
+	}
+	if len(keys.AgeRecipients) > 0 {
+		diags.AddAttributeError(base.AtName("age_recipients"),
+			"Non-FIPS key source",
+			"age wraps data keys with X25519 and ChaCha20-Poly1305, which are not FIPS 140-3 approved.")
+	}
+	if len(keys.PGPFingerprints) > 0 {
+		diags.AddAttributeError(base.AtName("pgp_fingerprints"),
+			"Non-FIPS key source",
+			"PGP keys are handled by GnuPG outside any FIPS 140-3 validated module.")
+	}
+}
@@ -0,0 +1,11 @@
+//go:build boringcrypto
+
+package provider
+
+import "crypto/boring"
+
+// boringEnabled reports whether BoringCrypto handles the provider's
+// cryptography.
+func boringEnabled() bool {
+	return boring.Enabled()
+}
@@ -0,0 +1,9 @@
+//go:build !boringcrypto
+
+package provider
+
+// boringEnabled reports whether BoringCrypto handles the provider's
+// cryptography; never without GOEXPERIMENT=boringcrypto.
+func boringEnabled() bool {
+	return false
+}
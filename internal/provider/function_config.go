@@ -0,0 +1,65 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"terraform-provider-sops/internal/sopsencrypt"
+)
+
+var _ function.Function = &configFunction{}
+
+// configFunction renders .sops.yaml content like the sops_config data source,
+// but from a full Transit URI so that no provider configuration is needed.
+type configFunction struct{}
+
+func NewConfigFunction() function.Function { return &configFunction{} }
+
+func (f *configFunction) Metadata(_ context.Context, _ function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "config"
+}
+
+func (f *configFunction) Definition(_ context.Context, _ function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary: "Render a .sops.yaml configuration for a Vault Transit key.",
+		Description: `Returns the content of a .sops.yaml file whose creation rules point to
+vault_uri. Each entry in path_regexes becomes one creation_rule; when
+path_regexes is null or empty, a single catch-all rule with no path_regex is
+emitted. Unlike the sops_config data source, the function does not read the
+provider configuration, so it can be used in locals and module outputs.`,
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:        "vault_uri",
+				Description: "Full Vault Transit key URI, e.g. https://vault.example.com/v1/transit/keys/my-key.",
+			},
+			function.ListParameter{
+				Name:           "path_regexes",
+				ElementType:    types.StringType,
+				AllowNullValue: true,
+				Description:    "Path regexes, one creation_rule each. Null or empty for a single catch-all rule.",
+			},
+		},
+		Return: function.StringReturn{},
+	}
+}
+
+func (f *configFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var uri string
+	var pathRegexes []string
+	resp.Error = function.ConcatFuncErrors(resp.Error, req.Arguments.Get(ctx, &uri, &pathRegexes))
+	if resp.Error != nil {
+		return
+	}
+	if uri == "" {
+		resp.Error = function.NewArgumentFuncError(0, "vault_uri must not be empty")
+		return
+	}
+
+	content, err := sopsencrypt.GenerateSOPSConfigForURI(uri, pathRegexes)
+	if err != nil {
+		resp.Error = function.NewFuncError(err.Error())
+		return
+	}
+	resp.Error = function.ConcatFuncErrors(resp.Error, resp.Result.Set(ctx, content))
+}
@@ -0,0 +1,53 @@
+package provider_test
+
+import (
+	"os"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/tfversion"
+)
+
+// TestAccConfigFunction verifies the function renders without any provider
+// configuration, since provider-defined functions cannot read it.
+func TestAccConfigFunction(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+
+	resource.Test(t, resource.TestCase{
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(tfversion.Version1_8_0),
+		},
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+output "test" {
+  value = provider::sops::config("https://vault.example.com/v1/transit/keys/app", ["^secrets/.*$"])
+}
+`,
+				Check: resource.TestCheckOutput("test",
+					"creation_rules:\n  - path_regex: ^secrets/.*$\n    hc_vault_transit_uri: https://vault.example.com/v1/transit/keys/app\n"),
+			},
+			{
+				Config: `
+output "test" {
+  value = provider::sops::config("https://vault.example.com/v1/transit/keys/app", null)
+}
+`,
+				Check: resource.TestCheckOutput("test",
+					"creation_rules:\n  - hc_vault_transit_uri: https://vault.example.com/v1/transit/keys/app\n"),
+			},
+			{
+				Config: `
+output "test" {
+  value = provider::sops::config("", null)
+}
+`,
+				ExpectError: regexp.MustCompile(`vault_uri must not be empty`),
+			},
+		},
+	})
+}
@@ -0,0 +1,45 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"terraform-provider-sops/internal/sopsencrypt"
+)
+
+var _ function.Function = &isEncryptedFunction{}
+
+// isEncryptedFunction reports whether a string is a SOPS document, for use in
+// preconditions that guard against committing plaintext.
+type isEncryptedFunction struct{}
+
+func NewIsEncryptedFunction() function.Function { return &isEncryptedFunction{} }
+
+func (f *isEncryptedFunction) Metadata(_ context.Context, _ function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "is_encrypted"
+}
+
+func (f *isEncryptedFunction) Definition(_ context.Context, _ function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary: "Check whether a string is a SOPS-encrypted document.",
+		Description: `Returns true when the value is a JSON or YAML document with a valid sops
+metadata block naming at least one key source, and false otherwise —
+including for input that is not JSON or YAML at all. Nothing is decrypted.`,
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:        "value",
+				Description: "String to inspect.",
+			},
+		},
+		Return: function.BoolReturn{},
+	}
+}
+
+func (f *isEncryptedFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var value string
+	resp.Error = function.ConcatFuncErrors(resp.Error, req.Arguments.Get(ctx, &value))
+	if resp.Error != nil {
+		return
+	}
+	resp.Error = function.ConcatFuncErrors(resp.Error, resp.Result.Set(ctx, sopsencrypt.IsEncrypted(value)))
+}
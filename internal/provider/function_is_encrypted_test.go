@@ -0,0 +1,46 @@
+package provider_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/tfversion"
+)
+
+func TestAccIsEncryptedFunction(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+
+	resource.Test(t, resource.TestCase{
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(tfversion.Version1_8_0),
+		},
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+output "encrypted" {
+  value = provider::sops::is_encrypted(<<-EOT
+` + testAccSOPSYAMLDocument + `EOT
+  )
+}
+
+output "plaintext" {
+  value = provider::sops::is_encrypted(jsonencode({ password = "secret" }))
+}
+
+output "garbage" {
+  value = provider::sops::is_encrypted("not a document")
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckOutput("encrypted", "true"),
+					resource.TestCheckOutput("plaintext", "false"),
+					resource.TestCheckOutput("garbage", "false"),
+				),
+			},
+		},
+	})
+}
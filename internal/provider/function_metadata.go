@@ -0,0 +1,120 @@
+package provider
+
+import (
+	"context"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"terraform-provider-sops/internal/sopsencrypt"
+)
+
+var _ function.Function = &metadataFunction{}
+
+// sopsMetadataModel is the Terraform representation of sopsencrypt.Metadata.
+// Attribute names follow the keys of the SOPS metadata block.
+type sopsMetadataModel struct {
+	Version           types.String     `tfsdk:"version"`
+	LastModified      types.String     `tfsdk:"lastmodified"`
+	UnencryptedSuffix types.String     `tfsdk:"unencrypted_suffix"`
+	EncryptedSuffix   types.String     `tfsdk:"encrypted_suffix"`
+	UnencryptedRegex  types.String     `tfsdk:"unencrypted_regex"`
+	EncryptedRegex    types.String     `tfsdk:"encrypted_regex"`
+	KeySources        []keySourceModel `tfsdk:"key_sources"`
+}
+
+type keySourceModel struct {
+	Type  types.String `tfsdk:"type"`
+	ID    types.String `tfsdk:"id"`
+	Group types.Int64  `tfsdk:"group"`
+}
+
+var keySourceAttrTypes = map[string]attr.Type{
+	"type":  types.StringType,
+	"id":    types.StringType,
+	"group": types.Int64Type,
+}
+
+var sopsMetadataAttrTypes = map[string]attr.Type{
+	"version":            types.StringType,
+	"lastmodified":       types.StringType,
+	"unencrypted_suffix": types.StringType,
+	"encrypted_suffix":   types.StringType,
+	"unencrypted_regex":  types.StringType,
+	"encrypted_regex":    types.StringType,
+	"key_sources":        types.ListType{ElemType: types.ObjectType{AttrTypes: keySourceAttrTypes}},
+}
+
+// newSOPSMetadataModel converts parsed metadata, mapping unset scope options
+// to null so configurations can test them with == null.
+func newSOPSMetadataModel(md sopsencrypt.Metadata) sopsMetadataModel {
+	m := sopsMetadataModel{
+		Version:           types.StringValue(md.Version),
+		LastModified:      types.StringValue(md.LastModified.UTC().Format(time.RFC3339)),
+		UnencryptedSuffix: stringOrNull(md.UnencryptedSuffix),
+		EncryptedSuffix:   stringOrNull(md.EncryptedSuffix),
+		UnencryptedRegex:  stringOrNull(md.UnencryptedRegex),
+		EncryptedRegex:    stringOrNull(md.EncryptedRegex),
+		KeySources:        make([]keySourceModel, len(md.KeySources)),
+	}
+	for i, ks := range md.KeySources {
+		m.KeySources[i] = keySourceModel{
+			Type:  types.StringValue(ks.Type),
+			ID:    types.StringValue(ks.ID),
+			Group: types.Int64Value(int64(ks.Group)),
+		}
+	}
+	return m
+}
+
+// stringOrNull returns a null string for "" and the value otherwise.
+func stringOrNull(s string) types.String {
+	if s == "" {
+		return types.StringNull()
+	}
+	return types.StringValue(s)
+}
+
+// metadataFunction exposes the sops block of a document without decrypting it.
+type metadataFunction struct{}
+
+func NewMetadataFunction() function.Function { return &metadataFunction{} }
+
+func (f *metadataFunction) Metadata(_ context.Context, _ function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "metadata"
+}
+
+func (f *metadataFunction) Definition(_ context.Context, _ function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary: "Parse the metadata of a SOPS document without decrypting it.",
+		Description: `Returns the sops metadata block of an encrypted JSON or YAML document:
+version, lastmodified, the scope options and the key sources that wrap the
+data key. No key material or Vault access is needed. Unset scope options are
+null.`,
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:        "ciphertext",
+				Description: "SOPS-encrypted JSON or YAML document.",
+			},
+		},
+		Return: function.ObjectReturn{
+			AttributeTypes: sopsMetadataAttrTypes,
+		},
+	}
+}
+
+func (f *metadataFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var ciphertext string
+	resp.Error = function.ConcatFuncErrors(resp.Error, req.Arguments.Get(ctx, &ciphertext))
+	if resp.Error != nil {
+		return
+	}
+
+	md, err := sopsencrypt.ParseMetadata(ciphertext)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(0, err.Error())
+		return
+	}
+	resp.Error = function.ConcatFuncErrors(resp.Error, resp.Result.Set(ctx, newSOPSMetadataModel(md)))
+}
@@ -0,0 +1,75 @@
+package provider_test
+
+import (
+	"os"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/tfversion"
+)
+
+// testAccSOPSYAMLDocument is a minimal SOPS YAML document. The values are
+// placeholders; only the metadata block matters to these tests.
+const testAccSOPSYAMLDocument = `password: ENC[AES256_GCM,data:3K8=,iv:AAAAAAAAAAAAAAAAAAAAAA==,tag:AAAAAAAAAAAAAAAAAAAAAA==,type:str]
+sops:
+    hc_vault:
+        - vault_address: https://vault.example.com
+          engine_path: transit
+          key_name: app
+          created_at: "2024-01-01T00:00:00Z"
+          enc: vault:v1:AAAA
+    lastmodified: "2024-01-01T00:00:00Z"
+    mac: ENC[AES256_GCM,data:AAAA,iv:AAAAAAAAAAAAAAAAAAAAAA==,tag:AAAAAAAAAAAAAAAAAAAAAA==,type:str]
+    encrypted_regex: ^password$
+    version: 3.12.1
+`
+
+func TestAccMetadataFunction(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+
+	resource.Test(t, resource.TestCase{
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(tfversion.Version1_8_0),
+		},
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+locals {
+  md = provider::sops::metadata(<<-EOT
+` + testAccSOPSYAMLDocument + `EOT
+  )
+}
+
+output "encrypted_regex" {
+  value = local.md.encrypted_regex
+}
+
+output "key_source" {
+  value = "${local.md.key_sources[0].type}:${local.md.key_sources[0].id}"
+}
+
+output "lastmodified" {
+  value = local.md.lastmodified
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckOutput("encrypted_regex", "^password$"),
+					resource.TestCheckOutput("key_source", "hc_vault:https://vault.example.com/v1/transit/keys/app"),
+					resource.TestCheckOutput("lastmodified", "2024-01-01T00:00:00Z"),
+				),
+			},
+			{
+				Config: `
+output "test" {
+  value = provider::sops::metadata(jsonencode({ password = "plaintext" }))
+}
+`,
+				ExpectError: regexp.MustCompile(`sops metadata not found`),
+			},
+		},
+	})
+}
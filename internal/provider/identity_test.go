@@ -0,0 +1,103 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	"terraform-provider-sops/internal/sopsencrypt"
+)
+
+// TestTokenIdentityLookups checks that concurrent tokenIdentity calls for one
+// Vault server share a single lookup, that a slow lookup does not hold up a
+// lookup at another server, and that a caller waiting for a lookup gives up
+// when its context is done.
+func TestTokenIdentityLookups(t *testing.T) {
+	var slowCalls atomic.Int32
+	unblock := make(chan struct{})
+	release := sync.OnceFunc(func() { close(unblock) })
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		slowCalls.Add(1)
+		<-unblock
+		writeLookupSelf(w, "slow")
+	}))
+	defer slow.Close()
+	defer release()
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeLookupSelf(w, "fast")
+	}))
+	defer fast.Close()
+
+	pool, err := sopsencrypt.VaultConfig{Address: slow.URL}.NewClientPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pd := &sopsProviderData{vaultAddress: slow.URL, vaultToken: "hvs.identity", vaultClients: pool}
+
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			id, ok, err := pd.tokenIdentity(context.Background(), types.StringNull(), 0)
+			if err != nil || !ok || id.EntityID != "slow" {
+				t.Errorf("tokenIdentity = %+v, %t, %v", id, ok, err)
+			}
+		}()
+	}
+	for slowCalls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	within(t, "lookup at another server", func() {
+		id, ok, err := pd.tokenIdentity(context.Background(), types.StringValue(fast.URL), 0)
+		if err != nil || !ok || id.EntityID != "fast" {
+			t.Errorf("tokenIdentity at another server = %+v, %t, %v", id, ok, err)
+		}
+	})
+	within(t, "lookup with an expired context", func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		if _, _, err := pd.tokenIdentity(ctx, types.StringNull(), 0); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("tokenIdentity with an expired context = %v, want %v", err, context.DeadlineExceeded)
+		}
+	})
+
+	release()
+	wg.Wait()
+	if n := slowCalls.Load(); n != 1 {
+		t.Errorf("token lookup-self called %d times, want 1", n)
+	}
+}
+
+// within runs f and reports an error if it does not return within five
+// seconds, leaving it running.
+func within(t *testing.T, name string, f func()) {
+	t.Helper()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		f()
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Errorf("%s did not return while another lookup was in flight", name)
+	}
+}
+
+// writeLookupSelf writes a token lookup-self response for entityID.
+func writeLookupSelf(w http.ResponseWriter, entityID string) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"data": map[string]any{"entity_id": entityID, "accessor": "accessor-" + entityID},
+	})
+}
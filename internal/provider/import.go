@@ -0,0 +1,225 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/dynamicplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/listplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"terraform-provider-sops/internal/sopsencrypt"
+)
+
+// importFilePrefix marks an import ID as the path of an encrypted file, as
+// in `terraform import sops_encrypted_json.x file:./secrets.enc.json`.
+const importFilePrefix = "file:"
+
+// privateImported is the private state key under which importFile records
+// that the content inputs of a resource are not in state yet.
+const privateImported = "imported"
+
+// privateReader reads private state, as the requests of plan modifiers and
+// ModifyPlan provide it.
+type privateReader interface {
+	GetKey(ctx context.Context, key string) ([]byte, diag.Diagnostics)
+}
+
+// importFile imports the SOPS document in the file named by a "file:" import
+// ID into a resource whose ciphertext is in format, "json" or "yaml". The
+// file becomes the ciphertext as is, the other serialisation is converted
+// from it, and the Vault key, scope and layout it records fill the matching
+// attributes. The content inputs stay null: planImported decides on the next
+// plan whether the configured content matches the file.
+func importFile(ctx context.Context, pd *sopsProviderData, id, format string, resp *resource.ImportStateResponse) {
+	name := strings.TrimPrefix(id, importFilePrefix)
+	content, err := os.ReadFile(name)
+	if err != nil {
+		resp.Diagnostics.AddError("Cannot read import file", err.Error())
+		return
+	}
+	document := string(content)
+	md, err := sopsencrypt.ParseMetadata(document)
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid import file",
+			fmt.Sprintf("%s is not a SOPS JSON or YAML document: %s", name, err))
+		return
+	}
+	if got := sopsencrypt.DetectInputType(document); got != format {
+		resp.Diagnostics.AddError("Wrong import file format",
+			fmt.Sprintf("%s is a SOPS %s document; import it into sops_encrypted_%s instead.", name, got, got))
+		return
+	}
+
+	var m ciphertextModel
+	m.set(document)
+	m.CiphertextJSON, m.CiphertextYAML = m.Ciphertext, m.Ciphertext
+	other := "yaml"
+	if format == "yaml" {
+		other = "json"
+	}
+	converted := types.StringNull()
+	// A YAML stream has no JSON form, which leaves ciphertext_json null.
+	if out, err := sopsencrypt.ConvertEncrypted(document, other, sopsencrypt.EncryptOpts{}); err == nil {
+		converted = types.StringValue(out)
+	} else if other == "yaml" {
+		resp.Diagnostics.AddError("Invalid import file", fmt.Sprintf("Converting %s to YAML: %s", name, err))
+		return
+	}
+	if other == "json" {
+		m.CiphertextJSON = converted
+	} else {
+		m.CiphertextYAML = converted
+	}
+
+	lineEndings := sopsencrypt.LineEndingsLF
+	if strings.Contains(document, "\r\n") {
+		lineEndings = sopsencrypt.LineEndingsCRLF
+	}
+	values := map[string]attr.Value{
+		"id":                m.CiphertextSHA256,
+		"ciphertext":        m.Ciphertext,
+		"ciphertext_base64": m.CiphertextBase64,
+		"ciphertext_sha256": m.CiphertextSHA256,
+		"ciphertext_json":   m.CiphertextJSON,
+		"ciphertext_yaml":   m.CiphertextYAML,
+		"key_version":       m.KeyVersion,
+		"trailing_newline":  types.BoolValue(strings.HasSuffix(document, "\n")),
+		"line_endings":      types.StringValue(lineEndings),
+	}
+	for name, v := range map[string]string{
+		"unencrypted_suffix": md.UnencryptedSuffix,
+		"encrypted_suffix":   md.EncryptedSuffix,
+		"unencrypted_regex":  md.UnencryptedRegex,
+		"encrypted_regex":    md.EncryptedRegex,
+	} {
+		if v != "" {
+			values[name] = types.StringValue(v)
+		}
+	}
+	if key, ok, err := sopsencrypt.WrappingTransitKey(document); err == nil && ok {
+		values["vault_key_name"] = types.StringValue(key.KeyName)
+		// Provider defaults are left to the provider, as for a resource
+		// that does not set them.
+		if key.Address != "" && key.Address != pd.vaultAddress {
+			values["vault_address"] = types.StringValue(key.Address)
+		}
+		if key.EnginePath != pd.vaultTransitEngine {
+			values["vault_transit_engine"] = types.StringValue(key.EnginePath)
+		}
+	}
+	for name, v := range values {
+		resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root(name), v)...)
+	}
+	resp.Diagnostics.Append(resp.Private.SetKey(ctx, privateImported, []byte("true"))...)
+	tflog.Info(ctx, "Imported encrypted file", map[string]any{"file": name, "format": format})
+}
+
+// importedContent reports whether private belongs to a resource imported
+// from a file whose content inputs are not in state yet.
+func importedContent(ctx context.Context, private privateReader, diags *diag.Diagnostics) bool {
+	if private == nil {
+		return false
+	}
+	imported, d := private.GetKey(ctx, privateImported)
+	diags.Append(d...)
+	return len(imported) > 0
+}
+
+// planImported plans the first change of a resource imported from a file,
+// whose content inputs are null in state and whose plan modifiers leave
+// replacement to it. The imported ciphertext is kept if it decrypts to the
+// document of m, and otherwise replaced with a warning that names the keys
+// that differ. Comparing needs Vault; in mock and age-only mode the
+// resource is always replaced.
+func planImported(ctx context.Context, pd *sopsProviderData, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse, m contentModel, address types.String, timeout time.Duration) {
+	if req.State.Raw.IsNull() || !importedContent(ctx, req.Private, &resp.Diagnostics) {
+		return
+	}
+	replace := func() {
+		resp.RequiresReplace = append(resp.RequiresReplace, m.attr())
+	}
+	if !m.known() {
+		replace()
+		return
+	}
+	document, inputYAML, d := m.document(ctx)
+	if d.HasError() {
+		// Encryption reports why the document does not render.
+		replace()
+		return
+	}
+	var ciphertext types.String
+	resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("ciphertext"), &ciphertext)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	client, err := pd.newVaultClientAt(address.ValueString(), timeout)
+	if err == nil && client == nil {
+		err = fmt.Errorf("decrypting needs Vault, which is not configured in mock or age-only mode")
+	}
+	var plaintext []byte
+	if err == nil {
+		plaintext, err = sopsencrypt.Decrypt(client, ciphertext.ValueString(), sopsencrypt.DetectInputType(ciphertext.ValueString()))
+	}
+	if err != nil {
+		resp.Diagnostics.AddAttributeWarning(m.attr(), "Imported file not compared",
+			"The imported file could not be decrypted to compare it with the configured content, so the resource is replaced and "+
+				"the content encrypted anew: "+err.Error())
+		replace()
+		return
+	}
+	importedYAML := sopsencrypt.DetectInputType(ciphertext.ValueString()) == "yaml"
+	if diff, err := sopsencrypt.DiffKeys(string(plaintext), importedYAML, document, inputYAML); err != nil || !diff.Empty() {
+		warnKeyDiff(m.attr(), string(plaintext), importedYAML, document, inputYAML, &resp.Diagnostics)
+		replace()
+		return
+	}
+	tflog.Info(ctx, "Imported file matches the configured content; keeping its ciphertext")
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("content_sha256"), sha256Hex(document))...)
+}
+
+const importedContentDescription = "Changes force replacement, except on the first plan after an import from a file."
+
+// contentStringRequiresReplace forces replacement when a string content
+// input changes, unless planImported decides; see importedContent.
+func contentStringRequiresReplace() planmodifier.String {
+	return stringplanmodifier.RequiresReplaceIf(
+		func(ctx context.Context, req planmodifier.StringRequest, resp *stringplanmodifier.RequiresReplaceIfFuncResponse) {
+			resp.RequiresReplace = !importedContent(ctx, req.Private, &resp.Diagnostics)
+		},
+		importedContentDescription, importedContentDescription,
+	)
+}
+
+// contentDynamicRequiresReplace is contentStringRequiresReplace for dynamic
+// content inputs.
+func contentDynamicRequiresReplace() planmodifier.Dynamic {
+	return dynamicplanmodifier.RequiresReplaceIf(
+		func(ctx context.Context, req planmodifier.DynamicRequest, resp *dynamicplanmodifier.RequiresReplaceIfFuncResponse) {
+			resp.RequiresReplace = !importedContent(ctx, req.Private, &resp.Diagnostics)
+		},
+		importedContentDescription, importedContentDescription,
+	)
+}
+
+// contentListRequiresReplace is contentStringRequiresReplace for list
+// content inputs.
+func contentListRequiresReplace() planmodifier.List {
+	return listplanmodifier.RequiresReplaceIf(
+		func(ctx context.Context, req planmodifier.ListRequest, resp *listplanmodifier.RequiresReplaceIfFuncResponse) {
+			resp.RequiresReplace = !importedContent(ctx, req.Private, &resp.Diagnostics)
+		},
+		importedContentDescription, importedContentDescription,
+	)
+}
@@ -0,0 +1,69 @@
+package provider
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/provider"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// TestLintPlaintextSecrets configures the provider in mock mode with and
+// without lint_plaintext_secrets and checks that an encryption scope leaving
+// a password in plaintext is warned about only when the lint is enabled.
+func TestLintPlaintextSecrets(t *testing.T) {
+	for _, enabled := range []bool{true, false} {
+		pd := configureTestProvider(t, map[string]tftypes.Value{
+			"mock":                   tftypes.NewValue(tftypes.Bool, true),
+			"lint_plaintext_secrets": tftypes.NewValue(tftypes.Bool, enabled),
+		})
+
+		m := encryptedJSONModel{EncryptedRegex: types.StringValue("^host$")}
+		var diags diag.Diagnostics
+		m.lintScope(context.Background(), pd, `{"host":"db.internal","password":"hunter2"}`, false, &diags)
+
+		var warned bool
+		for _, d := range diags.Warnings() {
+			if d.Summary() == "Secret-looking values left in plaintext" {
+				warned = true
+				if !strings.Contains(d.Detail(), "password") || strings.Contains(d.Detail(), "hunter2") {
+					t.Errorf("warning should name the path and not the value: %s", d.Detail())
+				}
+			}
+		}
+		if warned != enabled {
+			t.Errorf("lint_plaintext_secrets = %t: warned = %t, diagnostics: %v", enabled, warned, diags)
+		}
+	}
+}
+
+// configureTestProvider configures the provider with the given attributes,
+// all others null, and returns its provider data.
+func configureTestProvider(t *testing.T, attrs map[string]tftypes.Value) *sopsProviderData {
+	t.Helper()
+	ctx := context.Background()
+	p := New("test")()
+	var schemaResp provider.SchemaResponse
+	p.Schema(ctx, provider.SchemaRequest{}, &schemaResp)
+	typ := schemaResp.Schema.Type().TerraformType(ctx).(tftypes.Object)
+	values := map[string]tftypes.Value{}
+	for name, attrType := range typ.AttributeTypes {
+		values[name] = tftypes.NewValue(attrType, nil)
+		if v, ok := attrs[name]; ok {
+			values[name] = v
+		}
+	}
+
+	var resp provider.ConfigureResponse
+	p.Configure(ctx, provider.ConfigureRequest{
+		Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: tftypes.NewValue(typ, values)},
+	}, &resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("Configure: %v", resp.Diagnostics)
+	}
+	return resp.ResourceData.(*sopsProviderData)
+}
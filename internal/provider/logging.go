@@ -0,0 +1,106 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	vaultapi "github.com/hashicorp/vault/api"
+)
+
+// sensitiveLogFields are log field keys whose values are always masked, as a
+// guard against a secret being logged under a descriptive key.
+var sensitiveLogFields = []string{"token", "vault_token", "secret_id", "jwt", "content", "document", "plaintext"}
+
+// maskSecrets returns ctx with the given values masked wherever they appear
+// in a log message or field, and with sensitiveLogFields masked. Empty values
+// are skipped.
+func maskSecrets(ctx context.Context, secrets ...string) context.Context {
+	ctx = tflog.MaskFieldValuesWithFieldKeys(ctx, sensitiveLogFields...)
+	for _, s := range secrets {
+		if s == "" {
+			continue
+		}
+		ctx = tflog.MaskMessageStrings(ctx, s)
+		ctx = tflog.MaskAllFieldValuesStrings(ctx, s)
+	}
+	return ctx
+}
+
+// clientToken returns the token of client, which may be nil. The token of the
+// client is masked rather than the provider's, which a token file rotation
+// may replace while the operation runs.
+func clientToken(client *vaultapi.Client) string {
+	if client == nil {
+		return ""
+	}
+	return client.Token()
+}
+
+// mode names how the provider wraps data keys, for logs.
+func (pd *sopsProviderData) mode() string {
+	switch {
+	case pd.mock:
+		return "mock"
+	case pd.vaultAddress == "":
+		return "age-only"
+	default:
+		return "vault"
+	}
+}
+
+// logConfigured logs the resolved provider configuration. Credentials are
+// never part of it.
+func (pd *sopsProviderData) logConfigured(ctx context.Context) {
+	tflog.Debug(ctx, "Configured SOPS provider", map[string]any{
+		"mode":                   pd.mode(),
+		"vault_address":          pd.vaultAddress,
+		"vault_transit_engine":   pd.vaultTransitEngine,
+		"default_vault_key_name": pd.defaultVaultKeyName,
+		"age_recipients":         len(pd.ageRecipients),
+		"create_key_type":        pd.createKeyType,
+		"fips_mode":              pd.fips,
+		"disable_env_fallback":   pd.ignoreEnv,
+		"data_key_cache":         pd.dataKeyCache != nil,
+		"vault_proxy_url":        redactedURL(pd.vaultProxyURL),
+	})
+}
+
+// redactedURL returns rawURL with any password masked, for logs.
+func redactedURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return u.Redacted()
+}
+
+// logContext returns ctx prepared for logging an operation of client against
+// the given Transit key at the Vault server at address: the token of client
+// and secrets are masked, and the target is attached to every subsequent log
+// entry.
+func (pd *sopsProviderData) logContext(ctx context.Context, client *vaultapi.Client, address, transitEngine, keyName string, secrets ...string) context.Context {
+	ctx = maskSecrets(ctx, append([]string{clientToken(client)}, secrets...)...)
+	ctx = tflog.SetField(ctx, "mode", pd.mode())
+	ctx = tflog.SetField(ctx, "vault_address", address)
+	ctx = tflog.SetField(ctx, "vault_transit_engine", transitEngine)
+	return tflog.SetField(ctx, "vault_key_name", keyName)
+}
+
+// logResult logs the duration and outcome of operation. For a failed Vault
+// request the HTTP status is included.
+func logResult(ctx context.Context, operation string, start time.Time, err error) {
+	fields := map[string]any{"duration_ms": time.Since(start).Milliseconds()}
+	if err == nil {
+		tflog.Debug(ctx, operation+" succeeded", fields)
+		return
+	}
+	fields["error"] = err.Error()
+	var respErr *vaultapi.ResponseError
+	if errors.As(err, &respErr) {
+		fields["status"] = respErr.StatusCode
+	}
+	tflog.Debug(ctx, operation+" failed", fields)
+}
@@ -0,0 +1,183 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-log/tflogtest"
+
+	"terraform-provider-sops/internal/sopsencrypt"
+)
+
+// TestLoggingRedactsSecrets logs through authenticate and logContext with
+// known credentials and plaintext, including a Vault error echoing them, and
+// checks that none of them reach the log output.
+func TestLoggingRedactsSecrets(t *testing.T) {
+	const (
+		token     = "hvs.test-token-5f0c9a"
+		secretID  = "secret-id-7d41b2"
+		jwt       = "eyJhbGciOiJSUzI1NiJ9.test-jwt.c2lnbmF0dXJl"
+		plaintext = "hunter2-plaintext"
+	)
+
+	// A login endpoint that rejects every request and echoes the submitted
+	// credentials in its error, as a misbehaving auth plugin might.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"errors": []string{"invalid credentials: " + stringOr(body["secret_id"]) + stringOr(body["jwt"])},
+		})
+	}))
+	defer srv.Close()
+
+	var out bytes.Buffer
+	ctx := tflogtest.RootLogger(context.Background(), &out)
+	vault := sopsencrypt.VaultConfig{Address: srv.URL}
+
+	if _, diags := authenticate(ctx, vault, &sopsAuthModel{
+		Method:   types.StringValue(authMethodAppRole),
+		RoleID:   types.StringValue("role"),
+		SecretID: types.StringValue(secretID),
+	}, envFallback{disabled: true}); !diags.HasError() {
+		t.Fatal("AppRole login succeeded against a rejecting server")
+	}
+	if _, diags := authenticate(ctx, vault, &sopsAuthModel{
+		Method: types.StringValue(authMethodKubernetes),
+		Role:   types.StringValue("role"),
+		JWT:    types.StringValue(jwt),
+	}, envFallback{disabled: true}); !diags.HasError() {
+		t.Fatal("Kubernetes login succeeded against a rejecting server")
+	}
+
+	pool, err := vault.NewClientPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pd := &sopsProviderData{vaultAddress: srv.URL, vaultToken: token, vaultClients: pool}
+	client, err := pd.newVaultClient(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lctx := pd.logContext(ctx, client, srv.URL, "transit", "app", plaintext)
+	tflog.Debug(lctx, "Encrypting with token "+token, map[string]any{
+		"content": `{"password":"` + plaintext + `"}`,
+		"detail":  "token " + token,
+	})
+	tflog.Debug(pd.logContext(ctx, client, srv.URL, "transit", "app"), "Encrypting", map[string]any{
+		"content": "unrelated",
+	})
+
+	logs := out.String()
+	if !strings.Contains(logs, "Vault login failed") || !strings.Contains(logs, "Encrypting") {
+		t.Fatalf("expected log entries missing:\n%s", logs)
+	}
+	for name, secret := range map[string]string{"token": token, "secret_id": secretID, "jwt": jwt, "plaintext": plaintext, "content": "unrelated"} {
+		if strings.Contains(logs, secret) {
+			t.Errorf("%s logged in plaintext:\n%s", name, logs)
+		}
+	}
+}
+
+// TestLoggingMasksRotatedToken rotates vault_token_file while several
+// operations log with their clients' tokens, and checks that every token
+// is masked. Run with -race, it also checks that token refreshes and log
+// masking do not race.
+func TestLoggingMasksRotatedToken(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "token")
+	writeToken := func(token string) {
+		tmp := filepath.Join(dir, "token.tmp")
+		if err := os.WriteFile(tmp, []byte(token), 0o600); err != nil {
+			t.Error(err)
+		}
+		if err := os.Rename(tmp, file); err != nil {
+			t.Error(err)
+		}
+	}
+	// Consecutive tokens differ in length, so that every rotation changes
+	// the file size even when the modification time does not.
+	tokenAt := func(i int) string { return fmt.Sprintf("hvs.rotated-%03d%s", i, strings.Repeat("x", i%2)) }
+	writeToken(tokenAt(0))
+
+	pool, err := sopsencrypt.VaultConfig{Address: "http://127.0.0.1:8200"}.NewClientPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pd := &sopsProviderData{vaultAddress: "http://127.0.0.1:8200", vaultTokenFile: file, vaultClients: pool}
+
+	var out lockedBuffer
+	ctx := tflogtest.RootLogger(context.Background(), &out)
+	const rotations = 50
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 1; i <= rotations; i++ {
+			writeToken(tokenAt(i))
+		}
+	}()
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range rotations {
+				client, err := pd.newVaultClient(0)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				lctx := pd.logContext(ctx, client, pd.vaultAddress, "transit", "app")
+				tflog.Debug(lctx, "Encrypting with token "+client.Token())
+			}
+		}()
+	}
+	wg.Wait()
+
+	logs := out.String()
+	if !strings.Contains(logs, "Encrypting with token") {
+		t.Fatalf("expected log entries missing:\n%s", logs)
+	}
+	for i := 0; i <= rotations; i++ {
+		if token := tokenAt(i); strings.Contains(logs, token) {
+			t.Errorf("token %s logged in plaintext", token)
+		}
+	}
+}
+
+// lockedBuffer is a bytes.Buffer safe for concurrent writes.
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// stringOr returns v if it is a string, and "" otherwise.
+func stringOr(v any) string {
+	s, _ := v.(string)
+	return s
+}
@@ -2,38 +2,536 @@ package provider
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"math/big"
+	"net/url"
 	"os"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/provider"
 	"github.com/hashicorp/terraform-plugin-framework/provider/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	vaultapi "github.com/hashicorp/vault/api"
 	"terraform-provider-sops/internal/sopsencrypt"
 )
 
-var _ provider.Provider = &sopsProvider{}
+var (
+	_ provider.Provider                       = &sopsProvider{}
+	_ provider.ProviderWithValidateConfig     = &sopsProvider{}
+	_ provider.ProviderWithFunctions          = &sopsProvider{}
+	_ provider.ProviderWithEphemeralResources = &sopsProvider{}
+)
+
+// Supported values for auth.method.
+const (
+	authMethodToken      = "token"
+	authMethodAppRole    = "approle"
+	authMethodKubernetes = "kubernetes"
+)
+
+// defaultKubernetesJWTFile is the projected service account token path inside a pod.
+const defaultKubernetesJWTFile = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+
+// defaultCreateKeyType is the Transit key type created by
+// create_key_if_missing unless create_key_type is set.
+const defaultCreateKeyType = "aes256-gcm96"
 
 type sopsProvider struct {
 	version string
 }
 
 type sopsProviderModel struct {
-	VaultAddress       types.String `tfsdk:"vault_address"`
-	VaultToken         types.String `tfsdk:"vault_token"`
-	VaultTransitEngine types.String `tfsdk:"vault_transit_engine"`
-	VaultRoleID        types.String `tfsdk:"vault_role_id"`
-	VaultSecretID      types.String `tfsdk:"vault_secret_id"`
-	VaultApprolePath   types.String `tfsdk:"vault_approle_path"`
+	VaultAddress        types.String   `tfsdk:"vault_address"`
+	VaultProxyURL       types.String   `tfsdk:"vault_proxy_url"`
+	VaultTokenFile      types.String   `tfsdk:"vault_token_file"`
+	VaultUserAgentTag   types.String   `tfsdk:"vault_user_agent_tag"`
+	VaultCACertFile     types.String   `tfsdk:"vault_ca_cert_file"`
+	VaultCACertDir      types.String   `tfsdk:"vault_ca_cert_dir"`
+	VaultClientCertFile types.String   `tfsdk:"vault_client_cert_file"`
+	VaultClientKeyFile  types.String   `tfsdk:"vault_client_key_file"`
+	VaultSkipTLSVerify  types.Bool     `tfsdk:"vault_skip_tls_verify"`
+	VaultTransitEngine  types.String   `tfsdk:"vault_transit_engine"`
+	DefaultVaultKeyName types.String   `tfsdk:"default_vault_key_name"`
+	DefaultUnencSuffix  types.String   `tfsdk:"default_unencrypted_suffix"`
+	DefaultEncSuffix    types.String   `tfsdk:"default_encrypted_suffix"`
+	DefaultUnencRegex   types.String   `tfsdk:"default_unencrypted_regex"`
+	DefaultEncRegex     types.String   `tfsdk:"default_encrypted_regex"`
+	LintSecrets         types.Bool     `tfsdk:"lint_plaintext_secrets"`
+	Mock                types.Bool     `tfsdk:"mock"`
+	ValidateConnection  types.Bool     `tfsdk:"validate_connection"`
+	CreateKeyIfMissing  types.Bool     `tfsdk:"create_key_if_missing"`
+	CreateKeyType       types.String   `tfsdk:"create_key_type"`
+	MaxConcurrent       types.Int64    `tfsdk:"max_concurrent_vault_requests"`
+	StaleKeyMargin      types.Int64    `tfsdk:"stale_key_version_margin"`
+	MaxContentSize      types.Int64    `tfsdk:"max_content_size"`
+	RequestsPerSecond   types.Float64  `tfsdk:"vault_requests_per_second"`
+	AgeRecipients       types.List     `tfsdk:"age_recipients"`
+	FIPSMode            types.Bool     `tfsdk:"fips_mode"`
+	DisableEnvFallback  types.Bool     `tfsdk:"disable_env_fallback"`
+	DataKeyCache        types.Bool     `tfsdk:"data_key_cache"`
+	DataKeyCacheMaxUses types.Int64    `tfsdk:"data_key_cache_max_uses"`
+	Auth                *sopsAuthModel `tfsdk:"auth"`
+}
+
+// defaultScope maps each resource scope attribute to the provider attribute
+// that supplies its default.
+func (m *sopsProviderModel) defaultScope() map[string]types.String {
+	return map[string]types.String{
+		"unencrypted_suffix": m.DefaultUnencSuffix,
+		"encrypted_suffix":   m.DefaultEncSuffix,
+		"unencrypted_regex":  m.DefaultUnencRegex,
+		"encrypted_regex":    m.DefaultEncRegex,
+	}
 }
 
+// sopsAuthModel is the auth block. A nil pointer means the block is absent,
+// in which case the method is inferred from environment variables.
+type sopsAuthModel struct {
+	Method    types.String `tfsdk:"method"`
+	Token     types.String `tfsdk:"token"`
+	RoleID    types.String `tfsdk:"role_id"`
+	SecretID  types.String `tfsdk:"secret_id"`
+	Role      types.String `tfsdk:"role"`
+	JWT       types.String `tfsdk:"jwt"`
+	JWTFile   types.String `tfsdk:"jwt_file"`
+	MountPath types.String `tfsdk:"mount_path"`
+}
+
+// attributes maps each auth block attribute name to its value so the
+// method/attribute compatibility table can be checked generically.
+func (m *sopsAuthModel) attributes() map[string]types.String {
+	return map[string]types.String{
+		"token":      m.Token,
+		"role_id":    m.RoleID,
+		"secret_id":  m.SecretID,
+		"role":       m.Role,
+		"jwt":        m.JWT,
+		"jwt_file":   m.JWTFile,
+		"mount_path": m.MountPath,
+	}
+}
+
+// authMethodAttributes lists the auth block attributes each method accepts.
+// Setting any other attribute alongside a method is a configuration error.
+var authMethodAttributes = map[string][]string{
+	authMethodToken:      {"token"},
+	authMethodAppRole:    {"role_id", "secret_id", "mount_path"},
+	authMethodKubernetes: {"role", "jwt", "jwt_file", "mount_path"},
+}
+
+// scopeAttributes are the mutually exclusive encryption scope options shared
+// by every encryption resource, in the order the resources declare them.
+var scopeAttributes = []string{"unencrypted_suffix", "encrypted_suffix", "unencrypted_regex", "encrypted_regex"}
+
+// scopeListAttributes are the list-valued scope options of the resources
+// that select values by name or path, in the order the resources declare
+// them. They are mutually exclusive with scopeAttributes and have no
+// provider default.
+var scopeListAttributes = []string{"encrypted_paths", "unencrypted_keys"}
+
 // sopsProviderData carries resolved credentials to every data source and resource.
 // The vault token is kept here and injected directly into the vault API client —
 // it is never written to the process environment.
 type sopsProviderData struct {
-	vaultAddress       string
-	vaultToken         string
-	vaultTransitEngine string
+	vaultAddress   string
+	vaultProxyURL  string
+	vaultTLS       *vaultapi.TLSConfig
+	vaultUserAgent string
+	// vaultClients creates the Vault clients of every operation, which
+	// share its connections.
+	vaultClients *sopsencrypt.ClientPool
+	// tokenMu guards vaultToken and tokenFileInfo, which concurrent
+	// operations refresh from vaultTokenFile.
+	tokenMu    sync.Mutex
+	vaultToken string
+	// vaultTokenFile, when set, holds the token instead of vaultToken and is
+	// checked again for every Vault client, so a token refreshed on disk is
+	// picked up by the operations that follow.
+	vaultTokenFile string
+	// tokenFileInfo describes vaultTokenFile as of the last read of
+	// vaultToken.
+	tokenFileInfo       os.FileInfo
+	vaultTransitEngine  string
+	defaultVaultKeyName string
+	// defaultScope holds the provider-level scope option, keyed by the
+	// resource attribute it applies to. At most one entry is non-empty.
+	defaultScope map[string]string
+	// lintSecrets is lint_plaintext_secrets: encryptions warn about values
+	// with secret-looking key names that their scope leaves in plaintext.
+	lintSecrets bool
+	// mock disables all Vault access; resources emit placeholder ciphertext.
+	mock bool
+	// createKeyType is the type of Transit key created on first use when the
+	// key does not exist. Empty disables key creation.
+	createKeyType string
+	// ageRecipients receive a locally wrapped copy of every data key. With
+	// no Vault address configured they are the only key source.
+	ageRecipients []string
+	// fips is fips_mode: non-approved key sources are rejected.
+	fips bool
+	// ignoreEnv is disable_env_fallback: Vault clients ignore the VAULT_*
+	// environment variables too.
+	ignoreEnv bool
+	// vaultSlots holds one token per running Vault operation when
+	// max_concurrent_vault_requests is set; nil means unlimited.
+	vaultSlots chan struct{}
+	// staleKeyMargin is stale_key_version_margin, or -1 when unset.
+	staleKeyMargin int64
+	// maxContentSize is the largest plaintext document, in bytes, that
+	// resources accept; 0 means unlimited.
+	maxContentSize int64
+	// vaultLimiter paces the requests of every Vault client of the
+	// provider under vault_requests_per_second and Vault's rate-limit
+	// responses.
+	vaultLimiter *sopsencrypt.RateLimiter
+	// dataKeyCache shares data keys between encryptions with the same
+	// Transit key when data_key_cache is set; nil otherwise.
+	dataKeyCache *sopsencrypt.DataKeyCache
+	// identityMu guards identities, the token identity lookup per Vault
+	// address and token, made once for the audit attributes of resources.
+	identityMu sync.Mutex
+	identities map[string]*identityLookup
+}
+
+// identityLookup is a token identity lookup shared by the operations that
+// need it: done is closed once id and err are set.
+type identityLookup struct {
+	done chan struct{}
+	id   sopsencrypt.TokenIdentity
+	err  error
+}
+
+// usesVault reports whether documents are wrapped with Vault Transit (or its
+// mock stand-in), which is what makes vault_key_name mandatory.
+func (pd *sopsProviderData) usesVault() bool {
+	return pd.mock || pd.vaultAddress != ""
+}
+
+// vaultConfig returns the connection settings of the configured Vault.
+func (pd *sopsProviderData) vaultConfig() sopsencrypt.VaultConfig {
+	return sopsencrypt.VaultConfig{
+		Address:           pd.vaultAddress,
+		ProxyURL:          pd.vaultProxyURL,
+		RateLimiter:       pd.vaultLimiter,
+		TLS:               pd.vaultTLS,
+		UserAgent:         pd.vaultUserAgent,
+		IgnoreEnvironment: pd.ignoreEnv,
+	}
+}
+
+// tokenIdentity returns the identity of the Vault token at address, a
+// resource's vault_address, looked up once per address and token. ok is
+// false when no Vault calls may be made. Concurrent calls for one address and
+// token wait for a single lookup, and a failed lookup is retried by the next
+// call instead of being remembered.
+func (pd *sopsProviderData) tokenIdentity(ctx context.Context, address types.String, timeout time.Duration) (id sopsencrypt.TokenIdentity, ok bool, err error) {
+	client, err := pd.newVaultClientAt(address.ValueString(), timeout)
+	if err != nil || client == nil {
+		return id, false, err
+	}
+	key := client.Address() + "\x00" + client.Token()
+	pd.identityMu.Lock()
+	lookup, found := pd.identities[key]
+	if !found {
+		lookup = &identityLookup{done: make(chan struct{})}
+		if pd.identities == nil {
+			pd.identities = map[string]*identityLookup{}
+		}
+		pd.identities[key] = lookup
+	}
+	pd.identityMu.Unlock()
+
+	if !found {
+		lookup.id, lookup.err = pd.lookupTokenIdentity(ctx, client)
+		if lookup.err != nil {
+			pd.identityMu.Lock()
+			delete(pd.identities, key)
+			pd.identityMu.Unlock()
+		}
+		close(lookup.done)
+	}
+	select {
+	case <-lookup.done:
+	case <-ctx.Done():
+		return id, false, ctx.Err()
+	}
+	if lookup.err != nil {
+		return id, false, lookup.err
+	}
+	return lookup.id, true, nil
+}
+
+// lookupTokenIdentity looks up the identity of the token of client, within
+// the max_concurrent_vault_requests limit.
+func (pd *sopsProviderData) lookupTokenIdentity(ctx context.Context, client *vaultapi.Client) (sopsencrypt.TokenIdentity, error) {
+	release, err := pd.acquireVault(ctx)
+	if err != nil {
+		return sopsencrypt.TokenIdentity{}, err
+	}
+	defer release()
+	return sopsencrypt.LookupTokenIdentity(client)
+}
+
+// newVaultClient returns a client for the configured Vault, or nil when no
+// Vault calls may be made: in mock mode and in age-only mode. A non-zero
+// timeout bounds every request of the client, including its retries.
+func (pd *sopsProviderData) newVaultClient(timeout time.Duration) (*vaultapi.Client, error) {
+	return pd.newVaultClientAt("", timeout)
+}
+
+// newVaultClientAt is newVaultClient for the Vault server at address, as set
+// by a resource's vault_address. An empty address is the provider's. The
+// provider's token and connection settings are used either way.
+func (pd *sopsProviderData) newVaultClientAt(address string, timeout time.Duration) (*vaultapi.Client, error) {
+	if pd.mock || pd.vaultAddress == "" {
+		return nil, nil
+	}
+	token, err := pd.currentToken()
+	if err != nil {
+		return nil, err
+	}
+	client, err := pd.vaultClients.NewClient(address, token)
+	if err != nil {
+		return nil, err
+	}
+	if timeout > 0 {
+		client.SetClientTimeout(timeout)
+	}
+	return client, nil
+}
+
+// resolveTLS returns the Vault TLS settings of the provider attributes, each
+// falling back through env to the environment variable the Vault CLI reads.
+func resolveTLS(config sopsProviderModel, env envFallback) (*vaultapi.TLSConfig, error) {
+	cfg := &vaultapi.TLSConfig{
+		CACert:        env.resolveString(config.VaultCACertFile, "VAULT_CACERT"),
+		CAPath:        env.resolveString(config.VaultCACertDir, "VAULT_CAPATH"),
+		ClientCert:    env.resolveString(config.VaultClientCertFile, "VAULT_CLIENT_CERT"),
+		ClientKey:     env.resolveString(config.VaultClientKeyFile, "VAULT_CLIENT_KEY"),
+		TLSServerName: env.get("VAULT_TLS_SERVER_NAME"),
+	}
+	if !config.VaultSkipTLSVerify.IsNull() && !config.VaultSkipTLSVerify.IsUnknown() {
+		cfg.Insecure = config.VaultSkipTLSVerify.ValueBool()
+	} else if v := env.get("VAULT_SKIP_VERIFY"); v != "" {
+		insecure, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid VAULT_SKIP_VERIFY %q: expected true or false", v)
+		}
+		cfg.Insecure = insecure
+	}
+	return cfg, nil
+}
+
+// userAgent returns the User-Agent of the provider's Vault requests, naming
+// the Terraform and provider versions followed by the operator's tag.
+func userAgent(version, terraformVersion, tag string) string {
+	ua := "Terraform/" + terraformVersion + " terraform-provider-sops/" + version
+	if tag = strings.TrimSpace(tag); tag != "" {
+		ua += " " + tag
+	}
+	return ua
+}
+
+// validUserAgentTag reports whether tag can be sent in an HTTP header.
+func validUserAgentTag(tag string) bool {
+	for _, r := range tag {
+		if r < ' ' || r > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// currentToken returns the Vault token. With vault_token_file, the file is
+// read again when its size or modification time changed since the last read;
+// concurrent operations wait for that one read instead of each reading it.
+func (pd *sopsProviderData) currentToken() (string, error) {
+	pd.tokenMu.Lock()
+	defer pd.tokenMu.Unlock()
+	if pd.vaultTokenFile == "" {
+		return pd.vaultToken, nil
+	}
+	info, err := os.Stat(pd.vaultTokenFile)
+	if err != nil {
+		return "", fmt.Errorf("reading Vault token file: %w", err)
+	}
+	if last := pd.tokenFileInfo; last == nil || !info.ModTime().Equal(last.ModTime()) || info.Size() != last.Size() {
+		token, err := readTokenFile(pd.vaultTokenFile)
+		if err != nil {
+			return "", err
+		}
+		pd.vaultToken, pd.tokenFileInfo = token, info
+	}
+	return pd.vaultToken, nil
+}
+
+// readTokenFile returns the Vault token stored in file, without surrounding
+// whitespace.
+func readTokenFile(file string) (string, error) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return "", fmt.Errorf("reading Vault token file: %w", err)
+	}
+	token := strings.TrimSpace(string(b))
+	if token == "" {
+		return "", fmt.Errorf("Vault token file %s is empty", file)
+	}
+	return token, nil
+}
+
+// vaultAddressFor returns the Vault address a resource with the given
+// vault_address attribute talks to.
+func (pd *sopsProviderData) vaultAddressFor(address types.String) string {
+	if address.ValueString() != "" {
+		return address.ValueString()
+	}
+	return pd.vaultAddress
+}
+
+// acquireVault blocks until a Vault operation may start under
+// max_concurrent_vault_requests, or until ctx is done, and returns the
+// function that ends the operation.
+func (pd *sopsProviderData) acquireVault(ctx context.Context) (release func(), err error) {
+	if pd.vaultSlots == nil {
+		return func() {}, nil
+	}
+	select {
+	case pd.vaultSlots <- struct{}{}:
+	default:
+		tflog.Debug(ctx, "Waiting for a free Vault request slot", map[string]any{"max_concurrent_vault_requests": cap(pd.vaultSlots)})
+		select {
+		case pd.vaultSlots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	return func() { <-pd.vaultSlots }, nil
+}
+
+// setCachedDataKey sets opts.DataKey to the cached data key of the Transit
+// key keyName when data_key_cache is enabled. A data key of the resource's
+// own, mock mode and age-only mode leave opts as is.
+func (pd *sopsProviderData) setCachedDataKey(client *vaultapi.Client, transitEngine, keyName string, opts *sopsencrypt.EncryptOpts) error {
+	if pd.dataKeyCache == nil || client == nil || opts.Mock || opts.DataKey != nil {
+		return nil
+	}
+	dk, err := pd.dataKeyCache.Get(client, transitEngine, keyName, opts.CreateKeyType)
+	if err != nil {
+		return err
+	}
+	opts.DataKey = &dk
+	return nil
+}
+
+// defaultMaxContentSize is the max_content_size default, 4 MiB.
+const defaultMaxContentSize = 4 << 20
+
+// checkContentSize reports an error on attr when a plaintext document of
+// size bytes exceeds max_content_size.
+func (pd *sopsProviderData) checkContentSize(attr path.Path, size int, diags *diag.Diagnostics) {
+	if pd.maxContentSize == 0 || int64(size) <= pd.maxContentSize {
+		return
+	}
+	diags.AddAttributeError(attr, "Content too large",
+		fmt.Sprintf("The plaintext document is %d bytes, more than the provider's max_content_size of %d bytes. "+
+			"Terraform would keep it in state twice, as the sensitive input and as ciphertext, and in every plan. "+
+			"Encrypt large or binary files outside Terraform with `sops --encrypt` and read them with the "+
+			"sops_decrypted data source where needed, or raise max_content_size if the document is meant to be this large.",
+			size, pd.maxContentSize))
+}
+
+// checkPlaintext reports an error on attr when document is already
+// SOPS-encrypted, so that a file fed back into a resource is not encrypted a
+// second time. Documents that fail to parse are left to encryption to report.
+func checkPlaintext(attr path.Path, document string, inputYAML bool, diags *diag.Diagnostics) {
+	err := sopsencrypt.CheckPlaintext(document, inputYAML)
+	if !errors.Is(err, sopsencrypt.ErrAlreadyEncrypted) {
+		return
+	}
+	diags.AddAttributeError(attr, "Content already encrypted",
+		fmt.Sprintf("Encrypting %s again would nest its SOPS metadata and ciphertext in a new document. "+
+			"Pass the decrypted document instead, for example from the sops_decrypted data source.", err))
+}
+
+// checkDocuments applies checkContentSize and checkPlaintext to each
+// document of a sops_encrypted_documents resource.
+func (pd *sopsProviderData) checkDocuments(documents map[string]string, diags *diag.Diagnostics) {
+	for _, name := range slices.Sorted(maps.Keys(documents)) {
+		attr := path.Root("documents").AtMapKey(name)
+		pd.checkContentSize(attr, len(documents[name]), diags)
+		checkPlaintext(attr, documents[name], false, diags)
+	}
+}
+
+// resolveScope replaces unknown scope values with the provider defaults.
+// scope must be in scopeAttributes order. A scope option set on the resource
+// overrides the provider default entirely rather than combining with it;
+// lists holds the resource's scopeListAttributes, which count as such options.
+func (pd *sopsProviderData) resolveScope(lists []types.List, scope ...*types.String) {
+	explicit := false
+	for _, l := range lists {
+		explicit = explicit || !l.IsNull()
+	}
+	for _, v := range scope {
+		explicit = explicit || isSet(*v)
+	}
+	for i, v := range scope {
+		if !v.IsUnknown() {
+			continue
+		}
+		*v = types.StringNull()
+		if d := pd.defaultScope[scopeAttributes[i]]; d != "" && !explicit {
+			*v = types.StringValue(d)
+		}
+	}
+}
+
+// ruleScope returns a creation rule carrying the given scope options, or the
+// provider default when none is set. scope must be in scopeAttributes order.
+func (pd *sopsProviderData) ruleScope(scope ...types.String) sopsencrypt.CreationRule {
+	values := make(map[string]string, len(scopeAttributes))
+	for i, v := range scope {
+		if isSet(v) {
+			values[scopeAttributes[i]] = v.ValueString()
+		}
+	}
+	if len(values) == 0 {
+		values = pd.defaultScope
+	}
+	return sopsencrypt.CreationRule{
+		UnencryptedSuffix: values["unencrypted_suffix"],
+		EncryptedSuffix:   values["encrypted_suffix"],
+		UnencryptedRegex:  values["unencrypted_regex"],
+		EncryptedRegex:    values["encrypted_regex"],
+	}
+}
+
+// vaultKeyName returns the explicit vault_key_name if set, otherwise the
+// provider-level default. An empty result means neither was configured.
+func (pd *sopsProviderData) vaultKeyName(attr types.String) string {
+	return resolveStringDefault(attr, pd.defaultVaultKeyName)
 }
 
 func New(version string) func() provider.Provider {
@@ -55,30 +553,204 @@ func (p *sopsProvider) Schema(_ context.Context, _ provider.SchemaRequest, resp
 				Description: "Vault server URL. Falls back to the VAULT_ADDR environment variable.",
 				Optional:    true,
 			},
-			"vault_token": schema.StringAttribute{
-				Description: "Vault token. Falls back to the VAULT_TOKEN environment variable. " +
-					"Mutually exclusive with vault_role_id / vault_secret_id.",
-				Optional:  true,
-				Sensitive: true,
+			"vault_proxy_url": schema.StringAttribute{
+				Description: "HTTP, HTTPS or SOCKS5 proxy for Vault requests only, e.g. socks5://proxy.internal:1080. " +
+					"Falls back to the VAULT_PROXY_ADDR environment variable, then to HTTPS_PROXY, HTTP_PROXY and NO_PROXY.",
+				Optional: true,
+			},
+			"vault_ca_cert_file": schema.StringAttribute{
+				Description: "Path to a PEM-encoded CA certificate file used to verify the Vault server certificate. " +
+					"Falls back to the VAULT_CACERT environment variable.",
+				Optional: true,
+			},
+			"vault_ca_cert_dir": schema.StringAttribute{
+				Description: "Path to a directory of PEM-encoded CA certificate files used to verify the Vault server certificate. " +
+					"Ignored when vault_ca_cert_file is set. Falls back to the VAULT_CAPATH environment variable.",
+				Optional: true,
+			},
+			"vault_client_cert_file": schema.StringAttribute{
+				Description: "Path to a PEM-encoded client certificate presented to Vault. Requires vault_client_key_file. " +
+					"Falls back to the VAULT_CLIENT_CERT environment variable.",
+				Optional: true,
+			},
+			"vault_client_key_file": schema.StringAttribute{
+				Description: "Path to the PEM-encoded private key of vault_client_cert_file. " +
+					"Falls back to the VAULT_CLIENT_KEY environment variable.",
+				Optional: true,
+			},
+			"vault_skip_tls_verify": schema.BoolAttribute{
+				Description: "Skip verification of the Vault server certificate. Insecure; for testing only. " +
+					"Falls back to the VAULT_SKIP_VERIFY environment variable.",
+				Optional: true,
+			},
+			"vault_token_file": schema.StringAttribute{
+				Description: "Path to a file containing the Vault token. The file is checked again for every Vault operation, " +
+					"so a token refreshed on disk, e.g. by a Vault Agent sidecar, is used by the operations that follow. " +
+					"Mutually exclusive with the auth block.",
+				Optional: true,
+			},
+			"vault_user_agent_tag": schema.StringAttribute{
+				Description: "Text appended to the User-Agent of every Vault request, e.g. the Terraform workspace, " +
+					"so that Vault audit logs can attribute the traffic. Falls back to the TF_APPEND_USER_AGENT environment variable.",
+				Optional: true,
 			},
 			"vault_transit_engine": schema.StringAttribute{
 				Description: "Mount path for the Vault Transit secrets engine. Defaults to 'transit'.",
 				Optional:    true,
 			},
-			"vault_role_id": schema.StringAttribute{
-				Description: "AppRole role ID. Falls back to the VAULT_ROLE_ID environment variable. " +
-					"Must be used together with vault_secret_id. Mutually exclusive with vault_token.",
+			"default_vault_key_name": schema.StringAttribute{
+				Description: "Vault Transit key used by resources and data sources that omit vault_key_name.",
+				Optional:    true,
+			},
+			"mock": schema.BoolAttribute{
+				Description: "Skip Vault entirely and emit deterministic placeholder ciphertext that cannot be decrypted. " +
+					"Vault address and credentials are not required. Intended for plans and CI pipelines without Vault connectivity.",
 				Optional: true,
 			},
-			"vault_secret_id": schema.StringAttribute{
-				Description: "AppRole secret ID. Falls back to the VAULT_SECRET_ID environment variable. " +
-					"Must be used together with vault_role_id. Mutually exclusive with vault_token.",
-				Optional:  true,
-				Sensitive: true,
+			"fips_mode": schema.BoolAttribute{
+				Description: "Require FIPS 140-3 validated cryptography. Configuration fails unless the provider binary runs in FIPS 140-3 mode " +
+					"(built with GOFIPS140 or GOEXPERIMENT=boringcrypto, or run with GODEBUG=fips140=on), and when mock, age_recipients, " +
+					"a chacha20-poly1305 create_key_type, or age or PGP keys in sops_config are requested.",
+				Optional: true,
 			},
-			"vault_approle_path": schema.StringAttribute{
-				Description: "Mount path for the AppRole auth method. Defaults to 'approle'.",
-				Optional:    true,
+			"disable_env_fallback": schema.BoolAttribute{
+				Description: "Ignore every environment variable the provider otherwise falls back to — VAULT_ADDR, VAULT_TOKEN, VAULT_ROLE_ID, " +
+					"VAULT_SECRET_ID, the VAULT_* TLS, proxy, namespace, header and retry settings, TF_APPEND_USER_AGENT and SOPS_AGE_RECIPIENTS — " +
+					"so that addresses and credentials come only from the provider block.",
+				Optional: true,
+			},
+			"validate_connection": schema.BoolAttribute{
+				Description: "Check during provider configuration that Vault is reachable, the token is valid and a Transit " +
+					"engine is mounted at vault_transit_engine, so plans fail early with an actionable error. Ignored in mock and age-only mode.",
+				Optional: true,
+			},
+			"create_key_if_missing": schema.BoolAttribute{
+				Description: "Create the Vault Transit key of a resource before encrypting if it does not exist yet, " +
+					"so the first apply in a new environment can bootstrap the key. Requires create capability on " +
+					"<vault_transit_engine>/keys/<name> for missing keys only. Ignored in mock and age-only mode.",
+				Optional: true,
+			},
+			"create_key_type": schema.StringAttribute{
+				Description: "Type of the Transit keys created by create_key_if_missing, e.g. 'aes256-gcm96' (default), " +
+					"'chacha20-poly1305' or 'rsa-4096'. Existing keys are never modified.",
+				Optional: true,
+			},
+			"max_concurrent_vault_requests": schema.Int64Attribute{
+				Description: "Maximum number of Vault operations (encryptions, rewraps and key reads) the provider runs at once, " +
+					"across all resources and data sources. Operations beyond the limit wait for a free slot. Unlimited by default.",
+				Optional: true,
+			},
+			"stale_key_version_margin": schema.Int64Attribute{
+				Description: "Warn during refresh when the data key of an encrypted resource is wrapped with a Transit key version " +
+					"lower than the key's min_decryption_version plus this margin, so documents at risk of becoming undecryptable " +
+					"after key trimming are noticed. 0 warns only about documents that can no longer be decrypted. Disabled by default.",
+				Optional: true,
+			},
+			"max_content_size": schema.Int64Attribute{
+				Description: "Largest plaintext document, in bytes, that an encrypted resource accepts, so an accidental " +
+					"file() of a large file fails at plan time instead of ending up in state as plaintext and ciphertext. " +
+					"0 removes the limit. Defaults to 4194304 (4 MiB).",
+				Optional: true,
+			},
+			"vault_requests_per_second": schema.Float64Attribute{
+				Description: "Maximum number of Vault requests per second the provider starts, across all resources and data sources. " +
+					"Requests beyond the rate wait their turn. Independently of this limit, new requests are held back " +
+					"while Vault reports an exhausted rate-limit quota. Unlimited by default.",
+				Optional: true,
+			},
+			"data_key_cache": schema.BoolAttribute{
+				Description: "Reuse the data key of an encryption, and its Transit wrapped form, for later encryptions with the " +
+					"same Transit key during one apply, so creating many documents takes one Transit call per key " +
+					"instead of one per resource. Documents that share a data key can be decrypted with each other's key, " +
+					"so leave this off under policies that require a key per document. A resource's data_key_wo takes " +
+					"precedence. Ignored in mock and age-only mode. Disabled by default.",
+				Optional: true,
+			},
+			"data_key_cache_max_uses": schema.Int64Attribute{
+				Description: "Number of resources a cached data key is used for before a new one is generated, " +
+					"bounding how many documents share a key. Requires data_key_cache = true. Unlimited by default.",
+				Optional: true,
+			},
+			"age_recipients": schema.ListAttribute{
+				ElementType: types.StringType,
+				Description: "age public keys that can decrypt every document in addition to Vault. " +
+					"Falls back to the comma-separated SOPS_AGE_RECIPIENTS environment variable. " +
+					"When no Vault address is configured, documents are encrypted locally for these recipients only " +
+					"and no Vault credentials are required.",
+				Optional: true,
+			},
+			"default_unencrypted_suffix": schema.StringAttribute{
+				Description: "unencrypted_suffix inherited by resources that set no scope option of their own. " +
+					"Mutually exclusive with other default scope options.",
+				Optional: true,
+			},
+			"default_encrypted_suffix": schema.StringAttribute{
+				Description: "encrypted_suffix inherited by resources that set no scope option of their own. " +
+					"Mutually exclusive with other default scope options.",
+				Optional: true,
+			},
+			"default_unencrypted_regex": schema.StringAttribute{
+				Description: "unencrypted_regex inherited by resources that set no scope option of their own. " +
+					"Mutually exclusive with other default scope options.",
+				Optional:   true,
+				Validators: []validator.String{validRegex()},
+			},
+			"default_encrypted_regex": schema.StringAttribute{
+				Description: "encrypted_regex inherited by resources that set no scope option of their own. " +
+					"Mutually exclusive with other default scope options.",
+				Optional:   true,
+				Validators: []validator.String{validRegex()},
+			},
+			"lint_plaintext_secrets": schema.BoolAttribute{
+				Description: "Warn when an encryption leaves values in plaintext whose key name looks like it holds a secret, " +
+					"such as password, token, secret or api_key, so a scope option that misses them is caught before " +
+					"the file is committed. Disabled by default.",
+				Optional: true,
+			},
+		},
+		Blocks: map[string]schema.Block{
+			"auth": schema.SingleNestedBlock{
+				Description: "Vault authentication. When omitted, the method is inferred from VAULT_TOKEN " +
+					"or VAULT_ROLE_ID / VAULT_SECRET_ID.",
+				Attributes: map[string]schema.Attribute{
+					"method": schema.StringAttribute{
+						Description: "Authentication method: 'token', 'approle' or 'kubernetes'. " +
+							"Inferred from the other attributes and environment variables when unset.",
+						Optional: true,
+					},
+					"token": schema.StringAttribute{
+						Description: "Vault token for the 'token' method. Falls back to the VAULT_TOKEN environment variable.",
+						Optional:    true,
+						Sensitive:   true,
+					},
+					"role_id": schema.StringAttribute{
+						Description: "AppRole role ID for the 'approle' method. Falls back to the VAULT_ROLE_ID environment variable.",
+						Optional:    true,
+					},
+					"secret_id": schema.StringAttribute{
+						Description: "AppRole secret ID for the 'approle' method. Falls back to the VAULT_SECRET_ID environment variable.",
+						Optional:    true,
+						Sensitive:   true,
+					},
+					"role": schema.StringAttribute{
+						Description: "Vault role to log in as for the 'kubernetes' method.",
+						Optional:    true,
+					},
+					"jwt": schema.StringAttribute{
+						Description: "Service account JWT for the 'kubernetes' method. Mutually exclusive with jwt_file.",
+						Optional:    true,
+						Sensitive:   true,
+					},
+					"jwt_file": schema.StringAttribute{
+						Description: "Path to the service account JWT for the 'kubernetes' method. " +
+							"Defaults to '" + defaultKubernetesJWTFile + "'.",
+						Optional: true,
+					},
+					"mount_path": schema.StringAttribute{
+						Description: "Auth method mount path. Defaults to 'approle' or 'kubernetes' depending on the method.",
+						Optional:    true,
+					},
+				},
 			},
 		},
 	}
@@ -91,75 +763,312 @@ func (p *sopsProvider) Configure(ctx context.Context, req provider.ConfigureRequ
 		return
 	}
 
-	// Explicit provider config takes precedence; env vars are the fallback.
+	// Explicit provider config takes precedence; env vars are the fallback
+	// unless disable_env_fallback is set.
 	// Credentials are stored in sopsProviderData and injected directly into
 	// the vault API client — os.Setenv is intentionally not called here.
-	vaultAddress := resolveString(config.VaultAddress, "VAULT_ADDR")
+	env := envFallback{disabled: config.DisableEnvFallback.ValueBool()}
+	vaultAddress := env.resolveString(config.VaultAddress, "VAULT_ADDR")
 	vaultTransitEngine := resolveStringDefault(config.VaultTransitEngine, "transit")
-
-	if vaultAddress == "" {
-		resp.Diagnostics.AddError(
-			"Missing Vault address",
-			"Set vault_address in the provider block or the VAULT_ADDR environment variable.",
-		)
+	userAgentTag := env.resolveString(config.VaultUserAgentTag, "TF_APPEND_USER_AGENT")
+	if !validUserAgentTag(userAgentTag) {
+		resp.Diagnostics.AddError("Invalid Vault user agent tag",
+			fmt.Sprintf("Expected printable ASCII characters only, got %q.", userAgentTag))
 		return
 	}
 
-	vaultToken := resolveString(config.VaultToken, "VAULT_TOKEN")
-	roleID := resolveString(config.VaultRoleID, "VAULT_ROLE_ID")
-	secretID := resolveString(config.VaultSecretID, "VAULT_SECRET_ID")
+	pd := &sopsProviderData{
+		vaultAddress:        vaultAddress,
+		vaultProxyURL:       config.VaultProxyURL.ValueString(),
+		vaultUserAgent:      userAgent(p.version, req.TerraformVersion, userAgentTag),
+		vaultTransitEngine:  vaultTransitEngine,
+		defaultVaultKeyName: config.DefaultVaultKeyName.ValueString(),
+		defaultScope:        map[string]string{},
+		lintSecrets:         config.LintSecrets.ValueBool(),
+		mock:                config.Mock.ValueBool(),
+		vaultLimiter:        sopsencrypt.NewRateLimiter(config.RequestsPerSecond.ValueFloat64()),
+		staleKeyMargin:      -1,
+		maxContentSize:      defaultMaxContentSize,
+		ignoreEnv:           env.disabled,
+	}
+	if !config.StaleKeyMargin.IsNull() {
+		pd.staleKeyMargin = config.StaleKeyMargin.ValueInt64()
+	}
+	if !config.MaxContentSize.IsNull() {
+		pd.maxContentSize = config.MaxContentSize.ValueInt64()
+	}
+	if config.CreateKeyIfMissing.ValueBool() {
+		pd.createKeyType = resolveStringDefault(config.CreateKeyType, defaultCreateKeyType)
+	}
+	for name, v := range config.defaultScope() {
+		if isSet(v) {
+			pd.defaultScope[name] = v.ValueString()
+		}
+	}
+
+	if !config.AgeRecipients.IsNull() && !config.AgeRecipients.IsUnknown() {
+		resp.Diagnostics.Append(config.AgeRecipients.ElementsAs(ctx, &pd.ageRecipients, false)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	} else if v := env.get("SOPS_AGE_RECIPIENTS"); v != "" {
+		for _, r := range strings.Split(v, ",") {
+			if r = strings.TrimSpace(r); r != "" {
+				pd.ageRecipients = append(pd.ageRecipients, r)
+			}
+		}
+	}
+
+	if config.FIPSMode.ValueBool() {
+		pd.fips = true
+		pd.checkFIPS(&resp.Diagnostics)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
 
-	hasToken := vaultToken != ""
-	hasAppRole := roleID != "" || secretID != ""
+	// Mock mode never talks to Vault, and age-only mode encrypts locally, so
+	// neither requires an address or credentials.
+	if pd.mock || (vaultAddress == "" && len(pd.ageRecipients) > 0) {
+		pd.logConfigured(ctx)
+		resp.DataSourceData = pd
+		resp.ResourceData = pd
+		resp.EphemeralResourceData = pd
+		return
+	}
 
-	if hasToken && hasAppRole {
+	if vaultAddress == "" {
 		resp.Diagnostics.AddError(
-			"Conflicting Vault credentials",
-			"Provide either vault_token or AppRole credentials (vault_role_id + vault_secret_id), not both.",
+			"Missing Vault address",
+			"Set vault_address in the provider block or the VAULT_ADDR environment variable, "+
+				"or configure age_recipients to encrypt without Vault."+env.note(),
 		)
 		return
 	}
 
-	switch {
-	case hasToken:
-		// token already resolved above
+	vaultTLS, err := resolveTLS(config, env)
+	if err == nil {
+		pd.vaultTLS = vaultTLS
+		// The pool loads the certificates once, so a bad path fails here
+		// rather than in the first resource.
+		pd.vaultClients, err = pd.vaultConfig().NewClientPool()
+	}
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid Vault TLS configuration", err.Error())
+		return
+	}
 
-	case roleID != "" && secretID != "":
-		approlePath := resolveStringDefault(config.VaultApprolePath, "approle")
-		token, err := sopsencrypt.AppRoleLogin(vaultAddress, approlePath, roleID, secretID)
+	var vaultToken string
+	if isSet(config.VaultTokenFile) {
+		// The token is read here only to fail early; clients check the
+		// file again each time.
+		pd.vaultTokenFile = config.VaultTokenFile.ValueString()
+		token, err := pd.currentToken()
 		if err != nil {
-			resp.Diagnostics.AddError("AppRole authentication failed", err.Error())
+			resp.Diagnostics.AddAttributeError(path.Root("vault_token_file"), "Failed to read Vault token file", err.Error())
 			return
 		}
+		tflog.Debug(ctx, "Using Vault token file", map[string]any{"vault_token_file": pd.vaultTokenFile})
 		vaultToken = token
+	} else {
+		auth := config.Auth
+		if auth == nil {
+			auth = &sopsAuthModel{}
+		}
+		var diags diag.Diagnostics
+		vaultToken, diags = authenticate(ctx, pd.vaultConfig(), auth, env)
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
 
-	case roleID != "" || secretID != "":
-		resp.Diagnostics.AddError(
-			"Incomplete AppRole credentials",
-			"Both vault_role_id and vault_secret_id are required for AppRole authentication.",
-		)
+	pd.vaultToken = vaultToken
+	ctx = maskSecrets(ctx, vaultToken)
+	if n := config.MaxConcurrent.ValueInt64(); n > 0 {
+		pd.vaultSlots = make(chan struct{}, n)
+	}
+	if config.DataKeyCache.ValueBool() {
+		pd.dataKeyCache = sopsencrypt.NewDataKeyCache(int(config.DataKeyCacheMaxUses.ValueInt64()))
+	}
+
+	if config.ValidateConnection.ValueBool() {
+		start := time.Now()
+		client, err := pd.newVaultClient(0)
+		if err == nil {
+			err = sopsencrypt.CheckConnection(client, vaultTransitEngine)
+		}
+		logResult(ctx, "Vault connection check", start, err)
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("validate_connection"), "Vault connection check failed", err.Error())
+			return
+		}
+	}
+
+	pd.logConfigured(ctx)
+	resp.DataSourceData = pd
+	resp.ResourceData = pd
+	resp.EphemeralResourceData = pd
+}
+
+// ValidateConfig rejects auth block combinations that can never work, so the
+// error surfaces at plan time with the offending attribute path.
+func (p *sopsProvider) ValidateConfig(ctx context.Context, req provider.ValidateConfigRequest, resp *provider.ValidateConfigResponse) {
+	var config sopsProviderModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	if resp.Diagnostics.HasError() {
 		return
+	}
 
-	default:
-		resp.Diagnostics.AddError(
-			"Missing Vault credentials",
-			"Provide vault_token (or VAULT_TOKEN) or both vault_role_id and vault_secret_id for AppRole authentication.",
-		)
+	var defaults []string
+	for _, name := range scopeAttributes {
+		if isSet(config.defaultScope()[name]) {
+			defaults = append(defaults, "default_"+name)
+		}
+	}
+	if len(defaults) > 1 {
+		resp.Diagnostics.AddAttributeError(path.Root(defaults[1]),
+			"Conflicting default scope options",
+			fmt.Sprintf("At most one default scope option may be set; got %s.", strings.Join(defaults, ", ")))
+	}
+
+	if isSet(config.CreateKeyType) {
+		keyType := config.CreateKeyType.ValueString()
+		if !slices.Contains(sopsencrypt.TransitKeyTypes, keyType) {
+			resp.Diagnostics.AddAttributeError(path.Root("create_key_type"),
+				"Invalid Transit key type",
+				fmt.Sprintf("Expected one of %s, got %q.", strings.Join(sopsencrypt.TransitKeyTypes, ", "), keyType))
+		}
+		if !config.CreateKeyIfMissing.IsUnknown() && !config.CreateKeyIfMissing.ValueBool() {
+			resp.Diagnostics.AddAttributeError(path.Root("create_key_type"),
+				"Missing create_key_if_missing",
+				"create_key_type only applies with create_key_if_missing = true.")
+		}
+	}
+
+	if isSet(config.VaultProxyURL) {
+		if u, err := url.Parse(config.VaultProxyURL.ValueString()); err != nil || !slices.Contains(sopsencrypt.ProxySchemes, u.Scheme) || u.Host == "" {
+			resp.Diagnostics.AddAttributeError(path.Root("vault_proxy_url"),
+				"Invalid vault_proxy_url",
+				fmt.Sprintf("Expected an absolute URL with scheme %s.", strings.Join(sopsencrypt.ProxySchemes, ", ")))
+		}
+	}
+
+	if !config.MaxConcurrent.IsNull() && !config.MaxConcurrent.IsUnknown() && config.MaxConcurrent.ValueInt64() < 1 {
+		resp.Diagnostics.AddAttributeError(path.Root("max_concurrent_vault_requests"),
+			"Invalid max_concurrent_vault_requests",
+			fmt.Sprintf("Expected at least 1, got %d.", config.MaxConcurrent.ValueInt64()))
+	}
+
+	if !config.DataKeyCacheMaxUses.IsNull() && !config.DataKeyCacheMaxUses.IsUnknown() {
+		if n := config.DataKeyCacheMaxUses.ValueInt64(); n < 1 {
+			resp.Diagnostics.AddAttributeError(path.Root("data_key_cache_max_uses"),
+				"Invalid data_key_cache_max_uses",
+				fmt.Sprintf("Expected at least 1, got %d.", n))
+		}
+		if !config.DataKeyCache.IsUnknown() && !config.DataKeyCache.ValueBool() {
+			resp.Diagnostics.AddAttributeError(path.Root("data_key_cache_max_uses"),
+				"Missing data_key_cache",
+				"data_key_cache_max_uses only applies with data_key_cache = true.")
+		}
+	}
+
+	if !config.StaleKeyMargin.IsNull() && !config.StaleKeyMargin.IsUnknown() && config.StaleKeyMargin.ValueInt64() < 0 {
+		resp.Diagnostics.AddAttributeError(path.Root("stale_key_version_margin"),
+			"Invalid stale_key_version_margin",
+			fmt.Sprintf("Expected at least 0, got %d.", config.StaleKeyMargin.ValueInt64()))
+	}
+
+	if !config.MaxContentSize.IsNull() && !config.MaxContentSize.IsUnknown() && config.MaxContentSize.ValueInt64() < 0 {
+		resp.Diagnostics.AddAttributeError(path.Root("max_content_size"),
+			"Invalid max_content_size",
+			fmt.Sprintf("Expected at least 0, got %d.", config.MaxContentSize.ValueInt64()))
+	}
+
+	if !config.RequestsPerSecond.IsNull() && !config.RequestsPerSecond.IsUnknown() && config.RequestsPerSecond.ValueFloat64() <= 0 {
+		resp.Diagnostics.AddAttributeError(path.Root("vault_requests_per_second"),
+			"Invalid vault_requests_per_second",
+			fmt.Sprintf("Expected a positive number, got %g.", config.RequestsPerSecond.ValueFloat64()))
+	}
+
+	if isSet(config.VaultUserAgentTag) && !validUserAgentTag(config.VaultUserAgentTag.ValueString()) {
+		resp.Diagnostics.AddAttributeError(path.Root("vault_user_agent_tag"),
+			"Invalid vault_user_agent_tag",
+			fmt.Sprintf("Expected printable ASCII characters only, got %q.", config.VaultUserAgentTag.ValueString()))
+	}
+
+	if isSet(config.VaultClientCertFile) != isSet(config.VaultClientKeyFile) {
+		name := "vault_client_key_file"
+		if isSet(config.VaultClientKeyFile) {
+			name = "vault_client_cert_file"
+		}
+		resp.Diagnostics.AddAttributeError(path.Root(name),
+			"Incomplete Vault client certificate",
+			"vault_client_cert_file and vault_client_key_file must be set together.")
+	}
+
+	if isSet(config.VaultTokenFile) && config.Auth != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("vault_token_file"),
+			"Conflicting Vault credentials",
+			"Provide either vault_token_file or the auth block, not both.")
+	}
+
+	if config.Auth == nil || config.Auth.Method.IsUnknown() {
 		return
 	}
+	auth := config.Auth
+	authPath := path.Root("auth")
 
-	pd := &sopsProviderData{
-		vaultAddress:       vaultAddress,
-		vaultToken:         vaultToken,
-		vaultTransitEngine: vaultTransitEngine,
+	method := auth.Method.ValueString()
+	if method == "" {
+		// Without an explicit method only token or AppRole can be inferred.
+		if isSet(auth.Token) && (isSet(auth.RoleID) || isSet(auth.SecretID)) {
+			resp.Diagnostics.AddAttributeError(authPath.AtName("token"),
+				"Conflicting Vault credentials",
+				"Provide either token or AppRole credentials (role_id + secret_id), not both, or set method explicitly.")
+		}
+		for _, name := range []string{"role", "jwt", "jwt_file"} {
+			if isSet(auth.attributes()[name]) {
+				resp.Diagnostics.AddAttributeError(authPath.AtName(name),
+					"Missing auth method",
+					fmt.Sprintf("%s requires method = %q.", name, authMethodKubernetes))
+			}
+		}
+		return
+	}
+
+	allowed, ok := authMethodAttributes[method]
+	if !ok {
+		resp.Diagnostics.AddAttributeError(authPath.AtName("method"),
+			"Invalid auth method",
+			fmt.Sprintf("Expected one of %q, %q or %q, got %q.", authMethodToken, authMethodAppRole, authMethodKubernetes, method))
+		return
+	}
+	attrs := auth.attributes()
+	for _, name := range slices.Sorted(maps.Keys(attrs)) {
+		if isSet(attrs[name]) && !slices.Contains(allowed, name) {
+			resp.Diagnostics.AddAttributeError(authPath.AtName(name),
+				"Invalid auth attribute",
+				fmt.Sprintf("%s cannot be used with method %q.", name, method))
+		}
+	}
+	if method == authMethodKubernetes && isSet(auth.JWT) && isSet(auth.JWTFile) {
+		resp.Diagnostics.AddAttributeError(authPath.AtName("jwt_file"),
+			"Conflicting Kubernetes credentials",
+			"Provide either jwt or jwt_file, not both.")
 	}
-	resp.DataSourceData = pd
-	resp.ResourceData = pd
 }
 
 func (p *sopsProvider) DataSources(_ context.Context) []func() datasource.DataSource {
 	return []func() datasource.DataSource{
 		NewSOPSConfigDataSource,
+		NewTransitKeyDataSource,
+		NewDecryptedDataSource,
+		NewDecryptedTFVarsDataSource,
+		NewTransitDecryptedStringDataSource,
+		NewMetadataDataSource,
+		NewValidateDataSource,
+		NewCreationRuleMatchDataSource,
 	}
 }
 
@@ -167,21 +1076,921 @@ func (p *sopsProvider) Resources(_ context.Context) []func() resource.Resource {
 	return []func() resource.Resource{
 		NewEncryptedJSONResource,
 		NewEncryptedYAMLResource,
+		NewEncryptedKubernetesSecretResource,
+		NewEncryptedTFVarsResource,
+		NewEncryptedDocumentsResource,
+		NewSOPSConfigFileResource,
+		NewTransitEncryptedStringResource,
+		NewDocumentKeysResource,
+	}
+}
+
+func (p *sopsProvider) EphemeralResources(_ context.Context) []func() ephemeral.EphemeralResource {
+	return []func() ephemeral.EphemeralResource{
+		NewDataKeyEphemeralResource,
+	}
+}
+
+func (p *sopsProvider) Functions(_ context.Context) []func() function.Function {
+	return []func() function.Function{
+		NewConfigFunction,
+		NewMetadataFunction,
+		NewIsEncryptedFunction,
+	}
+}
+
+// planVaultKeyName fills vault_key_name in the plan from the provider default
+// when the resource omits it, and forces replacement when the resolved key
+// differs from the one recorded in state. Explicit values are left to the
+// attribute's RequiresReplace plan modifier, and rejected in age-only mode
+// like a resource vault_address.
+func planVaultKeyName(ctx context.Context, pd *sopsProviderData, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	keyPath := path.Root("vault_key_name")
+
+	var configured types.String
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, keyPath, &configured)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if !configured.IsNull() {
+		if !configured.IsUnknown() && !pd.usesVault() {
+			resp.Diagnostics.AddAttributeError(keyPath,
+				"Vault not configured",
+				"vault_key_name on a resource requires a provider configured for Vault, with vault_address and credentials. "+
+					"The provider is in age-only mode, where documents are encrypted for age_recipients only.")
+		}
+		return
+	}
+	if pd.defaultVaultKeyName == "" {
+		if !pd.usesVault() {
+			// Age-only mode: no Vault key is involved.
+			resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, keyPath, types.StringNull())...)
+			return
+		}
+		resp.Diagnostics.AddAttributeError(keyPath,
+			"Missing Vault key name",
+			"Set vault_key_name on the resource or default_vault_key_name on the provider.")
+		return
+	}
+
+	keyName := types.StringValue(pd.defaultVaultKeyName)
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, keyPath, keyName)...)
+	if req.State.Raw.IsNull() {
+		return
+	}
+	var prior types.String
+	resp.Diagnostics.Append(req.State.GetAttribute(ctx, keyPath, &prior)...)
+	if !prior.Equal(keyName) {
+		resp.RequiresReplace = append(resp.RequiresReplace, keyPath)
+	}
+}
+
+// planVaultAddress rejects a resource vault_address when the provider is in
+// age-only mode, where there is no Vault token to use with it. Mock mode
+// ignores the address like every other Vault setting.
+func planVaultAddress(ctx context.Context, pd *sopsProviderData, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	var configured types.String
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("vault_address"), &configured)...)
+	if resp.Diagnostics.HasError() || configured.IsNull() || configured.IsUnknown() || pd.usesVault() {
+		return
+	}
+	resp.Diagnostics.AddAttributeError(path.Root("vault_address"),
+		"Vault not configured",
+		"vault_address on a resource requires a provider configured for Vault, with vault_address and credentials. "+
+			"The provider is in age-only mode.")
+}
+
+// planScope fills scope options the resource omits from the provider
+// defaults — or nulls them when the resource sets a scope option of its own,
+// including the scopeListAttributes — and forces replacement when the
+// resolved scope differs from state.
+func planScope(ctx context.Context, pd *sopsProviderData, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	configured := make(map[string]types.String, len(scopeAttributes))
+	explicit := false
+	for _, name := range scopeListAttributes {
+		var v types.List
+		resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root(name), &v)...)
+		if resp.Diagnostics.HasError() || v.IsUnknown() {
+			return
+		}
+		explicit = explicit || !v.IsNull()
+	}
+	for _, name := range scopeAttributes {
+		var v types.String
+		resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root(name), &v)...)
+		if resp.Diagnostics.HasError() || v.IsUnknown() {
+			return
+		}
+		configured[name] = v
+		explicit = explicit || !v.IsNull()
+	}
+
+	for _, name := range scopeAttributes {
+		if !configured[name].IsNull() {
+			continue
+		}
+		planned := types.StringNull()
+		if d := pd.defaultScope[name]; d != "" && !explicit {
+			planned = types.StringValue(d)
+		}
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root(name), planned)...)
+		if req.State.Raw.IsNull() {
+			continue
+		}
+		var prior types.String
+		resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root(name), &prior)...)
+		if !prior.Equal(planned) {
+			resp.RequiresReplace = append(resp.RequiresReplace, path.Root(name))
+		}
+	}
+}
+
+// authenticate resolves the auth method — explicit, or inferred from the
+// token and AppRole credentials — and returns a Vault token, logging in first
+// for methods that exchange credentials for one.
+func authenticate(ctx context.Context, vault sopsencrypt.VaultConfig, auth *sopsAuthModel, env envFallback) (string, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	token := env.resolveString(auth.Token, "VAULT_TOKEN")
+	roleID := env.resolveString(auth.RoleID, "VAULT_ROLE_ID")
+	secretID := env.resolveString(auth.SecretID, "VAULT_SECRET_ID")
+	ctx = maskSecrets(ctx, token, secretID, auth.JWT.ValueString())
+	ctx = tflog.SetField(ctx, "vault_address", vault.Address)
+
+	method := auth.Method.ValueString()
+	if method == "" {
+		hasToken := token != ""
+		hasAppRole := roleID != "" || secretID != ""
+		switch {
+		case hasToken && hasAppRole:
+			diags.AddError(
+				"Conflicting Vault credentials",
+				"Provide either a token or AppRole credentials (role_id + secret_id), not both, or set auth.method explicitly.",
+			)
+			return "", diags
+		case hasToken:
+			method = authMethodToken
+		case hasAppRole:
+			method = authMethodAppRole
+		default:
+			diags.AddError(
+				"Missing Vault credentials",
+				"Configure the auth block, or set VAULT_TOKEN or both VAULT_ROLE_ID and VAULT_SECRET_ID."+env.note(),
+			)
+			return "", diags
+		}
+	}
+
+	switch method {
+	case authMethodToken:
+		if token == "" {
+			diags.AddError("Missing Vault token", "Set auth.token or the VAULT_TOKEN environment variable."+env.note())
+			return "", diags
+		}
+		tflog.Debug(ctx, "Using Vault token authentication")
+		return token, diags
+
+	case authMethodAppRole:
+		if roleID == "" || secretID == "" {
+			diags.AddError(
+				"Incomplete AppRole credentials",
+				"Both role_id and secret_id are required for AppRole authentication.",
+			)
+			return "", diags
+		}
+		mountPath := resolveStringDefault(auth.MountPath, "approle")
+		tflog.Debug(ctx, "Logging in to Vault", map[string]any{"auth_method": method, "mount_path": mountPath})
+		start := time.Now()
+		token, err := sopsencrypt.AppRoleLogin(vault, mountPath, roleID, secretID)
+		logResult(ctx, "Vault login", start, err)
+		if err != nil {
+			diags.AddError("AppRole authentication failed", err.Error())
+			return "", diags
+		}
+		return token, diags
+
+	case authMethodKubernetes:
+		role := auth.Role.ValueString()
+		if role == "" {
+			diags.AddError("Missing Kubernetes role", "auth.role is required for Kubernetes authentication.")
+			return "", diags
+		}
+		jwt := auth.JWT.ValueString()
+		if jwt == "" {
+			jwtFile := resolveStringDefault(auth.JWTFile, defaultKubernetesJWTFile)
+			b, err := os.ReadFile(jwtFile)
+			if err != nil {
+				diags.AddError("Failed to read Kubernetes service account token", err.Error())
+				return "", diags
+			}
+			jwt = strings.TrimSpace(string(b))
+			ctx = maskSecrets(ctx, jwt)
+		}
+		mountPath := resolveStringDefault(auth.MountPath, "kubernetes")
+		tflog.Debug(ctx, "Logging in to Vault", map[string]any{"auth_method": method, "mount_path": mountPath, "role": role})
+		start := time.Now()
+		token, err := sopsencrypt.KubernetesLogin(vault, mountPath, role, jwt)
+		logResult(ctx, "Vault login", start, err)
+		if err != nil {
+			diags.AddError("Kubernetes authentication failed", err.Error())
+			return "", diags
+		}
+		return token, diags
+
+	default:
+		diags.AddError("Invalid auth method",
+			fmt.Sprintf("Expected one of %q, %q or %q, got %q.", authMethodToken, authMethodAppRole, authMethodKubernetes, method))
+		return "", diags
 	}
 }
 
+// dataKeyConfig holds the write-only data_key_wo and wrapped_data_key_wo
+// arguments. They are null in plan and state, so resources read them from
+// the configuration when encrypting.
+type dataKeyConfig struct {
+	DataKey        types.String
+	WrappedDataKey types.String
+}
+
+// getDataKeyConfig reads the data key arguments of a resource from config.
+func getDataKeyConfig(ctx context.Context, config tfsdk.Config, diags *diag.Diagnostics) dataKeyConfig {
+	var c dataKeyConfig
+	diags.Append(config.GetAttribute(ctx, path.Root("data_key_wo"), &c.DataKey)...)
+	diags.Append(config.GetAttribute(ctx, path.Root("wrapped_data_key_wo"), &c.WrappedDataKey)...)
+	return c
+}
+
+// validate rejects a wrapped data key without the key it wraps.
+func (c dataKeyConfig) validate(diags *diag.Diagnostics) {
+	if isSet(c.WrappedDataKey) && c.DataKey.IsNull() {
+		diags.AddAttributeError(path.Root("wrapped_data_key_wo"),
+			"Missing data_key_wo",
+			"wrapped_data_key_wo requires data_key_wo, the key it wraps.")
+	}
+}
+
+// set sets opts.DataKey from the data key arguments. Without data_key_wo
+// the document gets a random data key of its own.
+func (c dataKeyConfig) set(opts *sopsencrypt.EncryptOpts) error {
+	if !isSet(c.DataKey) {
+		return nil
+	}
+	key, err := base64.StdEncoding.DecodeString(c.DataKey.ValueString())
+	if err != nil {
+		return fmt.Errorf("decoding data_key_wo: %w", err)
+	}
+	opts.DataKey = &sopsencrypt.DataKey{Key: key, Wrapped: c.WrappedDataKey.ValueString()}
+	return nil
+}
+
+// setTimestamp sets the metadata timestamp options of opts from a resource's
+// timestamp attribute. A null attribute leaves the current time in place.
+func setTimestamp(opts *sopsencrypt.EncryptOpts, timestamp types.String) {
+	switch v := timestamp.ValueString(); v {
+	case "":
+	case timestampFromContent:
+		opts.TimestampFromContent = true
+	default:
+		opts.Timestamp, _ = time.Parse(time.RFC3339, v)
+	}
+}
+
+// setLineLayout sets opts.NoTrailingNewline and opts.LineEndings from a
+// resource's trailing_newline and line_endings attributes.
+func setLineLayout(opts *sopsencrypt.EncryptOpts, trailingNewline types.Bool, lineEndings types.String) {
+	opts.NoTrailingNewline = !trailingNewline.IsNull() && !trailingNewline.ValueBool()
+	opts.LineEndings = lineEndings.ValueString()
+}
+
+// backfillLineLayout records the defaults of trailing_newline and
+// line_endings in a state written before they existed, whose documents were
+// emitted with that layout, so that the defaults do not force replacement.
+func backfillLineLayout(trailingNewline *types.Bool, lineEndings *types.String) {
+	if trailingNewline.IsNull() {
+		*trailingNewline = types.BoolValue(true)
+	}
+	if lineEndings.IsNull() {
+		*lineEndings = types.StringValue(sopsencrypt.LineEndingsLF)
+	}
+}
+
+// setScopeLists sets opts.EncryptedPaths and opts.UnencryptedKeys from a
+// resource's encrypted_paths and unencrypted_keys attributes.
+func setScopeLists(ctx context.Context, opts *sopsencrypt.EncryptOpts, encryptedPaths, unencryptedKeys types.List) error {
+	for i, l := range []types.List{encryptedPaths, unencryptedKeys} {
+		if l.IsNull() {
+			continue
+		}
+		dst := []*[]string{&opts.EncryptedPaths, &opts.UnencryptedKeys}[i]
+		if diags := l.ElementsAs(ctx, dst, false); diags.HasError() {
+			return fmt.Errorf("reading %s: %s", scopeListAttributes[i], diags.Errors()[0].Detail())
+		}
+	}
+	return nil
+}
+
+// isSet reports whether attr holds a known, non-empty value.
+func isSet(attr types.String) bool {
+	return !attr.IsNull() && !attr.IsUnknown() && attr.ValueString() != ""
+}
+
+// envFallback reads the environment variables provider attributes fall back
+// to. disable_env_fallback disables it, so that every setting comes from the
+// provider block.
+type envFallback struct{ disabled bool }
+
+// get returns the named env var, or "" when fallbacks are disabled.
+func (e envFallback) get(envVar string) string {
+	if e.disabled {
+		return ""
+	}
+	return os.Getenv(envVar)
+}
+
 // resolveString returns the explicit config value if set, otherwise the named env var.
-func resolveString(attr types.String, envVar string) string {
-	if !attr.IsNull() && !attr.IsUnknown() && attr.ValueString() != "" {
+func (e envFallback) resolveString(attr types.String, envVar string) string {
+	if isSet(attr) {
 		return attr.ValueString()
 	}
-	return os.Getenv(envVar)
+	return e.get(envVar)
+}
+
+// note returns a sentence to append to an error that suggests environment
+// variables, pointing out that they are ignored.
+func (e envFallback) note() string {
+	if e.disabled {
+		return " disable_env_fallback is set, so environment variables are ignored."
+	}
+	return ""
 }
 
 // resolveStringDefault returns the explicit config value if set, otherwise defaultVal.
 func resolveStringDefault(attr types.String, defaultVal string) string {
-	if !attr.IsNull() && !attr.IsUnknown() && attr.ValueString() != "" {
+	if isSet(attr) {
 		return attr.ValueString()
 	}
 	return defaultVal
 }
+
+// sha256Hex returns the hex-encoded SHA-256 of s.
+func sha256Hex(s string) types.String {
+	return types.StringValue(fmt.Sprintf("%x", sha256.Sum256([]byte(s))))
+}
+
+// ciphertextModel holds the ciphertext of an encrypted resource, the
+// attributes derived from it and its serialisation in both formats.
+type ciphertextModel struct {
+	Ciphertext       types.String `tfsdk:"ciphertext"`
+	CiphertextBase64 types.String `tfsdk:"ciphertext_base64"`
+	CiphertextSHA256 types.String `tfsdk:"ciphertext_sha256"`
+	CiphertextJSON   types.String `tfsdk:"ciphertext_json"`
+	CiphertextYAML   types.String `tfsdk:"ciphertext_yaml"`
+	KeyVersion       types.Int64  `tfsdk:"key_version"`
+	// CiphertextNonsensitive copies Ciphertext when ciphertext_sensitive is
+	// false; see expose.
+	CiphertextNonsensitive types.String `tfsdk:"ciphertext_nonsensitive"`
+	Signature              types.String `tfsdk:"signature"`
+	// The audit attributes describe the encryption that created the
+	// resource; see setAudit.
+	VaultRequestID types.String `tfsdk:"vault_request_id"`
+	EncryptedAt    types.String `tfsdk:"encrypted_at"`
+	VaultEntityID  types.String `tfsdk:"vault_entity_id"`
+	VaultAccessor  types.String `tfsdk:"vault_accessor"`
+}
+
+// set stores ciphertext and derives the other attributes from it.
+func (m *ciphertextModel) set(ciphertext string) {
+	m.Ciphertext = types.StringValue(ciphertext)
+	m.CiphertextBase64 = types.StringValue(base64.StdEncoding.EncodeToString([]byte(ciphertext)))
+	m.CiphertextSHA256 = sha256Hex(ciphertext)
+	m.KeyVersion = types.Int64Null()
+	if version, ok, err := sopsencrypt.WrappedKeyVersion(ciphertext); err == nil && ok {
+		m.KeyVersion = types.Int64Value(version)
+	}
+}
+
+// expose sets ciphertext_nonsensitive to ciphertext when sensitive, the
+// resource's ciphertext_sensitive, is false, and to null otherwise. State
+// written before ciphertext_sensitive existed holds null, which counts as
+// true.
+func (m *ciphertextModel) expose(sensitive types.Bool) {
+	m.CiphertextNonsensitive = types.StringNull()
+	if !sensitive.IsNull() && !sensitive.ValueBool() {
+		m.CiphertextNonsensitive = m.Ciphertext
+	}
+}
+
+// rewrap upgrades the Vault Transit wrapped data key in every serialisation
+// of the ciphertext to the latest version of its Transit key. Failures are
+// reported as warnings, since the stored ciphertext remains decryptable until
+// its key version is trimmed.
+func (m *ciphertextModel) rewrap(ctx context.Context, pd *sopsProviderData, address types.String, timeout time.Duration, diags *diag.Diagnostics) {
+	if pd == nil || m.Ciphertext.IsNull() {
+		return
+	}
+	client, err := pd.newVaultClientAt(address.ValueString(), timeout)
+	if err == nil && client == nil {
+		return
+	}
+	ctx = maskSecrets(ctx, clientToken(client))
+	ctx = tflog.SetField(ctx, "vault_address", pd.vaultAddressFor(address))
+	tflog.Debug(ctx, "Rewrapping data key", map[string]any{"key_version": m.KeyVersion.ValueInt64()})
+	var release func()
+	if err == nil {
+		release, err = pd.acquireVault(ctx)
+	}
+	start := time.Now()
+	var docs []string
+	if err == nil {
+		defer release()
+		docs, err = sopsencrypt.RewrapDataKeys(client, m.Ciphertext.ValueString(), m.CiphertextJSON.ValueString(), m.CiphertextYAML.ValueString())
+	}
+	logResult(ctx, "Rewrap", start, err)
+	if err != nil {
+		diags.AddWarning("Failed to rewrap data key",
+			"The ciphertext in state was kept as is: "+err.Error())
+		return
+	}
+	m.set(docs[0])
+	if !m.CiphertextJSON.IsNull() {
+		m.CiphertextJSON = types.StringValue(docs[1])
+	}
+	if !m.CiphertextYAML.IsNull() {
+		m.CiphertextYAML = types.StringValue(docs[2])
+	}
+}
+
+// setAudit sets the audit attributes of an encryption made at encryptedAt,
+// so that the ciphertext can be found in the Vault audit log: the ID of the
+// request that wrapped the data key from audit, and the entity and accessor
+// of the token. They are null where no Vault request was made. A failed
+// token lookup is reported as a warning and leaves the token attributes
+// null.
+func (m *ciphertextModel) setAudit(ctx context.Context, pd *sopsProviderData, address types.String, audit sopsencrypt.EncryptAudit, encryptedAt time.Time, timeout time.Duration, diags *diag.Diagnostics) {
+	m.EncryptedAt = types.StringValue(encryptedAt.UTC().Format(time.RFC3339))
+	m.VaultRequestID, m.VaultEntityID, m.VaultAccessor = types.StringNull(), types.StringNull(), types.StringNull()
+	if audit.VaultRequestID != "" {
+		m.VaultRequestID = types.StringValue(audit.VaultRequestID)
+	}
+	id, ok, err := pd.tokenIdentity(ctx, address, timeout)
+	if err != nil {
+		diags.AddWarning("Failed to look up Vault token",
+			"vault_entity_id and vault_accessor are null: "+err.Error())
+		return
+	}
+	if !ok {
+		return
+	}
+	if id.EntityID != "" {
+		m.VaultEntityID = types.StringValue(id.EntityID)
+	}
+	if id.Accessor != "" {
+		m.VaultAccessor = types.StringValue(id.Accessor)
+	}
+}
+
+// sign sets signature to the Vault Transit signature of ciphertext by the
+// signing key keyName, or to null when keyName is not set. In age-only mode
+// there is no Vault to sign with, which is an error.
+func (m *ciphertextModel) sign(ctx context.Context, pd *sopsProviderData, address, transitEngine, keyName types.String, timeout time.Duration) error {
+	m.Signature = types.StringNull()
+	if keyName.ValueString() == "" || m.Ciphertext.IsNull() {
+		return nil
+	}
+	if !pd.usesVault() {
+		return errors.New("signing_key_name requires Vault, which is not configured in age-only mode")
+	}
+	client, err := pd.newVaultClientAt(address.ValueString(), timeout)
+	if err != nil {
+		return err
+	}
+	engine := resolveStringDefault(transitEngine, pd.vaultTransitEngine)
+	ctx = pd.logContext(ctx, client, pd.vaultAddressFor(address), engine, keyName.ValueString())
+	release, err := pd.acquireVault(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+	start := time.Now()
+	signature, err := sopsencrypt.TransitSign(client, engine, keyName.ValueString(), []byte(m.Ciphertext.ValueString()), pd.mock)
+	logResult(ctx, "Signing", start, err)
+	if err != nil {
+		return err
+	}
+	m.Signature = types.StringValue(signature)
+	return nil
+}
+
+// resign signs ciphertext again after rewrap changed it from previous, so
+// that signature keeps matching. A failure is reported as a warning and
+// leaves signature null rather than stale.
+func (m *ciphertextModel) resign(ctx context.Context, pd *sopsProviderData, previous types.String, address, transitEngine, keyName types.String, timeout time.Duration, diags *diag.Diagnostics) {
+	if pd == nil || m.Ciphertext.Equal(previous) {
+		return
+	}
+	if err := m.sign(ctx, pd, address, transitEngine, keyName, timeout); err != nil {
+		diags.AddWarning("Failed to sign rewrapped ciphertext",
+			"signature was cleared, as it no longer matches ciphertext: "+err.Error())
+	}
+}
+
+// privateKeyRotated is the private state key under which Read records the
+// latest version of a Transit key that was rotated after the data key was
+// wrapped, for reencrypt_on_key_rotation.
+const privateKeyRotated = "key_rotated"
+
+// privateState is the private state of a resource, as set by Read.
+type privateState interface {
+	SetKey(ctx context.Context, key string, value []byte) diag.Diagnostics
+}
+
+// checkKeyVersion reads the Transit key keyName and compares its versions
+// with the one that wrapped the data key. With reencrypt, it records in
+// private whether the key has a newer version, so that ModifyPlan can propose
+// replacement. With stale_key_version_margin set on the provider, it warns
+// when the wrapping version is about to fall below min_decryption_version.
+// A failed key read is reported as a warning and leaves the previous record
+// in place.
+func (m *ciphertextModel) checkKeyVersion(ctx context.Context, pd *sopsProviderData, address, transitEngine, keyName types.String, reencrypt types.Bool, timeout time.Duration, private privateState, diags *diag.Diagnostics) {
+	if pd == nil || m.KeyVersion.IsNull() || keyName.ValueString() == "" {
+		return
+	}
+	if !reencrypt.ValueBool() && pd.staleKeyMargin < 0 {
+		return
+	}
+	client, err := pd.newVaultClientAt(address.ValueString(), timeout)
+	if err == nil && client == nil {
+		return
+	}
+	engine := resolveStringDefault(transitEngine, pd.vaultTransitEngine)
+	ctx = pd.logContext(ctx, client, pd.vaultAddressFor(address), engine, keyName.ValueString())
+	var release func()
+	if err == nil {
+		release, err = pd.acquireVault(ctx)
+	}
+	start := time.Now()
+	var key sopsencrypt.TransitKey
+	if err == nil {
+		defer release()
+		key, err = sopsencrypt.ReadTransitKey(client, engine, keyName.ValueString())
+	}
+	logResult(ctx, "Transit key read", start, err)
+	if err != nil {
+		diags.AddWarning("Failed to check Transit key version",
+			"The Transit key could not be read to compare its versions with key_version: "+err.Error())
+		return
+	}
+
+	version := m.KeyVersion.ValueInt64()
+	if pd.staleKeyMargin >= 0 && version < key.MinDecryptionVersion+pd.staleKeyMargin {
+		detail := fmt.Sprintf("The data key is wrapped with version %d of Transit key %s/keys/%s, whose min_decryption_version is %d. ",
+			version, engine, keyName.ValueString(), key.MinDecryptionVersion)
+		if version < key.MinDecryptionVersion {
+			detail += "The document can no longer be decrypted."
+		} else {
+			detail += fmt.Sprintf("Raising min_decryption_version above %d makes the document undecryptable. "+
+				"Re-encrypt it, or set rewrap_on_read or reencrypt_on_key_rotation.", version)
+		}
+		diags.AddWarning("Stale Transit key version", detail)
+	}
+	if reencrypt.ValueBool() {
+		var value []byte
+		if key.LatestVersion > version {
+			tflog.Debug(ctx, "Transit key rotated", map[string]any{"key_version": version, "latest_version": key.LatestVersion})
+			value = []byte(strconv.FormatInt(key.LatestVersion, 10))
+		}
+		diags.Append(private.SetKey(ctx, privateKeyRotated, value)...)
+	}
+}
+
+// planKeyRotation proposes replacement of a resource with
+// reencrypt_on_key_rotation whose Transit key Read found rotated, by
+// planning an unknown key_version.
+func planKeyRotation(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if req.State.Raw.IsNull() {
+		return
+	}
+	var reencrypt types.Bool
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("reencrypt_on_key_rotation"), &reencrypt)...)
+	if !reencrypt.ValueBool() {
+		return
+	}
+	latest, diags := req.Private.GetKey(ctx, privateKeyRotated)
+	resp.Diagnostics.Append(diags...)
+	if len(latest) == 0 {
+		return
+	}
+	tflog.Info(ctx, "Transit key rotated; planning re-encryption", map[string]any{"latest_version": string(latest)})
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("key_version"), types.Int64Unknown())...)
+	resp.RequiresReplace = append(resp.RequiresReplace, path.Root("key_version"))
+}
+
+// privateModified is the private state key under which Read records that
+// the ciphertext in state was modified out of band.
+const privateModified = "modified"
+
+// checkIntegrity looks for out-of-band edits of the ciphertext in state:
+// ciphertext_sha256 must match ciphertext, and ciphertext, ciphertext_json
+// and ciphertext_yaml must be consistent serialisations of one encryption.
+// With verify, it also unwraps the data key and checks the MAC of ciphertext.
+// A modified ciphertext is reported as a warning and recorded in private, so
+// that ModifyPlan can propose replacement. A failed key unwrap is only
+// reported as a warning and leaves the previous record in place.
+func (m *ciphertextModel) checkIntegrity(ctx context.Context, pd *sopsProviderData, address types.String, verify types.Bool, timeout time.Duration, private privateState, diags *diag.Diagnostics) {
+	if m.Ciphertext.IsNull() {
+		return
+	}
+	ciphertext := m.Ciphertext.ValueString()
+	var reason string
+	if !m.CiphertextSHA256.IsNull() && !m.CiphertextSHA256.Equal(sha256Hex(ciphertext)) {
+		reason = "ciphertext_sha256 does not match ciphertext."
+	} else if err := sopsencrypt.CheckConsistent(ciphertext, m.CiphertextJSON.ValueString(), m.CiphertextYAML.ValueString()); err != nil {
+		reason = "ciphertext, ciphertext_json and ciphertext_yaml are not serialisations of one encryption: " + err.Error()
+	} else if verify.ValueBool() && pd != nil {
+		client, err := pd.newVaultClientAt(address.ValueString(), timeout)
+		if err == nil && client == nil {
+			return
+		}
+		ctx = maskSecrets(ctx, clientToken(client))
+		ctx = tflog.SetField(ctx, "vault_address", pd.vaultAddressFor(address))
+		var release func()
+		if err == nil {
+			release, err = pd.acquireVault(ctx)
+		}
+		start := time.Now()
+		var v sopsencrypt.Validation
+		if err == nil {
+			defer release()
+			v, err = sopsencrypt.Validate(client, ciphertext, sopsencrypt.DetectInputType(ciphertext))
+			if err == nil && !v.Decryptable {
+				err = errors.New(v.DecryptableReason)
+			}
+		}
+		logResult(ctx, "MAC verification", start, err)
+		if err != nil {
+			diags.AddWarning("Failed to verify ciphertext",
+				"The MAC of the ciphertext in state could not be checked: "+err.Error())
+			return
+		}
+		if !v.MACValid {
+			reason = v.MACReason + "."
+		}
+	}
+
+	var value []byte
+	if reason != "" {
+		diags.AddWarning("Ciphertext modified out of band",
+			"The ciphertext in state was modified after encryption: "+reason+" Replacement is planned to re-encrypt content.")
+		value = []byte("true")
+	}
+	diags.Append(private.SetKey(ctx, privateModified, value)...)
+}
+
+// planModified proposes replacement of a resource whose ciphertext Read
+// found modified out of band, by planning an unknown ciphertext_sha256.
+func planModified(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if req.State.Raw.IsNull() {
+		return
+	}
+	modified, diags := req.Private.GetKey(ctx, privateModified)
+	resp.Diagnostics.Append(diags...)
+	if len(modified) == 0 {
+		return
+	}
+	tflog.Info(ctx, "Ciphertext modified out of band; planning re-encryption")
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("ciphertext_sha256"), types.StringUnknown())...)
+	resp.RequiresReplace = append(resp.RequiresReplace, path.Root("ciphertext_sha256"))
+}
+
+// planNonsensitive plans ciphertext_nonsensitive as the planned ciphertext
+// when ciphertext_sensitive is false, and as null otherwise. It runs after
+// every plan function that may make ciphertext unknown.
+func planNonsensitive(ctx context.Context, resp *resource.ModifyPlanResponse) {
+	var sensitive types.Bool
+	var ciphertext types.String
+	resp.Diagnostics.Append(resp.Plan.GetAttribute(ctx, path.Root("ciphertext_sensitive"), &sensitive)...)
+	resp.Diagnostics.Append(resp.Plan.GetAttribute(ctx, path.Root("ciphertext"), &ciphertext)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	planned := types.StringNull()
+	switch {
+	case sensitive.IsUnknown():
+		planned = types.StringUnknown()
+	case !sensitive.IsNull() && !sensitive.ValueBool():
+		planned = ciphertext
+	}
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("ciphertext_nonsensitive"), planned)...)
+}
+
+// validateKeyRotation rejects rewrap_on_read together with
+// reencrypt_on_key_rotation: the rewrap would always win.
+func validateKeyRotation(rewrap, reencrypt types.Bool, diags *diag.Diagnostics) {
+	if rewrap.ValueBool() && reencrypt.ValueBool() {
+		diags.AddAttributeError(path.Root("reencrypt_on_key_rotation"),
+			"Conflicting key rotation options",
+			"Set either rewrap_on_read or reencrypt_on_key_rotation, not both.")
+	}
+}
+
+// structurePreview returns the structure_preview of document in format,
+// sopsencrypt.StructurePreview under the given scope. scope must be in
+// scopeAttributes order and lists hold the resource's scopeListAttributes.
+// It is unknown while the scope is, and null if document cannot be
+// previewed, which encryption reports.
+func structurePreview(ctx context.Context, document string, inputYAML bool, format string, lists []types.List, scope ...types.String) types.String {
+	for _, l := range lists {
+		if l.IsUnknown() {
+			return types.StringUnknown()
+		}
+	}
+	for _, v := range scope {
+		if v.IsUnknown() {
+			return types.StringUnknown()
+		}
+	}
+	opts, err := scopeOpts(ctx, inputYAML, lists, scope)
+	if err != nil {
+		return types.StringNull()
+	}
+	preview, err := sopsencrypt.StructurePreview(document, format, opts)
+	if err != nil {
+		return types.StringNull()
+	}
+	return types.StringValue(preview)
+}
+
+// scopeOpts returns the encryption options holding the given scope, in the
+// order structurePreview takes it.
+func scopeOpts(ctx context.Context, inputYAML bool, lists []types.List, scope []types.String) (sopsencrypt.EncryptOpts, error) {
+	opts := sopsencrypt.EncryptOpts{
+		UnencryptedSuffix: scope[0].ValueString(),
+		EncryptedSuffix:   scope[1].ValueString(),
+		UnencryptedRegex:  scope[2].ValueString(),
+		EncryptedRegex:    scope[3].ValueString(),
+		InputYAML:         inputYAML,
+	}
+	err := setScopeLists(ctx, &opts, lists[0], lists[1])
+	return opts, err
+}
+
+// warnNothingEncrypted adds a warning when the scope leaves every value of a
+// non-empty document in plaintext, which would otherwise ship a file that
+// looks encrypted but is not. The warning is on the scope option in effect;
+// without one every value is encrypted. name labels the document in
+// multi-document resources and is empty otherwise. Documents that fail to
+// parse are left to encryption to report.
+func warnNothingEncrypted(ctx context.Context, name, document string, inputYAML bool, lists []types.List, scope []types.String, diags *diag.Diagnostics) {
+	opts, err := scopeOpts(ctx, inputYAML, lists, scope)
+	if err != nil {
+		return
+	}
+	encrypted, total, err := sopsencrypt.ValueCounts(document, opts)
+	if err != nil || total == 0 || encrypted > 0 {
+		return
+	}
+	attr := scopeAttributes[0]
+	for i, v := range scope {
+		if isSet(v) {
+			attr = scopeAttributes[i]
+		}
+	}
+	for i, l := range lists {
+		if !l.IsNull() && len(l.Elements()) > 0 {
+			attr = scopeListAttributes[i]
+		}
+	}
+	subject := "The document"
+	if name != "" {
+		subject = fmt.Sprintf("Document %q", name)
+	}
+	diags.AddAttributeWarning(path.Root(attr),
+		"No values encrypted",
+		fmt.Sprintf("%s has %d values, but %s leaves none of them encrypted, so the ciphertext holds every value in plaintext. "+
+			"Check %s against the keys of the document.", subject, total, attr, attr))
+}
+
+// warnPlaintextSecrets adds a warning, for lint_plaintext_secrets, when the
+// scope leaves values of document in plaintext whose key name looks like it
+// holds a secret. Its arguments are those of warnNothingEncrypted. The
+// warning names the paths of the values, never the values themselves.
+func warnPlaintextSecrets(ctx context.Context, name, document string, inputYAML bool, lists []types.List, scope []types.String, diags *diag.Diagnostics) {
+	opts, err := scopeOpts(ctx, inputYAML, lists, scope)
+	if err != nil {
+		return
+	}
+	paths, err := sopsencrypt.PlaintextSecrets(document, opts)
+	if err != nil || len(paths) == 0 {
+		return
+	}
+	subject := "the document"
+	if name != "" {
+		subject = fmt.Sprintf("document %q", name)
+	}
+	diags.AddWarning("Secret-looking values left in plaintext",
+		fmt.Sprintf("The encryption scope leaves these values of %s in plaintext although their key names look like they hold secrets: %s. "+
+			"Extend the scope option to encrypt them, or disable lint_plaintext_secrets on the provider if they are not secret.",
+			subject, strings.Join(paths, ", ")))
+}
+
+// contentRequiresReplace forces replacement when content changes, unless the
+// old and new value are the same JSON document: re-ordered keys or different
+// whitespace, as produced by reordering a map in HCL, do not re-encrypt.
+// After an import from a file, planImported decides instead.
+func contentRequiresReplace() planmodifier.String {
+	const description = "Changes to content force replacement unless the JSON document is semantically unchanged."
+	return stringplanmodifier.RequiresReplaceIf(
+		func(ctx context.Context, req planmodifier.StringRequest, resp *stringplanmodifier.RequiresReplaceIfFuncResponse) {
+			var contentType types.String
+			resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("content_type"), &contentType)...)
+			if importedContent(ctx, req.Private, &resp.Diagnostics) {
+				return
+			}
+			resp.RequiresReplace = contentType.ValueString() == "yaml" ||
+				req.PlanValue.IsUnknown() ||
+				!jsonEqual(req.StateValue.ValueString(), req.PlanValue.ValueString())
+		},
+		description, description,
+	)
+}
+
+// jsonEqual reports whether a and b are valid JSON encoding the same value.
+func jsonEqual(a, b string) bool {
+	var va, vb any
+	if json.Unmarshal([]byte(a), &va) != nil || json.Unmarshal([]byte(b), &vb) != nil {
+		return false
+	}
+	return reflect.DeepEqual(va, vb)
+}
+
+// dynamicValue converts a dynamic attribute to the equivalent encoding/json
+// value.
+func dynamicValue(ctx context.Context, v types.Dynamic) (any, error) {
+	tfValue, err := v.ToTerraformValue(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return jsonValue(tfValue)
+}
+
+// jsonValue converts a Terraform value to the equivalent encoding/json value,
+// keeping numbers, bools and nulls typed. Object and map keys are sorted on
+// encoding, as with jsonencode().
+func jsonValue(v tftypes.Value) (any, error) {
+	if !v.IsKnown() {
+		return nil, fmt.Errorf("contains an unknown value")
+	}
+	if v.IsNull() {
+		return nil, nil
+	}
+	typ := v.Type()
+	switch {
+	case typ.Is(tftypes.String):
+		var s string
+		err := v.As(&s)
+		return s, err
+	case typ.Is(tftypes.Bool):
+		var b bool
+		err := v.As(&b)
+		return b, err
+	case typ.Is(tftypes.Number):
+		var f big.Float
+		if err := v.As(&f); err != nil {
+			return nil, err
+		}
+		if f.IsInt() {
+			return json.Number(f.Text('f', 0)), nil
+		}
+		return json.Number(f.Text('g', -1)), nil
+	case typ.Is(tftypes.Object{}), typ.Is(tftypes.Map{}):
+		var elems map[string]tftypes.Value
+		if err := v.As(&elems); err != nil {
+			return nil, err
+		}
+		out := make(map[string]any, len(elems))
+		for k, e := range elems {
+			jv, err := jsonValue(e)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = jv
+		}
+		return out, nil
+	case typ.Is(tftypes.List{}), typ.Is(tftypes.Set{}), typ.Is(tftypes.Tuple{}):
+		var elems []tftypes.Value
+		if err := v.As(&elems); err != nil {
+			return nil, err
+		}
+		out := make([]any, 0, len(elems))
+		for _, e := range elems {
+			jv, err := jsonValue(e)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, jv)
+		}
+		return out, nil
+	default:
+		return nil, fmt.Errorf("unsupported type %s", typ)
+	}
+}
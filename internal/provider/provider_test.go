@@ -1,11 +1,15 @@
 package provider_test
 
 import (
+	"crypto/fips140"
+	"fmt"
 	"os"
+	"regexp"
 	"testing"
 
 	"github.com/hashicorp/terraform-plugin-framework/providerserver"
 	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
 	"terraform-provider-sops/internal/provider"
 )
 
@@ -33,3 +37,401 @@ func envOrDefault(key, fallback string) string {
 	}
 	return fallback
 }
+
+// TestAccProvider_AuthAttributeForWrongMethod verifies that attributes of a
+// different auth method are rejected at plan time.
+func TestAccProvider_AuthAttributeForWrongMethod(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+provider "sops" {
+  vault_address = "http://127.0.0.1:8200"
+
+  auth {
+    method  = "token"
+    token   = "s.example"
+    role_id = "example-role"
+  }
+}
+
+data "sops_config" "test" {
+  vault_key_name = "sops-test"
+}
+`,
+				ExpectError: regexp.MustCompile(`role_id cannot be used with method "token"`),
+			},
+		},
+	})
+}
+
+// TestAccProvider_InvalidCreateKeyType verifies that a Transit key type that
+// cannot wrap data keys is rejected at plan time.
+func TestAccProvider_InvalidCreateKeyType(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+provider "sops" {
+  mock                  = true
+  create_key_if_missing = true
+  create_key_type       = "ed25519"
+}
+
+data "sops_config" "test" {
+  vault_key_name = "sops-test"
+}
+`,
+				ExpectError: regexp.MustCompile(`Invalid Transit key type`),
+			},
+		},
+	})
+}
+
+func TestAccProvider_InvalidMaxConcurrentVaultRequests(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+provider "sops" {
+  mock                          = true
+  max_concurrent_vault_requests = 0
+}
+
+data "sops_config" "test" {
+  vault_key_name = "sops-test"
+}
+`,
+				ExpectError: regexp.MustCompile(`Invalid max_concurrent_vault_requests`),
+			},
+		},
+	})
+}
+
+// TestAccProvider_InvalidDataKeyCacheMaxUses verifies that
+// data_key_cache_max_uses must be positive and requires data_key_cache.
+func TestAccProvider_InvalidDataKeyCacheMaxUses(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+
+	const config = `
+provider "sops" {
+  mock                    = true
+  data_key_cache          = %t
+  data_key_cache_max_uses = %d
+}
+
+data "sops_config" "test" {
+  vault_key_name = "sops-test"
+}
+`
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config:      fmt.Sprintf(config, true, 0),
+				ExpectError: regexp.MustCompile(`Invalid data_key_cache_max_uses`),
+			},
+			{
+				Config:      fmt.Sprintf(config, false, 10),
+				ExpectError: regexp.MustCompile(`Missing data_key_cache`),
+			},
+		},
+	})
+}
+
+// TestAccProvider_InvalidVaultProxyURL verifies that a proxy URL with an
+// unsupported scheme is rejected during validation.
+func TestAccProvider_InvalidVaultProxyURL(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+provider "sops" {
+  mock            = true
+  vault_proxy_url = "ftp://proxy.internal:21"
+}
+
+data "sops_config" "test" {
+  vault_key_name = "sops-test"
+}
+`,
+				ExpectError: regexp.MustCompile(`Invalid vault_proxy_url`),
+			},
+		},
+	})
+}
+
+// TestAccProvider_InvalidVaultRequestsPerSecond verifies that a request rate
+// of zero is rejected during validation.
+func TestAccProvider_InvalidVaultRequestsPerSecond(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+provider "sops" {
+  mock                      = true
+  vault_requests_per_second = 0
+}
+
+data "sops_config" "test" {
+  vault_key_name = "sops-test"
+}
+`,
+				ExpectError: regexp.MustCompile(`Invalid vault_requests_per_second`),
+			},
+		},
+	})
+}
+
+// TestAccProvider_VaultTokenFileWithAuth verifies that a token file cannot be
+// combined with the auth block.
+func TestAccProvider_VaultTokenFileWithAuth(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+provider "sops" {
+  vault_address    = "http://127.0.0.1:8200"
+  vault_token_file = "/vault/secrets/token"
+
+  auth {
+    method = "token"
+    token  = "s.example"
+  }
+}
+
+data "sops_config" "test" {
+  vault_key_name = "sops-test"
+}
+`,
+				ExpectError: regexp.MustCompile(`Provide either vault_token_file or the auth block`),
+			},
+		},
+	})
+}
+
+// TestAccProvider_IncompleteVaultClientCert verifies that a client
+// certificate without its key is rejected during validation.
+func TestAccProvider_IncompleteVaultClientCert(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+provider "sops" {
+  vault_address          = "https://127.0.0.1:8200"
+  vault_client_cert_file = "/etc/vault/client.pem"
+}
+
+data "sops_config" "test" {
+  vault_key_name = "sops-test"
+}
+`,
+				ExpectError: regexp.MustCompile(`Incomplete Vault client certificate`),
+			},
+		},
+	})
+}
+
+// TestAccProvider_InvalidVaultUserAgentTag verifies that a tag that cannot
+// be sent in an HTTP header is rejected during validation.
+func TestAccProvider_InvalidVaultUserAgentTag(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+provider "sops" {
+  mock                 = true
+  vault_user_agent_tag = "workspace=prod\npipeline=1234"
+}
+
+data "sops_config" "test" {
+  vault_key_name = "sops-test"
+}
+`,
+				ExpectError: regexp.MustCompile(`Invalid vault_user_agent_tag`),
+			},
+		},
+	})
+}
+
+func TestAccProvider_InvalidStaleKeyVersionMargin(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+provider "sops" {
+  mock                     = true
+  stale_key_version_margin = -1
+}
+
+data "sops_config" "test" {
+  vault_key_name = "sops-test"
+}
+`,
+				ExpectError: regexp.MustCompile(`Invalid stale_key_version_margin`),
+			},
+		},
+	})
+}
+
+func TestAccProvider_FIPSModeRejectsNonFIPSKeySources(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+provider "sops" {
+  mock      = true
+  fips_mode = true
+}
+
+data "sops_config" "test" {
+  vault_key_name = "sops-test"
+}
+`,
+				ExpectError: regexp.MustCompile(`Non-FIPS key source`),
+			},
+			{
+				Config: `
+provider "sops" {
+  age_recipients = ["age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p"]
+  fips_mode      = true
+}
+
+data "sops_config" "test" {}
+`,
+				ExpectError: regexp.MustCompile(`Non-FIPS key source`),
+			},
+		},
+	})
+}
+
+// TestAccProvider_FIPSModeRejectsDocumentKeysAgeRecipients checks that
+// sops_document_keys cannot wrap the data key for age recipients in
+// fips_mode. The provider only configures in fips_mode in a FIPS 140-3 build,
+// so the test needs one, or GODEBUG=fips140=on.
+func TestAccProvider_FIPSModeRejectsDocumentKeysAgeRecipients(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+	if !fips140.Enabled() {
+		t.Skip("requires FIPS 140-3 mode; run with GODEBUG=fips140=on")
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+provider "sops" {
+  vault_address        = "http://127.0.0.1:8200"
+  disable_env_fallback = true
+  fips_mode            = true
+
+  auth {
+    token = "test"
+  }
+}
+
+resource "sops_document_keys" "test" {
+  document       = jsonencode({ password = "ENC[AES256_GCM,data:x,iv:x,tag:x,type:str]" })
+  age_recipients = ["age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p"]
+}
+`,
+				ExpectError: regexp.MustCompile(`Non-FIPS key source`),
+			},
+		},
+	})
+}
+
+// TestAccProvider_DisableEnvFallback checks that VAULT_ADDR and VAULT_TOKEN
+// are ignored once disable_env_fallback is set.
+func TestAccProvider_DisableEnvFallback(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+	t.Setenv("VAULT_ADDR", "http://127.0.0.1:8200")
+	t.Setenv("VAULT_TOKEN", "ambient-token")
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+provider "sops" {
+  disable_env_fallback = true
+}
+
+data "sops_config" "test" {
+  vault_key_name = "sops-test"
+}
+`,
+				ExpectError: regexp.MustCompile(`Missing Vault address`),
+			},
+			{
+				Config: `
+provider "sops" {
+  vault_address        = "http://127.0.0.1:8200"
+  disable_env_fallback = true
+}
+
+data "sops_config" "test" {
+  vault_key_name = "sops-test"
+}
+`,
+				ExpectError: regexp.MustCompile(`Missing Vault credentials`),
+			},
+		},
+	})
+}
@@ -0,0 +1,270 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/listplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"terraform-provider-sops/internal/sopsencrypt"
+)
+
+var (
+	_ resource.Resource                   = &documentKeysResource{}
+	_ resource.ResourceWithConfigure      = &documentKeysResource{}
+	_ resource.ResourceWithModifyPlan     = &documentKeysResource{}
+	_ resource.ResourceWithValidateConfig = &documentKeysResource{}
+)
+
+type documentKeysResource struct{ pd *sopsProviderData }
+
+type documentKeysModel struct {
+	ID                 types.String   `tfsdk:"id"`
+	Document           types.String   `tfsdk:"document"`
+	VaultKeyNames      types.List     `tfsdk:"vault_key_names"`
+	AgeRecipients      types.List     `tfsdk:"age_recipients"`
+	RemoveKeyNames     types.List     `tfsdk:"remove_vault_key_names"`
+	RemoveRecipients   types.List     `tfsdk:"remove_age_recipients"`
+	VaultAddress       types.String   `tfsdk:"vault_address"`
+	VaultTransitEngine types.String   `tfsdk:"vault_transit_engine"`
+	Ciphertext         types.String   `tfsdk:"ciphertext"`
+	Timeouts           *timeoutsModel `tfsdk:"timeouts"`
+}
+
+func NewDocumentKeysResource() resource.Resource {
+	return &documentKeysResource{}
+}
+
+func (r *documentKeysResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_document_keys"
+}
+
+func (r *documentKeysResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: `Adds master keys to and removes them from an existing SOPS document, as
+sops updatekeys would: the data key is unwrapped with Vault Transit and
+wrapped again for additional Transit keys and age recipients. Adding keys
+does not touch the encrypted values or the MAC, so the result decrypts to the
+same content with the original keys and with each added one.
+
+    resource "sops_document_keys" "ops" {
+      document        = file("secrets.enc.json")
+      vault_key_names = ["ops"]
+      age_recipients  = ["age1..."]
+    }
+
+Removing keys always rotates the data key, since the removed parties could
+have kept the old one: every value is encrypted again with a new data key,
+which is wrapped for each remaining and added key.
+
+Keys the document already has are not added twice. Changing any argument
+forces replacement, which starts again from document.`,
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:    true,
+				Description: "SHA-256 of the ciphertext.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"document": schema.StringAttribute{
+				Required: true,
+				Description: "Encrypted SOPS JSON or YAML document with a single key group, one of whose Vault Transit keys " +
+					"the provider's Vault token can decrypt with. Changing it forces replacement.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"vault_key_names": schema.ListAttribute{
+				ElementType: types.StringType,
+				Optional:    true,
+				Description: "Vault Transit keys, under vault_transit_engine, to wrap the data key for. Changing it forces replacement.",
+				PlanModifiers: []planmodifier.List{
+					listplanmodifier.RequiresReplace(),
+				},
+			},
+			"age_recipients": schema.ListAttribute{
+				ElementType: types.StringType,
+				Optional:    true,
+				Description: "age public keys to wrap the data key for. Changing it forces replacement.",
+				PlanModifiers: []planmodifier.List{
+					listplanmodifier.RequiresReplace(),
+				},
+			},
+			"remove_vault_key_names": schema.ListAttribute{
+				ElementType: types.StringType,
+				Optional:    true,
+				Description: "Vault Transit keys, under vault_transit_engine, to remove from the document, on whichever Vault server it records. " +
+					"Rotates the data key. Changing it forces replacement.",
+				PlanModifiers: []planmodifier.List{
+					listplanmodifier.RequiresReplace(),
+				},
+			},
+			"remove_age_recipients": schema.ListAttribute{
+				ElementType: types.StringType,
+				Optional:    true,
+				Description: "age public keys to remove from the document. Rotates the data key. Changing it forces replacement.",
+				PlanModifiers: []planmodifier.List{
+					listplanmodifier.RequiresReplace(),
+				},
+			},
+			"vault_address": schema.StringAttribute{
+				Optional:    true,
+				Description: "Vault server for this resource. Overrides the provider-level vault_address. The provider's Vault token must be valid here too. Ignored in mock mode.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"vault_transit_engine": schema.StringAttribute{
+				Optional:    true,
+				Description: "Vault Transit mount path of vault_key_names and remove_vault_key_names. Overrides the provider-level vault_transit_engine. Defaults to 'transit'.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"ciphertext": schema.StringAttribute{
+				Computed:    true,
+				Description: "document with the master keys added and removed, in the format of document.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+		},
+		Blocks: map[string]schema.Block{
+			"timeouts": resourceTimeoutsBlock(),
+		},
+	}
+}
+
+func (r *documentKeysResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	pd, ok := req.ProviderData.(*sopsProviderData)
+	if !ok {
+		resp.Diagnostics.AddError("Unexpected provider data type",
+			fmt.Sprintf("Expected *sopsProviderData, got %T", req.ProviderData))
+		return
+	}
+	r.pd = pd
+}
+
+// ValidateConfig requires at least one master key to add or remove.
+func (r *documentKeysResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var data documentKeysModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	for _, l := range []types.List{data.VaultKeyNames, data.AgeRecipients, data.RemoveKeyNames, data.RemoveRecipients} {
+		if l.IsUnknown() || len(l.Elements()) > 0 {
+			return
+		}
+	}
+	resp.Diagnostics.AddError("Missing master keys",
+		"Set vault_key_names or age_recipients to the master keys to add to the document, "+
+			"or remove_vault_key_names or remove_age_recipients to those to remove.")
+}
+
+// ModifyPlan rejects the resource in age-only mode and, in fips_mode, the
+// age recipients to add.
+func (r *documentKeysResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// Nothing to resolve on destroy or before the provider is configured.
+	if req.Plan.Raw.IsNull() || r.pd == nil {
+		return
+	}
+	if !r.pd.usesVault() {
+		resp.Diagnostics.AddError("Vault not configured",
+			"sops_document_keys unwraps the data key with Vault Transit and is not available in age-only mode.")
+	}
+	var recipients types.List
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("age_recipients"), &recipients)...)
+	if resp.Diagnostics.HasError() || recipients.IsUnknown() {
+		return
+	}
+	var keys sopsencrypt.KeySources
+	resp.Diagnostics.Append(recipients.ElementsAs(ctx, &keys.AgeRecipients, true)...)
+	r.pd.checkFIPSKeys(keys, path.Empty(), &resp.Diagnostics)
+}
+
+func (r *documentKeysResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var data documentKeysModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if !r.pd.usesVault() {
+		resp.Diagnostics.AddError("Vault not configured",
+			"sops_document_keys unwraps the data key with Vault Transit and is not available in age-only mode.")
+		return
+	}
+	transitEngine := resolveStringDefault(data.VaultTransitEngine, r.pd.vaultTransitEngine)
+	update := sopsencrypt.KeyUpdate{TransitPath: transitEngine}
+	resp.Diagnostics.Append(data.VaultKeyNames.ElementsAs(ctx, &update.AddVaultKeyNames, true)...)
+	resp.Diagnostics.Append(data.AgeRecipients.ElementsAs(ctx, &update.AddAgeRecipients, true)...)
+	resp.Diagnostics.Append(data.RemoveKeyNames.ElementsAs(ctx, &update.RemoveVaultKeyNames, true)...)
+	resp.Diagnostics.Append(data.RemoveRecipients.ElementsAs(ctx, &update.RemoveAgeRecipients, true)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	client, err := r.pd.newVaultClientAt(data.VaultAddress.ValueString(), data.Timeouts.create())
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to create Vault client", err.Error())
+		return
+	}
+	ctx = r.pd.logContext(ctx, client, r.pd.vaultAddressFor(data.VaultAddress), transitEngine, "")
+	if client != nil {
+		release, err := r.pd.acquireVault(ctx)
+		if err != nil {
+			resp.Diagnostics.AddError("Failed to update master keys", err.Error())
+			return
+		}
+		defer release()
+	}
+	start := time.Now()
+	ciphertext, err := sopsencrypt.UpdateMasterKeys(client, data.Document.ValueString(), update, sopsencrypt.EncryptOpts{Mock: r.pd.mock})
+	logResult(ctx, "Updating master keys", start, err)
+	if err != nil {
+		addVaultError(&resp.Diagnostics, path.Root("document"), "Failed to update master keys", err)
+		return
+	}
+
+	data.ID = sha256Hex(ciphertext)
+	data.Ciphertext = types.StringValue(ciphertext)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// Read makes no remote calls: the ciphertext in state remains valid until
+// inputs change.
+func (r *documentKeysResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var data documentKeysModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// Update is only reached when timeouts change; every other attribute
+// carries RequiresReplace. The existing ciphertext is kept.
+func (r *documentKeysResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var data, state documentKeysModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	data.ID = state.ID
+	data.Ciphertext = state.Ciphertext
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *documentKeysResource) Delete(_ context.Context, _ resource.DeleteRequest, _ *resource.DeleteResponse) {
+}
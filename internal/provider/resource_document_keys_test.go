@@ -0,0 +1,110 @@
+package provider_test
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+func TestAccDocumentKeysResource(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+provider "sops" {
+  mock = true
+}
+
+resource "sops_encrypted_json" "test" {
+  content        = jsonencode({ password = "hunter2" })
+  vault_key_name = "sops-test"
+}
+
+resource "sops_document_keys" "test" {
+  document        = sops_encrypted_json.test.ciphertext
+  vault_key_names = ["ops"]
+  age_recipients  = ["age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p"]
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestMatchResourceAttr("sops_document_keys.test", "ciphertext", regexp.MustCompile(`"key_name": "ops"`)),
+					resource.TestMatchResourceAttr("sops_document_keys.test", "ciphertext", regexp.MustCompile(`age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p`)),
+					resource.TestCheckResourceAttrSet("sops_document_keys.test", "id"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccDocumentKeysResource_Remove(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+provider "sops" {
+  mock = true
+}
+
+resource "sops_encrypted_json" "test" {
+  content        = jsonencode({ password = "hunter2" })
+  vault_key_name = "sops-test"
+}
+
+resource "sops_document_keys" "test" {
+  document               = sops_encrypted_json.test.ciphertext
+  vault_key_names        = ["ops"]
+  remove_vault_key_names = ["sops-test"]
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestMatchResourceAttr("sops_document_keys.test", "ciphertext", regexp.MustCompile(`"key_name": "ops"`)),
+					resource.TestCheckResourceAttrWith("sops_document_keys.test", "ciphertext", func(v string) error {
+						if strings.Contains(v, "sops-test") {
+							return fmt.Errorf("removed key still present")
+						}
+						return nil
+					}),
+				),
+			},
+		},
+	})
+}
+
+func TestAccDocumentKeysResource_MissingKeys(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+provider "sops" {
+  mock = true
+}
+
+resource "sops_document_keys" "test" {
+  document = "{}"
+}
+`,
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile(`Missing master keys`),
+			},
+		},
+	})
+}
@@ -0,0 +1,442 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/boolplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/listplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"terraform-provider-sops/internal/sopsencrypt"
+)
+
+var (
+	_ resource.Resource                     = &encryptedDocumentsResource{}
+	_ resource.ResourceWithConfigure        = &encryptedDocumentsResource{}
+	_ resource.ResourceWithConfigValidators = &encryptedDocumentsResource{}
+	_ resource.ResourceWithModifyPlan       = &encryptedDocumentsResource{}
+)
+
+// encryptedDocumentsResource encrypts a map of JSON documents with one
+// batched Vault Transit call, as a lighter alternative to for_each over
+// sops_encrypted_json.
+type encryptedDocumentsResource struct{ pd *sopsProviderData }
+
+type encryptedDocumentsModel struct {
+	ID                 types.String   `tfsdk:"id"`
+	Documents          types.Map      `tfsdk:"documents"`
+	VaultKeyName       types.String   `tfsdk:"vault_key_name"`
+	VaultAddress       types.String   `tfsdk:"vault_address"`
+	VaultTransitEngine types.String   `tfsdk:"vault_transit_engine"`
+	Timestamp          types.String   `tfsdk:"timestamp"`
+	SOPSFormatVersion  types.String   `tfsdk:"sops_format_version"`
+	UnencryptedSuffix  types.String   `tfsdk:"unencrypted_suffix"`
+	EncryptedSuffix    types.String   `tfsdk:"encrypted_suffix"`
+	UnencryptedRegex   types.String   `tfsdk:"unencrypted_regex"`
+	EncryptedRegex     types.String   `tfsdk:"encrypted_regex"`
+	EncryptedPaths     types.List     `tfsdk:"encrypted_paths"`
+	UnencryptedKeys    types.List     `tfsdk:"unencrypted_keys"`
+	Pretty             types.Bool     `tfsdk:"pretty"`
+	TrailingNewline    types.Bool     `tfsdk:"trailing_newline"`
+	LineEndings        types.String   `tfsdk:"line_endings"`
+	Ciphertexts        types.Map      `tfsdk:"ciphertexts"`
+	Timeouts           *timeoutsModel `tfsdk:"timeouts"`
+}
+
+func NewEncryptedDocumentsResource() resource.Resource { return &encryptedDocumentsResource{} }
+
+func (r *encryptedDocumentsResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_encrypted_documents"
+}
+
+func (r *encryptedDocumentsResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: `Encrypts a map of JSON documents using SOPS with a Vault Transit key and
+stores a map of SOPS JSON ciphertexts in state. Every document gets a data
+key of its own, but all data keys are wrapped in a single batched Vault
+call, which is much faster than for_each over sops_encrypted_json.
+
+    resource "sops_encrypted_documents" "services" {
+      documents = {
+        for name, svc in var.services : name => jsonencode(svc.secrets)
+      }
+      vault_key_name = "my-key"
+    }
+
+Adding, removing or changing documents only encrypts the documents that are
+new or changed; the ciphertexts of the others are kept.`,
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:    true,
+				Description: "SHA-256 of the ciphertexts as first created. Unique per resource.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"documents": schema.MapAttribute{
+				ElementType: types.StringType,
+				Required:    true,
+				Sensitive:   true,
+				Description: "Documents to encrypt, by name. Each is a JSON object, typically built with jsonencode().",
+			},
+			"vault_key_name": schema.StringAttribute{
+				Optional:    true,
+				Computed:    true,
+				Description: "Name of the Vault Transit key used to wrap the data keys. Defaults to the provider-level default_vault_key_name. An error in age-only mode.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplaceIfConfigured(),
+				},
+			},
+			"vault_address": schema.StringAttribute{
+				Optional:    true,
+				Description: "Vault server for this resource. Overrides the provider-level vault_address. The provider's Vault token must be valid here too. Ignored in mock mode.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"vault_transit_engine": schema.StringAttribute{
+				Optional:    true,
+				Description: "Vault Transit mount path for this resource. Overrides the provider-level vault_transit_engine. Defaults to 'transit'.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"timestamp": schema.StringAttribute{
+				Optional: true,
+				Description: "Time recorded as lastmodified and as the created_at of the Vault key source instead of the current time, so " +
+					"re-encrypting identical inputs yields identical metadata for reproducible builds: an RFC 3339 time such as " +
+					"\"2024-01-01T00:00:00Z\", or \"content\" to derive a fixed time from the SHA-256 of the document. The encrypted values, " +
+					"the wrapped data key and the MAC still change on every encryption.",
+				Validators: []validator.String{validTimestamp()},
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"sops_format_version": schema.StringAttribute{
+				Optional: true,
+				Description: "sops release recorded as the version of the document instead of the one built into the provider, " +
+					"e.g. \"3.7.3\" for consumers that still run an older sops. Must be 3.7.0 or later; every metadata field the " +
+					"provider writes is understood from 3.7.0 on.",
+				Validators: []validator.String{validFormatVersion()},
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"unencrypted_suffix": schema.StringAttribute{
+				Optional:    true,
+				Computed:    true,
+				Description: "Keys whose names end with this suffix are left in plaintext. Mutually exclusive with other scope options. Inherits the provider default when no scope option is set.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplaceIfConfigured(),
+				},
+			},
+			"encrypted_suffix": schema.StringAttribute{
+				Optional:    true,
+				Computed:    true,
+				Description: "Only keys whose names end with this suffix are encrypted. Mutually exclusive with other scope options. Inherits the provider default when no scope option is set.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplaceIfConfigured(),
+				},
+			},
+			"unencrypted_regex": schema.StringAttribute{
+				Optional:    true,
+				Computed:    true,
+				Description: "Keys whose names match this regex are left in plaintext. Mutually exclusive with other scope options. Inherits the provider default when no scope option is set.",
+				Validators:  []validator.String{validRegex()},
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplaceIfConfigured(),
+				},
+			},
+			"encrypted_regex": schema.StringAttribute{
+				Optional:    true,
+				Computed:    true,
+				Description: "Only keys whose names match this regex are encrypted. Mutually exclusive with other scope options. Inherits the provider default when no scope option is set.",
+				Validators:  []validator.String{validRegex()},
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplaceIfConfigured(),
+				},
+			},
+			"encrypted_paths": schema.ListAttribute{
+				ElementType: types.StringType,
+				Optional:    true,
+				Description: "Only values under these key paths are encrypted, e.g. \"database.password\" or \"credentials.*\". Keys are separated by dots, \"*\" matches any key, lists are traversed transparently and \"\\\" escapes a literal \".\" or \"*\". SOPS can only record key names, so the paths are stored as an encrypted_regex of the key names that select them, and encryption fails if a selected key name also occurs outside the paths. Mutually exclusive with other scope options.",
+				Validators:  []validator.List{validEncryptedPaths()},
+				PlanModifiers: []planmodifier.List{
+					listplanmodifier.RequiresReplace(),
+				},
+			},
+			"unencrypted_keys": schema.ListAttribute{
+				ElementType: types.StringType,
+				Optional:    true,
+				Description: "Keys with exactly these names are left in plaintext, at any depth; all others are encrypted. Recorded in the SOPS metadata as an unencrypted_regex matching exactly these names. Mutually exclusive with other scope options.",
+				Validators:  []validator.List{validKeyNames()},
+				PlanModifiers: []planmodifier.List{
+					listplanmodifier.RequiresReplace(),
+				},
+			},
+			"pretty": schema.BoolAttribute{
+				Optional:    true,
+				Computed:    true,
+				Description: "Indent the SOPS JSON output. Defaults to false.",
+				Default:     booldefault.StaticBool(false),
+				PlanModifiers: []planmodifier.Bool{
+					boolplanmodifier.RequiresReplace(),
+				},
+			},
+			"trailing_newline": schema.BoolAttribute{
+				Optional:    true,
+				Computed:    true,
+				Description: "End the SOPS JSON ciphertexts with a newline. Defaults to true; set to false for consumers that compare files byte for byte against output without one. Changing it forces replacement.",
+				Default:     booldefault.StaticBool(true),
+				PlanModifiers: []planmodifier.Bool{
+					boolplanmodifier.RequiresReplace(),
+				},
+			},
+			"line_endings": schema.StringAttribute{
+				Optional:    true,
+				Computed:    true,
+				Description: "Line breaks of the SOPS JSON ciphertexts: \"lf\" (default) or \"crlf\" for Windows consumers. Neither changes the encrypted values or the MAC. Changing it forces replacement.",
+				Default:     stringdefault.StaticString(sopsencrypt.LineEndingsLF),
+				Validators:  []validator.String{validLineEndings()},
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"ciphertexts": schema.MapAttribute{
+				ElementType: types.StringType,
+				Computed:    true,
+				Sensitive:   true,
+				Description: "SOPS-encrypted JSON documents, by the names in documents. Each is decryptable with `sops -d --input-type json`.",
+			},
+		},
+		Blocks: map[string]schema.Block{
+			"timeouts": resourceTimeoutsBlock(),
+		},
+	}
+}
+
+func (r *encryptedDocumentsResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	pd, ok := req.ProviderData.(*sopsProviderData)
+	if !ok {
+		resp.Diagnostics.AddError("Unexpected provider data type",
+			fmt.Sprintf("Expected *sopsProviderData, got %T", req.ProviderData))
+		return
+	}
+	r.pd = pd
+}
+
+func (r *encryptedDocumentsResource) ConfigValidators(context.Context) []resource.ConfigValidator {
+	return []resource.ConfigValidator{scopeConfigValidator{}}
+}
+
+func (r *encryptedDocumentsResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var data encryptedDocumentsModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	r.pd.resolveScope([]types.List{data.EncryptedPaths, data.UnencryptedKeys}, &data.UnencryptedSuffix, &data.EncryptedSuffix, &data.UnencryptedRegex, &data.EncryptedRegex)
+	// The plan leaves vault_key_name unknown when the provider was not yet
+	// configured during plan; resolve the provider default now.
+	if data.VaultKeyName.IsUnknown() || data.VaultKeyName.IsNull() {
+		keyName := r.pd.vaultKeyName(types.StringNull())
+		if keyName == "" && r.pd.usesVault() {
+			resp.Diagnostics.AddAttributeError(path.Root("vault_key_name"),
+				"Missing Vault key name",
+				"Set vault_key_name on the resource or default_vault_key_name on the provider.")
+			return
+		}
+		data.VaultKeyName = types.StringNull()
+		if keyName != "" {
+			data.VaultKeyName = types.StringValue(keyName)
+		}
+	}
+
+	documents := map[string]string{}
+	resp.Diagnostics.Append(data.Documents.ElementsAs(ctx, &documents, false)...)
+	r.pd.checkDocuments(documents, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	ciphertexts, err := r.encrypt(ctx, data, documents)
+	if err != nil {
+		addVaultError(&resp.Diagnostics, path.Root("documents"), "SOPS encryption failed", err)
+		return
+	}
+	data.lintScope(ctx, r.pd, documents, &resp.Diagnostics)
+
+	// The data keys are random, so the hash is unique per resource.
+	b, err := json.Marshal(ciphertexts)
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to hash ciphertexts", err.Error())
+		return
+	}
+	data.ID = sha256Hex(string(b))
+	m, diags := types.MapValueFrom(ctx, types.StringType, ciphertexts)
+	resp.Diagnostics.Append(diags...)
+	data.Ciphertexts = m
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// Read makes no remote calls: the ciphertexts in state remain valid until
+// the documents change.
+func (r *encryptedDocumentsResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var data encryptedDocumentsModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	backfillLineLayout(&data.TrailingNewline, &data.LineEndings)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// Update is reached when documents or timeouts change; every other attribute
+// carries RequiresReplace. Documents that are new or whose content changed
+// are encrypted in one batch, the ciphertexts of unchanged documents are
+// kept and those of removed documents dropped.
+func (r *encryptedDocumentsResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var data, state encryptedDocumentsModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	documents, prior, priorCiphertexts := map[string]string{}, map[string]string{}, map[string]string{}
+	resp.Diagnostics.Append(data.Documents.ElementsAs(ctx, &documents, false)...)
+	resp.Diagnostics.Append(state.Documents.ElementsAs(ctx, &prior, false)...)
+	resp.Diagnostics.Append(state.Ciphertexts.ElementsAs(ctx, &priorCiphertexts, false)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ciphertexts := make(map[string]string, len(documents))
+	changed := map[string]string{}
+	for name, document := range documents {
+		if ciphertext, ok := priorCiphertexts[name]; ok && prior[name] == document {
+			ciphertexts[name] = ciphertext
+		} else {
+			changed[name] = document
+		}
+	}
+	r.pd.checkDocuments(changed, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if len(changed) > 0 {
+		encrypted, err := r.encrypt(ctx, data, changed)
+		if err != nil {
+			addVaultError(&resp.Diagnostics, path.Root("documents"), "SOPS encryption failed", err)
+			return
+		}
+		data.lintScope(ctx, r.pd, changed, &resp.Diagnostics)
+		maps.Copy(ciphertexts, encrypted)
+	}
+
+	data.ID = state.ID
+	m, diags := types.MapValueFrom(ctx, types.StringType, ciphertexts)
+	resp.Diagnostics.Append(diags...)
+	data.Ciphertexts = m
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *encryptedDocumentsResource) Delete(_ context.Context, _ resource.DeleteRequest, _ *resource.DeleteResponse) {
+}
+
+func (r *encryptedDocumentsResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// Nothing to resolve on destroy or before the provider is configured.
+	if req.Plan.Raw.IsNull() || r.pd == nil {
+		return
+	}
+	planVaultKeyName(ctx, r.pd, req, resp)
+	planVaultAddress(ctx, r.pd, req, resp)
+	planScope(ctx, r.pd, req, resp)
+
+	// Report oversized and already encrypted documents at plan time once
+	// they are known.
+	var documents types.Map
+	resp.Diagnostics.Append(resp.Plan.GetAttribute(ctx, path.Root("documents"), &documents)...)
+	if resp.Diagnostics.HasError() || documents.IsUnknown() {
+		return
+	}
+	known := map[string]string{}
+	for name, v := range documents.Elements() {
+		if s, ok := v.(types.String); ok && !s.IsUnknown() {
+			known[name] = s.ValueString()
+		}
+	}
+	r.pd.checkDocuments(known, &resp.Diagnostics)
+}
+
+// lintScope warns about each of documents whose values the resource's scope
+// leaves all in plaintext and, with lint_plaintext_secrets, about the values
+// with secret-looking key names it leaves in plaintext.
+func (m encryptedDocumentsModel) lintScope(ctx context.Context, pd *sopsProviderData, documents map[string]string, diags *diag.Diagnostics) {
+	lists := []types.List{m.EncryptedPaths, m.UnencryptedKeys}
+	scope := []types.String{m.UnencryptedSuffix, m.EncryptedSuffix, m.UnencryptedRegex, m.EncryptedRegex}
+	for _, name := range slices.Sorted(maps.Keys(documents)) {
+		warnNothingEncrypted(ctx, name, documents[name], false, lists, scope, diags)
+		if pd.lintSecrets {
+			warnPlaintextSecrets(ctx, name, documents[name], false, lists, scope, diags)
+		}
+	}
+}
+
+// encrypt encrypts documents with a single batched wrapping of their data
+// keys and returns the SOPS JSON ciphertexts by name.
+func (r *encryptedDocumentsResource) encrypt(ctx context.Context, data encryptedDocumentsModel, documents map[string]string) (map[string]string, error) {
+	client, err := r.pd.newVaultClientAt(data.VaultAddress.ValueString(), data.Timeouts.create())
+	if err != nil {
+		return nil, err
+	}
+	transitEngine := data.VaultTransitEngine.ValueString()
+	if transitEngine == "" {
+		transitEngine = r.pd.vaultTransitEngine
+	}
+	opts := sopsencrypt.EncryptOpts{
+		UnencryptedSuffix: data.UnencryptedSuffix.ValueString(),
+		EncryptedSuffix:   data.EncryptedSuffix.ValueString(),
+		UnencryptedRegex:  data.UnencryptedRegex.ValueString(),
+		EncryptedRegex:    data.EncryptedRegex.ValueString(),
+		AgeRecipients:     r.pd.ageRecipients,
+		CreateKeyType:     r.pd.createKeyType,
+		Mock:              r.pd.mock,
+		PrettyJSON:        data.Pretty.ValueBool(),
+	}
+	setTimestamp(&opts, data.Timestamp)
+	opts.FormatVersion = data.SOPSFormatVersion.ValueString()
+	setLineLayout(&opts, data.TrailingNewline, data.LineEndings)
+	if err := setScopeLists(ctx, &opts, data.EncryptedPaths, data.UnencryptedKeys); err != nil {
+		return nil, err
+	}
+	ctx = r.pd.logContext(ctx, client, r.pd.vaultAddressFor(data.VaultAddress), transitEngine, data.VaultKeyName.ValueString(), slices.Collect(maps.Values(documents))...)
+	tflog.Debug(ctx, "Encrypting documents", map[string]any{"documents": len(documents)})
+	release, err := r.pd.acquireVault(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	if err := r.pd.setCachedDataKey(client, transitEngine, data.VaultKeyName.ValueString(), &opts); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	ciphertexts, err := sopsencrypt.EncryptBatch(client, transitEngine, data.VaultKeyName.ValueString(), documents, opts)
+	logResult(ctx, "Batch encryption", start, err)
+	return ciphertexts, err
+}
@@ -0,0 +1,134 @@
+package provider_test
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+// TestAccEncryptedDocumentsResource encrypts several documents against a real
+// Vault instance.
+//
+// Required environment variables:
+//
+//	VAULT_ADDR  – e.g. http://127.0.0.1:8200
+//	VAULT_TOKEN – a token with transit encrypt/decrypt access
+func TestAccEncryptedDocumentsResource(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+	vaultAddr := requireEnv(t, "VAULT_ADDR")
+	vaultToken := requireEnv(t, "VAULT_TOKEN")
+	keyName := envOrDefault("SOPS_VAULT_KEY", "sops-test")
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+provider "sops" {
+  vault_address = %q
+
+  auth {
+    token = %q
+  }
+}
+
+resource "sops_encrypted_documents" "test" {
+  documents = {
+    app    = jsonencode({ password = "app-secret" })
+    worker = jsonencode({ password = "worker-secret" })
+  }
+  vault_key_name = %q
+}
+`, vaultAddr, vaultToken, keyName),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("sops_encrypted_documents.test", "ciphertexts.%", "2"),
+					resource.TestCheckResourceAttrWith("sops_encrypted_documents.test", "ciphertexts.app",
+						notEqualsPlaintext("app-secret")),
+					resource.TestCheckResourceAttrWith("sops_encrypted_documents.test", "ciphertexts.worker",
+						func(v string) error {
+							if !strings.Contains(v, `"hc_vault"`) {
+								return fmt.Errorf("ciphertext has no hc_vault key source")
+							}
+							return nil
+						}),
+				),
+			},
+		},
+	})
+}
+
+// TestAccEncryptedDocumentsResource_Update verifies that changing the map
+// only encrypts the new and changed documents. Age data keys are random, so
+// an unchanged ciphertext shows the document was not re-encrypted.
+func TestAccEncryptedDocumentsResource_Update(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+
+	config := func(documents string) string {
+		return fmt.Sprintf(`
+provider "sops" {
+  age_recipients = ["age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p"]
+}
+
+resource "sops_encrypted_documents" "test" {
+  documents = {
+%s
+  }
+}
+`, documents)
+	}
+
+	var a, b string
+	capture := func(dst *string) func(string) error {
+		return func(v string) error { *dst = v; return nil }
+	}
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: config(`
+    a = jsonencode({ k = "1" })
+    b = jsonencode({ k = "2" })`),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttrWith("sops_encrypted_documents.test", "ciphertexts.a", capture(&a)),
+					resource.TestCheckResourceAttrWith("sops_encrypted_documents.test", "ciphertexts.b", capture(&b)),
+				),
+			},
+			{
+				Config: config(`
+    a = jsonencode({ k = "1" })
+    b = jsonencode({ k = "changed" })
+    c = jsonencode({ k = "3" })`),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("sops_encrypted_documents.test", "ciphertexts.%", "3"),
+					resource.TestCheckResourceAttrWith("sops_encrypted_documents.test", "ciphertexts.a",
+						func(v string) error {
+							if v != a {
+								return fmt.Errorf("unchanged document a was re-encrypted")
+							}
+							return nil
+						}),
+					resource.TestCheckResourceAttrWith("sops_encrypted_documents.test", "ciphertexts.b",
+						func(v string) error {
+							if v == b {
+								return fmt.Errorf("changed document b kept its old ciphertext")
+							}
+							return nil
+						}),
+					resource.TestCheckResourceAttrSet("sops_encrypted_documents.test", "ciphertexts.c"),
+				),
+			},
+			{
+				Config: config(`
+    a = jsonencode({ k = "1" })`),
+				Check: resource.TestCheckResourceAttr("sops_encrypted_documents.test", "ciphertexts.%", "1"),
+			},
+		},
+	})
+}
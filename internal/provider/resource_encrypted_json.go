@@ -3,37 +3,65 @@ package provider
 import (
 	"context"
 	"fmt"
+	"strings"
+	"time"
 
+	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/boolplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/listplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"terraform-provider-sops/internal/sopsencrypt"
 )
 
 var (
-	_ resource.Resource                = &encryptedJSONResource{}
-	_ resource.ResourceWithConfigure   = &encryptedJSONResource{}
-	_ resource.ResourceWithImportState = &encryptedJSONResource{}
+	_ resource.Resource                     = &encryptedJSONResource{}
+	_ resource.ResourceWithConfigure        = &encryptedJSONResource{}
+	_ resource.ResourceWithConfigValidators = &encryptedJSONResource{}
+	_ resource.ResourceWithImportState      = &encryptedJSONResource{}
+	_ resource.ResourceWithModifyPlan       = &encryptedJSONResource{}
+	_ resource.ResourceWithValidateConfig   = &encryptedJSONResource{}
 )
 
 type encryptedJSONResource struct{ pd *sopsProviderData }
 
 type encryptedJSONModel struct {
-	ID                 types.String `tfsdk:"id"`
-	Content            types.String `tfsdk:"content"`
-	VaultKeyName       types.String `tfsdk:"vault_key_name"`
-	VaultTransitEngine types.String `tfsdk:"vault_transit_engine"`
-	UnencryptedSuffix  types.String `tfsdk:"unencrypted_suffix"`
-	EncryptedSuffix    types.String `tfsdk:"encrypted_suffix"`
-	UnencryptedRegex   types.String `tfsdk:"unencrypted_regex"`
-	EncryptedRegex     types.String `tfsdk:"encrypted_regex"`
-	Pretty             types.Bool   `tfsdk:"pretty"`
-	Ciphertext         types.String `tfsdk:"ciphertext"`
+	ID                     types.String   `tfsdk:"id"`
+	VaultKeyName           types.String   `tfsdk:"vault_key_name"`
+	VaultAddress           types.String   `tfsdk:"vault_address"`
+	VaultTransitEngine     types.String   `tfsdk:"vault_transit_engine"`
+	SigningKeyName         types.String   `tfsdk:"signing_key_name"`
+	Timestamp              types.String   `tfsdk:"timestamp"`
+	SOPSFormatVersion      types.String   `tfsdk:"sops_format_version"`
+	DataKeyWO              types.String   `tfsdk:"data_key_wo"`
+	WrappedDataKeyWO       types.String   `tfsdk:"wrapped_data_key_wo"`
+	RewrapOnRead           types.Bool     `tfsdk:"rewrap_on_read"`
+	ReencryptOnKeyRotation types.Bool     `tfsdk:"reencrypt_on_key_rotation"`
+	VerifyOnRead           types.Bool     `tfsdk:"verify_on_read"`
+	CiphertextSensitive    types.Bool     `tfsdk:"ciphertext_sensitive"`
+	Timeouts               *timeoutsModel `tfsdk:"timeouts"`
+	UnencryptedSuffix      types.String   `tfsdk:"unencrypted_suffix"`
+	EncryptedSuffix        types.String   `tfsdk:"encrypted_suffix"`
+	UnencryptedRegex       types.String   `tfsdk:"unencrypted_regex"`
+	EncryptedRegex         types.String   `tfsdk:"encrypted_regex"`
+	EncryptedPaths         types.List     `tfsdk:"encrypted_paths"`
+	UnencryptedKeys        types.List     `tfsdk:"unencrypted_keys"`
+	Pretty                 types.Bool     `tfsdk:"pretty"`
+	TrailingNewline        types.Bool     `tfsdk:"trailing_newline"`
+	LineEndings            types.String   `tfsdk:"line_endings"`
+	ContentSHA256          types.String   `tfsdk:"content_sha256"`
+	StructurePreview       types.String   `tfsdk:"structure_preview"`
+	contentModel
+	ciphertextModel
 }
 
 func NewEncryptedJSONResource() resource.Resource { return &encryptedJSONResource{} }
@@ -66,22 +94,70 @@ The ciphertext is stable across plans until content or vault_key_name changes,
 at which point the resource is replaced and re-encrypted.`,
 		Attributes: map[string]schema.Attribute{
 			"id": schema.StringAttribute{
-				Computed: true,
+				Computed:    true,
+				Description: "SHA-256 of the ciphertext as first created. Unique per resource and unchanged by rewrap_on_read.",
 				PlanModifiers: []planmodifier.String{
 					stringplanmodifier.UseStateForUnknown(),
 				},
 			},
 			"content": schema.StringAttribute{
-				Required:    true,
+				Optional:    true,
 				Sensitive:   true,
-				Description: "JSON-encoded document to encrypt. Use jsonencode() to build the structure.",
+				Description: "Document to encrypt, JSON-encoded unless content_type is \"yaml\". Use jsonencode() to build the structure. Reordering keys or changing whitespace does not force re-encryption. Exactly one of content, content_object, content_sources and content_template must be set.",
 				PlanModifiers: []planmodifier.String{
-					stringplanmodifier.RequiresReplace(),
+					contentRequiresReplace(),
+				},
+			},
+			"content_object": schema.DynamicAttribute{
+				Optional:    true,
+				Sensitive:   true,
+				Description: "Document to encrypt as a Terraform object or map, as an alternative to content. Numbers, bools and nulls keep their type in the encrypted output. Exactly one of content, content_object, content_sources and content_template must be set.",
+				PlanModifiers: []planmodifier.Dynamic{
+					contentDynamicRequiresReplace(),
+				},
+			},
+			"content_type": schema.StringAttribute{
+				Optional:    true,
+				Description: "Format of content: \"json\" (default) or \"yaml\". YAML input, such as a values.yaml read with file(), keeps its key order and comments in ciphertext_yaml. YAML input must be a single document; use sops_encrypted_yaml for a multi-document stream. Applies to content, content_sources and the rendered content_template.",
+				PlanModifiers: []planmodifier.String{
+					contentStringRequiresReplace(),
+				},
+			},
+			"content_sources": schema.ListAttribute{
+				ElementType: types.StringType,
+				Optional:    true,
+				Sensitive:   true,
+				Description: "Documents to deep-merge into the document to encrypt, as an alternative to content. Later entries win: nested objects are merged key by key and any other value replaces the earlier one. Each entry must be a single object, encoded as JSON or, with content_type = \"yaml\", YAML; data after it, such as a second YAML document, is an error. Keys keep the order of the entry that introduced them, followed by keys added by later entries. Exactly one of content, content_object, content_sources and content_template must be set.",
+				PlanModifiers: []planmodifier.List{
+					contentListRequiresReplace(),
+				},
+			},
+			"content_template": schema.StringAttribute{
+				Optional:    true,
+				Description: "Template rendering the document to encrypt, with templatefile() syntax: ${...} interpolations and %{...} directives referencing vars. jsonencode() is available. The template is not sensitive, so it can be kept in the repository and the secrets passed in through vars. Exactly one of content, content_object, content_sources and content_template must be set.",
+				PlanModifiers: []planmodifier.String{
+					contentStringRequiresReplace(),
+				},
+			},
+			"vars": schema.DynamicAttribute{
+				Optional:    true,
+				Sensitive:   true,
+				Description: "Object of variables available to content_template.",
+				PlanModifiers: []planmodifier.Dynamic{
+					contentDynamicRequiresReplace(),
 				},
 			},
 			"vault_key_name": schema.StringAttribute{
-				Required:    true,
-				Description: "Name of the Vault Transit key used to wrap the data key.",
+				Optional:    true,
+				Computed:    true,
+				Description: "Name of the Vault Transit key used to wrap the data key. Defaults to the provider-level default_vault_key_name. An error in age-only mode.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplaceIfConfigured(),
+				},
+			},
+			"vault_address": schema.StringAttribute{
+				Optional:    true,
+				Description: "Vault server for this resource, e.g. https://vault-eu.example.com:8200. Overrides the provider-level vault_address. The provider's Vault token, obtained from the provider-level server, must be valid here too. Recorded in the sops metadata. Ignored in mock mode.",
 				PlanModifiers: []planmodifier.String{
 					stringplanmodifier.RequiresReplace(),
 				},
@@ -93,32 +169,104 @@ at which point the resource is replaced and re-encrypted.`,
 					stringplanmodifier.RequiresReplace(),
 				},
 			},
+			"signing_key_name": schema.StringAttribute{
+				Optional: true,
+				Description: "Vault Transit signing key, e.g. of type ed25519 or ecdsa-p256, in vault_transit_engine to sign ciphertext with, " +
+					"so that consumers of the committed file can verify its provenance. The signature is exported as signature.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"timestamp": schema.StringAttribute{
+				Optional: true,
+				Description: "Time recorded as lastmodified and as the created_at of the Vault key source instead of the current time, so " +
+					"re-encrypting identical inputs yields identical metadata for reproducible builds: an RFC 3339 time such as " +
+					"\"2024-01-01T00:00:00Z\", or \"content\" to derive a fixed time from the SHA-256 of the document. The encrypted values, " +
+					"the wrapped data key and the MAC still change on every encryption.",
+				Validators: []validator.String{validTimestamp()},
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"sops_format_version": schema.StringAttribute{
+				Optional: true,
+				Description: "sops release recorded as the version of the document instead of the one built into the provider, " +
+					"e.g. \"3.7.3\" for consumers that still run an older sops. Must be 3.7.0 or later; every metadata field the " +
+					"provider writes is understood from 3.7.0 on.",
+				Validators: []validator.String{validFormatVersion()},
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"data_key_wo": schema.StringAttribute{
+				Optional:  true,
+				Sensitive: true,
+				WriteOnly: true,
+				Description: "Base64-encoded 32-byte data key to encrypt the document with instead of a random one, typically the data_key of a " +
+					"sops_data_key ephemeral resource, so several documents share one key. Write-only: it is never stored, only used when " +
+					"the document is encrypted, and changing it alone does not re-encrypt. Requires Terraform 1.11 or later.",
+				Validators: []validator.String{validDataKey()},
+			},
+			"wrapped_data_key_wo": schema.StringAttribute{
+				Optional:  true,
+				WriteOnly: true,
+				Description: "Vault Transit ciphertext of data_key_wo under this resource's Transit key, typically the wrapped_data_key of the " +
+					"same sops_data_key, recorded as is so the documents share one wrapped key. When unset, data_key_wo is wrapped again. " +
+					"It is not checked against data_key_wo: a mismatch yields a document that cannot be decrypted. Write-only.",
+			},
+			"rewrap_on_read":            rewrapOnReadAttribute(),
+			"reencrypt_on_key_rotation": reencryptOnKeyRotationAttribute(),
+			"verify_on_read":            verifyOnReadAttribute(),
 			"unencrypted_suffix": schema.StringAttribute{
 				Optional:    true,
-				Description: "Keys whose names end with this suffix are left in plaintext. Mutually exclusive with other scope options.",
+				Computed:    true,
+				Description: "Keys whose names end with this suffix are left in plaintext. Mutually exclusive with other scope options. Inherits the provider default when no scope option is set.",
 				PlanModifiers: []planmodifier.String{
-					stringplanmodifier.RequiresReplace(),
+					stringplanmodifier.RequiresReplaceIfConfigured(),
 				},
 			},
 			"encrypted_suffix": schema.StringAttribute{
 				Optional:    true,
-				Description: "Only keys whose names end with this suffix are encrypted. Mutually exclusive with other scope options.",
+				Computed:    true,
+				Description: "Only keys whose names end with this suffix are encrypted. Mutually exclusive with other scope options. Inherits the provider default when no scope option is set.",
 				PlanModifiers: []planmodifier.String{
-					stringplanmodifier.RequiresReplace(),
+					stringplanmodifier.RequiresReplaceIfConfigured(),
 				},
 			},
 			"unencrypted_regex": schema.StringAttribute{
 				Optional:    true,
-				Description: "Keys whose names match this regex are left in plaintext. Mutually exclusive with other scope options.",
+				Computed:    true,
+				Description: "Keys whose names match this regex are left in plaintext. Mutually exclusive with other scope options. Inherits the provider default when no scope option is set.",
+				Validators:  []validator.String{validRegex()},
 				PlanModifiers: []planmodifier.String{
-					stringplanmodifier.RequiresReplace(),
+					stringplanmodifier.RequiresReplaceIfConfigured(),
 				},
 			},
 			"encrypted_regex": schema.StringAttribute{
 				Optional:    true,
-				Description: "Only keys whose names match this regex are encrypted. Mutually exclusive with other scope options.",
+				Computed:    true,
+				Description: "Only keys whose names match this regex are encrypted. Mutually exclusive with other scope options. Inherits the provider default when no scope option is set.",
+				Validators:  []validator.String{validRegex()},
 				PlanModifiers: []planmodifier.String{
-					stringplanmodifier.RequiresReplace(),
+					stringplanmodifier.RequiresReplaceIfConfigured(),
+				},
+			},
+			"encrypted_paths": schema.ListAttribute{
+				ElementType: types.StringType,
+				Optional:    true,
+				Description: "Only values under these key paths are encrypted, e.g. \"database.password\" or \"credentials.*\". Keys are separated by dots, \"*\" matches any key, lists are traversed transparently and \"\\\" escapes a literal \".\" or \"*\". SOPS can only record key names, so the paths are stored as an encrypted_regex of the key names that select them, and encryption fails if a selected key name also occurs outside the paths. Mutually exclusive with other scope options.",
+				Validators:  []validator.List{validEncryptedPaths()},
+				PlanModifiers: []planmodifier.List{
+					listplanmodifier.RequiresReplace(),
+				},
+			},
+			"unencrypted_keys": schema.ListAttribute{
+				ElementType: types.StringType,
+				Optional:    true,
+				Description: "Keys with exactly these names are left in plaintext, at any depth; all others are encrypted. Recorded in the SOPS metadata as an unencrypted_regex matching exactly these names. Mutually exclusive with other scope options.",
+				Validators:  []validator.List{validKeyNames()},
+				PlanModifiers: []planmodifier.List{
+					listplanmodifier.RequiresReplace(),
 				},
 			},
 			"pretty": schema.BoolAttribute{
@@ -130,6 +278,25 @@ at which point the resource is replaced and re-encrypted.`,
 					boolplanmodifier.RequiresReplace(),
 				},
 			},
+			"trailing_newline": schema.BoolAttribute{
+				Optional:    true,
+				Computed:    true,
+				Description: "End the SOPS JSON output and its YAML form with a newline. Defaults to true; set to false for consumers that compare files byte for byte against output without one. Changing it forces replacement.",
+				Default:     booldefault.StaticBool(true),
+				PlanModifiers: []planmodifier.Bool{
+					boolplanmodifier.RequiresReplace(),
+				},
+			},
+			"line_endings": schema.StringAttribute{
+				Optional:    true,
+				Computed:    true,
+				Description: "Line breaks of the SOPS JSON output and its YAML form: \"lf\" (default) or \"crlf\" for Windows consumers. Neither changes the encrypted values or the MAC. Changing it forces replacement.",
+				Default:     stringdefault.StaticString(sopsencrypt.LineEndingsLF),
+				Validators:  []validator.String{validLineEndings()},
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
 			"ciphertext": schema.StringAttribute{
 				Computed:    true,
 				Sensitive:   true,
@@ -138,6 +305,107 @@ at which point the resource is replaced and re-encrypted.`,
 					stringplanmodifier.UseStateForUnknown(),
 				},
 			},
+			"ciphertext_sensitive": schema.BoolAttribute{
+				Optional: true,
+				Computed: true,
+				Default:  booldefault.StaticBool(true),
+				Description: "Whether ciphertext is only available as a sensitive value. Set to false to also expose it as " +
+					"ciphertext_nonsensitive, which plan diffs and outputs show: it is encrypted, so reviewing generated files needs no secrets. " +
+					"Terraform fixes sensitivity per attribute, so ciphertext itself stays sensitive.",
+			},
+			"ciphertext_nonsensitive": schema.StringAttribute{
+				Computed:    true,
+				Description: "The same as ciphertext, but not marked sensitive. Null unless ciphertext_sensitive is false.",
+			},
+			"ciphertext_base64": schema.StringAttribute{
+				Computed:    true,
+				Sensitive:   true,
+				Description: "ciphertext encoded as standard base64, for fields that expect base64 such as Kubernetes Secret data.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"content_sha256": schema.StringAttribute{
+				Computed:    true,
+				Description: "Hex-encoded SHA-256 of the encrypted document: content, content_object encoded as JSON, the merged content_sources, or the rendered content_template. Not sensitive, so it can trigger downstream changes without referencing the plaintext.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"structure_preview": schema.StringAttribute{
+				Computed: true,
+				Description: "The document as JSON with every value replaced by \"<encrypted>\" or \"<plaintext>\", according to the encryption scope, and comments removed. " +
+					"Not sensitive, so plan output shows which keys exist and which will be encrypted without revealing any value.",
+			},
+			"ciphertext_sha256": schema.StringAttribute{
+				Computed:    true,
+				Description: "Hex-encoded SHA-256 of ciphertext, for verifying the integrity of the encrypted document.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"signature": schema.StringAttribute{
+				Computed: true,
+				Description: "Vault Transit signature of ciphertext by signing_key_name, e.g. \"vault:v1:…\", over the exact bytes of ciphertext. " +
+					"Verify it with the key's verify endpoint or exported public key. Null unless signing_key_name is set.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"vault_request_id": schema.StringAttribute{
+				Computed:    true,
+				Description: "ID of the Vault Transit request that wrapped the data key, as recorded in the Vault audit log. Null in mock and age-only mode and when wrapped_data_key_wo is set.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"encrypted_at": schema.StringAttribute{
+				Computed:    true,
+				Description: "Time of the encryption, in RFC 3339 format. Unlike the document's lastmodified, it is not affected by timestamp.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"vault_entity_id": schema.StringAttribute{
+				Computed:    true,
+				Description: "Identity entity of the Vault token that encrypted the document, from token lookup-self. Null without Vault or for tokens without an entity.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"vault_accessor": schema.StringAttribute{
+				Computed:    true,
+				Description: "Accessor of the Vault token that encrypted the document, from token lookup-self, as recorded in the Vault audit log. Null without Vault.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"key_version": schema.Int64Attribute{
+				Computed:    true,
+				Description: "Version of the Vault Transit key that wrapped the data key, parsed from the vault:vN: prefix of the wrapped key. Null in age-only mode; 0 in mock mode.",
+				PlanModifiers: []planmodifier.Int64{
+					int64planmodifier.UseStateForUnknown(),
+				},
+			},
+			"ciphertext_json": schema.StringAttribute{
+				Computed:    true,
+				Sensitive:   true,
+				Description: "The encrypted document serialised as SOPS JSON, the same as ciphertext. Shares its data key and MAC with ciphertext_yaml.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"ciphertext_yaml": schema.StringAttribute{
+				Computed:    true,
+				Sensitive:   true,
+				Description: "The encrypted document serialised as SOPS YAML 1.2. Shares its data key and MAC with ciphertext_json.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+		},
+		Blocks: map[string]schema.Block{
+			"timeouts": resourceTimeoutsBlock(),
 		},
 	}
 }
@@ -155,66 +423,215 @@ func (r *encryptedJSONResource) Configure(_ context.Context, req resource.Config
 	r.pd = pd
 }
 
+// ValidateConfig requires exactly one content input and a known content_type.
+func (r *encryptedJSONResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var data encryptedJSONModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	data.validate(&resp.Diagnostics)
+	dataKeyConfig{data.DataKeyWO, data.WrappedDataKeyWO}.validate(&resp.Diagnostics)
+	validateKeyRotation(data.RewrapOnRead, data.ReencryptOnKeyRotation, &resp.Diagnostics)
+}
+
+func (r *encryptedJSONResource) ConfigValidators(context.Context) []resource.ConfigValidator {
+	return []resource.ConfigValidator{scopeConfigValidator{}}
+}
+
 func (r *encryptedJSONResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
 	var data encryptedJSONModel
 	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
-	if err := r.validateScope(data); err != nil {
-		resp.Diagnostics.AddError("Invalid scope configuration", err.Error())
-		return
+	r.pd.resolveScope([]types.List{data.EncryptedPaths, data.UnencryptedKeys}, &data.UnencryptedSuffix, &data.EncryptedSuffix, &data.UnencryptedRegex, &data.EncryptedRegex)
+	// The plan leaves vault_key_name unknown when the provider was not yet
+	// configured during plan; resolve the provider default now.
+	if data.VaultKeyName.IsUnknown() || data.VaultKeyName.IsNull() {
+		keyName := r.pd.vaultKeyName(types.StringNull())
+		if keyName == "" && r.pd.usesVault() {
+			resp.Diagnostics.AddAttributeError(path.Root("vault_key_name"),
+				"Missing Vault key name",
+				"Set vault_key_name on the resource or default_vault_key_name on the provider.")
+			return
+		}
+		data.VaultKeyName = types.StringNull()
+		if keyName != "" {
+			data.VaultKeyName = types.StringValue(keyName)
+		}
 	}
 
-	ciphertext, err := r.encrypt(data)
+	document, inputYAML, diags := data.document(ctx)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	// Content unknown during plan is only checked now.
+	r.pd.checkContentSize(data.attr(), len(document), &resp.Diagnostics)
+	checkPlaintext(data.attr(), document, inputYAML, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	dataKey := getDataKeyConfig(ctx, req.Config, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	var audit sopsencrypt.EncryptAudit
+	encryptedAt := time.Now()
+	jsonOut, yamlOut, err := r.encrypt(ctx, data, dataKey, document, inputYAML, &audit)
 	if err != nil {
-		resp.Diagnostics.AddError("SOPS encryption failed", err.Error())
+		addVaultError(&resp.Diagnostics, path.Root("vault_key_name"), "SOPS encryption failed", err)
+		return
+	}
+	if jsonOut == "" {
+		resp.Diagnostics.AddAttributeError(path.Root("content"),
+			"Multiple YAML documents",
+			"A stream of several YAML documents cannot be emitted as JSON. Use sops_encrypted_yaml instead.")
 		return
 	}
 
-	data.ID = types.StringValue(data.VaultKeyName.ValueString())
-	data.Ciphertext = types.StringValue(ciphertext)
+	data.ContentSHA256 = sha256Hex(document)
+	data.StructurePreview = data.structurePreview(ctx, document, inputYAML)
+	data.lintScope(ctx, r.pd, document, inputYAML, &resp.Diagnostics)
+	data.set(jsonOut)
+	data.setAudit(ctx, r.pd, data.VaultAddress, audit, encryptedAt, data.Timeouts.create(), &resp.Diagnostics)
+	if err := data.sign(ctx, r.pd, data.VaultAddress, data.VaultTransitEngine, data.SigningKeyName, data.Timeouts.create()); err != nil {
+		addVaultError(&resp.Diagnostics, path.Root("signing_key_name"), "Failed to sign ciphertext", err)
+		return
+	}
+	data.expose(data.CiphertextSensitive)
+	// The data key is random, so the hash is unique per resource. It is
+	// fixed at creation; rewrap_on_read may change the ciphertext later.
+	data.ID = data.CiphertextSHA256
+	data.CiphertextJSON = types.StringValue(jsonOut)
+	data.CiphertextYAML = types.StringValue(yamlOut)
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 }
 
-// Read is a no-op: ciphertext in state remains valid until inputs change.
+// Read makes no remote calls unless rewrap_on_read, reencrypt_on_key_rotation,
+// verify_on_read or stale_key_version_margin is set: ciphertext in state
+// remains valid until inputs change. It checks the ciphertext for out-of-band
+// edits and otherwise only derives the attributes computed from content and
+// ciphertext for resources created before those attributes existed.
 func (r *encryptedJSONResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
 	var data encryptedJSONModel
 	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	data.checkIntegrity(ctx, r.pd, data.VaultAddress, data.VerifyOnRead, data.Timeouts.read(), resp.Private, &resp.Diagnostics)
+	if data.ContentSHA256.IsNull() && !data.Content.IsNull() {
+		data.ContentSHA256 = sha256Hex(data.Content.ValueString())
+	}
+	if data.StructurePreview.IsNull() {
+		if document, inputYAML, diags := data.document(ctx); !diags.HasError() {
+			data.StructurePreview = data.structurePreview(ctx, document, inputYAML)
+		}
+	}
+	if (data.CiphertextBase64.IsNull() || data.CiphertextSHA256.IsNull() || data.KeyVersion.IsNull()) && !data.Ciphertext.IsNull() {
+		data.set(data.Ciphertext.ValueString())
+	}
+	if data.RewrapOnRead.ValueBool() {
+		previous := data.Ciphertext
+		data.rewrap(ctx, r.pd, data.VaultAddress, data.Timeouts.read(), &resp.Diagnostics)
+		data.resign(ctx, r.pd, previous, data.VaultAddress, data.VaultTransitEngine, data.SigningKeyName, data.Timeouts.read(), &resp.Diagnostics)
+	}
+	data.checkKeyVersion(ctx, r.pd, data.VaultAddress, data.VaultTransitEngine, data.VaultKeyName, data.ReencryptOnKeyRotation, data.Timeouts.read(), resp.Private, &resp.Diagnostics)
+	backfillLineLayout(&data.TrailingNewline, &data.LineEndings)
+	data.expose(data.CiphertextSensitive)
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 }
 
-// Update is never reached because all meaningful attributes carry RequiresReplace.
+// Update is only reached when content changes to a semantically identical
+// JSON document, rewrap_on_read, reencrypt_on_key_rotation, verify_on_read or
+// ciphertext_sensitive is toggled or timeouts change, or on the first change
+// after an import from a file whose content matches; every other attribute
+// carries RequiresReplace. The new values are recorded and the existing
+// ciphertext kept.
 func (r *encryptedJSONResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
-	resp.Diagnostics.AddError("unexpected update", "sops_encrypted_json does not support in-place updates")
+	var data, state encryptedJSONModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	data.ID = state.ID
+	if data.ContentSHA256.IsUnknown() {
+		data.ContentSHA256 = state.ContentSHA256
+	}
+	if data.StructurePreview.IsUnknown() {
+		data.StructurePreview = state.StructurePreview
+	}
+	data.ciphertextModel = state.ciphertextModel
+	data.expose(data.CiphertextSensitive)
+	resp.Diagnostics.Append(resp.Private.SetKey(ctx, privateImported, nil)...)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 }
 
 func (r *encryptedJSONResource) Delete(_ context.Context, _ resource.DeleteRequest, _ *resource.DeleteResponse) {}
 
-func (r *encryptedJSONResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
-	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
-}
+func (r *encryptedJSONResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// Nothing to resolve on destroy or before the provider is configured.
+	if req.Plan.Raw.IsNull() || r.pd == nil {
+		return
+	}
+	planVaultKeyName(ctx, r.pd, req, resp)
+	planVaultAddress(ctx, r.pd, req, resp)
+	planKeyRotation(ctx, req, resp)
+	planModified(ctx, req, resp)
+	planNonsensitive(ctx, resp)
+	planScope(ctx, r.pd, req, resp)
 
-func (r *encryptedJSONResource) validateScope(data encryptedJSONModel) error {
-	set := 0
-	for _, v := range []types.String{
-		data.UnencryptedSuffix, data.EncryptedSuffix,
-		data.UnencryptedRegex, data.EncryptedRegex,
-	} {
-		if !v.IsNull() && !v.IsUnknown() && v.ValueString() != "" {
-			set++
+	var data encryptedJSONModel
+	resp.Diagnostics.Append(resp.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	data.planContent(ctx, r.pd, &resp.Diagnostics)
+	planImported(ctx, r.pd, req, resp, data.contentModel, data.VaultAddress, data.Timeouts.read())
+	if !req.State.Raw.IsNull() {
+		var state encryptedJSONModel
+		resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+		if !resp.Diagnostics.HasError() {
+			data.planKeyDiff(ctx, state.contentModel, &resp.Diagnostics)
+		}
+	}
+	if data.known() {
+		if document, inputYAML, diags := data.document(ctx); !diags.HasError() {
+			resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("structure_preview"), data.structurePreview(ctx, document, inputYAML))...)
 		}
 	}
-	if set > 1 {
-		return fmt.Errorf("at most one of unencrypted_suffix, encrypted_suffix, unencrypted_regex, encrypted_regex may be set")
+}
+
+// ImportState adopts the SOPS JSON document of an import ID "file:<path>";
+// see importFile. Any other ID is taken as the resource ID.
+func (r *encryptedJSONResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	if !strings.HasPrefix(req.ID, importFilePrefix) {
+		resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+		return
+	}
+	if r.pd == nil {
+		resp.Diagnostics.AddError("Provider not configured", "Importing from a file requires a configured provider.")
+		return
+	}
+	importFile(ctx, r.pd, req.ID, "json", resp)
+	if !resp.Diagnostics.HasError() {
+		// Indented output is the only layout of SOPS JSON that sets pretty.
+		var ciphertext types.String
+		resp.Diagnostics.Append(resp.State.GetAttribute(ctx, path.Root("ciphertext"), &ciphertext)...)
+		pretty := strings.Contains(strings.TrimSpace(ciphertext.ValueString()), "\n")
+		resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("pretty"), pretty)...)
 	}
-	return nil
 }
 
-func (r *encryptedJSONResource) encrypt(data encryptedJSONModel) (string, error) {
-	client, err := sopsencrypt.NewVaultClient(r.pd.vaultAddress, r.pd.vaultToken)
+// encrypt returns the document serialised as JSON and as YAML, both from a
+// single encryption, and records the Vault request that wrapped its data key
+// in audit.
+func (r *encryptedJSONResource) encrypt(ctx context.Context, data encryptedJSONModel, dataKey dataKeyConfig, document string, inputYAML bool, audit *sopsencrypt.EncryptAudit) (jsonOut, yamlOut string, err error) {
+	client, err := r.pd.newVaultClientAt(data.VaultAddress.ValueString(), data.Timeouts.create())
 	if err != nil {
-		return "", err
+		return "", "", err
 	}
 	transitEngine := data.VaultTransitEngine.ValueString()
 	if transitEngine == "" {
@@ -225,7 +642,53 @@ func (r *encryptedJSONResource) encrypt(data encryptedJSONModel) (string, error)
 		EncryptedSuffix:   data.EncryptedSuffix.ValueString(),
 		UnencryptedRegex:  data.UnencryptedRegex.ValueString(),
 		EncryptedRegex:    data.EncryptedRegex.ValueString(),
+		InputYAML:         inputYAML,
+		AgeRecipients:     r.pd.ageRecipients,
+		CreateKeyType:     r.pd.createKeyType,
+		Mock:              r.pd.mock,
 		PrettyJSON:        data.Pretty.ValueBool(),
 	}
-	return sopsencrypt.EncryptToJSON(client, transitEngine, data.VaultKeyName.ValueString(), data.Content.ValueString(), opts)
+	opts.Audit = audit
+	setTimestamp(&opts, data.Timestamp)
+	opts.FormatVersion = data.SOPSFormatVersion.ValueString()
+	setLineLayout(&opts, data.TrailingNewline, data.LineEndings)
+	if err := dataKey.set(&opts); err != nil {
+		return "", "", err
+	}
+	if err := setScopeLists(ctx, &opts, data.EncryptedPaths, data.UnencryptedKeys); err != nil {
+		return "", "", err
+	}
+	ctx = r.pd.logContext(ctx, client, r.pd.vaultAddressFor(data.VaultAddress), transitEngine, data.VaultKeyName.ValueString(), document)
+	tflog.Debug(ctx, "Encrypting document", map[string]any{"input_yaml": inputYAML, "content_bytes": len(document)})
+	release, err := r.pd.acquireVault(ctx)
+	if err != nil {
+		return "", "", err
+	}
+	defer release()
+	if err := r.pd.setCachedDataKey(client, transitEngine, data.VaultKeyName.ValueString(), &opts); err != nil {
+		return "", "", err
+	}
+	start := time.Now()
+	jsonOut, yamlOut, err = sopsencrypt.EncryptToJSONAndYAML(client, transitEngine, data.VaultKeyName.ValueString(), document, opts)
+	logResult(ctx, "Encryption", start, err)
+	return jsonOut, yamlOut, err
+}
+
+// structurePreview returns the structure_preview of document under the
+// resource's scope.
+func (m encryptedJSONModel) structurePreview(ctx context.Context, document string, inputYAML bool) types.String {
+	return structurePreview(ctx, document, inputYAML, "json", []types.List{m.EncryptedPaths, m.UnencryptedKeys},
+		m.UnencryptedSuffix, m.EncryptedSuffix, m.UnencryptedRegex, m.EncryptedRegex)
+}
+
+// lintScope warns when the resource's scope leaves every value of document
+// in plaintext and, with lint_plaintext_secrets, when it leaves values with
+// secret-looking key names in plaintext.
+func (m encryptedJSONModel) lintScope(ctx context.Context, pd *sopsProviderData, document string, inputYAML bool, diags *diag.Diagnostics) {
+	lists := []types.List{m.EncryptedPaths, m.UnencryptedKeys}
+	scope := []types.String{m.UnencryptedSuffix, m.EncryptedSuffix, m.UnencryptedRegex, m.EncryptedRegex}
+	warnNothingEncrypted(ctx, "", document, inputYAML, lists, scope, diags)
+	if pd.lintSecrets {
+		warnPlaintextSecrets(ctx, "", document, inputYAML, lists, scope, diags)
+	}
 }
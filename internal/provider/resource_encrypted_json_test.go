@@ -1,11 +1,22 @@
 package provider_test
 
 import (
+	"crypto/sha256"
+	"encoding/base64"
 	"fmt"
 	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
 	"testing"
 
 	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/knownvalue"
+	"github.com/hashicorp/terraform-plugin-testing/plancheck"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+	"github.com/hashicorp/terraform-plugin-testing/tfjsonpath"
+	"github.com/hashicorp/terraform-plugin-testing/tfversion"
+	"terraform-provider-sops/internal/sopsencrypt"
 )
 
 // TestAccEncryptedJSONResource exercises the full Terraform lifecycle against
@@ -26,17 +37,30 @@ func TestAccEncryptedJSONResource(t *testing.T) {
 	vaultAddr := requireEnv(t, "VAULT_ADDR")
 	vaultToken := requireEnv(t, "VAULT_TOKEN")
 	keyName := envOrDefault("SOPS_VAULT_KEY", "sops-test")
+	content := `{"database":{"host":"db.example.com","password":"secret"},"api_key":"mykey"}`
 
 	resource.Test(t, resource.TestCase{
 		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
 		Steps: []resource.TestStep{
 			{
-				Config: testAccEncryptedJSONConfig(vaultAddr, vaultToken, keyName,
-					`{"database":{"host":"db.example.com","password":"secret"},"api_key":"mykey"}`),
+				Config: testAccEncryptedJSONConfig(vaultAddr, vaultToken, keyName, content),
 				Check: resource.ComposeAggregateTestCheckFunc(
 					resource.TestCheckResourceAttrSet("sops_encrypted_json.test", "ciphertext"),
 					resource.TestCheckResourceAttrWith("sops_encrypted_json.test", "ciphertext",
 						notEqualsPlaintext("secret")),
+					testCheckCiphertextAttributes("sops_encrypted_json.test"),
+					resource.TestCheckResourceAttrPair("sops_encrypted_json.test", "ciphertext_json",
+						"sops_encrypted_json.test", "ciphertext"),
+					resource.TestCheckResourceAttrWith("sops_encrypted_json.test", "ciphertext_yaml",
+						func(v string) error {
+							if !strings.Contains(v, "sops:") {
+								return fmt.Errorf("ciphertext_yaml missing YAML sops block")
+							}
+							return nil
+						}),
+					resource.TestCheckResourceAttr("sops_encrypted_json.test", "content_sha256",
+						fmt.Sprintf("%x", sha256.Sum256([]byte(content)))),
+					resource.TestCheckResourceAttrSet("sops_encrypted_json.test", "key_version"),
 				),
 			},
 		},
@@ -123,7 +147,10 @@ func testAccEncryptedJSONConfig(vaultAddr, vaultToken, keyName, content string)
 	return fmt.Sprintf(`
 provider "sops" {
   vault_address = %q
-  vault_token   = %q
+
+  auth {
+    token = %q
+  }
 }
 
 resource "sops_encrypted_json" "test" {
@@ -137,7 +164,10 @@ func testAccEncryptedJSONPrettyConfig(vaultAddr, vaultToken, keyName string) str
 	return fmt.Sprintf(`
 provider "sops" {
   vault_address = %q
-  vault_token   = %q
+
+  auth {
+    token = %q
+  }
 }
 
 resource "sops_encrypted_json" "test" {
@@ -152,7 +182,10 @@ func testAccEncryptedJSONScopeConfig(vaultAddr, vaultToken, keyName string) stri
 	return fmt.Sprintf(`
 provider "sops" {
   vault_address = %q
-  vault_token   = %q
+
+  auth {
+    token = %q
+  }
 }
 
 resource "sops_encrypted_json" "test" {
@@ -171,3 +204,1181 @@ func notEqualsPlaintext(plain string) func(string) error {
 		return nil
 	}
 }
+
+// TestAccEncryptedJSONResource_UniqueID encrypts the same content with the
+// same options twice and checks that the resources get distinct IDs.
+func TestAccEncryptedJSONResource_UniqueID(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+	recipient := envOrDefault("SOPS_AGE_TEST_RECIPIENT", "age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p")
+	t.Setenv("VAULT_ADDR", "")
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+provider "sops" {
+  age_recipients = [%q]
+}
+
+resource "sops_encrypted_json" "a" {
+  content = jsonencode({ password = "secret" })
+}
+
+resource "sops_encrypted_json" "b" {
+  content = jsonencode({ password = "secret" })
+}
+`, recipient),
+				Check: func(s *terraform.State) error {
+					a := s.RootModule().Resources["sops_encrypted_json.a"].Primary.ID
+					b := s.RootModule().Resources["sops_encrypted_json.b"].Primary.ID
+					if a == b {
+						return fmt.Errorf("both resources have ID %q", a)
+					}
+					return nil
+				},
+			},
+		},
+	})
+}
+
+// testCheckCiphertextAttributes checks that ciphertext_base64 and
+// ciphertext_sha256 match ciphertext.
+func testCheckCiphertextAttributes(name string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[name]
+		if !ok {
+			return fmt.Errorf("resource %s not found", name)
+		}
+		ciphertext := rs.Primary.Attributes["ciphertext"]
+		decoded, err := base64.StdEncoding.DecodeString(rs.Primary.Attributes["ciphertext_base64"])
+		if err != nil {
+			return fmt.Errorf("ciphertext_base64: %w", err)
+		}
+		if string(decoded) != ciphertext {
+			return fmt.Errorf("ciphertext_base64 does not decode to ciphertext")
+		}
+		if got, want := rs.Primary.Attributes["ciphertext_sha256"], fmt.Sprintf("%x", sha256.Sum256([]byte(ciphertext))); got != want {
+			return fmt.Errorf("ciphertext_sha256 = %q, want %q", got, want)
+		}
+		return nil
+	}
+}
+
+func TestAccEncryptedJSONResource_ProviderDefaultKeyName(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+	vaultAddr := requireEnv(t, "VAULT_ADDR")
+	vaultToken := requireEnv(t, "VAULT_TOKEN")
+	keyName := envOrDefault("SOPS_VAULT_KEY", "sops-test")
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccEncryptedJSONDefaultKeyConfig(vaultAddr, vaultToken, keyName),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttrSet("sops_encrypted_json.test", "ciphertext"),
+					resource.TestCheckResourceAttr("sops_encrypted_json.test", "vault_key_name", keyName),
+				),
+			},
+		},
+	})
+}
+
+func testAccEncryptedJSONDefaultKeyConfig(vaultAddr, vaultToken, keyName string) string {
+	return fmt.Sprintf(`
+provider "sops" {
+  vault_address          = %q
+  default_vault_key_name = %q
+
+  auth {
+    token = %q
+  }
+}
+
+resource "sops_encrypted_json" "test" {
+  content = jsonencode({ key = "value" })
+}
+`, vaultAddr, keyName, vaultToken)
+}
+
+// TestAccEncryptedJSONResource_Mock runs without Vault: the provider is in
+// mock mode and must not require an address or credentials.
+func TestAccEncryptedJSONResource_Mock(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+provider "sops" {
+  mock = true
+}
+
+resource "sops_encrypted_json" "test" {
+  content        = jsonencode({ password = "secret" })
+  vault_key_name = "sops-test"
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttrWith("sops_encrypted_json.test", "ciphertext",
+						func(v string) error {
+							if !strings.Contains(v, "ENC[MOCK,") {
+								return fmt.Errorf("expected mock placeholder ciphertext, got:\n%s", v)
+							}
+							return nil
+						}),
+					resource.TestCheckResourceAttr("sops_encrypted_json.test", "key_version", "0"),
+				),
+			},
+		},
+	})
+}
+
+// TestAccEncryptedJSONResource_CiphertextNonsensitive toggles
+// ciphertext_sensitive in place and checks that the copy follows ciphertext.
+func TestAccEncryptedJSONResource_CiphertextNonsensitive(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+	config := func(sensitive bool) string {
+		return fmt.Sprintf(`
+provider "sops" {
+  mock = true
+}
+
+resource "sops_encrypted_json" "test" {
+  content              = jsonencode({ password = "secret" })
+  vault_key_name       = "sops-test"
+  ciphertext_sensitive = %t
+}
+`, sensitive)
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: config(true),
+				Check:  resource.TestCheckNoResourceAttr("sops_encrypted_json.test", "ciphertext_nonsensitive"),
+			},
+			{
+				Config: config(false),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectResourceAction("sops_encrypted_json.test", plancheck.ResourceActionUpdate),
+					},
+				},
+				Check: resource.TestCheckResourceAttrPair(
+					"sops_encrypted_json.test", "ciphertext_nonsensitive",
+					"sops_encrypted_json.test", "ciphertext"),
+			},
+		},
+	})
+}
+
+// TestAccEncryptedJSONResource_LineLayout checks that trailing_newline and
+// line_endings lay out both ciphertexts and that line_endings is validated.
+func TestAccEncryptedJSONResource_LineLayout(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+	config := func(lineEndings string) string {
+		return fmt.Sprintf(`
+provider "sops" {
+  mock = true
+}
+
+resource "sops_encrypted_json" "test" {
+  content          = jsonencode({ password = "secret" })
+  vault_key_name   = "sops-test"
+  pretty           = true
+  trailing_newline = false
+  line_endings     = %q
+}
+`, lineEndings)
+	}
+	crlf := func(value string) error {
+		if strings.HasSuffix(value, "\n") {
+			return fmt.Errorf("ends with a newline")
+		}
+		if strings.Count(value, "\n") != strings.Count(value, "\r\n") {
+			return fmt.Errorf("has bare LF line breaks")
+		}
+		return nil
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config:      config("cr"),
+				ExpectError: regexp.MustCompile(`Invalid line_endings`),
+			},
+			{
+				Config: config("crlf"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttrWith("sops_encrypted_json.test", "ciphertext", crlf),
+					resource.TestCheckResourceAttrWith("sops_encrypted_json.test", "ciphertext_yaml", crlf),
+				),
+			},
+		},
+	})
+}
+
+// TestAccEncryptedJSONResource_StructurePreview checks that the preview is
+// known at plan time and follows the encryption scope.
+func TestAccEncryptedJSONResource_StructurePreview(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+	want := `{
+  "db": {
+    "host_unencrypted": "<plaintext>",
+    "password": "<encrypted>"
+  }
+}
+`
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+provider "sops" {
+  mock = true
+}
+
+resource "sops_encrypted_json" "test" {
+  content            = jsonencode({ db = { host_unencrypted = "db.internal", password = "secret" } })
+  unencrypted_suffix = "_unencrypted"
+  vault_key_name     = "sops-test"
+}
+`,
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectKnownValue("sops_encrypted_json.test", tfjsonpath.New("structure_preview"), knownvalue.StringExact(want)),
+					},
+				},
+				Check: resource.TestCheckResourceAttr("sops_encrypted_json.test", "structure_preview", want),
+			},
+		},
+	})
+}
+
+// TestAccEncryptedJSONResource_ContentObject encrypts a native object and
+// checks that value types survive encoding.
+func TestAccEncryptedJSONResource_ContentObject(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+	document := `{"database":{"password":"secret","port":5432,"tls":true}}`
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+provider "sops" {
+  mock = true
+}
+
+resource "sops_encrypted_json" "test" {
+  content_object = {
+    database = {
+      password = "secret"
+      port     = 5432
+      tls      = true
+    }
+  }
+  vault_key_name = "sops-test"
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("sops_encrypted_json.test", "content_sha256",
+						fmt.Sprintf("%x", sha256.Sum256([]byte(document)))),
+					resource.TestCheckResourceAttrWith("sops_encrypted_json.test", "ciphertext",
+						func(v string) error {
+							if !strings.Contains(v, "type:bool]") {
+								return fmt.Errorf("tls was not encrypted as a bool:\n%s", v)
+							}
+							return nil
+						}),
+				),
+			},
+		},
+	})
+}
+
+// TestAccEncryptedJSONResource_ContentSources deep-merges an override into a
+// base document, keeping the key order of the sources.
+func TestAccEncryptedJSONResource_ContentSources(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+	merged := `{"database":{"host":"prod.example.com","password":"secret"},"api_key":"prod-key"}`
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+provider "sops" {
+  mock = true
+}
+
+resource "sops_encrypted_json" "test" {
+  content_sources = [
+    jsonencode({ database = { host = "db.example.com", password = "secret" } }),
+    jsonencode({ database = { host = "prod.example.com" }, api_key = "prod-key" }),
+  ]
+  vault_key_name = "sops-test"
+}
+`,
+				Check: resource.TestCheckResourceAttr("sops_encrypted_json.test", "content_sha256",
+					fmt.Sprintf("%x", sha256.Sum256([]byte(merged)))),
+			},
+		},
+	})
+}
+
+// TestAccEncryptedJSONResource_ContentSourcesKeepYAMLOrder verifies that
+// unsorted YAML sources keep their key order in the merged document, with
+// keys added by later sources appended.
+func TestAccEncryptedJSONResource_ContentSourcesKeepYAMLOrder(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+	merged := `{"zeta":{"port":5432,"host":"prod"},"alpha":"a","beta":"b"}`
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+provider "sops" {
+  mock = true
+}
+
+resource "sops_encrypted_json" "test" {
+  content_sources = [
+    "zeta:\n  port: 5432\n  host: dev\nalpha: a\n",
+    "beta: b\nzeta:\n  host: prod\n",
+  ]
+  content_type   = "yaml"
+  vault_key_name = "sops-test"
+}
+`,
+				Check: resource.TestCheckResourceAttr("sops_encrypted_json.test", "content_sha256",
+					fmt.Sprintf("%x", sha256.Sum256([]byte(merged)))),
+			},
+		},
+	})
+}
+
+// TestAccEncryptedJSONResource_ContentSourcesTrailingData rejects sources
+// holding data after their object instead of silently dropping it.
+func TestAccEncryptedJSONResource_ContentSourcesTrailingData(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+	config := func(sources, contentType string) string {
+		return fmt.Sprintf(`
+provider "sops" {
+  mock = true
+}
+
+resource "sops_encrypted_json" "test" {
+  content_sources = %s
+  content_type    = %q
+  vault_key_name  = "sops-test"
+}
+`, sources, contentType)
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config:      config(`["{\"a\":1}", "{\"b\":2}{\"c\":3}"]`, "json"),
+				ExpectError: regexp.MustCompile(`content_sources\[1\]: parsing JSON: unexpected data after the JSON value`),
+			},
+			{
+				Config:      config(`["a: 1\n---\nb: 2\n"]`, "yaml"),
+				ExpectError: regexp.MustCompile(`content_sources\[0\]: parsing YAML: unexpected second YAML document`),
+			},
+		},
+	})
+}
+
+// TestAccEncryptedJSONResource_ContentTemplate renders a template with
+// sensitive vars before encrypting it.
+func TestAccEncryptedJSONResource_ContentTemplate(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+	rendered := `{"host":"db.example.com","password":"s\"cret"}`
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+provider "sops" {
+  mock = true
+}
+
+resource "sops_encrypted_json" "test" {
+  content_template = "{\"host\":\"db.example.com\",\"password\":$${jsonencode(password)}}"
+  vars = {
+    password = "s\"cret"
+  }
+  vault_key_name = "sops-test"
+}
+`,
+				Check: resource.TestCheckResourceAttr("sops_encrypted_json.test", "content_sha256",
+					fmt.Sprintf("%x", sha256.Sum256([]byte(rendered)))),
+			},
+		},
+	})
+}
+
+// TestAccEncryptedJSONResource_ReorderedContent checks that re-ordering keys
+// in content updates it in place without re-encrypting.
+func TestAccEncryptedJSONResource_ReorderedContent(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+	config := func(content string) string {
+		return fmt.Sprintf(`
+provider "sops" {
+  age_recipients = ["age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p"]
+}
+
+resource "sops_encrypted_json" "test" {
+  content = %q
+}
+`, content)
+	}
+
+	var first string
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: config(`{"a":"1","b":"2"}`),
+				Check: resource.TestCheckResourceAttrWith("sops_encrypted_json.test", "ciphertext",
+					func(v string) error { first = v; return nil }),
+			},
+			{
+				Config: config(`{ "b": "2", "a": "1" }`),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectResourceAction("sops_encrypted_json.test", plancheck.ResourceActionUpdate),
+					},
+				},
+				Check: resource.TestCheckResourceAttrWith("sops_encrypted_json.test", "ciphertext",
+					func(v string) error {
+						if v != first {
+							return fmt.Errorf("ciphertext changed although the document did not")
+						}
+						return nil
+					}),
+			},
+			{
+				Config: config(`{"a":"1","b":"3"}`),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectResourceAction("sops_encrypted_json.test", plancheck.ResourceActionReplace),
+					},
+				},
+			},
+		},
+	})
+}
+
+func TestAccEncryptedJSONResource_InvalidRegex(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+provider "sops" {
+  mock = true
+}
+
+resource "sops_encrypted_json" "test" {
+  content         = jsonencode({ password = "secret" })
+  vault_key_name  = "sops-test"
+  encrypted_regex = "^(password"
+}
+`,
+				ExpectError: regexp.MustCompile(`Invalid regular expression`),
+			},
+		},
+	})
+}
+
+func TestAccEncryptedJSONResource_InvalidTimeout(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+provider "sops" {
+  mock = true
+}
+
+resource "sops_encrypted_json" "test" {
+  content        = jsonencode({ password = "secret" })
+  vault_key_name = "sops-test"
+
+  timeouts {
+    create = "30 seconds"
+  }
+}
+`,
+				ExpectError: regexp.MustCompile(`Invalid duration`),
+			},
+		},
+	})
+}
+
+func TestAccEncryptedJSONResource_MissingKey(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+	vaultAddr := requireEnv(t, "VAULT_ADDR")
+	vaultToken := requireEnv(t, "VAULT_TOKEN")
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config:      testAccEncryptedJSONConfig(vaultAddr, vaultToken, "sops-test-does-not-exist", `{"password":"secret"}`),
+				ExpectError: regexp.MustCompile(`Vault Transit key not found`),
+			},
+		},
+	})
+}
+
+func TestAccEncryptedJSONResource_ErrorsRedactContent(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+
+	for name, content := range map[string]string{
+		"content":         `content      = "password: !!int hunter2secret"`,
+		"content_sources": `content_sources = ["{}", "password: !!int hunter2secret"]`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			resource.Test(t, resource.TestCase{
+				ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+				Steps: []resource.TestStep{
+					{
+						Config: fmt.Sprintf(`
+provider "sops" {
+  mock = true
+}
+
+resource "sops_encrypted_json" "test" {
+  %s
+  content_type = "yaml"
+}
+`, content),
+						// The error names the offending value only as (redacted).
+						ExpectError: regexp.MustCompile("cannot decode !!str `\\(redacted\\)` as a !!int"),
+					},
+				},
+			})
+		})
+	}
+}
+
+// TestAccEncryptedJSONResource_VaultAddress verifies that a resource-level
+// vault_address is used for encryption and recorded in the sops metadata.
+// The same Vault is reached under a second name, localhost for 127.0.0.1.
+func TestAccEncryptedJSONResource_VaultAddress(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+	vaultAddr := requireEnv(t, "VAULT_ADDR")
+	vaultToken := requireEnv(t, "VAULT_TOKEN")
+	keyName := envOrDefault("SOPS_VAULT_KEY", "sops-test")
+	override := strings.Replace(vaultAddr, "127.0.0.1", "localhost", 1)
+	if override == vaultAddr {
+		t.Skip("VAULT_ADDR must use 127.0.0.1 to reach Vault under a second address")
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+provider "sops" {
+  vault_address = %q
+
+  auth {
+    token = %q
+  }
+}
+
+resource "sops_encrypted_json" "test" {
+  content        = jsonencode({ password = "secret" })
+  vault_key_name = %q
+  vault_address  = %q
+}
+`, vaultAddr, vaultToken, keyName, override),
+				Check: resource.TestCheckResourceAttrWith("sops_encrypted_json.test", "ciphertext",
+					func(v string) error {
+						if !strings.Contains(v, fmt.Sprintf(`"vault_address": %q`, override)) {
+							return fmt.Errorf("ciphertext does not record vault_address %s", override)
+						}
+						return nil
+					}),
+			},
+		},
+	})
+}
+
+// TestAccEncryptedJSONResource_VaultAddressAgeOnly verifies that a
+// resource-level vault_address is rejected without a Vault-backed provider.
+func TestAccEncryptedJSONResource_VaultAddressAgeOnly(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+provider "sops" {
+  age_recipients = ["age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p"]
+}
+
+resource "sops_encrypted_json" "test" {
+  content       = jsonencode({ password = "secret" })
+  vault_address = "https://vault-eu.example.com:8200"
+}
+`,
+				ExpectError: regexp.MustCompile(`Vault not configured`),
+			},
+		},
+	})
+}
+
+// TestAccEncryptedJSONResource_VaultKeyNameAgeOnly verifies that a
+// resource-level vault_key_name is rejected without a Vault-backed provider
+// instead of being ignored.
+func TestAccEncryptedJSONResource_VaultKeyNameAgeOnly(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+provider "sops" {
+  age_recipients = ["age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p"]
+}
+
+resource "sops_encrypted_json" "test" {
+  content        = jsonencode({ password = "secret" })
+  vault_key_name = "sops-test"
+}
+`,
+				ExpectError: regexp.MustCompile(`Vault not configured`),
+			},
+		},
+	})
+}
+
+// TestAccEncryptedJSONResource_Timestamp verifies that timestamp replaces the
+// current time in the sops metadata and that invalid values are rejected.
+func TestAccEncryptedJSONResource_Timestamp(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+
+	config := func(timestamp string) string {
+		return fmt.Sprintf(`
+provider "sops" {
+  age_recipients = ["age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p"]
+}
+
+resource "sops_encrypted_json" "test" {
+  content   = jsonencode({ password = "secret" })
+  timestamp = %q
+}
+`, timestamp)
+	}
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config:      config("yesterday"),
+				ExpectError: regexp.MustCompile(`Invalid timestamp`),
+			},
+			{
+				Config: config("2024-01-01T00:00:00Z"),
+				Check: resource.TestCheckResourceAttrWith("sops_encrypted_json.test", "ciphertext",
+					func(v string) error {
+						if !strings.Contains(v, `"lastmodified": "2024-01-01T00:00:00Z"`) {
+							return fmt.Errorf("ciphertext does not record the fixed lastmodified: %s", v)
+						}
+						return nil
+					}),
+			},
+		},
+	})
+}
+
+// TestAccEncryptedJSONResource_FormatVersion verifies that sops_format_version
+// is recorded in the metadata and rejects releases older than 3.7.0.
+func TestAccEncryptedJSONResource_FormatVersion(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+
+	config := func(version string) string {
+		return fmt.Sprintf(`
+provider "sops" {
+  age_recipients = ["age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p"]
+}
+
+resource "sops_encrypted_json" "test" {
+  content             = jsonencode({ password = "secret" })
+  sops_format_version = %q
+}
+`, version)
+	}
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config:      config("3.6.1"),
+				ExpectError: regexp.MustCompile(`Invalid sops_format_version`),
+			},
+			{
+				Config: config("3.7.3"),
+				Check: resource.TestCheckResourceAttrWith("sops_encrypted_json.test", "ciphertext",
+					func(v string) error {
+						if !strings.Contains(v, `"version": "3.7.3"`) {
+							return fmt.Errorf("ciphertext does not record sops version 3.7.3: %s", v)
+						}
+						return nil
+					}),
+			},
+		},
+	})
+}
+
+// TestAccEncryptedJSONResource_EncryptedPaths verifies that encrypted_paths
+// encrypts only the selected subtrees, records them as encrypted_regex, and
+// conflicts with the other scope options.
+func TestAccEncryptedJSONResource_EncryptedPaths(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+
+	config := func(scope string) string {
+		return fmt.Sprintf(`
+provider "sops" {
+  age_recipients = ["age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p"]
+}
+
+resource "sops_encrypted_json" "test" {
+  content = jsonencode({
+    database    = { host = "db.example.com", password = "secret" }
+    credentials = { user = "admin", token = "t0ken" }
+  })
+  %s
+}
+`, scope)
+	}
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config:      config(`encrypted_paths = ["database..password"]`),
+				ExpectError: regexp.MustCompile(`Invalid encrypted_paths`),
+			},
+			{
+				Config:      config("encrypted_paths = [\"database.password\"]\n  encrypted_regex = \"^password$\""),
+				ExpectError: regexp.MustCompile(`Conflicting scope options`),
+			},
+			{
+				Config: config(`encrypted_paths = ["database.password", "credentials.*"]`),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckNoResourceAttr("sops_encrypted_json.test", "encrypted_regex"),
+					resource.TestCheckResourceAttrWith("sops_encrypted_json.test", "ciphertext",
+						func(v string) error {
+							if !strings.Contains(v, `"host": "db.example.com"`) {
+								return fmt.Errorf("database.host should stay in plaintext: %s", v)
+							}
+							if strings.Contains(v, "secret") || strings.Contains(v, "admin") {
+								return fmt.Errorf("selected values should be encrypted: %s", v)
+							}
+							if !strings.Contains(v, `"encrypted_regex": "^(credentials|password)$"`) {
+								return fmt.Errorf("ciphertext does not record the paths as encrypted_regex: %s", v)
+							}
+							return nil
+						}),
+				),
+			},
+		},
+	})
+}
+
+// TestAccEncryptedJSONResource_DataKey verifies that a data key from
+// sops_data_key is accepted without being stored, and that a wrapped key
+// requires the key it wraps.
+func TestAccEncryptedJSONResource_DataKey(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+
+	const config = `
+provider "sops" {
+  mock = true
+}
+
+ephemeral "sops_data_key" "shared" {
+  vault_key_name = "sops-test"
+}
+
+resource "sops_encrypted_json" "test" {
+  content        = jsonencode({ password = "secret" })
+  vault_key_name = "sops-test"
+  %s
+}
+`
+	resource.Test(t, resource.TestCase{
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(tfversion.Version1_11_0),
+		},
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config:      fmt.Sprintf(config, `wrapped_data_key_wo = ephemeral.sops_data_key.shared.wrapped_data_key`),
+				ExpectError: regexp.MustCompile(`wrapped_data_key_wo requires data_key_wo`),
+			},
+			{
+				Config: fmt.Sprintf(config, `data_key_wo         = ephemeral.sops_data_key.shared.data_key
+  wrapped_data_key_wo = ephemeral.sops_data_key.shared.wrapped_data_key`),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttrSet("sops_encrypted_json.test", "ciphertext"),
+					resource.TestCheckNoResourceAttr("sops_encrypted_json.test", "data_key_wo"),
+					resource.TestCheckNoResourceAttr("sops_encrypted_json.test", "wrapped_data_key_wo"),
+				),
+			},
+		},
+	})
+}
+
+// TestAccEncryptedJSONResource_KeyRotationOptionsConflict verifies that
+// rewrap_on_read and reencrypt_on_key_rotation cannot be combined.
+func TestAccEncryptedJSONResource_KeyRotationOptionsConflict(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+provider "sops" {
+  mock = true
+}
+
+resource "sops_encrypted_json" "test" {
+  content                   = jsonencode({ password = "secret" })
+  vault_key_name            = "sops-test"
+  rewrap_on_read            = true
+  reencrypt_on_key_rotation = true
+}
+`,
+				ExpectError: regexp.MustCompile(`Conflicting key rotation options`),
+			},
+		},
+	})
+}
+
+func TestAccEncryptedJSONResource_MaxContentSize(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+provider "sops" {
+  mock             = true
+  max_content_size = 64
+}
+
+resource "sops_encrypted_json" "test" {
+  content        = jsonencode({ blob = join("", [for i in range(100) : "x"]) })
+  vault_key_name = "sops-test"
+}
+`,
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile(`Content too large`),
+			},
+			{
+				Config: `
+provider "sops" {
+  mock             = true
+  max_content_size = 0
+}
+
+resource "sops_encrypted_json" "test" {
+  content        = jsonencode({ blob = join("", [for i in range(100) : "x"]) })
+  vault_key_name = "sops-test"
+}
+`,
+				Check: resource.TestCheckResourceAttrSet("sops_encrypted_json.test", "ciphertext"),
+			},
+		},
+	})
+}
+
+func TestAccEncryptedJSONResource_AlreadyEncrypted(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+provider "sops" {
+  mock = true
+}
+
+resource "sops_encrypted_json" "first" {
+  content        = jsonencode({ password = "hunter2" })
+  vault_key_name = "sops-test"
+}
+
+resource "sops_encrypted_json" "test" {
+  content        = sops_encrypted_json.first.ciphertext
+  vault_key_name = "sops-test"
+}
+`,
+				ExpectError: regexp.MustCompile(`Content already encrypted`),
+			},
+		},
+	})
+}
+
+func TestAccEncryptedJSONResource_VerifyOnRead(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+	vaultAddr := requireEnv(t, "VAULT_ADDR")
+	vaultToken := requireEnv(t, "VAULT_TOKEN")
+	keyName := envOrDefault("SOPS_VAULT_KEY", "sops-test")
+
+	config := fmt.Sprintf(`
+provider "sops" {
+  vault_address = %q
+
+  auth {
+    token = %q
+  }
+}
+
+resource "sops_encrypted_json" "test" {
+  content        = jsonencode({ key = "value" })
+  vault_key_name = %q
+  verify_on_read = true
+}
+`, vaultAddr, vaultToken, keyName)
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: config,
+				Check:  resource.TestCheckResourceAttr("sops_encrypted_json.test", "verify_on_read", "true"),
+			},
+			{
+				// An untouched ciphertext verifies and plans no change.
+				Config:   config,
+				PlanOnly: true,
+			},
+		},
+	})
+}
+
+// TestAccEncryptedJSONResource_ImportFile checks that a file: import adopts
+// the file as ciphertext and fills the attributes its metadata records.
+func TestAccEncryptedJSONResource_ImportFile(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+	document, err := sopsencrypt.EncryptToJSON(nil, "transit", "sops-test", `{"password":"secret"}`,
+		sopsencrypt.EncryptOpts{Mock: true, EncryptedRegex: "^password$"})
+	if err != nil {
+		t.Fatalf("EncryptToJSON: %v", err)
+	}
+	name := filepath.Join(t.TempDir(), "secrets.enc.json")
+	if err := os.WriteFile(name, []byte(document), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+provider "sops" {
+  mock = true
+}
+
+resource "sops_encrypted_json" "test" {
+  content         = jsonencode({ password = "secret" })
+  vault_key_name  = "sops-test"
+  encrypted_regex = "^password$"
+}
+`,
+				ResourceName:       "sops_encrypted_json.test",
+				ImportState:        true,
+				ImportStateId:      "file:" + name,
+				ImportStatePersist: true,
+				ImportStateCheck: func(states []*terraform.InstanceState) error {
+					attrs := states[0].Attributes
+					for key, want := range map[string]string{
+						"ciphertext":      document,
+						"vault_key_name":  "sops-test",
+						"encrypted_regex": "^password$",
+						"pretty":          "false",
+					} {
+						if attrs[key] != want {
+							return fmt.Errorf("%s = %q, want %q", key, attrs[key], want)
+						}
+					}
+					return nil
+				},
+			},
+		},
+	})
+}
+
+// TestAccEncryptedJSONResource_DataKeyCache verifies that with data_key_cache
+// resources encrypted with the same Transit key share one wrapped data key.
+func TestAccEncryptedJSONResource_DataKeyCache(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+	vaultAddr := requireEnv(t, "VAULT_ADDR")
+	vaultToken := requireEnv(t, "VAULT_TOKEN")
+	keyName := envOrDefault("SOPS_VAULT_KEY", "sops-test")
+
+	wrappedKey := regexp.MustCompile(`"enc":\s*"(vault:v\d+:[^"]+)"`)
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+provider "sops" {
+  vault_address  = %q
+  data_key_cache = true
+
+  auth {
+    token = %q
+  }
+}
+
+resource "sops_encrypted_json" "a" {
+  content        = jsonencode({ password = "a" })
+  vault_key_name = %[3]q
+}
+
+resource "sops_encrypted_json" "b" {
+  content        = jsonencode({ password = "b" })
+  vault_key_name = %[3]q
+}
+`, vaultAddr, vaultToken, keyName),
+				Check: func(s *terraform.State) error {
+					var keys []string
+					for _, name := range []string{"sops_encrypted_json.a", "sops_encrypted_json.b"} {
+						m := wrappedKey.FindStringSubmatch(s.RootModule().Resources[name].Primary.Attributes["ciphertext"])
+						if m == nil {
+							return fmt.Errorf("%s records no wrapped data key", name)
+						}
+						keys = append(keys, m[1])
+					}
+					if keys[0] != keys[1] {
+						return fmt.Errorf("wrapped data keys differ: %q, %q", keys[0], keys[1])
+					}
+					return nil
+				},
+			},
+		},
+	})
+}
+
+// TestAccEncryptedJSONResource_SigningKey verifies that ciphertext is signed
+// when signing_key_name is set, and that signing needs Vault.
+func TestAccEncryptedJSONResource_SigningKey(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+provider "sops" {
+  mock = true
+}
+
+resource "sops_encrypted_json" "test" {
+  content          = jsonencode({ password = "secret" })
+  vault_key_name   = "sops-test"
+  signing_key_name = "gitops-signer"
+}
+`,
+				Check: resource.TestCheckResourceAttr("sops_encrypted_json.test", "signature", "vault:v0:mock-signature"),
+			},
+			{
+				Config: `
+provider "sops" {
+  age_recipients = ["age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p"]
+}
+
+resource "sops_encrypted_json" "test" {
+  content          = jsonencode({ password = "secret" })
+  signing_key_name = "gitops-signer"
+}
+`,
+				ExpectError: regexp.MustCompile(`signing_key_name requires Vault`),
+			},
+		},
+	})
+}
@@ -0,0 +1,674 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/boolplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/mapplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"terraform-provider-sops/internal/sopsencrypt"
+)
+
+var (
+	_ resource.Resource                   = &encryptedKubernetesSecretResource{}
+	_ resource.ResourceWithConfigure      = &encryptedKubernetesSecretResource{}
+	_ resource.ResourceWithImportState    = &encryptedKubernetesSecretResource{}
+	_ resource.ResourceWithModifyPlan     = &encryptedKubernetesSecretResource{}
+	_ resource.ResourceWithValidateConfig = &encryptedKubernetesSecretResource{}
+)
+
+// kubernetesEncryptedRegex limits encryption to the payload of the manifest,
+// leaving apiVersion, kind and metadata readable by kustomize and Flux.
+const kubernetesEncryptedRegex = "^(data|stringData)$"
+
+type encryptedKubernetesSecretResource struct{ pd *sopsProviderData }
+
+type encryptedKubernetesSecretModel struct {
+	ID                     types.String   `tfsdk:"id"`
+	Name                   types.String   `tfsdk:"name"`
+	Namespace              types.String   `tfsdk:"namespace"`
+	Kind                   types.String   `tfsdk:"kind"`
+	Type                   types.String   `tfsdk:"type"`
+	Labels                 types.Map      `tfsdk:"labels"`
+	Annotations            types.Map      `tfsdk:"annotations"`
+	Data                   types.Map      `tfsdk:"data"`
+	VaultKeyName           types.String   `tfsdk:"vault_key_name"`
+	VaultAddress           types.String   `tfsdk:"vault_address"`
+	VaultTransitEngine     types.String   `tfsdk:"vault_transit_engine"`
+	SigningKeyName         types.String   `tfsdk:"signing_key_name"`
+	Timestamp              types.String   `tfsdk:"timestamp"`
+	SOPSFormatVersion      types.String   `tfsdk:"sops_format_version"`
+	HeaderComment          types.String   `tfsdk:"header_comment"`
+	TrailingNewline        types.Bool     `tfsdk:"trailing_newline"`
+	LineEndings            types.String   `tfsdk:"line_endings"`
+	DataKeyWO              types.String   `tfsdk:"data_key_wo"`
+	WrappedDataKeyWO       types.String   `tfsdk:"wrapped_data_key_wo"`
+	RewrapOnRead           types.Bool     `tfsdk:"rewrap_on_read"`
+	ReencryptOnKeyRotation types.Bool     `tfsdk:"reencrypt_on_key_rotation"`
+	VerifyOnRead           types.Bool     `tfsdk:"verify_on_read"`
+	CiphertextSensitive    types.Bool     `tfsdk:"ciphertext_sensitive"`
+	Timeouts               *timeoutsModel `tfsdk:"timeouts"`
+	KSOPSFilename          types.String   `tfsdk:"ksops_filename"`
+	KSOPSGenerator         types.String   `tfsdk:"ksops_generator"`
+	ciphertextModel
+}
+
+// kubernetesManifest is the plaintext document that gets encrypted. Field
+// order follows the usual layout of a Kubernetes manifest.
+type kubernetesManifest struct {
+	APIVersion string             `json:"apiVersion"`
+	Kind       string             `json:"kind"`
+	Metadata   kubernetesMetadata `json:"metadata"`
+	Type       string             `json:"type,omitempty"`
+	StringData map[string]string  `json:"stringData,omitempty"`
+	Data       map[string]string  `json:"data,omitempty"`
+}
+
+type kubernetesMetadata struct {
+	Name        string            `json:"name"`
+	Namespace   string            `json:"namespace,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+func NewEncryptedKubernetesSecretResource() resource.Resource {
+	return &encryptedKubernetesSecretResource{}
+}
+
+func (r *encryptedKubernetesSecretResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_encrypted_kubernetes_secret"
+}
+
+func (r *encryptedKubernetesSecretResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: `Builds a Kubernetes Secret (or ConfigMap) manifest and encrypts it using
+SOPS with a Vault Transit key. Only stringData (data for a ConfigMap) is
+encrypted, so the result can be committed to a GitOps repository and applied
+by Flux with SOPS decryption enabled:
+
+    resource "sops_encrypted_kubernetes_secret" "example" {
+      name      = "db-credentials"
+      namespace = "app"
+      data = {
+        password = var.db_pass
+      }
+      vault_key_name = "my-key"
+    }
+
+The ciphertext is stable across plans until an input changes, at which point
+the resource is replaced and re-encrypted.`,
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:    true,
+				Description: "namespace/name of the manifest, or name when namespace is not set.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"name": schema.StringAttribute{
+				Required:    true,
+				Description: "metadata.name of the manifest.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"namespace": schema.StringAttribute{
+				Optional:    true,
+				Description: "metadata.namespace of the manifest. When omitted, the namespace is left to the tool that applies it.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"kind": schema.StringAttribute{
+				Optional:    true,
+				Computed:    true,
+				Description: "Kind of the manifest: Secret or ConfigMap. Defaults to Secret.",
+				Default:     stringdefault.StaticString("Secret"),
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"type": schema.StringAttribute{
+				Optional:    true,
+				Description: "Secret type, e.g. kubernetes.io/dockerconfigjson. Kubernetes defaults to Opaque. Only valid with kind Secret.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"labels": schema.MapAttribute{
+				ElementType: types.StringType,
+				Optional:    true,
+				Description: "metadata.labels of the manifest. Left in plaintext.",
+				PlanModifiers: []planmodifier.Map{
+					mapplanmodifier.RequiresReplace(),
+				},
+			},
+			"annotations": schema.MapAttribute{
+				ElementType: types.StringType,
+				Optional:    true,
+				Description: "metadata.annotations of the manifest. Left in plaintext.",
+				PlanModifiers: []planmodifier.Map{
+					mapplanmodifier.RequiresReplace(),
+				},
+			},
+			"data": schema.MapAttribute{
+				ElementType: types.StringType,
+				Required:    true,
+				Sensitive:   true,
+				Description: "Plaintext values, written as stringData for a Secret and data for a ConfigMap, and encrypted.",
+				PlanModifiers: []planmodifier.Map{
+					mapplanmodifier.RequiresReplace(),
+				},
+			},
+			"vault_key_name": schema.StringAttribute{
+				Optional:    true,
+				Computed:    true,
+				Description: "Name of the Vault Transit key used to wrap the data key. Defaults to the provider-level default_vault_key_name. An error in age-only mode.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"vault_address": schema.StringAttribute{
+				Optional:    true,
+				Description: "Vault server for this resource, e.g. https://vault-eu.example.com:8200. Overrides the provider-level vault_address. The provider's Vault token, obtained from the provider-level server, must be valid here too. Recorded in the sops metadata. Ignored in mock mode.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"vault_transit_engine": schema.StringAttribute{
+				Optional:    true,
+				Description: "Vault Transit mount path for this resource. Overrides the provider-level vault_transit_engine. Defaults to 'transit'.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"signing_key_name": schema.StringAttribute{
+				Optional: true,
+				Description: "Vault Transit signing key, e.g. of type ed25519 or ecdsa-p256, in vault_transit_engine to sign ciphertext with, " +
+					"so that consumers of the committed file can verify its provenance. The signature is exported as signature.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"timestamp": schema.StringAttribute{
+				Optional: true,
+				Description: "Time recorded as lastmodified and as the created_at of the Vault key source instead of the current time, so " +
+					"re-encrypting identical inputs yields identical metadata for reproducible builds: an RFC 3339 time such as " +
+					"\"2024-01-01T00:00:00Z\", or \"content\" to derive a fixed time from the SHA-256 of the document. The encrypted values, " +
+					"the wrapped data key and the MAC still change on every encryption.",
+				Validators: []validator.String{validTimestamp()},
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"sops_format_version": schema.StringAttribute{
+				Optional: true,
+				Description: "sops release recorded as the version of the document instead of the one built into the provider, " +
+					"e.g. \"3.7.3\" for consumers that still run an older sops. Must be 3.7.0 or later; every metadata field the " +
+					"provider writes is understood from 3.7.0 on.",
+				Validators: []validator.String{validFormatVersion()},
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"header_comment": schema.StringAttribute{
+				Optional: true,
+				Description: "Text written above the YAML ciphertext as comment lines, e.g. \"Managed by Terraform — do not edit\". Each line gets a \"# \" prefix unless it already starts with \"#\". " +
+					"The comment stays in plaintext and is not covered by the MAC. Changing it forces replacement.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"trailing_newline": schema.BoolAttribute{
+				Optional:    true,
+				Computed:    true,
+				Description: "End the YAML ciphertext and its JSON form with a newline. Defaults to true; set to false for consumers that compare files byte for byte against output without one. Changing it forces replacement.",
+				Default:     booldefault.StaticBool(true),
+				PlanModifiers: []planmodifier.Bool{
+					boolplanmodifier.RequiresReplace(),
+				},
+			},
+			"line_endings": schema.StringAttribute{
+				Optional:    true,
+				Computed:    true,
+				Description: "Line breaks of the YAML ciphertext and its JSON form: \"lf\" (default) or \"crlf\" for Windows consumers. Neither changes the encrypted values or the MAC. Changing it forces replacement.",
+				Default:     stringdefault.StaticString(sopsencrypt.LineEndingsLF),
+				Validators:  []validator.String{validLineEndings()},
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"data_key_wo": schema.StringAttribute{
+				Optional:  true,
+				Sensitive: true,
+				WriteOnly: true,
+				Description: "Base64-encoded 32-byte data key to encrypt the document with instead of a random one, typically the data_key of a " +
+					"sops_data_key ephemeral resource, so several documents share one key. Write-only: it is never stored, only used when " +
+					"the document is encrypted, and changing it alone does not re-encrypt. Requires Terraform 1.11 or later.",
+				Validators: []validator.String{validDataKey()},
+			},
+			"wrapped_data_key_wo": schema.StringAttribute{
+				Optional:  true,
+				WriteOnly: true,
+				Description: "Vault Transit ciphertext of data_key_wo under this resource's Transit key, typically the wrapped_data_key of the " +
+					"same sops_data_key, recorded as is so the documents share one wrapped key. When unset, data_key_wo is wrapped again. " +
+					"It is not checked against data_key_wo: a mismatch yields a document that cannot be decrypted. Write-only.",
+			},
+			"rewrap_on_read":            rewrapOnReadAttribute(),
+			"reencrypt_on_key_rotation": reencryptOnKeyRotationAttribute(),
+			"verify_on_read":            verifyOnReadAttribute(),
+			"ksops_filename": schema.StringAttribute{
+				Optional:    true,
+				Description: "Path, relative to the kustomization, that ksops_generator expects the ciphertext at. Defaults to '<name>.enc.yaml'.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"ksops_generator": schema.StringAttribute{
+				Computed:    true,
+				Description: "KSOPS generator manifest that decrypts the file at ksops_filename, for Argo CD with KSOPS. List it under generators in kustomization.yaml.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"ciphertext": schema.StringAttribute{
+				Computed:    true,
+				Sensitive:   true,
+				Description: "SOPS-encrypted YAML manifest, ready to commit for Flux. Decryptable with `sops -d --input-type yaml`.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"ciphertext_sensitive": schema.BoolAttribute{
+				Optional: true,
+				Computed: true,
+				Default:  booldefault.StaticBool(true),
+				Description: "Whether ciphertext is only available as a sensitive value. Set to false to also expose it as " +
+					"ciphertext_nonsensitive, which plan diffs and outputs show: it is encrypted, so reviewing generated files needs no secrets. " +
+					"Terraform fixes sensitivity per attribute, so ciphertext itself stays sensitive.",
+			},
+			"ciphertext_nonsensitive": schema.StringAttribute{
+				Computed:    true,
+				Description: "The same as ciphertext, but not marked sensitive. Null unless ciphertext_sensitive is false.",
+			},
+			"ciphertext_base64": schema.StringAttribute{
+				Computed:    true,
+				Sensitive:   true,
+				Description: "ciphertext encoded as standard base64.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"ciphertext_sha256": schema.StringAttribute{
+				Computed:    true,
+				Description: "Hex-encoded SHA-256 of ciphertext, for verifying the integrity of the encrypted manifest.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"signature": schema.StringAttribute{
+				Computed: true,
+				Description: "Vault Transit signature of ciphertext by signing_key_name, e.g. \"vault:v1:…\", over the exact bytes of ciphertext. " +
+					"Verify it with the key's verify endpoint or exported public key. Null unless signing_key_name is set.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"vault_request_id": schema.StringAttribute{
+				Computed:    true,
+				Description: "ID of the Vault Transit request that wrapped the data key, as recorded in the Vault audit log. Null in mock and age-only mode and when wrapped_data_key_wo is set.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"encrypted_at": schema.StringAttribute{
+				Computed:    true,
+				Description: "Time of the encryption, in RFC 3339 format. Unlike the document's lastmodified, it is not affected by timestamp.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"vault_entity_id": schema.StringAttribute{
+				Computed:    true,
+				Description: "Identity entity of the Vault token that encrypted the document, from token lookup-self. Null without Vault or for tokens without an entity.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"vault_accessor": schema.StringAttribute{
+				Computed:    true,
+				Description: "Accessor of the Vault token that encrypted the document, from token lookup-self, as recorded in the Vault audit log. Null without Vault.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"key_version": schema.Int64Attribute{
+				Computed:    true,
+				Description: "Version of the Vault Transit key that wrapped the data key, parsed from the vault:vN: prefix of the wrapped key. Null in age-only mode; 0 in mock mode.",
+				PlanModifiers: []planmodifier.Int64{
+					int64planmodifier.UseStateForUnknown(),
+				},
+			},
+			"ciphertext_json": schema.StringAttribute{
+				Computed:    true,
+				Sensitive:   true,
+				Description: "The encrypted manifest serialised as compact SOPS JSON. Shares its data key and MAC with ciphertext_yaml.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"ciphertext_yaml": schema.StringAttribute{
+				Computed:    true,
+				Sensitive:   true,
+				Description: "The encrypted manifest serialised as SOPS YAML 1.2, the same as ciphertext. Shares its data key and MAC with ciphertext_json.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+		},
+		Blocks: map[string]schema.Block{
+			"timeouts": resourceTimeoutsBlock(),
+		},
+	}
+}
+
+func (r *encryptedKubernetesSecretResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	pd, ok := req.ProviderData.(*sopsProviderData)
+	if !ok {
+		resp.Diagnostics.AddError("Unexpected provider data type",
+			fmt.Sprintf("Expected *sopsProviderData, got %T", req.ProviderData))
+		return
+	}
+	r.pd = pd
+}
+
+// ValidateConfig rejects kinds other than Secret and ConfigMap, a Secret type
+// on a ConfigMap, and a wrapped data key without its key.
+func (r *encryptedKubernetesSecretResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var data encryptedKubernetesSecretModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	dataKeyConfig{data.DataKeyWO, data.WrappedDataKeyWO}.validate(&resp.Diagnostics)
+	validateKeyRotation(data.RewrapOnRead, data.ReencryptOnKeyRotation, &resp.Diagnostics)
+	if data.Kind.IsUnknown() {
+		return
+	}
+	switch kind := data.Kind.ValueString(); kind {
+	case "", "Secret":
+	case "ConfigMap":
+		if !data.Type.IsNull() {
+			resp.Diagnostics.AddAttributeError(path.Root("type"),
+				"Invalid attribute combination",
+				"type applies to kind Secret only.")
+		}
+	default:
+		resp.Diagnostics.AddAttributeError(path.Root("kind"),
+			"Invalid kind",
+			fmt.Sprintf("Expected Secret or ConfigMap, got %q.", kind))
+	}
+}
+
+func (r *encryptedKubernetesSecretResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var data encryptedKubernetesSecretModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	// The plan leaves vault_key_name unknown when the provider was not yet
+	// configured during plan; resolve the provider default now.
+	if data.VaultKeyName.IsUnknown() || data.VaultKeyName.IsNull() {
+		keyName := r.pd.vaultKeyName(types.StringNull())
+		if keyName == "" && r.pd.usesVault() {
+			resp.Diagnostics.AddAttributeError(path.Root("vault_key_name"),
+				"Missing Vault key name",
+				"Set vault_key_name on the resource or default_vault_key_name on the provider.")
+			return
+		}
+		data.VaultKeyName = types.StringNull()
+		if keyName != "" {
+			data.VaultKeyName = types.StringValue(keyName)
+		}
+	}
+
+	manifest, diags := kubernetesManifestJSON(ctx, data)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	// Data unknown during plan is only checked now.
+	r.pd.checkContentSize(path.Root("data"), len(manifest), &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	dataKey := getDataKeyConfig(ctx, req.Config, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	var audit sopsencrypt.EncryptAudit
+	encryptedAt := time.Now()
+	jsonOut, yamlOut, err := r.encrypt(ctx, data, dataKey, manifest, &audit)
+	if err != nil {
+		addVaultError(&resp.Diagnostics, path.Root("vault_key_name"), "SOPS encryption failed", err)
+		return
+	}
+
+	data.ID = types.StringValue(data.Name.ValueString())
+	if isSet(data.Namespace) {
+		data.ID = types.StringValue(data.Namespace.ValueString() + "/" + data.Name.ValueString())
+	}
+	generator, err := ksopsGenerator(data)
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to generate KSOPS generator", err.Error())
+		return
+	}
+	data.KSOPSGenerator = types.StringValue(generator)
+	data.set(yamlOut)
+	data.setAudit(ctx, r.pd, data.VaultAddress, audit, encryptedAt, data.Timeouts.create(), &resp.Diagnostics)
+	if err := data.sign(ctx, r.pd, data.VaultAddress, data.VaultTransitEngine, data.SigningKeyName, data.Timeouts.create()); err != nil {
+		addVaultError(&resp.Diagnostics, path.Root("signing_key_name"), "Failed to sign ciphertext", err)
+		return
+	}
+	data.expose(data.CiphertextSensitive)
+	data.CiphertextJSON = types.StringValue(jsonOut)
+	data.CiphertextYAML = types.StringValue(yamlOut)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// Read makes no remote calls unless rewrap_on_read, reencrypt_on_key_rotation,
+// verify_on_read or stale_key_version_margin is set: ciphertext in state
+// remains valid until inputs change. It checks the ciphertext for out-of-band
+// edits and otherwise only derives ksops_generator for resources created before
+// that attribute existed.
+func (r *encryptedKubernetesSecretResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var data encryptedKubernetesSecretModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	data.checkIntegrity(ctx, r.pd, data.VaultAddress, data.VerifyOnRead, data.Timeouts.read(), resp.Private, &resp.Diagnostics)
+	if data.KSOPSGenerator.IsNull() && isSet(data.Name) {
+		if generator, err := ksopsGenerator(data); err == nil {
+			data.KSOPSGenerator = types.StringValue(generator)
+		}
+	}
+	if data.KeyVersion.IsNull() && !data.Ciphertext.IsNull() {
+		data.set(data.Ciphertext.ValueString())
+	}
+	if data.RewrapOnRead.ValueBool() {
+		previous := data.Ciphertext
+		data.rewrap(ctx, r.pd, data.VaultAddress, data.Timeouts.read(), &resp.Diagnostics)
+		data.resign(ctx, r.pd, previous, data.VaultAddress, data.VaultTransitEngine, data.SigningKeyName, data.Timeouts.read(), &resp.Diagnostics)
+	}
+	data.checkKeyVersion(ctx, r.pd, data.VaultAddress, data.VaultTransitEngine, data.VaultKeyName, data.ReencryptOnKeyRotation, data.Timeouts.read(), resp.Private, &resp.Diagnostics)
+	backfillLineLayout(&data.TrailingNewline, &data.LineEndings)
+	data.expose(data.CiphertextSensitive)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// Update is only reached when rewrap_on_read, reencrypt_on_key_rotation,
+// verify_on_read or ciphertext_sensitive is toggled or timeouts change; every
+// other attribute carries RequiresReplace. The existing ciphertext is kept.
+func (r *encryptedKubernetesSecretResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var data, state encryptedKubernetesSecretModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	data.ID = state.ID
+	data.KSOPSGenerator = state.KSOPSGenerator
+	data.ciphertextModel = state.ciphertextModel
+	data.expose(data.CiphertextSensitive)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *encryptedKubernetesSecretResource) Delete(_ context.Context, _ resource.DeleteRequest, _ *resource.DeleteResponse) {
+}
+
+func (r *encryptedKubernetesSecretResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// Nothing to resolve on destroy or before the provider is configured.
+	if req.Plan.Raw.IsNull() || r.pd == nil {
+		return
+	}
+	planVaultKeyName(ctx, r.pd, req, resp)
+	planVaultAddress(ctx, r.pd, req, resp)
+	planKeyRotation(ctx, req, resp)
+	planModified(ctx, req, resp)
+	planNonsensitive(ctx, resp)
+
+	// Report an oversized manifest and the data keys a change replaces at plan
+	// time once the inputs are known.
+	var data encryptedKubernetesSecretModel
+	resp.Diagnostics.Append(resp.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() || data.Data.IsUnknown() || data.Labels.IsUnknown() || data.Annotations.IsUnknown() {
+		return
+	}
+	if manifest, diags := kubernetesManifestJSON(ctx, data); !diags.HasError() {
+		r.pd.checkContentSize(path.Root("data"), len(manifest), &resp.Diagnostics)
+	}
+	if !req.State.Raw.IsNull() {
+		var state encryptedKubernetesSecretModel
+		resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		var oldValues, newValues map[string]string
+		resp.Diagnostics.Append(state.Data.ElementsAs(ctx, &oldValues, false)...)
+		resp.Diagnostics.Append(data.Data.ElementsAs(ctx, &newValues, false)...)
+		oldJSON, oldErr := json.Marshal(oldValues)
+		newJSON, newErr := json.Marshal(newValues)
+		if !resp.Diagnostics.HasError() && oldErr == nil && newErr == nil {
+			warnKeyDiff(path.Root("data"), string(oldJSON), false, string(newJSON), false, &resp.Diagnostics)
+		}
+	}
+}
+
+func (r *encryptedKubernetesSecretResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+}
+
+// kubernetesManifestJSON builds the plaintext manifest as JSON, the input
+// format of the sopsencrypt package.
+func kubernetesManifestJSON(ctx context.Context, data encryptedKubernetesSecretModel) (string, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	manifest := kubernetesManifest{
+		APIVersion: "v1",
+		Kind:       data.Kind.ValueString(),
+		Metadata: kubernetesMetadata{
+			Name:      data.Name.ValueString(),
+			Namespace: data.Namespace.ValueString(),
+		},
+		Type: data.Type.ValueString(),
+	}
+	if !data.Labels.IsNull() {
+		diags.Append(data.Labels.ElementsAs(ctx, &manifest.Metadata.Labels, false)...)
+	}
+	if !data.Annotations.IsNull() {
+		diags.Append(data.Annotations.ElementsAs(ctx, &manifest.Metadata.Annotations, false)...)
+	}
+	var values map[string]string
+	diags.Append(data.Data.ElementsAs(ctx, &values, false)...)
+	if diags.HasError() {
+		return "", diags
+	}
+	if manifest.Kind == "ConfigMap" {
+		manifest.Data = values
+	} else {
+		manifest.StringData = values
+	}
+
+	out, err := json.Marshal(manifest)
+	if err != nil {
+		diags.AddError("Failed to build Kubernetes manifest", err.Error())
+		return "", diags
+	}
+	return string(out), diags
+}
+
+// ksopsGenerator renders the KSOPS generator manifest for the resource's
+// encrypted file.
+func ksopsGenerator(data encryptedKubernetesSecretModel) (string, error) {
+	filename := resolveStringDefault(data.KSOPSFilename, data.Name.ValueString()+".enc.yaml")
+	return sopsencrypt.GenerateKSOPSGenerator(data.Name.ValueString()+"-ksops", []string{filename})
+}
+
+// encrypt returns the manifest serialised as JSON and as YAML, both from a
+// single encryption, and records the Vault request that wrapped its data key
+// in audit.
+func (r *encryptedKubernetesSecretResource) encrypt(ctx context.Context, data encryptedKubernetesSecretModel, dataKey dataKeyConfig, manifest string, audit *sopsencrypt.EncryptAudit) (jsonOut, yamlOut string, err error) {
+	client, err := r.pd.newVaultClientAt(data.VaultAddress.ValueString(), data.Timeouts.create())
+	if err != nil {
+		return "", "", err
+	}
+	transitEngine := data.VaultTransitEngine.ValueString()
+	if transitEngine == "" {
+		transitEngine = r.pd.vaultTransitEngine
+	}
+	opts := sopsencrypt.EncryptOpts{
+		EncryptedRegex: kubernetesEncryptedRegex,
+		AgeRecipients:  r.pd.ageRecipients,
+		CreateKeyType:  r.pd.createKeyType,
+		Mock:           r.pd.mock,
+	}
+	opts.Audit = audit
+	setTimestamp(&opts, data.Timestamp)
+	opts.FormatVersion = data.SOPSFormatVersion.ValueString()
+	opts.HeaderComment = data.HeaderComment.ValueString()
+	setLineLayout(&opts, data.TrailingNewline, data.LineEndings)
+	if err := dataKey.set(&opts); err != nil {
+		return "", "", err
+	}
+	ctx = r.pd.logContext(ctx, client, r.pd.vaultAddressFor(data.VaultAddress), transitEngine, data.VaultKeyName.ValueString(), manifest)
+	tflog.Debug(ctx, "Encrypting document", map[string]any{"content_bytes": len(manifest)})
+	release, err := r.pd.acquireVault(ctx)
+	if err != nil {
+		return "", "", err
+	}
+	defer release()
+	if err := r.pd.setCachedDataKey(client, transitEngine, data.VaultKeyName.ValueString(), &opts); err != nil {
+		return "", "", err
+	}
+	start := time.Now()
+	jsonOut, yamlOut, err = sopsencrypt.EncryptToJSONAndYAML(client, transitEngine, data.VaultKeyName.ValueString(), manifest, opts)
+	logResult(ctx, "Encryption", start, err)
+	return jsonOut, yamlOut, err
+}
@@ -0,0 +1,73 @@
+package provider_test
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+func TestAccEncryptedKubernetesSecretResource(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccEncryptedKubernetesSecretConfig("Secret"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("sops_encrypted_kubernetes_secret.test", "id", "app/db-credentials"),
+					resource.TestCheckResourceAttrWith("sops_encrypted_kubernetes_secret.test", "ciphertext",
+						manifestContains("kind: Secret", "name: db-credentials", "namespace: app", "app: web", "stringData:", "password: ENC[")),
+					resource.TestCheckResourceAttrWith("sops_encrypted_kubernetes_secret.test", "ksops_generator",
+						manifestContains("kind: ksops", "name: db-credentials-ksops", "path: ksops", "- db-credentials.enc.yaml")),
+				),
+			},
+			{
+				Config: testAccEncryptedKubernetesSecretConfig("ConfigMap"),
+				Check: resource.TestCheckResourceAttrWith("sops_encrypted_kubernetes_secret.test", "ciphertext",
+					manifestContains("kind: ConfigMap", "\ndata:", "password: ENC[")),
+			},
+		},
+	})
+}
+
+// manifestContains checks that the manifest contains every string in want and
+// not the plaintext of the test value.
+func manifestContains(want ...string) func(string) error {
+	return func(v string) error {
+		for _, w := range want {
+			if !strings.Contains(v, w) {
+				return fmt.Errorf("manifest missing %q; got:\n%s", w, v)
+			}
+		}
+		if strings.Contains(v, "s3cret") {
+			return fmt.Errorf("manifest contains the plaintext value")
+		}
+		return nil
+	}
+}
+
+func testAccEncryptedKubernetesSecretConfig(kind string) string {
+	return fmt.Sprintf(`
+provider "sops" {
+  age_recipients = ["age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p"]
+}
+
+resource "sops_encrypted_kubernetes_secret" "test" {
+  name      = "db-credentials"
+  namespace = "app"
+  kind      = %q
+  labels = {
+    app = "web"
+  }
+  data = {
+    password = "s3cret"
+  }
+}
+`, kind)
+}
@@ -0,0 +1,600 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/boolplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/dynamicplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/listplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"terraform-provider-sops/internal/sopsencrypt"
+)
+
+var (
+	_ resource.Resource                   = &encryptedTFVarsResource{}
+	_ resource.ResourceWithConfigure      = &encryptedTFVarsResource{}
+	_ resource.ResourceWithImportState    = &encryptedTFVarsResource{}
+	_ resource.ResourceWithModifyPlan     = &encryptedTFVarsResource{}
+	_ resource.ResourceWithValidateConfig = &encryptedTFVarsResource{}
+)
+
+// tfvarsVariableName matches the names Terraform accepts for input
+// variables.
+var tfvarsVariableName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+type encryptedTFVarsResource struct{ pd *sopsProviderData }
+
+type encryptedTFVarsModel struct {
+	ID                     types.String   `tfsdk:"id"`
+	Variables              types.Dynamic  `tfsdk:"variables"`
+	UnencryptedVariables   types.List     `tfsdk:"unencrypted_variables"`
+	VaultKeyName           types.String   `tfsdk:"vault_key_name"`
+	VaultAddress           types.String   `tfsdk:"vault_address"`
+	VaultTransitEngine     types.String   `tfsdk:"vault_transit_engine"`
+	SigningKeyName         types.String   `tfsdk:"signing_key_name"`
+	Timestamp              types.String   `tfsdk:"timestamp"`
+	SOPSFormatVersion      types.String   `tfsdk:"sops_format_version"`
+	Pretty                 types.Bool     `tfsdk:"pretty"`
+	TrailingNewline        types.Bool     `tfsdk:"trailing_newline"`
+	LineEndings            types.String   `tfsdk:"line_endings"`
+	DataKeyWO              types.String   `tfsdk:"data_key_wo"`
+	WrappedDataKeyWO       types.String   `tfsdk:"wrapped_data_key_wo"`
+	RewrapOnRead           types.Bool     `tfsdk:"rewrap_on_read"`
+	ReencryptOnKeyRotation types.Bool     `tfsdk:"reencrypt_on_key_rotation"`
+	VerifyOnRead           types.Bool     `tfsdk:"verify_on_read"`
+	CiphertextSensitive    types.Bool     `tfsdk:"ciphertext_sensitive"`
+	Timeouts               *timeoutsModel `tfsdk:"timeouts"`
+	ciphertextModel
+}
+
+func NewEncryptedTFVarsResource() resource.Resource { return &encryptedTFVarsResource{} }
+
+func (r *encryptedTFVarsResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_encrypted_tfvars"
+}
+
+func (r *encryptedTFVarsResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: `Encrypts a map of Terraform variable values as a .tfvars.json document using
+SOPS with a Vault Transit key, so a downstream root module can consume the
+variables an upstream run produced from an encrypted file:
+
+    resource "sops_encrypted_tfvars" "downstream" {
+      variables = {
+        db_password = random_password.db.result
+        db_port     = 5432
+        region      = "eu-west-1"
+      }
+      unencrypted_variables = ["region"]
+      vault_key_name        = "my-key"
+    }
+
+The downstream run decrypts the file with sops before Terraform reads it,
+e.g. sops exec-file. The ciphertext is stable across plans until an input
+changes, at which point the resource is replaced and re-encrypted.`,
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:    true,
+				Description: "SHA-256 of the ciphertext as first created. Unique per resource and unchanged by rewrap_on_read.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"variables": schema.DynamicAttribute{
+				Required:  true,
+				Sensitive: true,
+				Description: "Variable values by name: an object or map whose values may be of any type, encoded as in " +
+					"jsonencode(). Names must be valid Terraform variable names; \"sops\" is reserved for the metadata.",
+				PlanModifiers: []planmodifier.Dynamic{
+					dynamicplanmodifier.RequiresReplace(),
+				},
+			},
+			"unencrypted_variables": schema.ListAttribute{
+				ElementType: types.StringType,
+				Optional:    true,
+				Description: "Names of variables left in plaintext, e.g. a region that reviewers should see. Every other variable is " +
+					"encrypted, including keys of the same name nested in it. Each name must be a key of variables.",
+				PlanModifiers: []planmodifier.List{
+					listplanmodifier.RequiresReplace(),
+				},
+			},
+			"vault_key_name": schema.StringAttribute{
+				Optional:    true,
+				Computed:    true,
+				Description: "Name of the Vault Transit key used to wrap the data key. Defaults to the provider-level default_vault_key_name. An error in age-only mode.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"vault_address": schema.StringAttribute{
+				Optional:    true,
+				Description: "Vault server for this resource, e.g. https://vault-eu.example.com:8200. Overrides the provider-level vault_address. The provider's Vault token, obtained from the provider-level server, must be valid here too. Recorded in the sops metadata. Ignored in mock mode.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"vault_transit_engine": schema.StringAttribute{
+				Optional:    true,
+				Description: "Vault Transit mount path for this resource. Overrides the provider-level vault_transit_engine. Defaults to 'transit'.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"signing_key_name": schema.StringAttribute{
+				Optional: true,
+				Description: "Vault Transit signing key, e.g. of type ed25519 or ecdsa-p256, in vault_transit_engine to sign ciphertext with, " +
+					"so that consumers of the committed file can verify its provenance. The signature is exported as signature.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"timestamp": schema.StringAttribute{
+				Optional: true,
+				Description: "Time recorded as lastmodified and as the created_at of the Vault key source instead of the current time, so " +
+					"re-encrypting identical inputs yields identical metadata for reproducible builds: an RFC 3339 time such as " +
+					"\"2024-01-01T00:00:00Z\", or \"content\" to derive a fixed time from the SHA-256 of the document. The encrypted values, " +
+					"the wrapped data key and the MAC still change on every encryption.",
+				Validators: []validator.String{validTimestamp()},
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"sops_format_version": schema.StringAttribute{
+				Optional: true,
+				Description: "sops release recorded as the version of the document instead of the one built into the provider, " +
+					"e.g. \"3.7.3\" for consumers that still run an older sops. Must be 3.7.0 or later; every metadata field the " +
+					"provider writes is understood from 3.7.0 on.",
+				Validators: []validator.String{validFormatVersion()},
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"pretty": schema.BoolAttribute{
+				Optional:    true,
+				Computed:    true,
+				Description: "Indent the SOPS JSON output. Defaults to false.",
+				Default:     booldefault.StaticBool(false),
+				PlanModifiers: []planmodifier.Bool{
+					boolplanmodifier.RequiresReplace(),
+				},
+			},
+			"trailing_newline": schema.BoolAttribute{
+				Optional:    true,
+				Computed:    true,
+				Description: "End the JSON ciphertext and its YAML form with a newline. Defaults to true; set to false for consumers that compare files byte for byte against output without one. Changing it forces replacement.",
+				Default:     booldefault.StaticBool(true),
+				PlanModifiers: []planmodifier.Bool{
+					boolplanmodifier.RequiresReplace(),
+				},
+			},
+			"line_endings": schema.StringAttribute{
+				Optional:    true,
+				Computed:    true,
+				Description: "Line breaks of the JSON ciphertext and its YAML form: \"lf\" (default) or \"crlf\" for Windows consumers. Neither changes the encrypted values or the MAC. Changing it forces replacement.",
+				Default:     stringdefault.StaticString(sopsencrypt.LineEndingsLF),
+				Validators:  []validator.String{validLineEndings()},
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"data_key_wo": schema.StringAttribute{
+				Optional:  true,
+				Sensitive: true,
+				WriteOnly: true,
+				Description: "Base64-encoded 32-byte data key to encrypt the document with instead of a random one, typically the data_key of a " +
+					"sops_data_key ephemeral resource, so several documents share one key. Write-only: it is never stored, only used when " +
+					"the document is encrypted, and changing it alone does not re-encrypt. Requires Terraform 1.11 or later.",
+				Validators: []validator.String{validDataKey()},
+			},
+			"wrapped_data_key_wo": schema.StringAttribute{
+				Optional:  true,
+				WriteOnly: true,
+				Description: "Vault Transit ciphertext of data_key_wo under this resource's Transit key, typically the wrapped_data_key of the " +
+					"same sops_data_key, recorded as is so the documents share one wrapped key. When unset, data_key_wo is wrapped again. " +
+					"It is not checked against data_key_wo: a mismatch yields a document that cannot be decrypted. Write-only.",
+			},
+			"rewrap_on_read":            rewrapOnReadAttribute(),
+			"reencrypt_on_key_rotation": reencryptOnKeyRotationAttribute(),
+			"verify_on_read":            verifyOnReadAttribute(),
+			"ciphertext": schema.StringAttribute{
+				Computed:    true,
+				Sensitive:   true,
+				Description: "SOPS-encrypted .tfvars.json document. Decryptable with `sops -d --input-type json`.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"ciphertext_sensitive": schema.BoolAttribute{
+				Optional: true,
+				Computed: true,
+				Default:  booldefault.StaticBool(true),
+				Description: "Whether ciphertext is only available as a sensitive value. Set to false to also expose it as " +
+					"ciphertext_nonsensitive, which plan diffs and outputs show: it is encrypted, so reviewing generated files needs no secrets. " +
+					"Terraform fixes sensitivity per attribute, so ciphertext itself stays sensitive.",
+			},
+			"ciphertext_nonsensitive": schema.StringAttribute{
+				Computed:    true,
+				Description: "The same as ciphertext, but not marked sensitive. Null unless ciphertext_sensitive is false.",
+			},
+			"ciphertext_base64": schema.StringAttribute{
+				Computed:    true,
+				Sensitive:   true,
+				Description: "ciphertext encoded as standard base64.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"ciphertext_sha256": schema.StringAttribute{
+				Computed:    true,
+				Description: "Hex-encoded SHA-256 of ciphertext, for verifying the integrity of the encrypted document.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"signature": schema.StringAttribute{
+				Computed: true,
+				Description: "Vault Transit signature of ciphertext by signing_key_name, e.g. \"vault:v1:…\", over the exact bytes of ciphertext. " +
+					"Verify it with the key's verify endpoint or exported public key. Null unless signing_key_name is set.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"vault_request_id": schema.StringAttribute{
+				Computed:    true,
+				Description: "ID of the Vault Transit request that wrapped the data key, as recorded in the Vault audit log. Null in mock and age-only mode and when wrapped_data_key_wo is set.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"encrypted_at": schema.StringAttribute{
+				Computed:    true,
+				Description: "Time of the encryption, in RFC 3339 format. Unlike the document's lastmodified, it is not affected by timestamp.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"vault_entity_id": schema.StringAttribute{
+				Computed:    true,
+				Description: "Identity entity of the Vault token that encrypted the document, from token lookup-self. Null without Vault or for tokens without an entity.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"vault_accessor": schema.StringAttribute{
+				Computed:    true,
+				Description: "Accessor of the Vault token that encrypted the document, from token lookup-self, as recorded in the Vault audit log. Null without Vault.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"key_version": schema.Int64Attribute{
+				Computed:    true,
+				Description: "Version of the Vault Transit key that wrapped the data key, parsed from the vault:vN: prefix of the wrapped key. Null in age-only mode; 0 in mock mode.",
+				PlanModifiers: []planmodifier.Int64{
+					int64planmodifier.UseStateForUnknown(),
+				},
+			},
+			"ciphertext_json": schema.StringAttribute{
+				Computed:    true,
+				Sensitive:   true,
+				Description: "The encrypted document serialised as SOPS JSON, the same as ciphertext. Shares its data key and MAC with ciphertext_yaml.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"ciphertext_yaml": schema.StringAttribute{
+				Computed:    true,
+				Sensitive:   true,
+				Description: "The encrypted document serialised as SOPS YAML 1.2, for a downstream module that reads variables with the sops_decrypted data source instead. Shares its data key and MAC with ciphertext_json.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+		},
+		Blocks: map[string]schema.Block{
+			"timeouts": resourceTimeoutsBlock(),
+		},
+	}
+}
+
+func (r *encryptedTFVarsResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	pd, ok := req.ProviderData.(*sopsProviderData)
+	if !ok {
+		resp.Diagnostics.AddError("Unexpected provider data type",
+			fmt.Sprintf("Expected *sopsProviderData, got %T", req.ProviderData))
+		return
+	}
+	r.pd = pd
+}
+
+// ValidateConfig checks the variable names once variables is known, and
+// rejects a wrapped data key without its key.
+func (r *encryptedTFVarsResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var data encryptedTFVarsModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	dataKeyConfig{data.DataKeyWO, data.WrappedDataKeyWO}.validate(&resp.Diagnostics)
+	validateKeyRotation(data.RewrapOnRead, data.ReencryptOnKeyRotation, &resp.Diagnostics)
+	if !data.known(ctx) {
+		return
+	}
+	_, diags := data.document(ctx)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *encryptedTFVarsResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var data encryptedTFVarsModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	// The plan leaves vault_key_name unknown when the provider was not yet
+	// configured during plan; resolve the provider default now.
+	if data.VaultKeyName.IsUnknown() || data.VaultKeyName.IsNull() {
+		keyName := r.pd.vaultKeyName(types.StringNull())
+		if keyName == "" && r.pd.usesVault() {
+			resp.Diagnostics.AddAttributeError(path.Root("vault_key_name"),
+				"Missing Vault key name",
+				"Set vault_key_name on the resource or default_vault_key_name on the provider.")
+			return
+		}
+		data.VaultKeyName = types.StringNull()
+		if keyName != "" {
+			data.VaultKeyName = types.StringValue(keyName)
+		}
+	}
+
+	doc, diags := data.document(ctx)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	// Variables unknown during plan are only checked now.
+	r.pd.checkContentSize(path.Root("variables"), len(doc.document), &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	dataKey := getDataKeyConfig(ctx, req.Config, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	var audit sopsencrypt.EncryptAudit
+	encryptedAt := time.Now()
+	jsonOut, yamlOut, err := r.encrypt(ctx, data, dataKey, doc, &audit)
+	if err != nil {
+		addVaultError(&resp.Diagnostics, path.Root("vault_key_name"), "SOPS encryption failed", err)
+		return
+	}
+
+	data.set(jsonOut)
+	data.setAudit(ctx, r.pd, data.VaultAddress, audit, encryptedAt, data.Timeouts.create(), &resp.Diagnostics)
+	if err := data.sign(ctx, r.pd, data.VaultAddress, data.VaultTransitEngine, data.SigningKeyName, data.Timeouts.create()); err != nil {
+		addVaultError(&resp.Diagnostics, path.Root("signing_key_name"), "Failed to sign ciphertext", err)
+		return
+	}
+	data.expose(data.CiphertextSensitive)
+	// The data key is random, so the hash is unique per resource. It is
+	// fixed at creation; rewrap_on_read may change the ciphertext later.
+	data.ID = data.CiphertextSHA256
+	data.CiphertextJSON = types.StringValue(jsonOut)
+	data.CiphertextYAML = types.StringValue(yamlOut)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// Read makes no remote calls unless rewrap_on_read, reencrypt_on_key_rotation,
+// verify_on_read or stale_key_version_margin is set: ciphertext in state
+// remains valid until inputs change. It only checks the ciphertext for
+// out-of-band edits.
+func (r *encryptedTFVarsResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var data encryptedTFVarsModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	data.checkIntegrity(ctx, r.pd, data.VaultAddress, data.VerifyOnRead, data.Timeouts.read(), resp.Private, &resp.Diagnostics)
+	if data.RewrapOnRead.ValueBool() {
+		previous := data.Ciphertext
+		data.rewrap(ctx, r.pd, data.VaultAddress, data.Timeouts.read(), &resp.Diagnostics)
+		data.resign(ctx, r.pd, previous, data.VaultAddress, data.VaultTransitEngine, data.SigningKeyName, data.Timeouts.read(), &resp.Diagnostics)
+	}
+	data.checkKeyVersion(ctx, r.pd, data.VaultAddress, data.VaultTransitEngine, data.VaultKeyName, data.ReencryptOnKeyRotation, data.Timeouts.read(), resp.Private, &resp.Diagnostics)
+	data.expose(data.CiphertextSensitive)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// Update is only reached when rewrap_on_read, reencrypt_on_key_rotation,
+// verify_on_read or ciphertext_sensitive is toggled or timeouts change; every
+// other attribute carries RequiresReplace. The existing ciphertext is kept.
+func (r *encryptedTFVarsResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var data, state encryptedTFVarsModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	data.ID = state.ID
+	data.ciphertextModel = state.ciphertextModel
+	data.expose(data.CiphertextSensitive)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *encryptedTFVarsResource) Delete(_ context.Context, _ resource.DeleteRequest, _ *resource.DeleteResponse) {
+}
+
+func (r *encryptedTFVarsResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// Nothing to resolve on destroy or before the provider is configured.
+	if req.Plan.Raw.IsNull() || r.pd == nil {
+		return
+	}
+	planVaultKeyName(ctx, r.pd, req, resp)
+	planVaultAddress(ctx, r.pd, req, resp)
+	planKeyRotation(ctx, req, resp)
+	planModified(ctx, req, resp)
+	planNonsensitive(ctx, resp)
+
+	// Report an oversized document and the variables a change replaces at
+	// plan time once the inputs are known.
+	var data encryptedTFVarsModel
+	resp.Diagnostics.Append(resp.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() || !data.known(ctx) {
+		return
+	}
+	doc, diags := data.document(ctx)
+	if diags.HasError() {
+		// ValidateConfig has reported it.
+		return
+	}
+	r.pd.checkContentSize(path.Root("variables"), len(doc.document), &resp.Diagnostics)
+	if !req.State.Raw.IsNull() {
+		var state encryptedTFVarsModel
+		resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		if old, diags := state.document(ctx); !diags.HasError() {
+			warnKeyDiff(path.Root("variables"), old.document, false, doc.document, false, &resp.Diagnostics)
+		}
+	}
+}
+
+func (r *encryptedTFVarsResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+}
+
+// tfvarsDocument is the plaintext .tfvars.json document of a resource and
+// the names of the variables it encrypts.
+type tfvarsDocument struct {
+	document  string
+	encrypted []string
+}
+
+// known reports whether variables and unencrypted_variables are fully known.
+func (m encryptedTFVarsModel) known(ctx context.Context) bool {
+	v, err := m.Variables.ToTerraformValue(ctx)
+	return err == nil && v.IsFullyKnown() && !m.UnencryptedVariables.IsUnknown()
+}
+
+// document builds the plaintext .tfvars.json document from variables and
+// checks the variable names. Its diagnostics never contain any value.
+func (m encryptedTFVarsModel) document(ctx context.Context) (tfvarsDocument, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	v, err := dynamicValue(ctx, m.Variables)
+	if err != nil {
+		diags.AddAttributeError(path.Root("variables"), "Invalid variables", err.Error())
+		return tfvarsDocument{}, diags
+	}
+	values, ok := v.(map[string]any)
+	if !ok {
+		diags.AddAttributeError(path.Root("variables"), "Invalid variables",
+			"Expected an object or map of variable values.")
+		return tfvarsDocument{}, diags
+	}
+	var plaintext []string
+	if !m.UnencryptedVariables.IsNull() {
+		diags.Append(m.UnencryptedVariables.ElementsAs(ctx, &plaintext, false)...)
+		if diags.HasError() {
+			return tfvarsDocument{}, diags
+		}
+	}
+
+	var doc tfvarsDocument
+	for name := range values {
+		switch {
+		case name == "sops":
+			diags.AddAttributeError(path.Root("variables"), "Invalid variable name",
+				`"sops" is reserved for the SOPS metadata of the document.`)
+		case !tfvarsVariableName.MatchString(name):
+			diags.AddAttributeError(path.Root("variables"), "Invalid variable name",
+				fmt.Sprintf("%q is not a valid Terraform variable name: it must start with a letter or underscore and contain only letters, digits, underscores and hyphens.", name))
+		case !slices.Contains(plaintext, name):
+			doc.encrypted = append(doc.encrypted, name)
+		}
+	}
+	for _, name := range plaintext {
+		if _, ok := values[name]; !ok {
+			diags.AddAttributeError(path.Root("unencrypted_variables"), "Unknown variable",
+				fmt.Sprintf("%q is not a key of variables.", name))
+		}
+	}
+	if diags.HasError() {
+		return tfvarsDocument{}, diags
+	}
+	slices.Sort(doc.encrypted)
+	out, err := json.Marshal(values)
+	if err != nil {
+		diags.AddAttributeError(path.Root("variables"), "Invalid variables", err.Error())
+		return tfvarsDocument{}, diags
+	}
+	doc.document = string(out)
+	return doc, diags
+}
+
+// encrypt returns the document serialised as JSON and as YAML, both from a
+// single encryption, and records the Vault request that wrapped its data key
+// in audit.
+func (r *encryptedTFVarsResource) encrypt(ctx context.Context, data encryptedTFVarsModel, dataKey dataKeyConfig, doc tfvarsDocument, audit *sopsencrypt.EncryptAudit) (jsonOut, yamlOut string, err error) {
+	client, err := r.pd.newVaultClientAt(data.VaultAddress.ValueString(), data.Timeouts.create())
+	if err != nil {
+		return "", "", err
+	}
+	transitEngine := data.VaultTransitEngine.ValueString()
+	if transitEngine == "" {
+		transitEngine = r.pd.vaultTransitEngine
+	}
+	opts := sopsencrypt.EncryptOpts{
+		AgeRecipients: r.pd.ageRecipients,
+		CreateKeyType: r.pd.createKeyType,
+		Mock:          r.pd.mock,
+		PrettyJSON:    data.Pretty.ValueBool(),
+	}
+	if !data.UnencryptedVariables.IsNull() {
+		// Selecting the other variables by path keeps keys of the same
+		// name nested in them encrypted.
+		if len(doc.encrypted) == 0 {
+			return "", "", fmt.Errorf("unencrypted_variables leaves no variable to encrypt")
+		}
+		opts.EncryptedPaths = doc.encrypted
+	}
+	opts.Audit = audit
+	setTimestamp(&opts, data.Timestamp)
+	opts.FormatVersion = data.SOPSFormatVersion.ValueString()
+	setLineLayout(&opts, data.TrailingNewline, data.LineEndings)
+	if err := dataKey.set(&opts); err != nil {
+		return "", "", err
+	}
+	ctx = r.pd.logContext(ctx, client, r.pd.vaultAddressFor(data.VaultAddress), transitEngine, data.VaultKeyName.ValueString(), doc.document)
+	tflog.Debug(ctx, "Encrypting document", map[string]any{"content_bytes": len(doc.document)})
+	release, err := r.pd.acquireVault(ctx)
+	if err != nil {
+		return "", "", err
+	}
+	defer release()
+	if err := r.pd.setCachedDataKey(client, transitEngine, data.VaultKeyName.ValueString(), &opts); err != nil {
+		return "", "", err
+	}
+	start := time.Now()
+	jsonOut, yamlOut, err = sopsencrypt.EncryptToJSONAndYAML(client, transitEngine, data.VaultKeyName.ValueString(), doc.document, opts)
+	logResult(ctx, "Encryption", start, err)
+	return jsonOut, yamlOut, err
+}
@@ -0,0 +1,100 @@
+package provider_test
+
+import (
+	"os"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+// TestAccEncryptedTFVarsResource verifies that variables are encrypted into
+// a .tfvars.json document with the unencrypted variables left readable.
+func TestAccEncryptedTFVarsResource(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+provider "sops" {
+  age_recipients = ["age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p"]
+}
+
+resource "sops_encrypted_tfvars" "test" {
+  variables = {
+    db_password = "s3cret"
+    db_port     = 5432
+    region      = "eu-west-1"
+  }
+  unencrypted_variables = ["region"]
+  pretty                = true
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttrPair("sops_encrypted_tfvars.test", "id", "sops_encrypted_tfvars.test", "ciphertext_sha256"),
+					resource.TestCheckResourceAttrWith("sops_encrypted_tfvars.test", "ciphertext",
+						manifestContains(`"db_password": "ENC[`, `"db_port": "ENC[`, `"region": "eu-west-1"`, `"encrypted_regex": "^(db_password|db_port)$"`)),
+				),
+			},
+		},
+	})
+}
+
+// TestAccEncryptedTFVarsResource_InvalidVariables verifies that variables
+// must be an object of valid variable names, and that unencrypted_variables
+// must name them.
+func TestAccEncryptedTFVarsResource_InvalidVariables(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+provider "sops" {
+  mock = true
+}
+
+resource "sops_encrypted_tfvars" "test" {
+  variables      = ["a", "b"]
+  vault_key_name = "sops-test"
+}
+`,
+				ExpectError: regexp.MustCompile(`Expected an object or map of variable values`),
+			},
+			{
+				Config: `
+provider "sops" {
+  mock = true
+}
+
+resource "sops_encrypted_tfvars" "test" {
+  variables      = { sops = "x" }
+  vault_key_name = "sops-test"
+}
+`,
+				ExpectError: regexp.MustCompile(`Invalid variable name`),
+			},
+			{
+				Config: `
+provider "sops" {
+  mock = true
+}
+
+resource "sops_encrypted_tfvars" "test" {
+  variables             = { password = "x" }
+  unencrypted_variables = ["region"]
+  vault_key_name        = "sops-test"
+}
+`,
+				ExpectError: regexp.MustCompile(`"region" is not a key of variables`),
+			},
+		},
+	})
+}
@@ -3,8 +3,10 @@ package provider_test
 import (
 	"fmt"
 	"os"
+	"regexp"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
 )
@@ -36,6 +38,16 @@ func TestAccEncryptedYAMLResource(t *testing.T) {
 							}
 							return nil
 						}),
+					testCheckCiphertextAttributes("sops_encrypted_yaml.test"),
+					resource.TestCheckResourceAttrPair("sops_encrypted_yaml.test", "ciphertext_yaml",
+						"sops_encrypted_yaml.test", "ciphertext"),
+					resource.TestCheckResourceAttrWith("sops_encrypted_yaml.test", "ciphertext_json",
+						func(v string) error {
+							if !strings.HasPrefix(v, "{") {
+								return fmt.Errorf("ciphertext_json is not a JSON object")
+							}
+							return nil
+						}),
 				),
 			},
 		},
@@ -78,7 +90,10 @@ func testAccEncryptedYAMLConfig(vaultAddr, vaultToken, keyName, content string)
 	return fmt.Sprintf(`
 provider "sops" {
   vault_address = %q
-  vault_token   = %q
+
+  auth {
+    token = %q
+  }
 }
 
 resource "sops_encrypted_yaml" "test" {
@@ -87,3 +102,387 @@ resource "sops_encrypted_yaml" "test" {
 }
 `, vaultAddr, vaultToken, content, keyName)
 }
+
+func TestAccEncryptedYAMLResource_ProviderDefaultScope(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+	vaultAddr := requireEnv(t, "VAULT_ADDR")
+	vaultToken := requireEnv(t, "VAULT_TOKEN")
+	keyName := envOrDefault("SOPS_VAULT_KEY", "sops-test")
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccEncryptedYAMLDefaultScopeConfig(vaultAddr, vaultToken, keyName),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("sops_encrypted_yaml.inherits", "unencrypted_suffix", "_public"),
+					resource.TestCheckResourceAttrWith("sops_encrypted_yaml.inherits", "ciphertext",
+						func(v string) error {
+							if !strings.Contains(v, "url_public: https://example.com") {
+								return fmt.Errorf("url_public should be left in plaintext:\n%s", v)
+							}
+							return nil
+						}),
+					resource.TestCheckNoResourceAttr("sops_encrypted_yaml.overrides", "unencrypted_suffix"),
+					resource.TestCheckResourceAttr("sops_encrypted_yaml.overrides", "encrypted_regex", "^password$"),
+				),
+			},
+		},
+	})
+}
+
+func testAccEncryptedYAMLDefaultScopeConfig(vaultAddr, vaultToken, keyName string) string {
+	return fmt.Sprintf(`
+provider "sops" {
+  vault_address              = %q
+  default_unencrypted_suffix = "_public"
+
+  auth {
+    token = %q
+  }
+}
+
+resource "sops_encrypted_yaml" "inherits" {
+  content        = jsonencode({ password = "secret", url_public = "https://example.com" })
+  vault_key_name = %q
+}
+
+resource "sops_encrypted_yaml" "overrides" {
+  content         = jsonencode({ password = "secret", url_public = "https://example.com" })
+  vault_key_name  = %q
+  encrypted_regex = "^password$"
+}
+`, vaultAddr, vaultToken, keyName, keyName)
+}
+
+// TestAccEncryptedYAMLResource_AgeOnly encrypts locally for an age recipient
+// with no Vault configured.
+func TestAccEncryptedYAMLResource_AgeOnly(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+	recipient := envOrDefault("SOPS_AGE_TEST_RECIPIENT", "age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p")
+	t.Setenv("VAULT_ADDR", "")
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+provider "sops" {
+  age_recipients = [%q]
+}
+
+resource "sops_encrypted_yaml" "test" {
+  content = jsonencode({ password = "secret" })
+}
+`, recipient),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckNoResourceAttr("sops_encrypted_yaml.test", "vault_key_name"),
+					resource.TestCheckNoResourceAttr("sops_encrypted_yaml.test", "key_version"),
+					resource.TestCheckResourceAttrPair("sops_encrypted_yaml.test", "id",
+						"sops_encrypted_yaml.test", "ciphertext_sha256"),
+					resource.TestCheckResourceAttrWith("sops_encrypted_yaml.test", "ciphertext",
+						func(v string) error {
+							if !strings.Contains(v, recipient) {
+								return fmt.Errorf("ciphertext missing age recipient:\n%s", v)
+							}
+							if strings.Contains(v, "hc_vault") {
+								return fmt.Errorf("age-only ciphertext must not reference Vault:\n%s", v)
+							}
+							return nil
+						}),
+				),
+			},
+		},
+	})
+}
+
+// TestAccEncryptedYAMLResource_YAMLContent encrypts raw YAML and checks that
+// its key order survives.
+func TestAccEncryptedYAMLResource_YAMLContent(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+provider "sops" {
+  mock = true
+}
+
+resource "sops_encrypted_yaml" "test" {
+  content        = "replicas: 2\nimage:\n  tag: v1\n"
+  content_type   = "yaml"
+  vault_key_name = "sops-test"
+}
+`,
+				Check: resource.TestCheckResourceAttrWith("sops_encrypted_yaml.test", "ciphertext",
+					func(v string) error {
+						if !strings.HasPrefix(v, "replicas: ENC[") || !strings.Contains(v, "type:int]") {
+							return fmt.Errorf("YAML input was not encrypted in order:\n%s", v)
+						}
+						return nil
+					}),
+			},
+		},
+	})
+}
+
+// TestAccEncryptedYAMLResource_YAMLStream encrypts a multi-document manifest
+// bundle as one resource.
+func TestAccEncryptedYAMLResource_YAMLStream(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+provider "sops" {
+  mock = true
+}
+
+resource "sops_encrypted_yaml" "test" {
+  content        = "kind: Secret\nmetadata:\n  name: a\n---\nkind: Secret\nmetadata:\n  name: b\n"
+  content_type   = "yaml"
+  vault_key_name = "sops-test"
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckNoResourceAttr("sops_encrypted_yaml.test", "ciphertext_json"),
+					resource.TestCheckResourceAttrWith("sops_encrypted_yaml.test", "ciphertext",
+						func(v string) error {
+							if n := strings.Count(v, "sops:"); n != 2 {
+								return fmt.Errorf("expected 2 documents with sops metadata, got %d:\n%s", n, v)
+							}
+							return nil
+						}),
+				),
+			},
+		},
+	})
+}
+
+func TestAccEncryptedYAMLResource_ConflictingScope(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+provider "sops" {
+  mock = true
+}
+
+resource "sops_encrypted_yaml" "test" {
+  content            = jsonencode({ password = "secret" })
+  vault_key_name     = "sops-test"
+  encrypted_regex    = "^password$"
+  unencrypted_suffix = "_plain"
+}
+`,
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile(`Conflicting scope options`),
+			},
+		},
+	})
+}
+
+// TestAccEncryptedYAMLResource_UnencryptedKeys verifies that unencrypted_keys
+// leaves exactly the listed keys in plaintext.
+func TestAccEncryptedYAMLResource_UnencryptedKeys(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+provider "sops" {
+  age_recipients = ["age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p"]
+}
+
+resource "sops_encrypted_yaml" "test" {
+  content          = jsonencode({ host = "db.example.com", hostname = "db", password = "secret" })
+  unencrypted_keys = ["host"]
+}
+`,
+				Check: resource.TestCheckResourceAttrWith("sops_encrypted_yaml.test", "ciphertext",
+					func(v string) error {
+						if !strings.Contains(v, "host: db.example.com") {
+							return fmt.Errorf("host should stay in plaintext: %s", v)
+						}
+						if strings.Contains(v, "hostname: db\n") || strings.Contains(v, "secret") {
+							return fmt.Errorf("keys not listed should be encrypted: %s", v)
+						}
+						if !strings.Contains(v, "unencrypted_regex:") || !strings.Contains(v, "^(host)$") {
+							return fmt.Errorf("ciphertext does not record unencrypted_regex: %s", v)
+						}
+						return nil
+					}),
+			},
+			{
+				Config: `
+provider "sops" {
+  mock = true
+}
+
+resource "sops_encrypted_yaml" "test" {
+  content          = jsonencode({ password = "secret" })
+  vault_key_name   = "sops-test"
+  unencrypted_keys = ["host"]
+  encrypted_regex  = "^password$"
+}
+`,
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile(`Conflicting scope options`),
+			},
+		},
+	})
+}
+
+// TestAccEncryptedYAMLResource_Layout verifies yaml_indent and
+// yaml_string_style.
+func TestAccEncryptedYAMLResource_Layout(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+
+	config := func(indent int, style string) string {
+		return fmt.Sprintf(`
+provider "sops" {
+  mock = true
+}
+
+resource "sops_encrypted_yaml" "test" {
+  content           = jsonencode({ database = { host = "db.example.com", password = "secret" } })
+  vault_key_name    = "sops-test"
+  unencrypted_keys  = ["host"]
+  yaml_indent       = %d
+  yaml_string_style = %q
+}
+`, indent, style)
+	}
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config:      config(1, "plain"),
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile(`Invalid yaml_indent`),
+			},
+			{
+				Config:      config(2, "literal"),
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile(`Invalid yaml_string_style`),
+			},
+			{
+				Config: config(2, "double_quoted"),
+				Check: resource.TestCheckResourceAttrWith("sops_encrypted_yaml.test", "ciphertext",
+					func(v string) error {
+						if !strings.Contains(v, "database:\n  host: \"db.example.com\"\n") {
+							return fmt.Errorf("ciphertext is not indented by 2 with double-quoted strings: %s", v)
+						}
+						return nil
+					}),
+			},
+		},
+	})
+}
+
+// TestAccEncryptedYAMLResource_HeaderComment verifies that header_comment is
+// written above the YAML ciphertext only.
+func TestAccEncryptedYAMLResource_HeaderComment(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+provider "sops" {
+  mock = true
+}
+
+resource "sops_encrypted_yaml" "test" {
+  content        = jsonencode({ password = "secret" })
+  vault_key_name = "sops-test"
+  header_comment = "Managed by Terraform — do not edit"
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttrWith("sops_encrypted_yaml.test", "ciphertext",
+						func(v string) error {
+							if !strings.HasPrefix(v, "# Managed by Terraform — do not edit\npassword: ") {
+								return fmt.Errorf("ciphertext does not start with the header comment: %s", v)
+							}
+							return nil
+						}),
+					resource.TestCheckResourceAttrWith("sops_encrypted_yaml.test", "ciphertext_json",
+						func(v string) error {
+							if strings.Contains(v, "Managed by Terraform") {
+								return fmt.Errorf("ciphertext_json should have no header: %s", v)
+							}
+							return nil
+						}),
+				),
+			},
+		},
+	})
+}
+
+// TestAccEncryptedYAMLResource_AuditAttributes verifies that the Vault
+// request and token of the encryption are recorded for the audit log.
+func TestAccEncryptedYAMLResource_AuditAttributes(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+	vaultAddr := requireEnv(t, "VAULT_ADDR")
+	vaultToken := requireEnv(t, "VAULT_TOKEN")
+	keyName := envOrDefault("SOPS_VAULT_KEY", "sops-test")
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+provider "sops" {
+  vault_address = %q
+
+  auth {
+    token = %q
+  }
+}
+
+resource "sops_encrypted_yaml" "test" {
+  content        = "password: secret\n"
+  vault_key_name = %q
+}
+`, vaultAddr, vaultToken, keyName),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestMatchResourceAttr("sops_encrypted_yaml.test", "vault_request_id", regexp.MustCompile(`^[0-9a-f-]{36}$`)),
+					resource.TestCheckResourceAttrSet("sops_encrypted_yaml.test", "vault_accessor"),
+					resource.TestCheckResourceAttrWith("sops_encrypted_yaml.test", "encrypted_at", func(v string) error {
+						_, err := time.Parse(time.RFC3339, v)
+						return err
+					}),
+				),
+			},
+		},
+	})
+}
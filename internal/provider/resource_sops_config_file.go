@@ -0,0 +1,413 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	dschema "github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var (
+	_ resource.Resource                = &sopsConfigFileResource{}
+	_ resource.ResourceWithConfigure   = &sopsConfigFileResource{}
+	_ resource.ResourceWithImportState = &sopsConfigFileResource{}
+	_ resource.ResourceWithModifyPlan  = &sopsConfigFileResource{}
+)
+
+type sopsConfigFileResource struct{ pd *sopsProviderData }
+
+// sopsConfigFileModel accepts everything the sops_config data source does,
+// plus where to write the result.
+type sopsConfigFileModel struct {
+	sopsConfigModel
+	Filename       types.String `tfsdk:"filename"`
+	FilePermission types.String `tfsdk:"file_permission"`
+}
+
+func NewSOPSConfigFileResource() resource.Resource { return &sopsConfigFileResource{} }
+
+func (r *sopsConfigFileResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_config_file"
+}
+
+func (r *sopsConfigFileResource) Schema(ctx context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	// The rendering arguments are those of the sops_config data source, so
+	// the two cannot drift apart.
+	var ds datasource.SchemaResponse
+	(&sopsConfigDataSource{}).Schema(ctx, datasource.SchemaRequest{}, &ds)
+
+	attributes, diags := resourceAttributes(ds.Schema.Attributes)
+	resp.Diagnostics.Append(diags...)
+	blocks, diags := resourceBlocks(ds.Schema.Blocks)
+	resp.Diagnostics.Append(diags...)
+	attributes["id"] = schema.StringAttribute{
+		Computed:    true,
+		Description: "SHA-256 of the file content.",
+	}
+	attributes["content"] = schema.StringAttribute{
+		Computed:    true,
+		Description: "Rendered .sops.yaml YAML content written to filename.",
+	}
+	attributes["filename"] = schema.StringAttribute{
+		Required:    true,
+		Description: "Path of the file to write, e.g. \"${path.module}/.sops.yaml\". Missing parent directories are created. Changing it forces replacement.",
+		PlanModifiers: []planmodifier.String{
+			stringplanmodifier.RequiresReplace(),
+		},
+	}
+	attributes["file_permission"] = schema.StringAttribute{
+		Optional:    true,
+		Computed:    true,
+		Description: "Permissions of the file as an octal string. Defaults to '0644'.",
+		Default:     stringdefault.StaticString("0644"),
+	}
+
+	resp.Schema = schema.Schema{
+		Description: `Renders a ` + "`.sops.yaml`" + ` configuration file and writes it to disk.
+
+Accepts the same arguments as the sops_config data source. The file is
+rewritten whenever the rendered content or file_permission differs from what
+is on disk, so edits made outside Terraform show up as drift in the plan. A
+deleted file is recreated.
+
+    resource "sops_config_file" "example" {
+      filename       = "${path.module}/.sops.yaml"
+      vault_key_name = "my-key"
+    }`,
+		Attributes: attributes,
+		Blocks:     blocks,
+	}
+}
+
+// resourceAttributes converts data source attributes to their resource
+// equivalents, validators included. Attribute types without a conversion
+// are reported as an error.
+func resourceAttributes(attrs map[string]dschema.Attribute) (map[string]schema.Attribute, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	out := make(map[string]schema.Attribute, len(attrs))
+	for name, a := range attrs {
+		switch a := a.(type) {
+		case dschema.StringAttribute:
+			out[name] = schema.StringAttribute{
+				Optional: a.Optional, Required: a.Required, Computed: a.Computed, Sensitive: a.Sensitive,
+				Description: a.Description, MarkdownDescription: a.MarkdownDescription, DeprecationMessage: a.DeprecationMessage,
+				CustomType: a.CustomType, Validators: a.Validators,
+			}
+		case dschema.BoolAttribute:
+			out[name] = schema.BoolAttribute{
+				Optional: a.Optional, Required: a.Required, Computed: a.Computed, Sensitive: a.Sensitive,
+				Description: a.Description, MarkdownDescription: a.MarkdownDescription, DeprecationMessage: a.DeprecationMessage,
+				CustomType: a.CustomType, Validators: a.Validators,
+			}
+		case dschema.Int64Attribute:
+			out[name] = schema.Int64Attribute{
+				Optional: a.Optional, Required: a.Required, Computed: a.Computed, Sensitive: a.Sensitive,
+				Description: a.Description, MarkdownDescription: a.MarkdownDescription, DeprecationMessage: a.DeprecationMessage,
+				CustomType: a.CustomType, Validators: a.Validators,
+			}
+		case dschema.Float64Attribute:
+			out[name] = schema.Float64Attribute{
+				Optional: a.Optional, Required: a.Required, Computed: a.Computed, Sensitive: a.Sensitive,
+				Description: a.Description, MarkdownDescription: a.MarkdownDescription, DeprecationMessage: a.DeprecationMessage,
+				CustomType: a.CustomType, Validators: a.Validators,
+			}
+		case dschema.NumberAttribute:
+			out[name] = schema.NumberAttribute{
+				Optional: a.Optional, Required: a.Required, Computed: a.Computed, Sensitive: a.Sensitive,
+				Description: a.Description, MarkdownDescription: a.MarkdownDescription, DeprecationMessage: a.DeprecationMessage,
+				CustomType: a.CustomType, Validators: a.Validators,
+			}
+		case dschema.ListAttribute:
+			out[name] = schema.ListAttribute{
+				ElementType: a.ElementType,
+				Optional:    a.Optional, Required: a.Required, Computed: a.Computed, Sensitive: a.Sensitive,
+				Description: a.Description, MarkdownDescription: a.MarkdownDescription, DeprecationMessage: a.DeprecationMessage,
+				CustomType: a.CustomType, Validators: a.Validators,
+			}
+		case dschema.SetAttribute:
+			out[name] = schema.SetAttribute{
+				ElementType: a.ElementType,
+				Optional:    a.Optional, Required: a.Required, Computed: a.Computed, Sensitive: a.Sensitive,
+				Description: a.Description, MarkdownDescription: a.MarkdownDescription, DeprecationMessage: a.DeprecationMessage,
+				CustomType: a.CustomType, Validators: a.Validators,
+			}
+		case dschema.MapAttribute:
+			out[name] = schema.MapAttribute{
+				ElementType: a.ElementType,
+				Optional:    a.Optional, Required: a.Required, Computed: a.Computed, Sensitive: a.Sensitive,
+				Description: a.Description, MarkdownDescription: a.MarkdownDescription, DeprecationMessage: a.DeprecationMessage,
+				CustomType: a.CustomType, Validators: a.Validators,
+			}
+		case dschema.ObjectAttribute:
+			out[name] = schema.ObjectAttribute{
+				AttributeTypes: a.AttributeTypes,
+				Optional:       a.Optional, Required: a.Required, Computed: a.Computed, Sensitive: a.Sensitive,
+				Description: a.Description, MarkdownDescription: a.MarkdownDescription, DeprecationMessage: a.DeprecationMessage,
+				CustomType: a.CustomType, Validators: a.Validators,
+			}
+		case dschema.ListNestedAttribute:
+			nested, d := resourceNestedObject(a.NestedObject)
+			diags.Append(d...)
+			out[name] = schema.ListNestedAttribute{
+				NestedObject: nested,
+				Optional:     a.Optional, Required: a.Required, Computed: a.Computed, Sensitive: a.Sensitive,
+				Description: a.Description, MarkdownDescription: a.MarkdownDescription, DeprecationMessage: a.DeprecationMessage,
+				CustomType: a.CustomType, Validators: a.Validators,
+			}
+		case dschema.SetNestedAttribute:
+			nested, d := resourceNestedObject(a.NestedObject)
+			diags.Append(d...)
+			out[name] = schema.SetNestedAttribute{
+				NestedObject: nested,
+				Optional:     a.Optional, Required: a.Required, Computed: a.Computed, Sensitive: a.Sensitive,
+				Description: a.Description, MarkdownDescription: a.MarkdownDescription, DeprecationMessage: a.DeprecationMessage,
+				CustomType: a.CustomType, Validators: a.Validators,
+			}
+		case dschema.MapNestedAttribute:
+			nested, d := resourceNestedObject(a.NestedObject)
+			diags.Append(d...)
+			out[name] = schema.MapNestedAttribute{
+				NestedObject: nested,
+				Optional:     a.Optional, Required: a.Required, Computed: a.Computed, Sensitive: a.Sensitive,
+				Description: a.Description, MarkdownDescription: a.MarkdownDescription, DeprecationMessage: a.DeprecationMessage,
+				CustomType: a.CustomType, Validators: a.Validators,
+			}
+		case dschema.SingleNestedAttribute:
+			attributes, d := resourceAttributes(a.Attributes)
+			diags.Append(d...)
+			out[name] = schema.SingleNestedAttribute{
+				Attributes: attributes,
+				Optional:   a.Optional, Required: a.Required, Computed: a.Computed, Sensitive: a.Sensitive,
+				Description: a.Description, MarkdownDescription: a.MarkdownDescription, DeprecationMessage: a.DeprecationMessage,
+				CustomType: a.CustomType, Validators: a.Validators,
+			}
+		default:
+			diags.AddError("Unsupported sops_config attribute",
+				fmt.Sprintf("sops_config_file cannot mirror attribute %q of type %T of the sops_config data source.", name, a))
+		}
+	}
+	return out, diags
+}
+
+// resourceNestedObject converts the nested object of a data source nested
+// attribute.
+func resourceNestedObject(o dschema.NestedAttributeObject) (schema.NestedAttributeObject, diag.Diagnostics) {
+	attributes, diags := resourceAttributes(o.Attributes)
+	return schema.NestedAttributeObject{Attributes: attributes, CustomType: o.CustomType, Validators: o.Validators}, diags
+}
+
+// resourceBlocks converts data source blocks to their resource equivalents,
+// like resourceAttributes.
+func resourceBlocks(blocks map[string]dschema.Block) (map[string]schema.Block, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	out := make(map[string]schema.Block, len(blocks))
+	for name, b := range blocks {
+		switch b := b.(type) {
+		case dschema.ListNestedBlock:
+			nested, d := resourceNestedBlockObject(b.NestedObject)
+			diags.Append(d...)
+			out[name] = schema.ListNestedBlock{
+				NestedObject: nested,
+				Description:  b.Description, MarkdownDescription: b.MarkdownDescription, DeprecationMessage: b.DeprecationMessage,
+				CustomType: b.CustomType, Validators: b.Validators,
+			}
+		case dschema.SetNestedBlock:
+			nested, d := resourceNestedBlockObject(b.NestedObject)
+			diags.Append(d...)
+			out[name] = schema.SetNestedBlock{
+				NestedObject: nested,
+				Description:  b.Description, MarkdownDescription: b.MarkdownDescription, DeprecationMessage: b.DeprecationMessage,
+				CustomType: b.CustomType, Validators: b.Validators,
+			}
+		case dschema.SingleNestedBlock:
+			attributes, d := resourceAttributes(b.Attributes)
+			diags.Append(d...)
+			nestedBlocks, d := resourceBlocks(b.Blocks)
+			diags.Append(d...)
+			out[name] = schema.SingleNestedBlock{
+				Attributes: attributes, Blocks: nestedBlocks,
+				Description: b.Description, MarkdownDescription: b.MarkdownDescription, DeprecationMessage: b.DeprecationMessage,
+				CustomType: b.CustomType, Validators: b.Validators,
+			}
+		default:
+			diags.AddError("Unsupported sops_config block",
+				fmt.Sprintf("sops_config_file cannot mirror block %q of type %T of the sops_config data source.", name, b))
+		}
+	}
+	return out, diags
+}
+
+// resourceNestedBlockObject converts the nested object of a data source
+// nested block.
+func resourceNestedBlockObject(o dschema.NestedBlockObject) (schema.NestedBlockObject, diag.Diagnostics) {
+	attributes, diags := resourceAttributes(o.Attributes)
+	blocks, d := resourceBlocks(o.Blocks)
+	diags.Append(d...)
+	return schema.NestedBlockObject{Attributes: attributes, Blocks: blocks, CustomType: o.CustomType, Validators: o.Validators}, diags
+}
+
+func (r *sopsConfigFileResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	pd, ok := req.ProviderData.(*sopsProviderData)
+	if !ok {
+		resp.Diagnostics.AddError("Unexpected provider data type",
+			fmt.Sprintf("Expected *sopsProviderData, got %T", req.ProviderData))
+		return
+	}
+	r.pd = pd
+}
+
+func (r *sopsConfigFileResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var data sopsConfigFileModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.Diagnostics.Append(r.write(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// Read refreshes content and file_permission from disk, so that changes made
+// outside Terraform are planned away. A missing file removes the resource.
+func (r *sopsConfigFileResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var data sopsConfigFileModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	filename := data.Filename.ValueString()
+	content, err := os.ReadFile(filename)
+	if os.IsNotExist(err) {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to read SOPS config file", err.Error())
+		return
+	}
+	info, err := os.Stat(filename)
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to read SOPS config file", err.Error())
+		return
+	}
+
+	data.ID = sha256Hex(string(content))
+	data.Content = types.StringValue(string(content))
+	data.FilePermission = types.StringValue(fmt.Sprintf("%04o", info.Mode().Perm()))
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *sopsConfigFileResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var data sopsConfigFileModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.Diagnostics.Append(r.write(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *sopsConfigFileResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var data sopsConfigFileModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if err := os.Remove(data.Filename.ValueString()); err != nil && !os.IsNotExist(err) {
+		resp.Diagnostics.AddError("Failed to delete SOPS config file", err.Error())
+	}
+}
+
+// ModifyPlan renders the content during plan, so the diff shows the new file
+// and drift detected by Read is planned as an update.
+func (r *sopsConfigFileResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// Nothing to render on destroy, before the provider is configured or
+	// while the configuration has unknown values.
+	if req.Plan.Raw.IsNull() || r.pd == nil || !req.Config.Raw.IsFullyKnown() {
+		return
+	}
+	var data sopsConfigFileModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if _, err := parseFilePermission(data.FilePermission.ValueString()); err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("file_permission"), "Invalid file permission", err.Error())
+		return
+	}
+	content, diags := r.pd.renderConfig(ctx, data.sopsConfigModel)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("content"), content)...)
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("id"), sha256Hex(content))...)
+}
+
+func (r *sopsConfigFileResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	resource.ImportStatePassthroughID(ctx, path.Root("filename"), req, resp)
+}
+
+// write renders data, writes it to data.Filename and records the result in
+// data.
+func (r *sopsConfigFileResource) write(ctx context.Context, data *sopsConfigFileModel) diag.Diagnostics {
+	content, diags := r.pd.renderConfig(ctx, data.sopsConfigModel)
+	if diags.HasError() {
+		return diags
+	}
+	perm, err := parseFilePermission(data.FilePermission.ValueString())
+	if err != nil {
+		diags.AddAttributeError(path.Root("file_permission"), "Invalid file permission", err.Error())
+		return diags
+	}
+
+	filename := data.Filename.ValueString()
+	if err := os.MkdirAll(filepath.Dir(filename), 0o755); err != nil {
+		diags.AddError("Failed to write SOPS config file", err.Error())
+		return diags
+	}
+	if err := os.WriteFile(filename, []byte(content), perm); err != nil {
+		diags.AddError("Failed to write SOPS config file", err.Error())
+		return diags
+	}
+	// WriteFile applies perm only when creating the file, and then subject to
+	// the umask.
+	if err := os.Chmod(filename, perm); err != nil {
+		diags.AddError("Failed to write SOPS config file", err.Error())
+		return diags
+	}
+
+	data.ID = sha256Hex(content)
+	data.Content = types.StringValue(content)
+	return diags
+}
+
+// parseFilePermission parses an octal permission string such as "0644".
+func parseFilePermission(s string) (os.FileMode, error) {
+	perm, err := strconv.ParseUint(s, 8, 32)
+	if err != nil || perm > 0o777 {
+		return 0, fmt.Errorf("file_permission must be an octal string between 0000 and 0777, got %q", s)
+	}
+	return os.FileMode(perm), nil
+}
@@ -0,0 +1,181 @@
+package provider_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	fwresource "github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+
+	"terraform-provider-sops/internal/provider"
+)
+
+const testAccSOPSConfigFileContent = "creation_rules:\n" +
+	"  - age: age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p\n"
+
+func TestAccSOPSConfigFileResource(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+	filename := filepath.Join(t.TempDir(), "nested", ".sops.yaml")
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy: func(*terraform.State) error {
+			if _, err := os.Stat(filename); !os.IsNotExist(err) {
+				return fmt.Errorf("%s still exists after destroy", filename)
+			}
+			return nil
+		},
+		Steps: []resource.TestStep{
+			{
+				Config: testAccSOPSConfigFileConfig(filename, "0600"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("sops_config_file.test", "content", testAccSOPSConfigFileContent),
+					testAccCheckSOPSConfigFile(filename, testAccSOPSConfigFileContent, 0o600),
+				),
+			},
+			// Edits made outside Terraform are reverted on the next apply.
+			{
+				PreConfig: func() {
+					if err := os.WriteFile(filename, []byte("creation_rules: []\n"), 0o644); err != nil {
+						t.Fatal(err)
+					}
+				},
+				Config: testAccSOPSConfigFileConfig(filename, "0600"),
+				Check:  testAccCheckSOPSConfigFile(filename, testAccSOPSConfigFileContent, 0o600),
+			},
+			// A deleted file is recreated.
+			{
+				PreConfig: func() {
+					if err := os.Remove(filename); err != nil {
+						t.Fatal(err)
+					}
+				},
+				Config: testAccSOPSConfigFileConfig(filename, "0640"),
+				Check:  testAccCheckSOPSConfigFile(filename, testAccSOPSConfigFileContent, 0o640),
+			},
+		},
+	})
+}
+
+func testAccCheckSOPSConfigFile(filename, want string, perm os.FileMode) resource.TestCheckFunc {
+	return func(*terraform.State) error {
+		got, err := os.ReadFile(filename)
+		if err != nil {
+			return err
+		}
+		if string(got) != want {
+			return fmt.Errorf("file content = %q, want %q", got, want)
+		}
+		info, err := os.Stat(filename)
+		if err != nil {
+			return err
+		}
+		if info.Mode().Perm() != perm {
+			return fmt.Errorf("file permission = %04o, want %04o", info.Mode().Perm(), perm)
+		}
+		return nil
+	}
+}
+
+func testAccSOPSConfigFileConfig(filename, perm string) string {
+	return fmt.Sprintf(`
+provider "sops" {
+  age_recipients = ["age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p"]
+}
+
+resource "sops_config_file" "test" {
+  filename        = %q
+  file_permission = %q
+  age_recipients  = ["age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p"]
+}
+`, filename, perm)
+}
+
+// TestSOPSConfigFileResource_SchemaMirrorsDataSource walks the sops_config
+// data source schema and checks that sops_config_file declares every
+// attribute and block with the same type, flags, descriptions and
+// validators, and nothing besides its own file attributes.
+func TestSOPSConfigFileResource_SchemaMirrorsDataSource(t *testing.T) {
+	ctx := context.Background()
+	var ds datasource.SchemaResponse
+	provider.NewSOPSConfigDataSource().Schema(ctx, datasource.SchemaRequest{}, &ds)
+	var rs fwresource.SchemaResponse
+	provider.NewSOPSConfigFileResource().Schema(ctx, fwresource.SchemaRequest{}, &rs)
+	if rs.Diagnostics.HasError() {
+		t.Fatalf("Schema: %v", rs.Diagnostics)
+	}
+
+	// id and content are redescribed for the file; filename and
+	// file_permission are the resource's own.
+	own := map[string]bool{"id": true, "content": true, "filename": true, "file_permission": true}
+	for name := range rs.Schema.Attributes {
+		if _, ok := ds.Schema.Attributes[name]; !ok && !own[name] {
+			t.Errorf("resource attribute %q is not in the data source", name)
+		}
+	}
+	for name, a := range ds.Schema.Attributes {
+		if own[name] {
+			continue
+		}
+		compareSchemaValues(t, name, reflect.ValueOf(a), reflect.ValueOf(rs.Schema.Attributes[name]))
+	}
+	compareSchemaValues(t, "blocks", reflect.ValueOf(ds.Schema.Blocks), reflect.ValueOf(rs.Schema.Blocks))
+}
+
+// compareSchemaValues compares a data source schema value with its resource
+// counterpart: maps of attributes or blocks key by key, structs field by
+// field of the data source struct, validator slices by length and
+// everything else by equality.
+func compareSchemaValues(t *testing.T, where string, ds, rs reflect.Value) {
+	t.Helper()
+	if ds.Kind() == reflect.Interface {
+		ds = ds.Elem()
+	}
+	if rs.IsValid() && rs.Kind() == reflect.Interface {
+		rs = rs.Elem()
+	}
+	if !ds.IsValid() || !rs.IsValid() {
+		if ds.IsValid() != rs.IsValid() {
+			t.Errorf("%s: set in only one of data source and resource", where)
+		}
+		return
+	}
+	switch ds.Kind() {
+	case reflect.Map:
+		if ds.Len() != rs.Len() {
+			t.Errorf("%s: %d entries in the data source, %d in the resource", where, ds.Len(), rs.Len())
+		}
+		for _, k := range ds.MapKeys() {
+			compareSchemaValues(t, where+"."+k.String(), ds.MapIndex(k), rs.MapIndex(k))
+		}
+	case reflect.Struct:
+		if ds.Type().Name() != rs.Type().Name() {
+			t.Errorf("%s: %s in the data source, %s in the resource", where, ds.Type().Name(), rs.Type().Name())
+			return
+		}
+		for i := 0; i < ds.NumField(); i++ {
+			name := ds.Type().Field(i).Name
+			if _, ok := rs.Type().FieldByName(name); !ok {
+				t.Errorf("%s: resource %s has no field %s", where, rs.Type().Name(), name)
+				continue
+			}
+			compareSchemaValues(t, where+"."+name, ds.Field(i), rs.FieldByName(name))
+		}
+	case reflect.Slice:
+		if ds.Len() != rs.Len() {
+			t.Errorf("%s: %d in the data source, %d in the resource", where, ds.Len(), rs.Len())
+		}
+	default:
+		if !reflect.DeepEqual(ds.Interface(), rs.Interface()) {
+			t.Errorf("%s: %v in the data source, %v in the resource", where, ds.Interface(), rs.Interface())
+		}
+	}
+}
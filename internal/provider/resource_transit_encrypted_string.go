@@ -0,0 +1,267 @@
+package provider
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"terraform-provider-sops/internal/sopsencrypt"
+)
+
+var (
+	_ resource.Resource                   = &transitEncryptedStringResource{}
+	_ resource.ResourceWithConfigure      = &transitEncryptedStringResource{}
+	_ resource.ResourceWithModifyPlan     = &transitEncryptedStringResource{}
+	_ resource.ResourceWithValidateConfig = &transitEncryptedStringResource{}
+)
+
+type transitEncryptedStringResource struct{ pd *sopsProviderData }
+
+type transitEncryptedStringModel struct {
+	ID                 types.String   `tfsdk:"id"`
+	Plaintext          types.String   `tfsdk:"plaintext"`
+	Context            types.String   `tfsdk:"context"`
+	VaultKeyName       types.String   `tfsdk:"vault_key_name"`
+	VaultAddress       types.String   `tfsdk:"vault_address"`
+	VaultTransitEngine types.String   `tfsdk:"vault_transit_engine"`
+	Ciphertext         types.String   `tfsdk:"ciphertext"`
+	KeyVersion         types.Int64    `tfsdk:"key_version"`
+	Timeouts           *timeoutsModel `tfsdk:"timeouts"`
+}
+
+func NewTransitEncryptedStringResource() resource.Resource {
+	return &transitEncryptedStringResource{}
+}
+
+func (r *transitEncryptedStringResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_transit_encrypted_string"
+}
+
+func (r *transitEncryptedStringResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: `Encrypts a single value with Vault Transit and stores the Transit
+ciphertext (vault:vN:...) as is, without a SOPS envelope, for consumers that
+call Transit decrypt themselves instead of reading SOPS documents. Uses the
+provider's Vault address and credentials.
+
+    resource "sops_transit_encrypted_string" "db_password" {
+      plaintext      = var.db_password
+      vault_key_name = "app-secrets"
+    }
+
+The ciphertext is stable across plans until plaintext or another input
+changes, at which point the resource is replaced and the value encrypted again.`,
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:    true,
+				Description: "SHA-256 of the ciphertext.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"plaintext": schema.StringAttribute{
+				Required:    true,
+				Sensitive:   true,
+				Description: "Value to encrypt. Changing it forces replacement.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"context": schema.StringAttribute{
+				Optional: true,
+				Description: "Base64-encoded key derivation context, required by Transit keys created with derived=true. " +
+					"Decrypting needs the same context. Changing it forces replacement.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+				Validators: []validator.String{validBase64()},
+			},
+			"vault_key_name": schema.StringAttribute{
+				Optional:    true,
+				Computed:    true,
+				Description: "Name of the Vault Transit key that encrypts plaintext. Defaults to the provider-level default_vault_key_name.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplaceIfConfigured(),
+				},
+			},
+			"vault_address": schema.StringAttribute{
+				Optional:    true,
+				Description: "Vault server for this resource. Overrides the provider-level vault_address. The provider's Vault token must be valid here too. Ignored in mock mode.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"vault_transit_engine": schema.StringAttribute{
+				Optional:    true,
+				Description: "Vault Transit mount path for this resource. Overrides the provider-level vault_transit_engine. Defaults to 'transit'.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"ciphertext": schema.StringAttribute{
+				Computed:    true,
+				Description: "Transit ciphertext of plaintext, e.g. vault:v1:..., as returned by the Transit encrypt endpoint. 'vault:v0:mock' in mock mode.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"key_version": schema.Int64Attribute{
+				Computed:    true,
+				Description: "Version of the Transit key that encrypted plaintext, parsed from the vault:vN: prefix of ciphertext. 0 in mock mode.",
+				PlanModifiers: []planmodifier.Int64{
+					int64planmodifier.UseStateForUnknown(),
+				},
+			},
+		},
+		Blocks: map[string]schema.Block{
+			"timeouts": resourceTimeoutsBlock(),
+		},
+	}
+}
+
+func (r *transitEncryptedStringResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	pd, ok := req.ProviderData.(*sopsProviderData)
+	if !ok {
+		resp.Diagnostics.AddError("Unexpected provider data type",
+			fmt.Sprintf("Expected *sopsProviderData, got %T", req.ProviderData))
+		return
+	}
+	r.pd = pd
+}
+
+// ValidateConfig rejects an empty plaintext, which Vault refuses to encrypt.
+func (r *transitEncryptedStringResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var data transitEncryptedStringModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if isSet(data.Plaintext) && data.Plaintext.ValueString() == "" {
+		resp.Diagnostics.AddAttributeError(path.Root("plaintext"),
+			"Empty plaintext",
+			"Vault Transit cannot encrypt an empty value.")
+	}
+}
+
+func (r *transitEncryptedStringResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// Nothing to resolve on destroy or before the provider is configured.
+	if req.Plan.Raw.IsNull() || r.pd == nil {
+		return
+	}
+	if !r.pd.usesVault() {
+		resp.Diagnostics.AddError("Vault not configured",
+			"sops_transit_encrypted_string encrypts with Vault Transit and is not available in age-only mode.")
+		return
+	}
+	planVaultKeyName(ctx, r.pd, req, resp)
+}
+
+func (r *transitEncryptedStringResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var data transitEncryptedStringModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if !r.pd.usesVault() {
+		resp.Diagnostics.AddError("Vault not configured",
+			"sops_transit_encrypted_string encrypts with Vault Transit and is not available in age-only mode.")
+		return
+	}
+	// The plan leaves vault_key_name unknown when the provider was not yet
+	// configured during plan; resolve the provider default now.
+	keyName := r.pd.vaultKeyName(data.VaultKeyName)
+	if data.VaultKeyName.IsUnknown() {
+		keyName = r.pd.vaultKeyName(types.StringNull())
+	}
+	if keyName == "" {
+		resp.Diagnostics.AddAttributeError(path.Root("vault_key_name"),
+			"Missing Vault key name",
+			"Set vault_key_name on the resource or default_vault_key_name on the provider.")
+		return
+	}
+	data.VaultKeyName = types.StringValue(keyName)
+
+	opts := sopsencrypt.TransitOpts{CreateKeyType: r.pd.createKeyType, Mock: r.pd.mock}
+	if isSet(data.Context) {
+		// Checked by validBase64.
+		opts.Context, _ = base64.StdEncoding.DecodeString(data.Context.ValueString())
+	}
+	client, err := r.pd.newVaultClientAt(data.VaultAddress.ValueString(), data.Timeouts.create())
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to create Vault client", err.Error())
+		return
+	}
+	transitEngine := resolveStringDefault(data.VaultTransitEngine, r.pd.vaultTransitEngine)
+	plaintext := data.Plaintext.ValueString()
+	ctx = r.pd.logContext(ctx, client, r.pd.vaultAddressFor(data.VaultAddress), transitEngine, keyName, plaintext)
+	if client != nil {
+		release, err := r.pd.acquireVault(ctx)
+		if err != nil {
+			resp.Diagnostics.AddError("Vault Transit encryption failed", err.Error())
+			return
+		}
+		defer release()
+	}
+	start := time.Now()
+	ciphertext, err := sopsencrypt.TransitEncrypt(client, transitEngine, keyName, []byte(plaintext), opts)
+	logResult(ctx, "Transit encryption", start, err)
+	if err != nil {
+		addVaultError(&resp.Diagnostics, path.Root("vault_key_name"), "Vault Transit encryption failed", err)
+		return
+	}
+
+	data.setCiphertext(ciphertext)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// Read makes no remote calls: the ciphertext in state remains valid until
+// inputs change.
+func (r *transitEncryptedStringResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var data transitEncryptedStringModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// Update is only reached when timeouts change; every other attribute
+// carries RequiresReplace. The existing ciphertext is kept.
+func (r *transitEncryptedStringResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var data, state transitEncryptedStringModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	data.ID = state.ID
+	data.VaultKeyName = state.VaultKeyName
+	data.Ciphertext = state.Ciphertext
+	data.KeyVersion = state.KeyVersion
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *transitEncryptedStringResource) Delete(_ context.Context, _ resource.DeleteRequest, _ *resource.DeleteResponse) {
+}
+
+// setCiphertext stores ciphertext and derives id and key_version from it.
+func (m *transitEncryptedStringModel) setCiphertext(ciphertext string) {
+	m.Ciphertext = types.StringValue(ciphertext)
+	m.ID = sha256Hex(ciphertext)
+	m.KeyVersion = types.Int64Null()
+	if version, err := sopsencrypt.TransitCiphertextVersion(ciphertext); err == nil {
+		m.KeyVersion = types.Int64Value(version)
+	}
+}
@@ -0,0 +1,105 @@
+package provider_test
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+func TestAccTransitEncryptedStringResource(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+	vaultAddr := requireEnv(t, "VAULT_ADDR")
+	vaultToken := requireEnv(t, "VAULT_TOKEN")
+	keyName := envOrDefault("SOPS_VAULT_KEY", "sops-test")
+
+	config := func(plaintext string) string {
+		return `
+provider "sops" {
+  vault_address = "` + vaultAddr + `"
+
+  auth {
+    token = "` + vaultToken + `"
+  }
+}
+
+resource "sops_transit_encrypted_string" "test" {
+  plaintext      = "` + plaintext + `"
+  vault_key_name = "` + keyName + `"
+}
+`
+	}
+
+	var first string
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: config("s3cret"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestMatchResourceAttr("sops_transit_encrypted_string.test", "ciphertext", regexp.MustCompile(`^vault:v\d+:`)),
+					resource.TestCheckResourceAttrSet("sops_transit_encrypted_string.test", "key_version"),
+					resource.TestCheckResourceAttrWith("sops_transit_encrypted_string.test", "ciphertext",
+						func(v string) error { first = v; return nil }),
+				),
+			},
+			{
+				// Changing the plaintext encrypts again.
+				Config: config("other"),
+				Check: resource.TestCheckResourceAttrWith("sops_transit_encrypted_string.test", "ciphertext",
+					func(v string) error {
+						if v == first {
+							return fmt.Errorf("ciphertext unchanged after plaintext change")
+						}
+						return nil
+					}),
+			},
+		},
+	})
+}
+
+func TestAccTransitEncryptedStringResource_Mock(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests")
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+provider "sops" {
+  mock = true
+}
+
+resource "sops_transit_encrypted_string" "test" {
+  plaintext      = ""
+  vault_key_name = "sops-test"
+}
+`,
+				ExpectError: regexp.MustCompile(`Empty plaintext`),
+			},
+			{
+				Config: `
+provider "sops" {
+  mock = true
+}
+
+resource "sops_transit_encrypted_string" "test" {
+  plaintext      = "s3cret"
+  vault_key_name = "sops-test"
+  context        = base64encode("tenant-1")
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("sops_transit_encrypted_string.test", "ciphertext", "vault:v0:mock"),
+					resource.TestCheckResourceAttr("sops_transit_encrypted_string.test", "key_version", "0"),
+				),
+			},
+		},
+	})
+}
@@ -0,0 +1,154 @@
+package provider
+
+import (
+	"context"
+	"time"
+
+	dsschema "github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// timeoutsModel is the timeouts block of the resources that call Vault. A
+// nil pointer means the block is absent.
+type timeoutsModel struct {
+	Create types.String `tfsdk:"create"`
+	Read   types.String `tfsdk:"read"`
+}
+
+// dataSourceTimeoutsModel is the timeouts block of the data sources that
+// call Vault.
+type dataSourceTimeoutsModel struct {
+	Read types.String `tfsdk:"read"`
+}
+
+// create returns the create timeout, or 0 for the Vault client default.
+func (m *timeoutsModel) create() time.Duration {
+	if m == nil {
+		return 0
+	}
+	return parseTimeout(m.Create)
+}
+
+// read returns the read timeout, or 0 for the Vault client default.
+func (m *timeoutsModel) read() time.Duration {
+	if m == nil {
+		return 0
+	}
+	return parseTimeout(m.Read)
+}
+
+// read returns the read timeout, or 0 for the Vault client default.
+func (m *dataSourceTimeoutsModel) read() time.Duration {
+	if m == nil {
+		return 0
+	}
+	return parseTimeout(m.Read)
+}
+
+// parseTimeout parses a duration already checked by validDuration.
+func parseTimeout(v types.String) time.Duration {
+	d, _ := time.ParseDuration(v.ValueString())
+	return d
+}
+
+// timeoutDescription documents the effect of a timeout on an operation.
+func timeoutDescription(operation string) string {
+	return "Time limit for the Vault requests made during " + operation + ", e.g. '30s' or '2m'. " +
+		"A request still pending when the limit passes, including its retries, fails. Defaults to the Vault client's 60s per request."
+}
+
+// resourceTimeoutsBlock is the timeouts block of the encryption resources.
+// read only applies when rewrap_on_read, reencrypt_on_key_rotation or
+// stale_key_version_margin calls Vault during refresh.
+func resourceTimeoutsBlock() schema.SingleNestedBlock {
+	return schema.SingleNestedBlock{
+		Description: "Time limits for operations against Vault.",
+		Attributes: map[string]schema.Attribute{
+			"create": schema.StringAttribute{
+				Optional:    true,
+				Description: timeoutDescription("create"),
+				Validators:  []validator.String{validDuration()},
+			},
+			"read": schema.StringAttribute{
+				Optional:    true,
+				Description: timeoutDescription("refresh") + " Only used with rewrap_on_read, reencrypt_on_key_rotation, verify_on_read and the provider's stale_key_version_margin.",
+				Validators:  []validator.String{validDuration()},
+			},
+		},
+	}
+}
+
+// dataSourceTimeoutsBlock is the timeouts block of the data sources that
+// call Vault.
+func dataSourceTimeoutsBlock() dsschema.SingleNestedBlock {
+	return dsschema.SingleNestedBlock{
+		Description: "Time limits for operations against Vault.",
+		Attributes: map[string]dsschema.Attribute{
+			"read": dsschema.StringAttribute{
+				Optional:    true,
+				Description: timeoutDescription("read"),
+				Validators:  []validator.String{validDuration()},
+			},
+		},
+	}
+}
+
+// durationValidator checks that a string parses as a positive Go duration.
+type durationValidator struct{}
+
+func validDuration() validator.String { return durationValidator{} }
+
+func (durationValidator) Description(context.Context) string {
+	return "value must be a positive duration such as 30s or 2m"
+}
+
+func (v durationValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (durationValidator) ValidateString(_ context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+	d, err := time.ParseDuration(req.ConfigValue.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(req.Path, "Invalid duration", err.Error())
+		return
+	}
+	if d <= 0 {
+		resp.Diagnostics.AddAttributeError(req.Path, "Invalid duration", "The duration must be positive.")
+	}
+}
+
+// rewrapOnReadAttribute, reencryptOnKeyRotationAttribute and
+// verifyOnReadAttribute are the refresh options of the encryption resources
+// that store a SOPS document, handled by ciphertextModel.rewrap,
+// checkKeyVersion and checkIntegrity.
+func rewrapOnReadAttribute() schema.BoolAttribute {
+	return schema.BoolAttribute{
+		Optional: true,
+		Description: "On every refresh, rewrap the Vault Transit wrapped data key with the latest version of the Transit key " +
+			"once the key has been rotated, so old key versions can be trimmed without invalidating the ciphertext in state. " +
+			"Only the wrapped key in the sops metadata changes; the encrypted values and the MAC are kept. Ignored in mock and age-only mode.",
+	}
+}
+
+func reencryptOnKeyRotationAttribute() schema.BoolAttribute {
+	return schema.BoolAttribute{
+		Optional: true,
+		Description: "On every refresh, compare the version of the Transit key that wrapped the data key with the latest version " +
+			"of the Transit key, and plan replacement when the key has been rotated, so the document is re-encrypted with a new data key " +
+			"under current key material. Mutually exclusive with rewrap_on_read. Ignored in mock and age-only mode.",
+	}
+}
+
+func verifyOnReadAttribute() schema.BoolAttribute {
+	return schema.BoolAttribute{
+		Optional: true,
+		Description: "On every refresh, unwrap the data key with Vault Transit and verify the MAC of ciphertext, so that edits of the " +
+			"encrypted values in state are detected and replacement is planned. Edits of ciphertext_sha256 or of one serialisation " +
+			"are detected without it. Ignored in mock and age-only mode.",
+	}
+}
@@ -0,0 +1,264 @@
+package provider
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	"terraform-provider-sops/internal/sopsencrypt"
+)
+
+// scopeConfigValidator rejects resources that set more than one of the
+// mutually exclusive scope options, including the scopeListAttributes.
+type scopeConfigValidator struct{}
+
+func (scopeConfigValidator) Description(context.Context) string {
+	return "at most one of " + strings.Join(append(slices.Clone(scopeAttributes), scopeListAttributes...), ", ") + " may be set"
+}
+
+func (v scopeConfigValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (scopeConfigValidator) ValidateResource(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var set []string
+	for _, name := range scopeAttributes {
+		var v types.String
+		resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root(name), &v)...)
+		if isSet(v) {
+			set = append(set, name)
+		}
+	}
+	for _, name := range scopeListAttributes {
+		var v types.List
+		resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root(name), &v)...)
+		if !v.IsNull() {
+			set = append(set, name)
+		}
+	}
+	if len(set) > 1 {
+		resp.Diagnostics.AddAttributeError(path.Root(set[1]),
+			"Conflicting scope options",
+			fmt.Sprintf("At most one scope option may be set; got %s.", strings.Join(set, ", ")))
+	}
+}
+
+// regexValidator checks that a string compiles as a Go regular expression,
+// the dialect SOPS matches key names with.
+type regexValidator struct{}
+
+func validRegex() validator.String { return regexValidator{} }
+
+func (regexValidator) Description(context.Context) string {
+	return "value must be a valid Go regular expression"
+}
+
+func (v regexValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (regexValidator) ValidateString(_ context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+	if _, err := regexp.Compile(req.ConfigValue.ValueString()); err != nil {
+		resp.Diagnostics.AddAttributeError(req.Path, "Invalid regular expression", err.Error())
+	}
+}
+
+// timestampFromContent is the timestamp attribute value that derives the
+// metadata timestamps from the document.
+const timestampFromContent = "content"
+
+// timestampValidator checks the timestamp attribute of the encryption
+// resources: an RFC 3339 time or "content".
+type timestampValidator struct{}
+
+func validTimestamp() validator.String { return timestampValidator{} }
+
+func (timestampValidator) Description(context.Context) string {
+	return `value must be an RFC 3339 time such as 2024-01-01T00:00:00Z, or "content"`
+}
+
+func (v timestampValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (timestampValidator) ValidateString(_ context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() || req.ConfigValue.ValueString() == timestampFromContent {
+		return
+	}
+	if _, err := time.Parse(time.RFC3339, req.ConfigValue.ValueString()); err != nil {
+		resp.Diagnostics.AddAttributeError(req.Path, "Invalid timestamp",
+			fmt.Sprintf("Expected an RFC 3339 time such as 2024-01-01T00:00:00Z, or %q: %s", timestampFromContent, err))
+	}
+}
+
+// encryptedPathsValidator checks the syntax of encrypted_paths.
+type encryptedPathsValidator struct{}
+
+func validEncryptedPaths() validator.List { return encryptedPathsValidator{} }
+
+func (encryptedPathsValidator) Description(context.Context) string {
+	return "value must be a non-empty list of dot-separated key paths"
+}
+
+func (v encryptedPathsValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (encryptedPathsValidator) ValidateList(ctx context.Context, req validator.ListRequest, resp *validator.ListResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+	var paths []types.String
+	resp.Diagnostics.Append(req.ConfigValue.ElementsAs(ctx, &paths, false)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if len(paths) == 0 {
+		resp.Diagnostics.AddAttributeError(req.Path, "Invalid encrypted_paths", "Expected at least one path.")
+	}
+	for i, p := range paths {
+		if p.IsUnknown() {
+			continue
+		}
+		if err := sopsencrypt.CheckEncryptedPaths([]string{p.ValueString()}); err != nil {
+			resp.Diagnostics.AddAttributeError(req.Path.AtListIndex(i), "Invalid encrypted_paths", err.Error())
+		}
+	}
+}
+
+// keyNamesValidator checks that unencrypted_keys lists at least one key name
+// and no empty ones.
+type keyNamesValidator struct{}
+
+func validKeyNames() validator.List { return keyNamesValidator{} }
+
+func (keyNamesValidator) Description(context.Context) string {
+	return "value must be a non-empty list of key names"
+}
+
+func (v keyNamesValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (keyNamesValidator) ValidateList(ctx context.Context, req validator.ListRequest, resp *validator.ListResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+	var keys []types.String
+	resp.Diagnostics.Append(req.ConfigValue.ElementsAs(ctx, &keys, false)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if len(keys) == 0 {
+		resp.Diagnostics.AddAttributeError(req.Path, "Invalid unencrypted_keys", "Expected at least one key name.")
+	}
+	for i, k := range keys {
+		if !k.IsUnknown() && k.ValueString() == "" {
+			resp.Diagnostics.AddAttributeError(req.Path.AtListIndex(i), "Invalid unencrypted_keys", "Expected a key name, got an empty string.")
+		}
+	}
+}
+
+// formatVersionValidator checks that a sops_format_version can be recorded
+// in encrypted documents.
+type formatVersionValidator struct{}
+
+func validFormatVersion() validator.String { return formatVersionValidator{} }
+
+func (formatVersionValidator) Description(context.Context) string {
+	return "value must be a sops release no older than " + sopsencrypt.MinFormatVersion
+}
+
+func (v formatVersionValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (formatVersionValidator) ValidateString(_ context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+	if err := sopsencrypt.CheckFormatVersion(req.ConfigValue.ValueString()); err != nil {
+		resp.Diagnostics.AddAttributeError(req.Path, "Invalid sops_format_version", err.Error())
+	}
+}
+
+// lineEndingsValidator checks that a line_endings value is a known style.
+type lineEndingsValidator struct{}
+
+func validLineEndings() validator.String { return lineEndingsValidator{} }
+
+func (lineEndingsValidator) Description(context.Context) string {
+	return "value must be one of " + strings.Join(sopsencrypt.LineEndingStyles, ", ")
+}
+
+func (v lineEndingsValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (lineEndingsValidator) ValidateString(_ context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+	if v := req.ConfigValue.ValueString(); !slices.Contains(sopsencrypt.LineEndingStyles, v) {
+		resp.Diagnostics.AddAttributeError(req.Path, "Invalid line_endings",
+			fmt.Sprintf("Expected one of %s, got %q.", strings.Join(sopsencrypt.LineEndingStyles, ", "), v))
+	}
+}
+
+// dataKeyValidator checks that a data_key_wo is a base64-encoded 32-byte key.
+type dataKeyValidator struct{}
+
+func validDataKey() validator.String { return dataKeyValidator{} }
+
+func (dataKeyValidator) Description(context.Context) string {
+	return "value must be a base64-encoded 32-byte key"
+}
+
+func (v dataKeyValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (dataKeyValidator) ValidateString(_ context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+	if key, err := base64.StdEncoding.DecodeString(req.ConfigValue.ValueString()); err != nil || len(key) != 32 {
+		resp.Diagnostics.AddAttributeError(req.Path, "Invalid data_key_wo",
+			"Expected a base64-encoded 32-byte key, such as the data_key of a sops_data_key ephemeral resource.")
+	}
+}
+
+// base64Validator checks that a string is standard base64.
+type base64Validator struct{}
+
+func validBase64() validator.String { return base64Validator{} }
+
+func (base64Validator) Description(context.Context) string {
+	return "value must be base64-encoded"
+}
+
+func (v base64Validator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (base64Validator) ValidateString(_ context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+	if _, err := base64.StdEncoding.DecodeString(req.ConfigValue.ValueString()); err != nil {
+		resp.Diagnostics.AddAttributeError(req.Path, "Invalid base64 value",
+			"Expected a standard base64-encoded value, e.g. from base64encode().")
+	}
+}
@@ -0,0 +1,25 @@
+package sopsencrypt
+
+import (
+	"fmt"
+
+	"github.com/getsops/sops/v3/age"
+	"github.com/getsops/sops/v3/keys"
+)
+
+// ageMasterKeys wraps dataKey for every age recipient. Wrapping happens
+// locally; no network access is involved.
+func ageMasterKeys(recipients []string, dataKey []byte) ([]keys.MasterKey, error) {
+	out := make([]keys.MasterKey, 0, len(recipients))
+	for _, r := range recipients {
+		key, err := age.MasterKeyFromRecipient(r)
+		if err != nil {
+			return nil, fmt.Errorf("parsing age recipient %q: %w", r, err)
+		}
+		if err := key.Encrypt(dataKey); err != nil {
+			return nil, fmt.Errorf("wrapping data key for age recipient %q: %w", r, err)
+		}
+		out = append(out, key)
+	}
+	return out, nil
+}
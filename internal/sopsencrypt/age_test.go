@@ -0,0 +1,97 @@
+package sopsencrypt_test
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+
+	"terraform-provider-sops/internal/sopsencrypt"
+)
+
+func TestEncryptToJSON_AgeOnlyWithoutVault(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatalf("GenerateX25519Identity: %v", err)
+	}
+
+	result, err := sopsencrypt.EncryptToJSON(nil, "", "", `{"password":"secret"}`,
+		sopsencrypt.EncryptOpts{AgeRecipients: []string{identity.Recipient().String()}})
+	if err != nil {
+		t.Fatalf("EncryptToJSON: %v", err)
+	}
+
+	var doc struct {
+		Password string `json:"password"`
+		SOPS     struct {
+			HCVault []interface{} `json:"hc_vault"`
+			Age     []struct {
+				Recipient string `json:"recipient"`
+				Enc       string `json:"enc"`
+			} `json:"age"`
+		} `json:"sops"`
+	}
+	if err := json.Unmarshal([]byte(result), &doc); err != nil {
+		t.Fatalf("result is not valid JSON: %v\n%s", err, result)
+	}
+	if !strings.HasPrefix(doc.Password, "ENC[") {
+		t.Errorf("password not encrypted: %q", doc.Password)
+	}
+	if len(doc.SOPS.HCVault) != 0 {
+		t.Errorf("want no hc_vault entries without a Vault client, got %d", len(doc.SOPS.HCVault))
+	}
+	if len(doc.SOPS.Age) != 1 {
+		t.Fatalf("want 1 age entry, got %d", len(doc.SOPS.Age))
+	}
+
+	// The wrapped data key must be recoverable with the matching identity.
+	r, err := age.Decrypt(armor.NewReader(strings.NewReader(doc.SOPS.Age[0].Enc)), identity)
+	if err != nil {
+		t.Fatalf("age.Decrypt: %v", err)
+	}
+	dataKey, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("reading data key: %v", err)
+	}
+	if len(dataKey) != 32 {
+		t.Errorf("data key length = %d, want 32", len(dataKey))
+	}
+}
+
+func TestEncryptToJSON_AgeAlongsideVault(t *testing.T) {
+	srv := mockVaultServer(t)
+	defer srv.Close()
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatalf("GenerateX25519Identity: %v", err)
+	}
+
+	result, err := sopsencrypt.EncryptToJSON(newTestClient(t, srv), "transit", "test-key", `{"k":"v"}`,
+		sopsencrypt.EncryptOpts{AgeRecipients: []string{identity.Recipient().String()}})
+	if err != nil {
+		t.Fatalf("EncryptToJSON: %v", err)
+	}
+	for _, want := range []string{`"hc_vault"`, `"age"`, identity.Recipient().String()} {
+		if !strings.Contains(result, want) {
+			t.Errorf("result missing %s:\n%s", want, result)
+		}
+	}
+}
+
+func TestEncryptToJSON_InvalidAgeRecipient(t *testing.T) {
+	_, err := sopsencrypt.EncryptToJSON(nil, "", "", `{"k":"v"}`,
+		sopsencrypt.EncryptOpts{AgeRecipients: []string{"not-a-recipient"}})
+	if err == nil {
+		t.Fatal("expected error for invalid age recipient")
+	}
+}
+
+func TestEncryptToJSON_NoKeySources(t *testing.T) {
+	_, err := sopsencrypt.EncryptToJSON(nil, "", "", `{"k":"v"}`, sopsencrypt.EncryptOpts{})
+	if err == nil {
+		t.Fatal("expected error when neither Vault nor age is configured")
+	}
+}
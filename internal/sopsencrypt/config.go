@@ -10,12 +10,201 @@ import (
 
 // sopsFileConfig is the Go representation of a .sops.yaml file.
 type sopsFileConfig struct {
-	CreationRules []sopsCreationRule `yaml:"creation_rules"`
+	// CreationRules holds sopsCreationRule values, followed by the
+	// *yaml.Node mappings of ConfigFile.ExtraCreationRules.
+	CreationRules    []any                 `yaml:"creation_rules"`
+	DestinationRules []sopsDestinationRule `yaml:"destination_rules,omitempty"`
+}
+
+type sopsDestinationRule struct {
+	PathRegex        string `yaml:"path_regex,omitempty"`
+	S3Bucket         string `yaml:"s3_bucket,omitempty"`
+	S3Prefix         string `yaml:"s3_prefix,omitempty"`
+	GCSBucket        string `yaml:"gcs_bucket,omitempty"`
+	GCSPrefix        string `yaml:"gcs_prefix,omitempty"`
+	VaultPath        string `yaml:"vault_path,omitempty"`
+	VaultAddress     string `yaml:"vault_address,omitempty"`
+	VaultKVMountName string `yaml:"vault_kv_mount_name,omitempty"`
+	VaultKVVersion   int    `yaml:"vault_kv_version,omitempty"`
+	OmitExtensions   bool   `yaml:"omit_extensions,omitempty"`
 }
 
 type sopsCreationRule struct {
-	PathRegex         string `yaml:"path_regex,omitempty"`
-	HCVaultTransitURI string `yaml:"hc_vault_transit_uri"`
+	PathRegex         string         `yaml:"path_regex,omitempty"`
+	KMS               string         `yaml:"kms,omitempty"`
+	GCPKMS            string         `yaml:"gcp_kms,omitempty"`
+	AzureKeyVault     string         `yaml:"azure_keyvault,omitempty"`
+	HCVaultTransitURI string         `yaml:"hc_vault_transit_uri,omitempty"`
+	Age               string         `yaml:"age,omitempty"`
+	PGP               string         `yaml:"pgp,omitempty"`
+	KeyGroups         []sopsKeyGroup `yaml:"key_groups,omitempty"`
+	ShamirThreshold   int            `yaml:"shamir_threshold,omitempty"`
+	UnencryptedSuffix string         `yaml:"unencrypted_suffix,omitempty"`
+	EncryptedSuffix   string         `yaml:"encrypted_suffix,omitempty"`
+	UnencryptedRegex  string         `yaml:"unencrypted_regex,omitempty"`
+	EncryptedRegex    string         `yaml:"encrypted_regex,omitempty"`
+	MACOnlyEncrypted  bool           `yaml:"mac_only_encrypted,omitempty"`
+}
+
+// sopsKeyGroup is one entry of a creation rule's key_groups list. It is the
+// only form in which SOPS reads a KMS encryption context from .sops.yaml.
+type sopsKeyGroup struct {
+	KMS     []sopsKMSKey     `yaml:"kms,omitempty"`
+	GCPKMS  []sopsGCPKMSKey  `yaml:"gcp_kms,omitempty"`
+	AzureKV []sopsAzureKVKey `yaml:"azure_keyvault,omitempty"`
+	Vault   []string         `yaml:"hc_vault,omitempty"`
+	Age     []string         `yaml:"age,omitempty"`
+	PGP     []string         `yaml:"pgp,omitempty"`
+}
+
+type sopsKMSKey struct {
+	Arn        string            `yaml:"arn"`
+	Role       string            `yaml:"role,omitempty"`
+	Context    map[string]string `yaml:"context,omitempty"`
+	AWSProfile string            `yaml:"aws_profile,omitempty"`
+}
+
+type sopsGCPKMSKey struct {
+	ResourceID string `yaml:"resource_id"`
+}
+
+type sopsAzureKVKey struct {
+	VaultURL string `yaml:"vaultUrl"`
+	Key      string `yaml:"key"`
+	Version  string `yaml:"version"`
+}
+
+// KeySources lists the master keys every generated creation rule encrypts
+// for. Empty fields are omitted from the rules; at least one must be set.
+type KeySources struct {
+	VaultTransitURI string
+	AgeRecipients   []string
+	PGPFingerprints []string
+	KMSKeys         []KMSKey
+	GCPKMSIDs       []string
+	AzureKVKeys     []AzureKVKey
+}
+
+// KMSKey is an AWS KMS key referenced by a creation rule. Role is an
+// optional IAM role to assume before calling KMS; Context is an optional
+// encryption context bound to the data key; Profile is an optional profile
+// of the AWS shared config to call KMS with.
+type KMSKey struct {
+	ARN     string
+	Role    string
+	Context map[string]string
+	Profile string
+}
+
+// Replicas returns the key followed by one copy per region in regions, each
+// with the ARN of the replica of the key in that region and the same role,
+// context and profile. The key must be an AWS KMS multi-region key, whose
+// replicas share its key ID, "mrk-…", and key material, so that each of them
+// unwraps the data key on its own when a region is unavailable.
+func (k KMSKey) Replicas(regions []string) ([]KMSKey, error) {
+	parts := strings.SplitN(k.ARN, ":", 6)
+	if len(parts) != 6 || parts[0] != "arn" || parts[2] != "kms" || !strings.HasPrefix(parts[5], "key/mrk-") {
+		return nil, fmt.Errorf("%q is not the ARN of a multi-region KMS key, arn:<partition>:kms:<region>:<account>:key/mrk-…", k.ARN)
+	}
+	keys := []KMSKey{k}
+	seen := map[string]bool{parts[3]: true}
+	for _, region := range regions {
+		if region == "" || strings.Contains(region, ":") {
+			return nil, fmt.Errorf("invalid region %q", region)
+		}
+		if seen[region] {
+			return nil, fmt.Errorf("region %s is listed twice or is the region of the key", region)
+		}
+		seen[region] = true
+		replica := k
+		parts[3] = region
+		replica.ARN = strings.Join(parts, ":")
+		keys = append(keys, replica)
+	}
+	return keys, nil
+}
+
+// AzureKVKey is an Azure Key Vault key referenced by a creation rule. An
+// empty Version selects the latest version of the key when SOPS encrypts.
+type AzureKVKey struct {
+	VaultURL string
+	Key      string
+	Version  string
+}
+
+// url returns the key in the https://<vault>/keys/<key>[/<version>] form
+// used by the flat azure_keyvault field.
+func (k AzureKVKey) url() string {
+	u := strings.TrimRight(k.VaultURL, "/") + "/keys/" + k.Key
+	if k.Version != "" {
+		u += "/" + k.Version
+	}
+	return u
+}
+
+// Empty reports whether no key source is set.
+func (k KeySources) Empty() bool {
+	return k.VaultTransitURI == "" && len(k.AgeRecipients) == 0 &&
+		len(k.PGPFingerprints) == 0 && len(k.KMSKeys) == 0 &&
+		len(k.GCPKMSIDs) == 0 && len(k.AzureKVKeys) == 0
+}
+
+// creationRule renders the key sources as a creation rule without a
+// path_regex. The flat comma-separated fields are used unless a KMS key
+// carries an encryption context or a profile, which SOPS only reads per key
+// from key_groups; in that case all sources are placed in a single key
+// group.
+func (k KeySources) creationRule() sopsCreationRule {
+	for _, key := range k.KMSKeys {
+		if len(key.Context) > 0 || key.Profile != "" {
+			return sopsCreationRule{KeyGroups: []sopsKeyGroup{k.keyGroup()}}
+		}
+	}
+
+	kms := make([]string, len(k.KMSKeys))
+	for i, key := range k.KMSKeys {
+		kms[i] = key.ARN
+		if key.Role != "" {
+			kms[i] += "+" + key.Role
+		}
+	}
+	azkv := make([]string, len(k.AzureKVKeys))
+	for i, key := range k.AzureKVKeys {
+		azkv[i] = key.url()
+	}
+	return sopsCreationRule{
+		KMS:               strings.Join(kms, ","),
+		GCPKMS:            strings.Join(k.GCPKMSIDs, ","),
+		AzureKeyVault:     strings.Join(azkv, ","),
+		HCVaultTransitURI: k.VaultTransitURI,
+		Age:               strings.Join(k.AgeRecipients, ","),
+		PGP:               strings.Join(k.PGPFingerprints, ","),
+	}
+}
+
+// keyGroup renders the key sources as one entry of key_groups.
+func (k KeySources) keyGroup() sopsKeyGroup {
+	group := sopsKeyGroup{
+		Age: k.AgeRecipients,
+		PGP: k.PGPFingerprints,
+	}
+	if k.VaultTransitURI != "" {
+		group.Vault = []string{k.VaultTransitURI}
+	}
+	for _, key := range k.KMSKeys {
+		group.KMS = append(group.KMS, sopsKMSKey{Arn: key.ARN, Role: key.Role, Context: key.Context, AWSProfile: key.Profile})
+	}
+	for _, id := range k.GCPKMSIDs {
+		group.GCPKMS = append(group.GCPKMS, sopsGCPKMSKey{ResourceID: id})
+	}
+	for _, key := range k.AzureKVKeys {
+		group.AzureKV = append(group.AzureKV, sopsAzureKVKey{
+			VaultURL: strings.TrimRight(key.VaultURL, "/"),
+			Key:      key.Key,
+			Version:  key.Version,
+		})
+	}
+	return group
 }
 
 // GenerateSOPSConfig renders a .sops.yaml configuration file that instructs
@@ -31,22 +220,204 @@ type sopsCreationRule struct {
 //
 //	<vaultAddress>/v1/<transitPath>/keys/<keyName>
 func GenerateSOPSConfig(vaultAddress, transitPath, keyName string, pathRegexes []string) (string, error) {
-	uri := strings.TrimRight(vaultAddress, "/") + "/v1/" + transitPath + "/keys/" + keyName
+	return GenerateSOPSConfigForURI(VaultTransitURI(vaultAddress, transitPath, keyName), pathRegexes)
+}
 
-	var rules []sopsCreationRule
+// VaultTransitURI returns the hc_vault_transit_uri SOPS uses to address a
+// Transit key: <vaultAddress>/v1/<transitPath>/keys/<keyName>.
+func VaultTransitURI(vaultAddress, transitPath, keyName string) string {
+	return strings.TrimRight(vaultAddress, "/") + "/v1/" + transitPath + "/keys/" + keyName
+}
+
+// GenerateSOPSConfigForURI is GenerateSOPSConfig for a caller that already
+// holds the full hc_vault_transit_uri.
+func GenerateSOPSConfigForURI(uri string, pathRegexes []string) (string, error) {
+	return GenerateSOPSConfigForKeys(KeySources{VaultTransitURI: uri}, pathRegexes)
+}
+
+// GenerateSOPSConfigForKeys is GenerateSOPSConfig for an arbitrary set of
+// key sources. Each kind of key is written as the comma-separated field that
+// the SOPS CLI expects (age, pgp, kms, gcp_kms, azure_keyvault); a KMS role is
+// appended to its ARN as arn+role, and Azure keys are written as key URLs.
+func GenerateSOPSConfigForKeys(keys KeySources, pathRegexes []string) (string, error) {
 	if len(pathRegexes) == 0 {
-		rules = []sopsCreationRule{{HCVaultTransitURI: uri}}
-	} else {
-		rules = make([]sopsCreationRule, len(pathRegexes))
-		for i, re := range pathRegexes {
-			rules[i] = sopsCreationRule{
-				PathRegex:         re,
-				HCVaultTransitURI: uri,
-			}
+		return GenerateSOPSConfigForRules([]CreationRule{{Keys: keys}})
+	}
+	rules := make([]CreationRule, len(pathRegexes))
+	for i, re := range pathRegexes {
+		rules[i] = CreationRule{PathRegex: re, Keys: keys}
+	}
+	return GenerateSOPSConfigForRules(rules)
+}
+
+// CreationRule is one entry of the creation_rules list. PathRegex may be
+// empty for a catch-all rule. The rule's keys are given either as Keys or as
+// KeyGroups, not both; with KeyGroups, ShamirThreshold is the number of
+// groups needed to recover the data key, and zero means all of them. At most
+// one of the scope options may be set; they behave as the flags of the same
+// name on the SOPS CLI. MACOnlyEncrypted limits the MAC to encrypted values,
+// so plaintext values can change without invalidating the file.
+type CreationRule struct {
+	PathRegex         string
+	Keys              KeySources
+	KeyGroups         []KeySources
+	ShamirThreshold   int
+	UnencryptedSuffix string
+	EncryptedSuffix   string
+	UnencryptedRegex  string
+	EncryptedRegex    string
+	MACOnlyEncrypted  bool
+}
+
+func (r CreationRule) validate() error {
+	switch {
+	case len(r.KeyGroups) == 0 && r.Keys.Empty():
+		return fmt.Errorf("at least one key source is required")
+	case len(r.KeyGroups) > 0 && !r.Keys.Empty():
+		return fmt.Errorf("key sources must be given either directly or as key groups, not both")
+	case r.ShamirThreshold < 0 || r.ShamirThreshold > len(r.KeyGroups):
+		return fmt.Errorf("shamir threshold %d must be between 1 and the number of key groups (%d)", r.ShamirThreshold, len(r.KeyGroups))
+	}
+	for i, g := range r.KeyGroups {
+		if g.Empty() {
+			return fmt.Errorf("key group %d has no key sources", i)
+		}
+	}
+	set := 0
+	for _, v := range []string{r.UnencryptedSuffix, r.EncryptedSuffix, r.UnencryptedRegex, r.EncryptedRegex} {
+		if v != "" {
+			set++
+		}
+	}
+	if set > 1 {
+		return fmt.Errorf("at most one of unencrypted_suffix, encrypted_suffix, unencrypted_regex, encrypted_regex may be set")
+	}
+	return nil
+}
+
+// DestinationRule is one entry of the destination_rules list read by
+// sops publish. Exactly one of S3Bucket, GCSBucket and VaultPath must be set;
+// the matching prefix, address and KV options are only meaningful alongside
+// it. Published files keep their existing keys.
+type DestinationRule struct {
+	PathRegex        string
+	S3Bucket         string
+	S3Prefix         string
+	GCSBucket        string
+	GCSPrefix        string
+	VaultPath        string
+	VaultAddress     string
+	VaultKVMountName string
+	VaultKVVersion   int
+	OmitExtensions   bool
+}
+
+func (r DestinationRule) validate() error {
+	set := 0
+	for _, v := range []string{r.S3Bucket, r.GCSBucket, r.VaultPath} {
+		if v != "" {
+			set++
+		}
+	}
+	if set != 1 {
+		return fmt.Errorf("exactly one of s3_bucket, gcs_bucket, vault_path must be set")
+	}
+	return nil
+}
+
+// ConfigFile is the content of a .sops.yaml file. ExtraCreationRules, if
+// set, is a YAML list of further creation rules appended after
+// CreationRules as written, for sops options that CreationRule does not
+// model.
+type ConfigFile struct {
+	CreationRules      []CreationRule
+	DestinationRules   []DestinationRule
+	ExtraCreationRules string
+}
+
+// ParseExtraCreationRules parses a YAML list of creation rules, as accepted
+// by ConfigFile.ExtraCreationRules, and returns its entries. Each entry must
+// be a mapping; its fields are not checked.
+func ParseExtraCreationRules(content string) ([]*yaml.Node, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(content), &doc); err != nil {
+		return nil, fmt.Errorf("parsing extra creation rules: %w", err)
+	}
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+	list := doc.Content[0]
+	if list.Kind != yaml.SequenceNode {
+		return nil, fmt.Errorf("extra creation rules must be a YAML list, got a %s at line %d", nodeKind(list), list.Line)
+	}
+	for i, rule := range list.Content {
+		if rule.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("extra creation rule %d must be a mapping, got a %s at line %d", i, nodeKind(rule), rule.Line)
 		}
 	}
+	return list.Content, nil
+}
+
+// nodeKind describes the kind of n for error messages.
+func nodeKind(n *yaml.Node) string {
+	switch n.Kind {
+	case yaml.MappingNode:
+		return "mapping"
+	case yaml.SequenceNode:
+		return "list"
+	case yaml.AliasNode:
+		return "alias"
+	}
+	return "scalar"
+}
+
+// GenerateSOPSConfigForRules renders a .sops.yaml configuration file with
+// one creation_rule per entry, in order. SOPS uses the first rule whose
+// path_regex matches, so catch-all rules belong last.
+func GenerateSOPSConfigForRules(rules []CreationRule) (string, error) {
+	return GenerateSOPSConfigFile(ConfigFile{CreationRules: rules})
+}
 
-	cfg := sopsFileConfig{CreationRules: rules}
+// GenerateSOPSConfigFile renders a .sops.yaml configuration file with both
+// creation and destination rules. As with creation rules, sops publish uses
+// the first destination rule whose path_regex matches.
+func GenerateSOPSConfigFile(file ConfigFile) (string, error) {
+	extra, err := ParseExtraCreationRules(file.ExtraCreationRules)
+	if err != nil {
+		return "", err
+	}
+	rules := file.CreationRules
+	cfg := sopsFileConfig{CreationRules: make([]any, len(rules), len(rules)+len(extra))}
+	for i, r := range rules {
+		if err := r.validate(); err != nil {
+			return "", fmt.Errorf("creation rule %d: %w", i, err)
+		}
+		var rule sopsCreationRule
+		if len(r.KeyGroups) > 0 {
+			for _, g := range r.KeyGroups {
+				rule.KeyGroups = append(rule.KeyGroups, g.keyGroup())
+			}
+			rule.ShamirThreshold = r.ShamirThreshold
+		} else {
+			rule = r.Keys.creationRule()
+		}
+		rule.PathRegex = r.PathRegex
+		rule.UnencryptedSuffix = r.UnencryptedSuffix
+		rule.EncryptedSuffix = r.EncryptedSuffix
+		rule.UnencryptedRegex = r.UnencryptedRegex
+		rule.EncryptedRegex = r.EncryptedRegex
+		rule.MACOnlyEncrypted = r.MACOnlyEncrypted
+		cfg.CreationRules[i] = rule
+	}
+	for _, rule := range extra {
+		cfg.CreationRules = append(cfg.CreationRules, rule)
+	}
+	for i, r := range file.DestinationRules {
+		if err := r.validate(); err != nil {
+			return "", fmt.Errorf("destination rule %d: %w", i, err)
+		}
+		cfg.DestinationRules = append(cfg.DestinationRules, sopsDestinationRule(r))
+	}
 
 	var buf bytes.Buffer
 	enc := yaml.NewEncoder(&buf)
@@ -140,3 +140,468 @@ func TestGenerateSOPSConfig_EmptyRegexListEqualsNil(t *testing.T) {
 		t.Error("nil and empty pathRegexes should produce identical output")
 	}
 }
+
+func TestGenerateSOPSConfigForURI_MatchesAddressForm(t *testing.T) {
+	fromParts, err := sopsencrypt.GenerateSOPSConfig("http://127.0.0.1:8200/", "transit", "k", []string{`\.yaml$`})
+	if err != nil {
+		t.Fatalf("GenerateSOPSConfig: %v", err)
+	}
+	fromURI, err := sopsencrypt.GenerateSOPSConfigForURI("http://127.0.0.1:8200/v1/transit/keys/k", []string{`\.yaml$`})
+	if err != nil {
+		t.Fatalf("GenerateSOPSConfigForURI: %v", err)
+	}
+	if fromParts != fromURI {
+		t.Errorf("outputs differ:\n%s\n---\n%s", fromParts, fromURI)
+	}
+}
+
+func TestGenerateSOPSConfigForKeys_AgeAlongsideVault(t *testing.T) {
+	content, err := sopsencrypt.GenerateSOPSConfigForKeys(sopsencrypt.KeySources{
+		VaultTransitURI: "http://127.0.0.1:8200/v1/transit/keys/k",
+		AgeRecipients:   []string{"age1aaa", "age1bbb"},
+	}, []string{`\.yaml$`})
+	if err != nil {
+		t.Fatalf("GenerateSOPSConfigForKeys: %v", err)
+	}
+
+	var doc struct {
+		CreationRules []struct {
+			PathRegex         string `yaml:"path_regex"`
+			HCVaultTransitURI string `yaml:"hc_vault_transit_uri"`
+			Age               string `yaml:"age"`
+		} `yaml:"creation_rules"`
+	}
+	if err := yaml.Unmarshal([]byte(content), &doc); err != nil {
+		t.Fatalf("output is not valid YAML: %v", err)
+	}
+	if len(doc.CreationRules) != 1 {
+		t.Fatalf("want 1 creation rule, got %d", len(doc.CreationRules))
+	}
+	rule := doc.CreationRules[0]
+	if rule.Age != "age1aaa,age1bbb" {
+		t.Errorf("age = %q, want comma-separated recipients", rule.Age)
+	}
+	if rule.HCVaultTransitURI == "" {
+		t.Error("hc_vault_transit_uri missing")
+	}
+}
+
+func TestGenerateSOPSConfigForKeys_AgeOnly(t *testing.T) {
+	content, err := sopsencrypt.GenerateSOPSConfigForKeys(sopsencrypt.KeySources{
+		AgeRecipients: []string{"age1aaa"},
+	}, nil)
+	if err != nil {
+		t.Fatalf("GenerateSOPSConfigForKeys: %v", err)
+	}
+	if strings.Contains(content, "hc_vault_transit_uri") {
+		t.Errorf("hc_vault_transit_uri must be omitted when unset:\n%s", content)
+	}
+	if !strings.Contains(content, "age: age1aaa") {
+		t.Errorf("age recipient missing:\n%s", content)
+	}
+}
+
+func TestGenerateSOPSConfigForKeys_NoKeySources(t *testing.T) {
+	if _, err := sopsencrypt.GenerateSOPSConfigForKeys(sopsencrypt.KeySources{}, nil); err == nil {
+		t.Error("expected error when no key source is set")
+	}
+}
+
+func TestGenerateSOPSConfigForKeys_PGP(t *testing.T) {
+	content, err := sopsencrypt.GenerateSOPSConfigForKeys(sopsencrypt.KeySources{
+		PGPFingerprints: []string{"FBC7B9E2A4F9289AC0C1D4843D16CEE4A27381B4", "D7229043384BCC60326C6FB9D8720D957C3D3074"},
+	}, nil)
+	if err != nil {
+		t.Fatalf("GenerateSOPSConfigForKeys: %v", err)
+	}
+	want := "pgp: FBC7B9E2A4F9289AC0C1D4843D16CEE4A27381B4,D7229043384BCC60326C6FB9D8720D957C3D3074"
+	if !strings.Contains(content, want) {
+		t.Errorf("pgp fingerprints missing:\n%s", content)
+	}
+	if strings.Contains(content, "age:") {
+		t.Errorf("age must be omitted when unset:\n%s", content)
+	}
+}
+
+func TestGenerateSOPSConfigForKeys_KMSWithRole(t *testing.T) {
+	content, err := sopsencrypt.GenerateSOPSConfigForKeys(sopsencrypt.KeySources{
+		VaultTransitURI: "http://127.0.0.1:8200/v1/transit/keys/k",
+		KMSKeys: []sopsencrypt.KMSKey{
+			{ARN: "arn:aws:kms:us-east-1:111122223333:key/a"},
+			{ARN: "arn:aws:kms:us-east-1:111122223333:key/b", Role: "arn:aws:iam::111122223333:role/sops"},
+		},
+	}, nil)
+	if err != nil {
+		t.Fatalf("GenerateSOPSConfigForKeys: %v", err)
+	}
+	want := "kms: arn:aws:kms:us-east-1:111122223333:key/a,arn:aws:kms:us-east-1:111122223333:key/b+arn:aws:iam::111122223333:role/sops"
+	if !strings.Contains(content, want) {
+		t.Errorf("kms field missing or malformed:\n%s", content)
+	}
+	if strings.Contains(content, "key_groups") {
+		t.Errorf("key_groups must not be used without an encryption context:\n%s", content)
+	}
+}
+
+func TestGenerateSOPSConfigForKeys_KMSContextUsesKeyGroup(t *testing.T) {
+	content, err := sopsencrypt.GenerateSOPSConfigForKeys(sopsencrypt.KeySources{
+		VaultTransitURI: "http://127.0.0.1:8200/v1/transit/keys/k",
+		AgeRecipients:   []string{"age1aaa"},
+		KMSKeys: []sopsencrypt.KMSKey{{
+			ARN:     "arn:aws:kms:us-east-1:111122223333:key/a",
+			Role:    "arn:aws:iam::111122223333:role/sops",
+			Context: map[string]string{"app": "web"},
+		}},
+	}, []string{`\.yaml$`})
+	if err != nil {
+		t.Fatalf("GenerateSOPSConfigForKeys: %v", err)
+	}
+
+	var doc struct {
+		CreationRules []struct {
+			PathRegex         string `yaml:"path_regex"`
+			KMS               string `yaml:"kms"`
+			HCVaultTransitURI string `yaml:"hc_vault_transit_uri"`
+			KeyGroups         []struct {
+				KMS []struct {
+					Arn     string            `yaml:"arn"`
+					Role    string            `yaml:"role"`
+					Context map[string]string `yaml:"context"`
+				} `yaml:"kms"`
+				Vault []string `yaml:"hc_vault"`
+				Age   []string `yaml:"age"`
+			} `yaml:"key_groups"`
+		} `yaml:"creation_rules"`
+	}
+	if err := yaml.Unmarshal([]byte(content), &doc); err != nil {
+		t.Fatalf("output is not valid YAML: %v", err)
+	}
+	if len(doc.CreationRules) != 1 {
+		t.Fatalf("want 1 creation rule, got %d", len(doc.CreationRules))
+	}
+	rule := doc.CreationRules[0]
+	if rule.PathRegex != `\.yaml$` {
+		t.Errorf("path_regex = %q", rule.PathRegex)
+	}
+	if rule.KMS != "" || rule.HCVaultTransitURI != "" {
+		t.Errorf("flat key fields must be empty when key_groups is used:\n%s", content)
+	}
+	if len(rule.KeyGroups) != 1 {
+		t.Fatalf("want 1 key group, got %d", len(rule.KeyGroups))
+	}
+	group := rule.KeyGroups[0]
+	if len(group.KMS) != 1 || group.KMS[0].Context["app"] != "web" || group.KMS[0].Role == "" {
+		t.Errorf("kms key group entry = %+v", group.KMS)
+	}
+	if len(group.Vault) != 1 || len(group.Age) != 1 {
+		t.Errorf("other key sources missing from group:\n%s", content)
+	}
+}
+
+func TestGenerateSOPSConfigForKeys_GCPKMS(t *testing.T) {
+	ids := []string{
+		"projects/p/locations/global/keyRings/r/cryptoKeys/a",
+		"projects/p/locations/global/keyRings/r/cryptoKeys/b",
+	}
+	content, err := sopsencrypt.GenerateSOPSConfigForKeys(sopsencrypt.KeySources{GCPKMSIDs: ids}, nil)
+	if err != nil {
+		t.Fatalf("GenerateSOPSConfigForKeys: %v", err)
+	}
+	if !strings.Contains(content, "gcp_kms: "+strings.Join(ids, ",")) {
+		t.Errorf("gcp_kms field missing:\n%s", content)
+	}
+
+	content, err = sopsencrypt.GenerateSOPSConfigForKeys(sopsencrypt.KeySources{
+		GCPKMSIDs: ids[:1],
+		KMSKeys:   []sopsencrypt.KMSKey{{ARN: "arn:aws:kms:us-east-1:111122223333:key/a", Context: map[string]string{"app": "web"}}},
+	}, nil)
+	if err != nil {
+		t.Fatalf("GenerateSOPSConfigForKeys: %v", err)
+	}
+	if !strings.Contains(content, "resource_id: "+ids[0]) {
+		t.Errorf("gcp_kms key group entry missing:\n%s", content)
+	}
+}
+
+func TestGenerateSOPSConfigForKeys_AzureKeyVault(t *testing.T) {
+	content, err := sopsencrypt.GenerateSOPSConfigForKeys(sopsencrypt.KeySources{
+		AzureKVKeys: []sopsencrypt.AzureKVKey{
+			{VaultURL: "https://v.vault.azure.net/", Key: "a", Version: "1234"},
+			{VaultURL: "https://v.vault.azure.net", Key: "b"},
+		},
+	}, nil)
+	if err != nil {
+		t.Fatalf("GenerateSOPSConfigForKeys: %v", err)
+	}
+	want := "azure_keyvault: https://v.vault.azure.net/keys/a/1234,https://v.vault.azure.net/keys/b"
+	if !strings.Contains(content, want) {
+		t.Errorf("azure_keyvault field missing or malformed:\n%s", content)
+	}
+}
+
+func TestGenerateSOPSConfigForRules_Scope(t *testing.T) {
+	keys := sopsencrypt.KeySources{VaultTransitURI: "http://127.0.0.1:8200/v1/transit/keys/k"}
+	content, err := sopsencrypt.GenerateSOPSConfigForRules([]sopsencrypt.CreationRule{
+		{PathRegex: `^secrets/`, Keys: keys, EncryptedRegex: "^(data|stringData)$"},
+		{Keys: keys, UnencryptedSuffix: "_unencrypted"},
+	})
+	if err != nil {
+		t.Fatalf("GenerateSOPSConfigForRules: %v", err)
+	}
+
+	var doc struct {
+		CreationRules []struct {
+			PathRegex         string `yaml:"path_regex"`
+			UnencryptedSuffix string `yaml:"unencrypted_suffix"`
+			EncryptedRegex    string `yaml:"encrypted_regex"`
+		} `yaml:"creation_rules"`
+	}
+	if err := yaml.Unmarshal([]byte(content), &doc); err != nil {
+		t.Fatalf("output is not valid YAML: %v", err)
+	}
+	if len(doc.CreationRules) != 2 {
+		t.Fatalf("want 2 creation rules, got %d", len(doc.CreationRules))
+	}
+	if got := doc.CreationRules[0].EncryptedRegex; got != "^(data|stringData)$" {
+		t.Errorf("rule 0 encrypted_regex = %q", got)
+	}
+	if got := doc.CreationRules[1].UnencryptedSuffix; got != "_unencrypted" {
+		t.Errorf("rule 1 unencrypted_suffix = %q", got)
+	}
+	if strings.Contains(content, "unencrypted_regex:") || strings.Contains(content, " encrypted_suffix:") {
+		t.Errorf("unset scope options must be omitted:\n%s", content)
+	}
+}
+
+func TestGenerateSOPSConfigForRules_ConflictingScope(t *testing.T) {
+	_, err := sopsencrypt.GenerateSOPSConfigForRules([]sopsencrypt.CreationRule{{
+		Keys:             sopsencrypt.KeySources{AgeRecipients: []string{"age1aaa"}},
+		EncryptedRegex:   "^data$",
+		UnencryptedRegex: "^meta$",
+	}})
+	if err == nil {
+		t.Error("expected error when two scope options are set")
+	}
+}
+
+func TestGenerateSOPSConfigForRules_KeyGroups(t *testing.T) {
+	content, err := sopsencrypt.GenerateSOPSConfigForRules([]sopsencrypt.CreationRule{{
+		KeyGroups: []sopsencrypt.KeySources{
+			{VaultTransitURI: "http://127.0.0.1:8200/v1/transit/keys/k"},
+			{AgeRecipients: []string{"age1aaa"}, PGPFingerprints: []string{"FBC7B9E2A4F9289AC0C1D4843D16CEE4A27381B4"}},
+		},
+		ShamirThreshold: 2,
+	}})
+	if err != nil {
+		t.Fatalf("GenerateSOPSConfigForRules: %v", err)
+	}
+
+	var doc struct {
+		CreationRules []struct {
+			HCVaultTransitURI string `yaml:"hc_vault_transit_uri"`
+			KeyGroups         []struct {
+				Vault []string `yaml:"hc_vault"`
+				Age   []string `yaml:"age"`
+				PGP   []string `yaml:"pgp"`
+			} `yaml:"key_groups"`
+			ShamirThreshold int `yaml:"shamir_threshold"`
+		} `yaml:"creation_rules"`
+	}
+	if err := yaml.Unmarshal([]byte(content), &doc); err != nil {
+		t.Fatalf("output is not valid YAML: %v", err)
+	}
+	rule := doc.CreationRules[0]
+	if rule.ShamirThreshold != 2 {
+		t.Errorf("shamir_threshold = %d, want 2", rule.ShamirThreshold)
+	}
+	if len(rule.KeyGroups) != 2 {
+		t.Fatalf("want 2 key groups, got %d", len(rule.KeyGroups))
+	}
+	if len(rule.KeyGroups[0].Vault) != 1 || len(rule.KeyGroups[1].Age) != 1 || len(rule.KeyGroups[1].PGP) != 1 {
+		t.Errorf("unexpected key groups:\n%s", content)
+	}
+	if rule.HCVaultTransitURI != "" {
+		t.Errorf("flat key fields must be empty when key_groups is used:\n%s", content)
+	}
+}
+
+func TestGenerateSOPSConfigForRules_InvalidKeyGroups(t *testing.T) {
+	age := sopsencrypt.KeySources{AgeRecipients: []string{"age1aaa"}}
+	for name, rule := range map[string]sopsencrypt.CreationRule{
+		"keys and groups":     {Keys: age, KeyGroups: []sopsencrypt.KeySources{age}},
+		"empty group":         {KeyGroups: []sopsencrypt.KeySources{age, {}}},
+		"threshold too large": {KeyGroups: []sopsencrypt.KeySources{age}, ShamirThreshold: 2},
+		"threshold no groups": {Keys: age, ShamirThreshold: 1},
+	} {
+		if _, err := sopsencrypt.GenerateSOPSConfigForRules([]sopsencrypt.CreationRule{rule}); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestGenerateSOPSConfigFile_DestinationRules(t *testing.T) {
+	content, err := sopsencrypt.GenerateSOPSConfigFile(sopsencrypt.ConfigFile{
+		CreationRules: []sopsencrypt.CreationRule{{Keys: sopsencrypt.KeySources{AgeRecipients: []string{"age1aaa"}}}},
+		DestinationRules: []sopsencrypt.DestinationRule{
+			{PathRegex: `^s3/`, S3Bucket: "bucket", S3Prefix: "sops/"},
+			{PathRegex: `^vault/`, VaultPath: "app", VaultAddress: "http://127.0.0.1:8200", VaultKVVersion: 2, OmitExtensions: true},
+		},
+	})
+	if err != nil {
+		t.Fatalf("GenerateSOPSConfigFile: %v", err)
+	}
+
+	var doc struct {
+		DestinationRules []struct {
+			PathRegex      string `yaml:"path_regex"`
+			S3Bucket       string `yaml:"s3_bucket"`
+			S3Prefix       string `yaml:"s3_prefix"`
+			VaultPath      string `yaml:"vault_path"`
+			VaultAddress   string `yaml:"vault_address"`
+			VaultKVVersion int    `yaml:"vault_kv_version"`
+			OmitExtensions bool   `yaml:"omit_extensions"`
+		} `yaml:"destination_rules"`
+	}
+	if err := yaml.Unmarshal([]byte(content), &doc); err != nil {
+		t.Fatalf("output is not valid YAML: %v", err)
+	}
+	if len(doc.DestinationRules) != 2 {
+		t.Fatalf("want 2 destination rules, got %d:\n%s", len(doc.DestinationRules), content)
+	}
+	if r := doc.DestinationRules[0]; r.S3Bucket != "bucket" || r.S3Prefix != "sops/" {
+		t.Errorf("s3 rule = %+v", r)
+	}
+	if r := doc.DestinationRules[1]; r.VaultPath != "app" || r.VaultKVVersion != 2 || !r.OmitExtensions {
+		t.Errorf("vault rule = %+v", r)
+	}
+	if strings.Contains(content, "gcs_bucket") {
+		t.Errorf("unset destination fields must be omitted:\n%s", content)
+	}
+}
+
+func TestGenerateSOPSConfigFile_DestinationRuleNeedsOneDestination(t *testing.T) {
+	keys := []sopsencrypt.CreationRule{{Keys: sopsencrypt.KeySources{AgeRecipients: []string{"age1aaa"}}}}
+	for name, rule := range map[string]sopsencrypt.DestinationRule{
+		"none": {PathRegex: `^s3/`},
+		"two":  {S3Bucket: "bucket", GCSBucket: "bucket"},
+	} {
+		_, err := sopsencrypt.GenerateSOPSConfigFile(sopsencrypt.ConfigFile{CreationRules: keys, DestinationRules: []sopsencrypt.DestinationRule{rule}})
+		if err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestGenerateSOPSConfigForRules_NoDestinationRules(t *testing.T) {
+	content, err := sopsencrypt.GenerateSOPSConfigForKeys(sopsencrypt.KeySources{AgeRecipients: []string{"age1aaa"}}, nil)
+	if err != nil {
+		t.Fatalf("GenerateSOPSConfigForKeys: %v", err)
+	}
+	if strings.Contains(content, "destination_rules") {
+		t.Errorf("destination_rules must be omitted when empty:\n%s", content)
+	}
+}
+
+func TestGenerateSOPSConfigForRules_MACOnlyEncrypted(t *testing.T) {
+	keys := sopsencrypt.KeySources{AgeRecipients: []string{"age1aaa"}}
+	content, err := sopsencrypt.GenerateSOPSConfigForRules([]sopsencrypt.CreationRule{
+		{PathRegex: `^partial/`, Keys: keys, EncryptedRegex: "^password$", MACOnlyEncrypted: true},
+		{Keys: keys},
+	})
+	if err != nil {
+		t.Fatalf("GenerateSOPSConfigForRules: %v", err)
+	}
+	if strings.Count(content, "mac_only_encrypted: true") != 1 {
+		t.Errorf("want mac_only_encrypted on the first rule only:\n%s", content)
+	}
+}
+
+func TestGenerateSOPSConfigFile_ExtraCreationRules(t *testing.T) {
+	content, err := sopsencrypt.GenerateSOPSConfigFile(sopsencrypt.ConfigFile{
+		CreationRules: []sopsencrypt.CreationRule{
+			{PathRegex: `^app/`, Keys: sopsencrypt.KeySources{AgeRecipients: []string{"age1aaa"}}},
+		},
+		ExtraCreationRules: "- path_regex: ^legacy/\n  pgp: ABCDEF\n  input_type: dotenv\n",
+	})
+	if err != nil {
+		t.Fatalf("GenerateSOPSConfigFile: %v", err)
+	}
+	var cfg struct {
+		CreationRules []map[string]any `yaml:"creation_rules"`
+	}
+	if err := yaml.Unmarshal([]byte(content), &cfg); err != nil {
+		t.Fatalf("output is not valid YAML: %v", err)
+	}
+	if len(cfg.CreationRules) != 2 {
+		t.Fatalf("want 2 creation rules, got %d:\n%s", len(cfg.CreationRules), content)
+	}
+	extra := cfg.CreationRules[1]
+	if extra["path_regex"] != "^legacy/" || extra["input_type"] != "dotenv" {
+		t.Errorf("extra rule not passed through:\n%s", content)
+	}
+
+	for _, bad := range []string{"path_regex: x", "- just a string", "- [a, b]", "- {"} {
+		if _, err := sopsencrypt.GenerateSOPSConfigFile(sopsencrypt.ConfigFile{ExtraCreationRules: bad}); err == nil {
+			t.Errorf("%q: expected error", bad)
+		}
+	}
+}
+
+func TestGenerateSOPSConfigForKeys_KMSProfileUsesKeyGroup(t *testing.T) {
+	content, err := sopsencrypt.GenerateSOPSConfigForKeys(sopsencrypt.KeySources{
+		KMSKeys: []sopsencrypt.KMSKey{
+			{ARN: "arn:aws:kms:us-east-1:111122223333:key/a", Role: "arn:aws:iam::111122223333:role/sops"},
+			{ARN: "arn:aws:kms:eu-west-1:444455556666:key/b", Profile: "shared-services"},
+		},
+	}, nil)
+	if err != nil {
+		t.Fatalf("GenerateSOPSConfigForKeys: %v", err)
+	}
+	for _, want := range []string{
+		"key_groups:",
+		"- arn: arn:aws:kms:us-east-1:111122223333:key/a\n            role: arn:aws:iam::111122223333:role/sops\n",
+		"- arn: arn:aws:kms:eu-west-1:444455556666:key/b\n            aws_profile: shared-services\n",
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("content missing %q:\n%s", want, content)
+		}
+	}
+}
+
+func TestKMSKeyReplicas(t *testing.T) {
+	key := sopsencrypt.KMSKey{
+		ARN:  "arn:aws:kms:us-east-1:111122223333:key/mrk-1234abcd12ab34cd56ef1234567890ab",
+		Role: "arn:aws:iam::111122223333:role/sops",
+	}
+	keys, err := key.Replicas([]string{"eu-west-1", "ap-southeast-2"})
+	if err != nil {
+		t.Fatalf("Replicas: %v", err)
+	}
+	want := []string{
+		"arn:aws:kms:us-east-1:111122223333:key/mrk-1234abcd12ab34cd56ef1234567890ab",
+		"arn:aws:kms:eu-west-1:111122223333:key/mrk-1234abcd12ab34cd56ef1234567890ab",
+		"arn:aws:kms:ap-southeast-2:111122223333:key/mrk-1234abcd12ab34cd56ef1234567890ab",
+	}
+	if len(keys) != len(want) {
+		t.Fatalf("got %d keys, want %d", len(keys), len(want))
+	}
+	for i, k := range keys {
+		if k.ARN != want[i] || k.Role != key.Role {
+			t.Errorf("key %d = %+v, want ARN %s with the role of the primary", i, k, want[i])
+		}
+	}
+
+	for _, tc := range []struct {
+		arn     string
+		regions []string
+	}{
+		{"arn:aws:kms:us-east-1:111122223333:key/1234abcd-12ab-34cd-56ef-1234567890ab", []string{"eu-west-1"}},
+		{"arn:aws:kms:us-east-1:111122223333:alias/sops", []string{"eu-west-1"}},
+		{key.ARN, []string{"us-east-1"}},
+		{key.ARN, []string{"eu-west-1", "eu-west-1"}},
+	} {
+		if _, err := (sopsencrypt.KMSKey{ARN: tc.arn}).Replicas(tc.regions); err == nil {
+			t.Errorf("Replicas(%s, %v): expected an error", tc.arn, tc.regions)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package sopsencrypt
+
+import (
+	"sync"
+
+	vaultapi "github.com/hashicorp/vault/api"
+)
+
+// DataKey is a SOPS data key together with its Vault Transit wrapped form,
+// as recorded in the enc field of a document's Vault key source.
+type DataKey struct {
+	// Key is the 32-byte AES-256 data key.
+	Key []byte
+	// Wrapped is the Transit ciphertext of Key, e.g. "vault:v1:…".
+	Wrapped string
+	// RequestID is the ID of the Vault request that wrapped Key, as
+	// recorded in the Vault audit log. Empty in mock mode.
+	RequestID string
+}
+
+// NewDataKey generates a data key and wraps it with the Vault Transit key
+// keyName, creating the key with createKeyType first when that is set. With
+// mock, Vault is not called and client may be nil: the key is all zeros and
+// Wrapped is the placeholder used by mock documents.
+func NewDataKey(client *vaultapi.Client, transitPath, keyName, createKeyType string, mock bool) (DataKey, error) {
+	if mock {
+		return DataKey{Key: make([]byte, 32), Wrapped: mockEncryptedKey}, nil
+	}
+	key, err := generateDataKey()
+	if err != nil {
+		return DataKey{}, err
+	}
+	if createKeyType != "" {
+		if err := EnsureTransitKey(client, transitPath, keyName, createKeyType); err != nil {
+			return DataKey{}, err
+		}
+	}
+	wrapped, requestID, err := wrapDataKey(client, transitPath, keyName, key)
+	if err != nil {
+		return DataKey{}, err
+	}
+	return DataKey{Key: key, Wrapped: wrapped, RequestID: requestID}, nil
+}
+
+// DataKeyCache hands out the data keys of NewDataKey, reusing each one for
+// further encryptions with the same Transit key, so that N documents cost
+// one Transit call instead of N. Every document encrypted with a cached key
+// shares its data key and wrapped form with the others.
+//
+// A DataKeyCache is safe for concurrent use and is meant to live no longer
+// than one provider instance, i.e. one plan or apply.
+type DataKeyCache struct {
+	// maxUses is the number of encryptions a key serves before it is
+	// replaced; 0 means no limit.
+	maxUses int
+
+	// mu is held while a new key is wrapped, so concurrent encryptions
+	// with the same Transit key wait for one key instead of each making
+	// their own.
+	mu   sync.Mutex
+	keys map[string]*cachedDataKey
+}
+
+type cachedDataKey struct {
+	key  DataKey
+	uses int
+}
+
+// NewDataKeyCache returns a DataKeyCache whose keys serve at most maxUses
+// encryptions each; zero or less removes the limit.
+func NewDataKeyCache(maxUses int) *DataKeyCache {
+	return &DataKeyCache{maxUses: max(maxUses, 0), keys: map[string]*cachedDataKey{}}
+}
+
+// Get returns the cached data key of the Transit key keyName at the Vault
+// server and namespace of client, or a new one from NewDataKey when there is
+// none yet or the cached key has served maxUses encryptions.
+func (c *DataKeyCache) Get(client *vaultapi.Client, transitPath, keyName, createKeyType string) (DataKey, error) {
+	id := client.Address() + "\x00" + client.Namespace() + "\x00" + transitPath + "\x00" + keyName
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if cached, ok := c.keys[id]; ok && (c.maxUses == 0 || cached.uses < c.maxUses) {
+		cached.uses++
+		return cached.key, nil
+	}
+	key, err := NewDataKey(client, transitPath, keyName, createKeyType, false)
+	if err != nil {
+		return DataKey{}, err
+	}
+	c.keys[id] = &cachedDataKey{key: key, uses: 1}
+	return key, nil
+}
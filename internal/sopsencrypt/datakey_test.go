@@ -0,0 +1,103 @@
+package sopsencrypt_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"terraform-provider-sops/internal/sopsencrypt"
+)
+
+func TestNewDataKey(t *testing.T) {
+	srv := mockVaultServer(t)
+	defer srv.Close()
+
+	dk, err := sopsencrypt.NewDataKey(newTestClient(t, srv), "transit", "test-key", "", false)
+	if err != nil {
+		t.Fatalf("NewDataKey: %v", err)
+	}
+	if len(dk.Key) != 32 {
+		t.Errorf("len(Key) = %d, want 32", len(dk.Key))
+	}
+	// The mock server wraps as vault:v1:<base64(plaintext)>.
+	wrapped, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(dk.Wrapped, "vault:v1:"))
+	if err != nil || !bytes.Equal(wrapped, dk.Key) {
+		t.Errorf("Wrapped = %q does not wrap Key", dk.Wrapped)
+	}
+}
+
+func TestNewDataKey_Mock(t *testing.T) {
+	dk, err := sopsencrypt.NewDataKey(nil, "transit", "test-key", "", true)
+	if err != nil {
+		t.Fatalf("NewDataKey: %v", err)
+	}
+	if !bytes.Equal(dk.Key, make([]byte, 32)) || dk.Wrapped != "vault:v0:mock" {
+		t.Errorf("NewDataKey in mock mode = %x, %q", dk.Key, dk.Wrapped)
+	}
+}
+
+func TestDataKeyCache(t *testing.T) {
+	srv := mockVaultServer(t)
+	defer srv.Close()
+	client := newTestClient(t, srv)
+
+	cache := sopsencrypt.NewDataKeyCache(2)
+	get := func(keyName string) sopsencrypt.DataKey {
+		t.Helper()
+		dk, err := cache.Get(client, "transit", keyName, "")
+		if err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+		return dk
+	}
+	first, second, other := get("app"), get("app"), get("other")
+	if !bytes.Equal(first.Key, second.Key) || first.Wrapped != second.Wrapped {
+		t.Error("second Get did not reuse the cached key")
+	}
+	if bytes.Equal(first.Key, other.Key) {
+		t.Error("another Transit key shares the cached key")
+	}
+	// The key has served maxUses encryptions.
+	if third := get("app"); bytes.Equal(first.Key, third.Key) {
+		t.Error("third Get reused a key past maxUses")
+	}
+}
+
+func TestEncryptToJSON_SharedDataKey(t *testing.T) {
+	srv := mockVaultServer(t)
+	defer srv.Close()
+	client := newTestClient(t, srv)
+
+	dk, err := sopsencrypt.NewDataKey(client, "transit", "test-key", "", false)
+	if err != nil {
+		t.Fatalf("NewDataKey: %v", err)
+	}
+	// With only the key, each document wraps it again; with the wrapped
+	// key too, it is recorded as is.
+	for _, shared := range []sopsencrypt.DataKey{{Key: dk.Key}, dk} {
+		for _, content := range []string{`{"a":"1"}`, `{"b":"2"}`} {
+			doc, err := sopsencrypt.EncryptToJSON(client, "transit", "test-key", content, sopsencrypt.EncryptOpts{DataKey: &shared})
+			if err != nil {
+				t.Fatalf("EncryptToJSON: %v", err)
+			}
+			if !strings.Contains(doc, dk.Wrapped) {
+				t.Errorf("document does not record the wrapped key %q:\n%s", dk.Wrapped, doc)
+			}
+			if _, err := sopsencrypt.Decrypt(client, doc, "json"); err != nil {
+				t.Errorf("Decrypt: %v", err)
+			}
+		}
+	}
+}
+
+func TestEncryptToJSON_InvalidDataKey(t *testing.T) {
+	srv := mockVaultServer(t)
+	defer srv.Close()
+
+	_, err := sopsencrypt.EncryptToJSON(newTestClient(t, srv), "transit", "test-key", `{"a":"1"}`,
+		sopsencrypt.EncryptOpts{DataKey: &sopsencrypt.DataKey{Key: make([]byte, 16)}})
+	if err == nil || !strings.Contains(err.Error(), "32 bytes") {
+		t.Errorf("EncryptToJSON error = %v, want a data key length error", err)
+	}
+}
@@ -0,0 +1,224 @@
+package sopsencrypt
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/getsops/sops/v3"
+	"github.com/getsops/sops/v3/aes"
+	sopsconfig "github.com/getsops/sops/v3/config"
+	"github.com/getsops/sops/v3/keyservice"
+	"github.com/getsops/sops/v3/stores/dotenv"
+	"github.com/getsops/sops/v3/stores/ini"
+	sopsjson "github.com/getsops/sops/v3/stores/json"
+	sopsyaml "github.com/getsops/sops/v3/stores/yaml"
+	vaultapi "github.com/hashicorp/vault/api"
+	"google.golang.org/grpc"
+)
+
+// InputTypes are the document formats Decrypt accepts, as for
+// `sops -d --input-type`.
+var InputTypes = []string{"json", "yaml", "dotenv", "ini", "binary"}
+
+// DetectInputType guesses the format of an encrypted document the way
+// ParseMetadata does: "json" if it starts with '{', "yaml" otherwise.
+func DetectInputType(document string) string {
+	if strings.HasPrefix(strings.TrimSpace(document), "{") {
+		return "json"
+	}
+	return "yaml"
+}
+
+// DetectInputTypeForPath guesses the format of an encrypted document read
+// from name by its extension, as the sops CLI does, and falls back to
+// DetectInputType for other extensions.
+func DetectInputTypeForPath(name, document string) string {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".json":
+		return "json"
+	case ".yaml", ".yml":
+		return "yaml"
+	case ".env":
+		return "dotenv"
+	case ".ini":
+		return "ini"
+	}
+	return DetectInputType(document)
+}
+
+// Decrypt decrypts a SOPS document of the given input type and returns the
+// plaintext in the same format, as `sops -d --input-type <inputType>` would;
+// for "binary" that is the original file content. The data key is unwrapped
+// with Vault Transit through client, which is the only key source: documents
+// whose key groups can only be opened with other keys, such as age
+// identities, fail. The MAC is verified before anything is returned.
+func Decrypt(client *vaultapi.Client, document, inputType string) ([]byte, error) {
+	store, err := storeFor(inputType)
+	if err != nil {
+		return nil, err
+	}
+	tree, err := store.LoadEncryptedFile([]byte(document))
+	if err != nil {
+		return nil, fmt.Errorf("parsing SOPS %s document: %w", inputType, err)
+	}
+	dataKey, err := unwrapDataKey(client, tree)
+	if err != nil {
+		return nil, err
+	}
+	if err := decryptTree(&tree, dataKey); err != nil {
+		return nil, err
+	}
+
+	out, err := store.EmitPlainFile(tree.Branches)
+	if err != nil {
+		return nil, fmt.Errorf("emitting plaintext %s document: %w", inputType, err)
+	}
+	return out, nil
+}
+
+// DecryptFromJSON decrypts a SOPS JSON document, such as the output of
+// EncryptToJSON, with Vault Transit through client and returns the plaintext
+// JSON document, as `sops -d --input-type json` would. See Decrypt.
+func DecryptFromJSON(client *vaultapi.Client, document string) (string, error) {
+	plaintext, err := Decrypt(client, document, "json")
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// DecryptFromYAML decrypts a SOPS YAML document, such as the output of
+// EncryptToYAML, with Vault Transit through client and returns the plaintext
+// YAML document, comments included, as `sops -d --input-type yaml` would.
+// See Decrypt.
+func DecryptFromYAML(client *vaultapi.Client, document string) (string, error) {
+	plaintext, err := Decrypt(client, document, "yaml")
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// errMACMismatch reports that a document's values do not match its MAC.
+var errMACMismatch = errors.New("MAC mismatch: the document was modified after encryption")
+
+// unwrapDataKey unwraps the data key of tree with Vault Transit. sops only
+// reports how many key groups failed, so the errors of the individual Vault
+// requests are collected and returned instead.
+func unwrapDataKey(client *vaultapi.Client, tree sops.Tree) ([]byte, error) {
+	if client == nil {
+		return nil, errors.New("unwrapping data key: no Vault client configured")
+	}
+	var errs []error
+	dataKey, err := tree.Metadata.GetDataKeyWithKeyServices(
+		[]keyservice.KeyServiceClient{transitKeyService{client: client, errs: &errs}}, nil)
+	if err == nil {
+		return dataKey, nil
+	}
+	if len(errs) == 0 {
+		return nil, errors.New("unwrapping data key: no Vault Transit key wraps the data key")
+	}
+	return nil, fmt.Errorf("unwrapping data key: %w", errors.Join(errs...))
+}
+
+// decryptTree decrypts the values of tree in place and verifies its MAC,
+// failing with errMACMismatch if the values were modified.
+func decryptTree(tree *sops.Tree, dataKey []byte) error {
+	cipher := aes.NewCipher()
+	mac, err := tree.Decrypt(dataKey, cipher)
+	if err != nil {
+		return fmt.Errorf("decrypting document: %w", err)
+	}
+	storedMAC, err := cipher.Decrypt(tree.Metadata.MessageAuthenticationCode, dataKey,
+		tree.Metadata.LastModified.Format(time.RFC3339))
+	if err != nil {
+		return fmt.Errorf("decrypting MAC: %w", err)
+	}
+	if storedMAC != mac {
+		return errMACMismatch
+	}
+	return nil
+}
+
+// storeFor returns the sops store for inputType, configured like the sops
+// CLI's defaults.
+func storeFor(inputType string) (sops.Store, error) {
+	cfg := sopsconfig.NewStoresConfig()
+	switch inputType {
+	case "json":
+		return sopsjson.NewStore(&cfg.JSON), nil
+	case "yaml":
+		return sopsyaml.NewStore(&cfg.YAML), nil
+	case "dotenv":
+		return dotenv.NewStore(&cfg.Dotenv), nil
+	case "ini":
+		return ini.NewStore(&cfg.INI), nil
+	case "binary":
+		return sopsjson.NewBinaryStore(&cfg.JSONBinary), nil
+	}
+	return nil, fmt.Errorf("unsupported input type %q; expected one of %s", inputType, strings.Join(InputTypes, ", "))
+}
+
+// transitKeyService unwraps Vault Transit data keys with an injected client,
+// so that sops resolves key groups and Shamir thresholds without reading
+// credentials from the environment. Other key types are refused. Failed
+// Vault requests are appended to errs.
+type transitKeyService struct {
+	client *vaultapi.Client
+	errs   *[]error
+}
+
+func (s transitKeyService) Encrypt(context.Context, *keyservice.EncryptRequest, ...grpc.CallOption) (*keyservice.EncryptResponse, error) {
+	return nil, errors.New("encryption is not supported by this key service")
+}
+
+func (s transitKeyService) Decrypt(_ context.Context, req *keyservice.DecryptRequest, _ ...grpc.CallOption) (*keyservice.DecryptResponse, error) {
+	key := req.GetKey().GetVaultKey()
+	if key == nil {
+		return nil, errors.New("only Vault Transit keys can be used for decryption")
+	}
+	plaintext, err := s.decrypt(key, string(req.Ciphertext))
+	if err != nil {
+		*s.errs = append(*s.errs, err)
+		return nil, err
+	}
+	return &keyservice.DecryptResponse{Plaintext: plaintext}, nil
+}
+
+// decrypt unwraps ciphertext with the Transit key described by key.
+func (s transitKeyService) decrypt(key *keyservice.VaultKey, ciphertext string) ([]byte, error) {
+	return transitDecrypt(s.client, key.EnginePath, key.KeyName, ciphertext, nil)
+}
+
+// transitDecrypt decrypts a Transit ciphertext with the key keyName,
+// deriving the key from keyContext when it is set.
+func transitDecrypt(client *vaultapi.Client, transitPath, keyName, ciphertext string, keyContext []byte) ([]byte, error) {
+	path := strings.Trim(transitPath, "/") + "/decrypt/" + keyName
+	data := map[string]interface{}{
+		"ciphertext": ciphertext,
+	}
+	if keyContext != nil {
+		data["context"] = base64.StdEncoding.EncodeToString(keyContext)
+	}
+	secret, err := client.Logical().Write(path, data)
+	if err != nil {
+		return nil, fmt.Errorf("vault transit decrypt (%s): %w", path, err)
+	}
+	if secret == nil {
+		return nil, errors.New("unexpected vault response: empty decrypt response")
+	}
+	encoded, ok := secret.Data["plaintext"].(string)
+	if !ok {
+		return nil, errors.New("unexpected vault response: plaintext not a string")
+	}
+	plaintext, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("unexpected vault response: %w", err)
+	}
+	return plaintext, nil
+}
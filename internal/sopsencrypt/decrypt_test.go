@@ -0,0 +1,125 @@
+package sopsencrypt_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/getsops/sops/v3"
+	"github.com/getsops/sops/v3/stores/dotenv"
+	"github.com/getsops/sops/v3/stores/ini"
+	sopsjson "github.com/getsops/sops/v3/stores/json"
+	vaultapi "github.com/hashicorp/vault/api"
+
+	"terraform-provider-sops/internal/sopsencrypt"
+)
+
+// reformat converts an encrypted JSON document to another sops format. Only
+// the layout changes, so the values and MAC stay valid.
+func reformat(t *testing.T, document string, store sops.Store) string {
+	t.Helper()
+	tree, err := (&sopsjson.Store{}).LoadEncryptedFile([]byte(document))
+	if err != nil {
+		t.Fatalf("LoadEncryptedFile: %v", err)
+	}
+	out, err := store.EmitEncryptedFile(tree)
+	if err != nil {
+		t.Fatalf("EmitEncryptedFile: %v", err)
+	}
+	return string(out)
+}
+
+func TestDecrypt_InputTypes(t *testing.T) {
+	srv := mockVaultServer(t)
+	defer srv.Close()
+	client := newTestClient(t, srv)
+
+	flat, err := sopsencrypt.EncryptToJSON(client, "transit", "test-key", `{"USER":"admin","PASSWORD":"secret"}`, sopsencrypt.EncryptOpts{})
+	if err != nil {
+		t.Fatalf("EncryptToJSON: %v", err)
+	}
+	yamlDoc, err := sopsencrypt.EncryptToYAML(client, "transit", "test-key", "# db\nuser: admin\npassword: secret\n", sopsencrypt.EncryptOpts{InputYAML: true})
+	if err != nil {
+		t.Fatalf("EncryptToYAML: %v", err)
+	}
+	sections, err := sopsencrypt.EncryptToJSON(client, "transit", "test-key", `{"db":{"user":"admin","password":"secret"}}`, sopsencrypt.EncryptOpts{})
+	if err != nil {
+		t.Fatalf("EncryptToJSON: %v", err)
+	}
+	binary, err := sopsencrypt.EncryptToJSON(client, "transit", "test-key", `{"data":"line one\nline two\n"}`, sopsencrypt.EncryptOpts{})
+	if err != nil {
+		t.Fatalf("EncryptToJSON: %v", err)
+	}
+
+	for _, tc := range []struct {
+		inputType, document, want string
+	}{
+		{"json", flat, "{\n\t\"USER\": \"admin\",\n\t\"PASSWORD\": \"secret\"\n}\n"},
+		{"yaml", yamlDoc, "# db\nuser: admin\npassword: secret\n"},
+		{"dotenv", reformat(t, flat, &dotenv.Store{}), "USER=admin\nPASSWORD=secret\n"},
+		{"ini", reformat(t, sections, &ini.Store{}), "[db]\nuser     = admin\npassword = secret\n"},
+		{"binary", binary, "line one\nline two\n"},
+	} {
+		t.Run(tc.inputType, func(t *testing.T) {
+			got, err := sopsencrypt.Decrypt(client, tc.document, tc.inputType)
+			if err != nil {
+				t.Fatalf("Decrypt: %v", err)
+			}
+			if string(got) != tc.want {
+				t.Errorf("Decrypt = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestDecryptFromJSONAndYAML(t *testing.T) {
+	srv := mockVaultServer(t)
+	defer srv.Close()
+	client := newTestClient(t, srv)
+
+	jsonDoc, yamlDoc, err := sopsencrypt.EncryptToJSONAndYAML(client, "transit", "test-key",
+		`{"user":"admin","password":"secret"}`, sopsencrypt.EncryptOpts{})
+	if err != nil {
+		t.Fatalf("EncryptToJSONAndYAML: %v", err)
+	}
+	got, err := sopsencrypt.DecryptFromJSON(client, jsonDoc)
+	if err != nil {
+		t.Fatalf("DecryptFromJSON: %v", err)
+	}
+	if want := "{\n\t\"user\": \"admin\",\n\t\"password\": \"secret\"\n}\n"; got != want {
+		t.Errorf("DecryptFromJSON = %q, want %q", got, want)
+	}
+	got, err = sopsencrypt.DecryptFromYAML(client, yamlDoc)
+	if err != nil {
+		t.Fatalf("DecryptFromYAML: %v", err)
+	}
+	if want := "user: admin\npassword: secret\n"; got != want {
+		t.Errorf("DecryptFromYAML = %q, want %q", got, want)
+	}
+
+	if _, err := sopsencrypt.DecryptFromYAML(client, jsonDoc+"x"); err == nil {
+		t.Error("DecryptFromYAML accepted a malformed document")
+	}
+}
+
+func TestDecrypt_VaultError(t *testing.T) {
+	srv := mockVaultServer(t)
+	client := newTestClient(t, srv)
+	doc, err := sopsencrypt.EncryptToJSON(client, "transit", "test-key", `{"a":"b"}`, sopsencrypt.EncryptOpts{})
+	srv.Close()
+	if err != nil {
+		t.Fatalf("EncryptToJSON: %v", err)
+	}
+
+	denied := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"errors":["permission denied"]}`, http.StatusForbidden)
+	}))
+	defer denied.Close()
+
+	_, err = sopsencrypt.Decrypt(newTestClient(t, denied), doc, "json")
+	var respErr *vaultapi.ResponseError
+	if !errors.As(err, &respErr) || respErr.StatusCode != http.StatusForbidden {
+		t.Errorf("Decrypt error = %v, want a Vault 403 response error", err)
+	}
+}
@@ -0,0 +1,105 @@
+package sopsencrypt
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/getsops/sops/v3"
+)
+
+// A KeyDiff lists the top-level keys that differ between two plaintext
+// documents, by name only. Keys of the documents of a YAML stream after the
+// first are qualified with their index, as in "1:password".
+type KeyDiff struct {
+	Added, Removed, Changed []string
+}
+
+// Empty reports whether the documents have the same keys and values.
+func (d KeyDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// DiffKeys compares the top-level keys of two plaintext documents, each JSON
+// or, if its yaml flag is set, YAML. Values are compared as JSON, so
+// comments, key order and formatting do not count as changes. Keys are
+// returned in the order they appear in their document.
+func DiffKeys(oldContent string, oldYAML bool, newContent string, newYAML bool) (KeyDiff, error) {
+	oldBranches, err := inputStore(EncryptOpts{InputYAML: oldYAML}).LoadPlain(oldContent)
+	if err != nil {
+		return KeyDiff{}, RedactError(err, oldContent)
+	}
+	newBranches, err := inputStore(EncryptOpts{InputYAML: newYAML}).LoadPlain(newContent)
+	if err != nil {
+		return KeyDiff{}, RedactError(err, newContent)
+	}
+
+	var diff KeyDiff
+	for i := range max(len(oldBranches), len(newBranches)) {
+		var oldBranch, newBranch sops.TreeBranch
+		if i < len(oldBranches) {
+			oldBranch = oldBranches[i]
+		}
+		if i < len(newBranches) {
+			newBranch = newBranches[i]
+		}
+		name := func(key string) string {
+			if i == 0 {
+				return key
+			}
+			return fmt.Sprintf("%d:%s", i, key)
+		}
+		oldValues, err := topLevelValues(oldBranch)
+		if err != nil {
+			return KeyDiff{}, err
+		}
+		newValues, err := topLevelValues(newBranch)
+		if err != nil {
+			return KeyDiff{}, err
+		}
+		for _, item := range newBranch {
+			key, ok := item.Key.(string)
+			if !ok {
+				continue
+			}
+			old, found := oldValues[key]
+			switch {
+			case !found:
+				diff.Added = append(diff.Added, name(key))
+			case !reflect.DeepEqual(old, newValues[key]):
+				diff.Changed = append(diff.Changed, name(key))
+			}
+		}
+		for _, item := range oldBranch {
+			if key, ok := item.Key.(string); ok {
+				if _, found := newValues[key]; !found {
+					diff.Removed = append(diff.Removed, name(key))
+				}
+			}
+		}
+	}
+	return diff, nil
+}
+
+// topLevelValues returns the values of branch by key, decoded from their
+// JSON encoding so that equal values compare equal with reflect.DeepEqual.
+func topLevelValues(branch sops.TreeBranch) (map[string]any, error) {
+	values := make(map[string]any, len(branch))
+	for _, item := range branch {
+		key, ok := item.Key.(string)
+		if !ok {
+			continue
+		}
+		var buf bytes.Buffer
+		if err := encodeJSONValue(&buf, item.Value); err != nil {
+			return nil, fmt.Errorf("encoding value of %q: %w", key, err)
+		}
+		var v any
+		if err := json.Unmarshal(buf.Bytes(), &v); err != nil {
+			return nil, fmt.Errorf("encoding value of %q: %w", key, err)
+		}
+		values[key] = v
+	}
+	return values, nil
+}
@@ -0,0 +1,74 @@
+package sopsencrypt_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"terraform-provider-sops/internal/sopsencrypt"
+)
+
+func TestDiffKeys(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		oldContent string
+		oldYAML    bool
+		newContent string
+		newYAML    bool
+		want       sopsencrypt.KeyDiff
+	}{
+		{
+			name:       "added removed changed",
+			oldContent: `{"user":"app","password":"old","port":5432}`,
+			newContent: `{"password":"new","port":5432,"host":"db"}`,
+			want: sopsencrypt.KeyDiff{
+				Added:   []string{"host"},
+				Removed: []string{"user"},
+				Changed: []string{"password"},
+			},
+		},
+		{
+			name:       "nested key order",
+			oldContent: `{"db":{"a":1,"b":2}}`,
+			newContent: `{"db":{"b":2,"a":1}}`,
+		},
+		{
+			name:       "yaml comments and format",
+			oldContent: `{"db":{"port":5432}}`,
+			newContent: "# database\ndb:\n  port: 5432 # default\n",
+			newYAML:    true,
+		},
+		{
+			name:       "yaml stream",
+			oldContent: "a: 1\n---\nb: 2\n",
+			oldYAML:    true,
+			newContent: "a: 1\n---\nb: 3\n---\nc: 4\n",
+			newYAML:    true,
+			want: sopsencrypt.KeyDiff{
+				Added:   []string{"2:c"},
+				Changed: []string{"1:b"},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := sopsencrypt.DiffKeys(tc.oldContent, tc.oldYAML, tc.newContent, tc.newYAML)
+			if err != nil {
+				t.Fatalf("DiffKeys: %v", err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got %+v, want %+v", got, tc.want)
+			}
+			if got.Empty() != tc.want.Empty() {
+				t.Errorf("Empty() = %v", got.Empty())
+			}
+		})
+	}
+
+	_, err := sopsencrypt.DiffKeys(`{"password":"hunter2"}`, false, `{"password":"hunter2"`, false)
+	if err == nil {
+		t.Fatal("expected an error for invalid JSON")
+	}
+	if strings.Contains(err.Error(), "hunter2") {
+		t.Errorf("error leaks content: %v", err)
+	}
+}
@@ -0,0 +1,356 @@
+package sopsencrypt
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/getsops/sops/v3"
+	"github.com/getsops/sops/v3/stores"
+	sopsyaml "github.com/getsops/sops/v3/stores/yaml"
+	"gopkg.in/yaml.v3"
+)
+
+// emitJSON serialises tree as a SOPS JSON document. Its output is
+// byte-identical to the sops JSON store's, whose encoder builds the document
+// by repeated string concatenation and so takes time quadratic in its size:
+// minutes for a document of a few megabytes.
+func emitJSON(tree sops.Tree, pretty bool) (string, error) {
+	// The JSON store would silently emit only the first document.
+	if len(tree.Branches) != 1 {
+		return "", fmt.Errorf("a stream of %d YAML documents cannot be emitted as JSON", len(tree.Branches))
+	}
+	branch := append(slices.Clip(tree.Branches[0]), sops.TreeItem{
+		Key:   stores.SopsMetadataKey,
+		Value: stores.MetadataFromInternal(tree.Metadata),
+	})
+	var compact bytes.Buffer
+	if err := encodeJSONValue(&compact, branch); err != nil {
+		return "", fmt.Errorf("emitting encrypted document: %w", err)
+	}
+
+	// The store indents with newlines only; json.Indent drops the
+	// whitespace of its input, so one pass serves both layouts.
+	indent := ""
+	if pretty {
+		indent = "  "
+	}
+	var out bytes.Buffer
+	out.Grow(compact.Len() + compact.Len()/4)
+	if err := json.Indent(&out, compact.Bytes(), "", indent); err != nil {
+		return "", fmt.Errorf("emitting encrypted document: %w", err)
+	}
+	out.WriteByte('\n')
+	return out.String(), nil
+}
+
+// encodeJSONValue appends the JSON encoding of a tree value to buf the way
+// the sops JSON store does: comments are dropped and object members are
+// separated by ": ".
+func encodeJSONValue(buf *bytes.Buffer, v interface{}) error {
+	switch v := v.(type) {
+	case sops.TreeBranch:
+		buf.WriteByte('{')
+		empty := true
+		for _, item := range v {
+			key, ok := item.Key.(string)
+			if !ok {
+				continue
+			}
+			if !empty {
+				buf.WriteByte(',')
+			}
+			k, err := json.Marshal(key)
+			if err != nil {
+				return err
+			}
+			buf.Write(k)
+			buf.WriteString(": ")
+			if err := encodeJSONValue(buf, item.Value); err != nil {
+				return fmt.Errorf("encoding value of %s: %w", k, err)
+			}
+			empty = false
+		}
+		buf.WriteByte('}')
+	case []interface{}:
+		buf.WriteByte('[')
+		empty := true
+		for _, item := range v {
+			if _, ok := item.(sops.Comment); ok {
+				continue
+			}
+			if !empty {
+				buf.WriteByte(',')
+			}
+			if err := encodeJSONValue(buf, item); err != nil {
+				return err
+			}
+			empty = false
+		}
+		buf.WriteByte(']')
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		buf.Write(b)
+	}
+	return nil
+}
+
+// YAML string styles accepted in EncryptOpts.YAMLStringStyle.
+const (
+	// YAMLStylePlain quotes strings only where YAML requires it and writes
+	// multi-line strings as literal blocks, as sops does.
+	YAMLStylePlain = "plain"
+	// YAMLStyleSingleQuoted and YAMLStyleDoubleQuoted quote every string
+	// value. Strings that cannot be single-quoted are double-quoted.
+	YAMLStyleSingleQuoted = "single_quoted"
+	YAMLStyleDoubleQuoted = "double_quoted"
+	// YAMLStyleFolded writes multi-line strings as folded blocks (>).
+	YAMLStyleFolded = "folded"
+)
+
+// YAMLStringStyles are the values accepted in EncryptOpts.YAMLStringStyle.
+var YAMLStringStyles = []string{YAMLStylePlain, YAMLStyleSingleQuoted, YAMLStyleDoubleQuoted, YAMLStyleFolded}
+
+// yamlStyles maps the YAMLStringStyles to node styles.
+var yamlStyles = map[string]yaml.Style{
+	YAMLStylePlain:        0,
+	YAMLStyleSingleQuoted: yaml.SingleQuotedStyle,
+	YAMLStyleDoubleQuoted: yaml.DoubleQuotedStyle,
+	YAMLStyleFolded:       yaml.FoldedStyle,
+}
+
+// emitYAML serialises tree as a SOPS YAML document, or a stream of them,
+// indented by indent spaces (sops' default for 0) and with string values in
+// stringStyle, one of YAMLStringStyles or empty for YAMLStylePlain. With the
+// defaults, its output is byte-identical to the sops YAML store's, which builds every
+// scalar node with yaml.Node.Encode: each call renders the value to text and
+// parses it back, allocating over a gigabyte per megabyte of input.
+// Encrypted values, the bulk of any document, are plain strings by
+// construction and are built directly, as are plain keys; other key nodes are
+// built once per key.
+func emitYAML(tree sops.Tree, indent int, stringStyle string) (string, error) {
+	style, ok := yamlStyles[cmp.Or(stringStyle, YAMLStylePlain)]
+	if !ok {
+		return "", fmt.Errorf("invalid YAML string style %q: expected one of %s", stringStyle, strings.Join(YAMLStringStyles, ", "))
+	}
+	if indent == 0 {
+		indent = sopsyaml.IndentDefault
+	}
+	if indent < 2 || indent > 9 {
+		return "", fmt.Errorf("invalid YAML indent %d: expected 2 to 9 spaces", indent)
+	}
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(indent)
+	e := yamlEmitter{keys: map[string]yaml.Node{}}
+	for _, branch := range tree.Branches {
+		mapping := &yaml.Node{Kind: yaml.MappingNode}
+		branch = append(slices.Clip(branch), sops.TreeItem{
+			Key:   stores.SopsMetadataKey,
+			Value: stores.MetadataFromInternal(tree.Metadata),
+		})
+		if err := e.appendTreeBranch(branch, mapping); err != nil {
+			return "", fmt.Errorf("emitting encrypted document: %w", err)
+		}
+		if style != 0 {
+			restyleStrings(mapping, style)
+		}
+		doc := &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{mapping}}
+		if err := enc.Encode(doc); err != nil {
+			return "", fmt.Errorf("emitting encrypted document: %w", err)
+		}
+	}
+	if err := enc.Close(); err != nil {
+		return "", fmt.Errorf("emitting encrypted document: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// plainKey matches keys that are plain YAML strings unless they are one of
+// yamlKeywords: they start with a letter, so they are neither numbers nor
+// timestamps, and contain no indicator characters.
+var plainKey = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// yamlKeywords are the lower-cased words YAML 1.1 or 1.2 resolve to booleans
+// or null, which the encoder quotes.
+var yamlKeywords = map[string]bool{
+	"y": true, "yes": true, "n": true, "no": true, "on": true, "off": true,
+	"true": true, "false": true, "null": true,
+}
+
+// yamlEmitter converts a sops tree to YAML nodes, mirroring the sops YAML
+// store including its placement of comments.
+type yamlEmitter struct {
+	keys map[string]yaml.Node
+}
+
+func (e *yamlEmitter) valueNode(in interface{}) (*yaml.Node, error) {
+	switch in := in.(type) {
+	case sops.TreeBranch:
+		mapping := &yaml.Node{Kind: yaml.MappingNode}
+		return mapping, e.appendTreeBranch(in, mapping)
+	case []interface{}:
+		sequence := &yaml.Node{Kind: yaml.SequenceNode}
+		return sequence, e.appendSequence(in, sequence)
+	case string:
+		if strings.HasPrefix(in, "ENC[") && !strings.Contains(in, "\n") {
+			return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: in}, nil
+		}
+	}
+	node := &yaml.Node{}
+	return node, node.Encode(in)
+}
+
+func (e *yamlEmitter) keyNode(key interface{}) (*yaml.Node, error) {
+	s, ok := key.(string)
+	if !ok {
+		node := &yaml.Node{}
+		return node, node.Encode(key)
+	}
+	if cached, ok := e.keys[s]; ok {
+		return &cached, nil
+	}
+	if plainKey.MatchString(s) && !yamlKeywords[strings.ToLower(s)] {
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: s}, nil
+	}
+	node := &yaml.Node{}
+	if err := node.Encode(s); err != nil {
+		return nil, err
+	}
+	e.keys[s] = *node
+	return node, nil
+}
+
+func (e *yamlEmitter) appendSequence(in []interface{}, sequence *yaml.Node) error {
+	var comments []string
+	beginning := true
+	for _, item := range in {
+		if comment, ok := item.(sops.Comment); ok {
+			comments = append(comments, comment.Value)
+			continue
+		}
+		if beginning {
+			comments = addHeadComments(sequence, comments)
+			beginning = false
+		}
+		itemNode, err := e.valueNode(item)
+		if err != nil {
+			return err
+		}
+		comments = addHeadComments(itemNode, comments)
+		sequence.Content = append(sequence.Content, itemNode)
+	}
+	if len(comments) > 0 {
+		if beginning {
+			addHeadComments(sequence, comments)
+		} else {
+			addFootComments(sequence.Content[len(sequence.Content)-1], comments)
+		}
+	}
+	return nil
+}
+
+func (e *yamlEmitter) appendTreeBranch(branch sops.TreeBranch, mapping *yaml.Node) error {
+	var comments []string
+	beginning := true
+	for _, item := range branch {
+		if comment, ok := item.Key.(sops.Comment); ok {
+			comments = append(comments, comment.Value)
+			continue
+		}
+		if beginning {
+			comments = addHeadComments(mapping, comments)
+			beginning = false
+		}
+		keyNode, err := e.keyNode(item.Key)
+		if err != nil {
+			return err
+		}
+		comments = addHeadComments(keyNode, comments)
+		valueNode, err := e.valueNode(item.Value)
+		if err != nil {
+			return err
+		}
+		mapping.Content = append(mapping.Content, keyNode, valueNode)
+	}
+	if len(comments) > 0 {
+		if beginning {
+			addHeadComments(mapping, comments)
+		} else {
+			addFootComments(mapping.Content[len(mapping.Content)-2], comments)
+		}
+	}
+	return nil
+}
+
+// restyleStrings sets the style of the string values below node, leaving
+// keys alone. The folded style only applies to multi-line strings.
+func restyleStrings(node *yaml.Node, style yaml.Style) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 1; i < len(node.Content); i += 2 {
+			restyleStrings(node.Content[i], style)
+		}
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			restyleStrings(item, style)
+		}
+	case yaml.ScalarNode:
+		if node.Tag == "!!str" && (style != yaml.FoldedStyle || strings.Contains(node.Value, "\n")) {
+			node.Style = style
+		}
+	}
+}
+
+// yamlComment returns text as YAML comment lines. Lines already starting
+// with "#" are kept as they are, others get a "# " prefix. Empty text yields
+// no lines.
+func yamlComment(text string) string {
+	if text == "" {
+		return ""
+	}
+	var b strings.Builder
+	for _, line := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		switch {
+		case line == "":
+			b.WriteString("#")
+		case !strings.HasPrefix(line, "#"):
+			b.WriteString("# ")
+		}
+		b.WriteString(line)
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// addHeadComments prepends comments to the head comment of node and returns
+// nil, the emptied list of pending comments.
+func addHeadComments(node *yaml.Node, comments []string) []string {
+	if len(comments) > 0 {
+		comment := "#" + strings.Join(comments, "\n#")
+		if len(node.HeadComment) > 0 {
+			node.HeadComment = comment + "\n" + node.HeadComment
+		} else {
+			node.HeadComment = comment
+		}
+	}
+	return nil
+}
+
+// addFootComments appends comments to the foot comment of node.
+func addFootComments(node *yaml.Node, comments []string) {
+	comment := "#" + strings.Join(comments, "\n#")
+	if len(node.FootComment) > 0 {
+		node.FootComment += "\n" + comment
+	} else {
+		node.FootComment = comment
+	}
+}
@@ -1,22 +1,30 @@
 // Package sopsencrypt wraps SOPS encryption primitives with HashiCorp Vault
 // Transit as the key backend. The vault client is injected explicitly so that
 // no environment variables are read or written by this package.
+//
+// Encryption is assembled from an InputStore that parses the content, the
+// MasterKeySources that wrap the data key and an OutputStore that emits the
+// document, so a new format or key backend plugs in once for every
+// combination.
 package sopsencrypt
 
 import (
-	"bytes"
 	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
 	"encoding/base64"
-	"encoding/json"
+	"encoding/binary"
 	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/getsops/sops/v3"
 	"github.com/getsops/sops/v3/aes"
-	"github.com/getsops/sops/v3/cmd/sops/common"
 	"github.com/getsops/sops/v3/hcvault"
-	sopsjson "github.com/getsops/sops/v3/stores/json"
-	sopsyaml "github.com/getsops/sops/v3/stores/yaml"
 	sopsversion "github.com/getsops/sops/v3/version"
 	vaultapi "github.com/hashicorp/vault/api"
 )
@@ -29,16 +37,142 @@ import (
 //	EncryptedSuffix   – only keys ending with this suffix are encrypted
 //	UnencryptedRegex  – keys matching this regex are left in plaintext
 //	EncryptedRegex    – only keys matching this regex are encrypted
+//	EncryptedPaths    – only values under these paths are encrypted
+//	UnencryptedKeys   – keys with exactly these names are left in plaintext
 //
 // If all scope fields are empty, every key is encrypted (SOPS default).
 //
+// EncryptedPaths are dot-separated key paths such as "database.password",
+// where "*" matches any key and lists are traversed transparently. SOPS has
+// no notion of paths, so each document records an EncryptedRegex of whole
+// key names derived from the paths; a document whose selected keys also
+// occur outside the selected subtrees cannot be encrypted this way.
+// UnencryptedKeys is recorded as an UnencryptedRegex matching exactly the
+// listed names.
+//
 // PrettyJSON is only respected by EncryptToJSON.
+//
+// YAMLIndent and YAMLStringStyle lay out YAML output: the number of spaces
+// per indentation level, 2 to 9 (0 keeps sops' 4), and one of
+// YAMLStringStyles for string values. Neither changes the encrypted values or
+// the MAC.
+//
+// HeaderComment, if set, is written above YAML output as comment lines, one
+// per line of text. It stays in plaintext outside the encrypted tree, so it
+// is not covered by the MAC; sops keeps it when decrypting, warning that the
+// comment is not encrypted unless a scope option leaves top-level comments
+// unencrypted.
+//
+// NoTrailingNewline drops the newline that otherwise ends every emitted
+// document, and LineEndings, one of LineEndingStyles or empty for
+// LineEndingsLF, sets the line breaks of the output. Like the YAML layout,
+// neither changes the encrypted values or the MAC.
+//
+// InputYAML parses the content as YAML instead of JSON. Key order and
+// comments are kept in YAML output; JSON output has no place for comments and
+// drops them. A YAML stream of several "---" separated documents is encrypted
+// as one SOPS file with a shared data key and MAC, but can only be emitted as
+// YAML.
+//
+// AgeRecipients wraps the data key for each age recipient in addition to
+// Vault Transit. Age wrapping is local, so with a nil Vault client the
+// document is encrypted for the age recipients alone.
+//
+// CreateKeyType creates the Vault Transit key with this type before the data
+// key is wrapped, if the key does not exist yet. Empty leaves key management
+// to the caller, and a missing key fails the encryption.
+//
+// Timestamp, if non-zero, is recorded as lastmodified and as the creation
+// date of the Vault key source instead of the current time, so encrypting
+// identical inputs yields identical metadata. TimestampFromContent derives
+// the timestamp from the SHA-256 of each document instead: a fixed second
+// between 1970 and 2106. The encrypted values still differ between runs, as
+// the data key and IVs are random.
+//
+// FormatVersion, if set, is recorded as the sops version of the document
+// instead of the version of the sops library in use, for consumers with an
+// older sops release. It must pass CheckFormatVersion.
+//
+// DataKey, if set, encrypts every document with DataKey.Key instead of a
+// random data key of its own, e.g. a key from NewDataKey shared by several
+// documents. DataKey.Wrapped, if set, is recorded as the Vault Transit
+// wrapped key as is and must be the Transit ciphertext of the key under
+// keyName; otherwise the key is wrapped as usual. Age recipients still wrap
+// the key locally.
+//
+// MasterKeySources wraps the data key with further key sources, after Vault
+// Transit and the age recipients. Each of them adds its master keys to the
+// document's key group.
+//
+// Audit, if set, receives the ID of the Vault request that wrapped the data
+// key.
+//
+// Mock skips Vault entirely: the client may be nil, values are replaced by
+// fixed placeholders and all timestamps are pinned, so the output is
+// deterministic but cannot be decrypted. It exists for plans and CI runs
+// without Vault connectivity.
 type EncryptOpts struct {
-	UnencryptedSuffix string
-	EncryptedSuffix   string
-	UnencryptedRegex  string
-	EncryptedRegex    string
-	PrettyJSON        bool
+	UnencryptedSuffix    string
+	EncryptedSuffix      string
+	UnencryptedRegex     string
+	EncryptedRegex       string
+	EncryptedPaths       []string
+	UnencryptedKeys      []string
+	PrettyJSON           bool
+	YAMLIndent           int
+	YAMLStringStyle      string
+	HeaderComment        string
+	NoTrailingNewline    bool
+	LineEndings          string
+	InputYAML            bool
+	AgeRecipients        []string
+	CreateKeyType        string
+	Mock                 bool
+	Timestamp            time.Time
+	TimestampFromContent bool
+	FormatVersion        string
+	DataKey              *DataKey
+	MasterKeySources     []MasterKeySource
+	Audit                *EncryptAudit
+}
+
+// EncryptAudit receives, from an encryption with EncryptOpts.Audit set, what
+// correlates the encryption with the Vault audit log.
+type EncryptAudit struct {
+	// VaultRequestID is the ID of the Vault Transit request that wrapped the
+	// data key, or of the one that wrapped EncryptOpts.DataKey. It is empty
+	// when no request was made: in mock and age-only mode, and for a
+	// DataKey whose wrapped form was supplied by the caller.
+	VaultRequestID string
+}
+
+// timestamp returns the time recorded in the metadata of a document with
+// content that is encrypted at now.
+func (o EncryptOpts) timestamp(content string, now time.Time) time.Time {
+	switch {
+	case o.TimestampFromContent:
+		sum := sha256.Sum256([]byte(content))
+		return time.Unix(int64(binary.BigEndian.Uint32(sum[:4])), 0).UTC()
+	case !o.Timestamp.IsZero():
+		return o.Timestamp.UTC().Truncate(time.Second)
+	}
+	return now
+}
+
+// Encrypt parses content (JSON, or YAML if opts.InputYAML), encrypts it
+// with Vault Transit and the other key sources of opts, and returns a SOPS
+// document in format, one of OutputFormats. The ciphertext is decryptable
+// with `sops -d --input-type <format>`.
+func Encrypt(client *vaultapi.Client, transitPath, keyName, content, format string, opts EncryptOpts) (string, error) {
+	store, err := outputStore(format, opts)
+	if err != nil {
+		return "", err
+	}
+	tree, err := encryptDocument(client, transitPath, keyName, content, opts)
+	if err != nil {
+		return "", err
+	}
+	return store.EmitEncrypted(tree)
 }
 
 // EncryptToJSON parses jsonContent (a JSON document, typically produced by
@@ -48,21 +182,7 @@ type EncryptOpts struct {
 //
 // If opts.PrettyJSON is true the output is indented with two spaces.
 func EncryptToJSON(client *vaultapi.Client, transitPath, keyName, jsonContent string, opts EncryptOpts) (string, error) {
-	out, err := encryptDocument(client, transitPath, keyName, jsonContent, opts,
-		func(tree sops.Tree) ([]byte, error) {
-			return (&sopsjson.Store{}).EmitEncryptedFile(tree)
-		})
-	if err != nil {
-		return "", err
-	}
-	if opts.PrettyJSON {
-		var buf bytes.Buffer
-		if err := json.Indent(&buf, out, "", "  "); err != nil {
-			return "", fmt.Errorf("pretty-printing JSON: %w", err)
-		}
-		return buf.String(), nil
-	}
-	return string(out), nil
+	return Encrypt(client, transitPath, keyName, jsonContent, "json", opts)
 }
 
 // EncryptToYAML parses jsonContent, encrypts it with Vault Transit, and
@@ -72,110 +192,465 @@ func EncryptToJSON(client *vaultapi.Client, transitPath, keyName, jsonContent st
 // Input is always JSON (jsonencode() output); the YAML serialisation is
 // handled internally.
 func EncryptToYAML(client *vaultapi.Client, transitPath, keyName, jsonContent string, opts EncryptOpts) (string, error) {
-	out, err := encryptDocument(client, transitPath, keyName, jsonContent, opts,
-		func(tree sops.Tree) ([]byte, error) {
-			return (&sopsyaml.Store{}).EmitEncryptedFile(tree)
-		})
+	return Encrypt(client, transitPath, keyName, jsonContent, "yaml", opts)
+}
+
+// EncryptToJSONAndYAML encrypts jsonContent once and returns the result
+// serialised both as a SOPS JSON document (indented if opts.PrettyJSON) and
+// as a SOPS YAML 1.2 document. Both share the data key, the Vault Transit
+// call and the MAC, so either decrypts to the same content.
+//
+// A multi-document YAML stream has no JSON form; jsonOut is then empty.
+func EncryptToJSONAndYAML(client *vaultapi.Client, transitPath, keyName, jsonContent string, opts EncryptOpts) (jsonOut, yamlOut string, err error) {
+	tree, err := encryptDocument(client, transitPath, keyName, jsonContent, opts)
 	if err != nil {
-		return "", err
+		return "", "", err
 	}
-	return string(out), nil
+	if len(tree.Branches) == 1 {
+		if jsonOut, err = outputStores["json"](opts).EmitEncrypted(tree); err != nil {
+			return "", "", err
+		}
+	}
+	if yamlOut, err = outputStores["yaml"](opts).EmitEncrypted(tree); err != nil {
+		return "", "", err
+	}
+	return jsonOut, yamlOut, nil
+}
+
+// EncryptBatch encrypts each JSON document of documents like EncryptToJSON
+// and returns the SOPS JSON documents under the same names. Every document
+// gets a data key of its own, but all data keys are wrapped with a single
+// Vault Transit batch request, so encrypting hundreds of documents costs one
+// round trip to Vault.
+func EncryptBatch(client *vaultapi.Client, transitPath, keyName string, documents map[string]string, opts EncryptOpts) (map[string]string, error) {
+	names := slices.Sorted(maps.Keys(documents))
+	contents := make([]string, len(names))
+	for i, name := range names {
+		contents[i] = documents[name]
+	}
+	trees, err := encryptDocuments(client, transitPath, keyName, contents, names, opts)
+	if err != nil {
+		return nil, err
+	}
+	store := outputStores["json"](opts)
+	out := make(map[string]string, len(names))
+	for i, name := range names {
+		if out[name], err = store.EmitEncrypted(trees[i]); err != nil {
+			return nil, fmt.Errorf("document %q: %w", name, err)
+		}
+	}
+	return out, nil
 }
 
 // encryptDocument is the shared implementation. jsonContent is parsed with
-// the JSON store (format-agnostic input), encrypted, then serialised by
-// emit into the target format.
+// the InputStore of opts and encrypted; the caller serialises the returned
+// tree with an OutputStore.
 func encryptDocument(
 	client *vaultapi.Client,
 	transitPath, keyName, jsonContent string,
 	opts EncryptOpts,
-	emit func(sops.Tree) ([]byte, error),
-) ([]byte, error) {
-	branches, err := (&sopsjson.Store{}).LoadPlainFile([]byte(jsonContent))
+) (sops.Tree, error) {
+	trees, err := encryptDocuments(client, transitPath, keyName, []string{jsonContent}, nil, opts)
 	if err != nil {
-		return nil, fmt.Errorf("parsing content as JSON: %w", err)
+		return sops.Tree{}, err
 	}
+	return trees[0], nil
+}
 
-	dataKey, err := generateDataKey()
-	if err != nil {
-		return nil, err
+// encryptDocuments encrypts each of contents like encryptDocument, with a
+// data key of its own. Each key source wraps all data keys at once, so Vault
+// Transit takes a single request. labels, if non-nil, names each document in
+// errors.
+func encryptDocuments(
+	client *vaultapi.Client,
+	transitPath, keyName string,
+	contents, labels []string,
+	opts EncryptOpts,
+) ([]sops.Tree, error) {
+	documentError := func(i int, err error) error {
+		err = RedactError(err, contents[i])
+		if labels != nil {
+			return fmt.Errorf("document %q: %w", labels[i], err)
+		}
+		return err
+	}
+	version := sopsversion.Version
+	if opts.FormatVersion != "" {
+		if err := CheckFormatVersion(opts.FormatVersion); err != nil {
+			return nil, err
+		}
+		version = opts.FormatVersion
+	}
+	if opts.DataKey != nil && !opts.Mock {
+		if len(opts.DataKey.Key) != 32 {
+			return nil, fmt.Errorf("data key must be 32 bytes, got %d", len(opts.DataKey.Key))
+		}
+		if opts.DataKey.Wrapped != "" && client == nil {
+			return nil, fmt.Errorf("a wrapped data key requires Vault")
+		}
+	}
+	if len(opts.EncryptedPaths) > 0 || len(opts.UnencryptedKeys) > 0 {
+		scopes := 0
+		for _, set := range []bool{
+			opts.UnencryptedSuffix != "", opts.EncryptedSuffix != "", opts.UnencryptedRegex != "", opts.EncryptedRegex != "",
+			len(opts.EncryptedPaths) > 0, len(opts.UnencryptedKeys) > 0,
+		} {
+			if set {
+				scopes++
+			}
+		}
+		if scopes > 1 {
+			return nil, fmt.Errorf("encrypted paths and unencrypted keys cannot be combined with other scope options")
+		}
+		if err := CheckEncryptedPaths(opts.EncryptedPaths); err != nil {
+			return nil, err
+		}
+	}
+	unencryptedRegex := opts.UnencryptedRegex
+	if len(opts.UnencryptedKeys) > 0 {
+		unencryptedRegex = keysRegex(opts.UnencryptedKeys)
+	}
+	input := inputStore(opts)
+	branches := make([]sops.TreeBranches, len(contents))
+	encryptedRegex := make([]string, len(contents))
+	for i, content := range contents {
+		b, err := input.LoadPlain(content)
+		if err != nil {
+			return nil, documentError(i, err)
+		}
+		branches[i] = b
+		encryptedRegex[i] = opts.EncryptedRegex
+		if len(opts.EncryptedPaths) > 0 {
+			if encryptedRegex[i], err = pathsRegex(b, opts.EncryptedPaths); err != nil {
+				return nil, documentError(i, err)
+			}
+		}
 	}
 
-	encryptedKey, err := wrapDataKey(client, transitPath, keyName, dataKey)
-	if err != nil {
-		return nil, err
+	var (
+		dataKeys             = make([][]byte, len(contents))
+		groups               = make([]sops.KeyGroup, len(contents))
+		times                = make([]time.Time, len(contents))
+		cipher   sops.Cipher = aes.NewCipher()
+	)
+	now := time.Now().UTC()
+	if opts.Mock {
+		now = mockTimestamp
+		cipher = mockCipher{}
+	}
+	for i, content := range contents {
+		times[i] = opts.timestamp(content, now)
+		switch {
+		case opts.Mock:
+			dataKeys[i] = make([]byte, 32)
+		case opts.DataKey != nil:
+			dataKeys[i] = opts.DataKey.Key
+		default:
+			dataKey, err := generateDataKey()
+			if err != nil {
+				return nil, err
+			}
+			dataKeys[i] = dataKey
+		}
 	}
+	for _, source := range keySources(client, transitPath, keyName, opts) {
+		masterKeys, err := source.WrapDataKeys(dataKeys, times)
+		if err != nil {
+			return nil, err
+		}
+		for i := range groups {
+			groups[i] = append(groups[i], masterKeys[i]...)
+		}
+	}
+
+	trees := make([]sops.Tree, len(contents))
+	for i := range contents {
+		if len(groups[i]) == 0 {
+			return nil, fmt.Errorf("no key sources: a Vault client or at least one age recipient is required")
+		}
+		trees[i] = sops.Tree{
+			Branches: branches[i],
+			Metadata: sops.Metadata{
+				KeyGroups:         []sops.KeyGroup{groups[i]},
+				Version:           version,
+				UnencryptedSuffix: opts.UnencryptedSuffix,
+				EncryptedSuffix:   opts.EncryptedSuffix,
+				UnencryptedRegex:  unencryptedRegex,
+				EncryptedRegex:    encryptedRegex[i],
+			},
+		}
+		if err := encryptTree(&trees[i], dataKeys[i], cipher, times[i]); err != nil {
+			return nil, documentError(i, err)
+		}
+	}
+	return trees, nil
+}
 
-	masterKey := &hcvault.MasterKey{
-		VaultAddress: client.Address(),
+// vaultMasterKey describes a data key wrapped by Vault Transit. client may be
+// nil in mock mode, in which case the address is left empty.
+func vaultMasterKey(client *vaultapi.Client, transitPath, keyName, encryptedKey string, created time.Time) *hcvault.MasterKey {
+	var address string
+	if client != nil {
+		address = client.Address()
+	}
+	return &hcvault.MasterKey{
+		VaultAddress: address,
 		EnginePath:   transitPath,
 		KeyName:      keyName,
 		EncryptedKey: encryptedKey,
-		CreationDate: time.Now().UTC(),
+		CreationDate: created,
 	}
+}
 
-	tree := sops.Tree{
-		Branches: branches,
-		Metadata: sops.Metadata{
-			KeyGroups:         []sops.KeyGroup{{masterKey}},
-			Version:           sopsversion.Version,
-			UnencryptedSuffix: opts.UnencryptedSuffix,
-			EncryptedSuffix:   opts.EncryptedSuffix,
-			UnencryptedRegex:  opts.UnencryptedRegex,
-			EncryptedRegex:    opts.EncryptedRegex,
-		},
+// encryptTree encrypts the tree values and MAC in place. It mirrors
+// common.EncryptTree but takes lastModified explicitly instead of reading the
+// clock, so callers control every timestamp in the output.
+func encryptTree(tree *sops.Tree, dataKey []byte, cipher sops.Cipher, lastModified time.Time) error {
+	unencryptedMAC, err := tree.Encrypt(dataKey, cipher)
+	if err != nil {
+		return fmt.Errorf("encrypting tree: %w", err)
 	}
-
-	if err := common.EncryptTree(common.EncryptTreeOpts{
-		DataKey: dataKey,
-		Tree:    &tree,
-		Cipher:  aes.NewCipher(),
-	}); err != nil {
-		return nil, fmt.Errorf("encrypting tree: %w", err)
+	tree.Metadata.LastModified = lastModified
+	tree.Metadata.MessageAuthenticationCode, err = cipher.Encrypt(unencryptedMAC, dataKey, lastModified.Format(time.RFC3339))
+	if err != nil {
+		return fmt.Errorf("encrypting MAC: %w", err)
 	}
+	return nil
+}
 
-	out, err := emit(tree)
+// VaultConfig holds the connection settings of a Vault server.
+type VaultConfig struct {
+	// Address is the full Vault server URL.
+	Address string
+	// ProxyURL is an http, https, socks5 or socks5h URL that every Vault
+	// request is sent through. Empty leaves proxying to the VAULT_PROXY_ADDR,
+	// HTTPS_PROXY, HTTP_PROXY and NO_PROXY environment variables.
+	ProxyURL string
+	// RateLimiter, if set, paces the requests of the client together with
+	// every other client sharing it.
+	RateLimiter *RateLimiter
+	// TLS, if set, replaces the TLS settings the Vault API reads from the
+	// VAULT_CACERT, VAULT_CAPATH, VAULT_CLIENT_CERT, VAULT_CLIENT_KEY,
+	// VAULT_SKIP_VERIFY and VAULT_TLS_SERVER_NAME environment variables.
+	TLS *vaultapi.TLSConfig
+	// UserAgent, if set, is sent as the User-Agent header of every request,
+	// where Vault audit logs can record it.
+	UserAgent string
+	// IgnoreEnvironment undoes every setting the Vault API reads from VAULT_*
+	// environment variables: agent address, namespace, headers, retries,
+	// timeout, rate limit, SRV lookup, redirects, proxy and TLS. The Vault API
+	// reads them regardless, so a malformed variable still fails.
+	IgnoreEnvironment bool
+}
+
+// ProxySchemes are the URL schemes accepted in VaultConfig.ProxyURL.
+var ProxySchemes = []string{"http", "https", "socks5", "socks5h"}
+
+// NewClient creates a Vault API client for c with an explicit token.
+func (c VaultConfig) NewClient(token string) (*vaultapi.Client, error) {
+	httpClient, err := c.httpClient()
 	if err != nil {
-		return nil, fmt.Errorf("emitting encrypted document: %w", err)
+		return nil, err
 	}
+	return c.newClient(httpClient, token)
+}
 
-	return out, nil
+// httpClient returns the HTTP client of the Vault API defaults with the proxy
+// and TLS settings of c applied.
+func (c VaultConfig) httpClient() (*http.Client, error) {
+	cfg := vaultapi.DefaultConfig()
+	if c.ProxyURL != "" {
+		proxy, err := url.Parse(c.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Vault proxy URL: %w", err)
+		}
+		if !slices.Contains(ProxySchemes, proxy.Scheme) || proxy.Host == "" {
+			return nil, fmt.Errorf("invalid Vault proxy URL %q: expected an %s URL",
+				proxy.Redacted(), strings.Join(ProxySchemes, ", "))
+		}
+		cfg.HttpClient.Transport.(*http.Transport).Proxy = http.ProxyURL(proxy)
+	} else if c.IgnoreEnvironment {
+		// The default of the Vault API before VAULT_PROXY_ADDR and
+		// VAULT_HTTP_PROXY.
+		cfg.HttpClient.Transport.(*http.Transport).Proxy = http.ProxyFromEnvironment
+	}
+	if c.TLS != nil || c.IgnoreEnvironment {
+		// Start from the Vault API defaults rather than the environment, so
+		// that unset fields of c.TLS are really unset.
+		cfg.HttpClient.Transport.(*http.Transport).TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+		if c.TLS != nil {
+			if err := cfg.ConfigureTLS(c.TLS); err != nil {
+				return nil, fmt.Errorf("configuring Vault TLS: %w", err)
+			}
+		}
+	}
+	return cfg.HttpClient, nil
 }
 
-// NewVaultClient creates a Vault API client with an explicit address and
-// token. No environment variables are consulted.
-func NewVaultClient(address, token string) (*vaultapi.Client, error) {
+// newClient creates a Vault API client for c that sends its requests with
+// httpClient.
+func (c VaultConfig) newClient(httpClient *http.Client, token string) (*vaultapi.Client, error) {
 	cfg := vaultapi.DefaultConfig()
-	cfg.Address = address
+	cfg.Address = c.Address
+	cfg.HttpClient = httpClient
+	if c.IgnoreEnvironment {
+		// The defaults of the Vault API.
+		cfg.AgentAddress = ""
+		cfg.MaxRetries = 2
+		cfg.Timeout = 60 * time.Second
+		cfg.Limiter = nil
+		cfg.SRVLookup = false
+		cfg.DisableRedirects = false
+	}
+	if c.RateLimiter != nil {
+		c.RateLimiter.configure(cfg)
+	}
 	client, err := vaultapi.NewClient(cfg)
 	if err != nil {
 		return nil, fmt.Errorf("creating vault client: %w", err)
 	}
+	if c.RateLimiter != nil {
+		// The clone shares its configuration with client, so the callback
+		// sees a client timeout set later.
+		var limited *vaultapi.Client
+		limited = client.WithRequestCallbacks(func(*vaultapi.Request) { c.RateLimiter.wait(limited.ClientTimeout()) })
+		client = limited
+	}
+	if c.IgnoreEnvironment {
+		// Drop VAULT_NAMESPACE and VAULT_HEADERS, keeping the request
+		// header every client sends.
+		client.ClearNamespace()
+		client.SetHeaders(http.Header{vaultapi.RequestHeaderName: []string{"true"}})
+	}
+	if c.UserAgent != "" {
+		client.AddHeader("User-Agent", c.UserAgent)
+	}
 	client.SetToken(token)
 	return client, nil
 }
 
-// AppRoleLogin authenticates to Vault using the AppRole auth method and
-// returns the resulting client token. address is the full Vault server URL;
-// approlePath is the auth mount path (typically "approle").
-func AppRoleLogin(address, approlePath, roleID, secretID string) (string, error) {
-	client, err := NewVaultClient(address, "")
+// ClientPool creates Vault API clients that share one HTTP client, and so
+// one pool of keep-alive connections per Vault server, instead of each
+// opening connections and loading TLS certificates of its own. A ClientPool
+// is safe for concurrent use and is meant to be shared by all operations of
+// one provider instance; each operation still gets a client of its own, whose
+// token and timeout it may set freely.
+type ClientPool struct {
+	config     VaultConfig
+	httpClient *http.Client
+}
+
+// NewClientPool returns a ClientPool for c, loading its TLS certificates.
+func (c VaultConfig) NewClientPool() (*ClientPool, error) {
+	httpClient, err := c.httpClient()
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+	return &ClientPool{config: c, httpClient: httpClient}, nil
+}
+
+// NewClient creates a client with an explicit token for the Vault server at
+// address, or for the pool's server when address is empty. Every server
+// shares the pool's connection settings.
+func (p *ClientPool) NewClient(address, token string) (*vaultapi.Client, error) {
+	c := p.config
+	if address != "" {
+		c.Address = address
 	}
-	secret, err := client.Logical().Write("auth/"+approlePath+"/login", map[string]interface{}{
+	return c.newClient(p.httpClient, token)
+}
+
+// NewVaultClient creates a Vault API client with an explicit address and
+// token. No environment variables are consulted.
+func NewVaultClient(address, token string) (*vaultapi.Client, error) {
+	return VaultConfig{Address: address}.NewClient(token)
+}
+
+// AppRoleLogin authenticates to Vault using the AppRole auth method and
+// returns the resulting client token. approlePath is the auth mount path
+// (typically "approle").
+func AppRoleLogin(vault VaultConfig, approlePath, roleID, secretID string) (string, error) {
+	return login(vault, "auth/"+approlePath+"/login", "approle", map[string]interface{}{
 		"role_id":   roleID,
 		"secret_id": secretID,
 	})
+}
+
+// KubernetesLogin authenticates to Vault using the Kubernetes auth method with
+// a service account JWT and returns the resulting client token. mountPath is
+// the auth mount path (typically "kubernetes").
+func KubernetesLogin(vault VaultConfig, mountPath, role, jwt string) (string, error) {
+	return login(vault, "auth/"+mountPath+"/login", "kubernetes", map[string]interface{}{
+		"role": role,
+		"jwt":  jwt,
+	})
+}
+
+// login writes data to an auth method's login endpoint with an
+// unauthenticated client and returns the issued client token.
+func login(vault VaultConfig, loginPath, method string, data map[string]interface{}) (string, error) {
+	client, err := vault.NewClient("")
 	if err != nil {
-		return "", fmt.Errorf("approle login at auth/%s/login: %w", approlePath, err)
+		return "", err
+	}
+	secret, err := client.Logical().Write(loginPath, data)
+	if err != nil {
+		return "", fmt.Errorf("%s login at %s: %w", method, loginPath, err)
 	}
 	if secret == nil || secret.Auth == nil {
-		return "", fmt.Errorf("approle login: empty auth response from Vault")
+		return "", fmt.Errorf("%s login: empty auth response from Vault", method)
 	}
 	return secret.Auth.ClientToken, nil
 }
 
+// CheckConnection verifies that client can reach Vault with a valid token and
+// that a Transit secrets engine is mounted at transitPath. It makes two
+// read-only calls: token lookup-self and the mount lookup the Vault CLI uses,
+// which any token with a policy on transitPath may read.
+func CheckConnection(client *vaultapi.Client, transitPath string) error {
+	if _, err := client.Auth().Token().LookupSelf(); err != nil {
+		return fmt.Errorf("token lookup-self at %s failed; check that the address is reachable and the token is valid and not expired: %w", client.Address(), err)
+	}
+	transitPath = strings.Trim(transitPath, "/")
+	secret, err := client.Logical().Read("sys/internal/ui/mounts/" + transitPath)
+	if err != nil {
+		return fmt.Errorf("reading mount %q failed; check that the token's policy grants access to %s/*: %w", transitPath, transitPath, err)
+	}
+	if secret == nil || secret.Data == nil {
+		return fmt.Errorf("no secrets engine is mounted at %q; enable one with `vault secrets enable -path=%s transit`", transitPath, transitPath)
+	}
+	if typ, _ := secret.Data["type"].(string); typ != "transit" {
+		return fmt.Errorf("the secrets engine mounted at %q is of type %q, not transit", transitPath, typ)
+	}
+	return nil
+}
+
+// TokenIdentity identifies the token of a Vault client in the Vault audit
+// log.
+type TokenIdentity struct {
+	// EntityID is the identity entity of the token; empty for tokens
+	// without one, such as root tokens.
+	EntityID string
+	// Accessor is the accessor of the token, which audit log entries
+	// record in the clear.
+	Accessor string
+}
+
+// LookupTokenIdentity returns the TokenIdentity of the token of client with
+// token lookup-self, which the default policy allows.
+func LookupTokenIdentity(client *vaultapi.Client) (TokenIdentity, error) {
+	secret, err := client.Auth().Token().LookupSelf()
+	if err != nil {
+		return TokenIdentity{}, fmt.Errorf("token lookup-self at %s: %w", client.Address(), err)
+	}
+	if secret == nil {
+		return TokenIdentity{}, fmt.Errorf("unexpected vault response: empty token lookup response")
+	}
+	entityID, _ := secret.Data["entity_id"].(string)
+	accessor, _ := secret.Data["accessor"].(string)
+	return TokenIdentity{EntityID: entityID, Accessor: accessor}, nil
+}
+
 // generateDataKey returns 32 cryptographically random bytes (AES-256).
 func generateDataKey() ([]byte, error) {
 	key := make([]byte, 32)
@@ -186,18 +661,74 @@ func generateDataKey() ([]byte, error) {
 }
 
 // wrapDataKey calls the Vault Transit encrypt endpoint and returns the
-// ciphertext blob (e.g. "vault:v1:…").
-func wrapDataKey(client *vaultapi.Client, transitPath, keyName string, dataKey []byte) (string, error) {
+// ciphertext blob (e.g. "vault:v1:…") and the ID of the request.
+func wrapDataKey(client *vaultapi.Client, transitPath, keyName string, dataKey []byte) (ciphertext, requestID string, err error) {
+	return transitEncrypt(client, transitPath, keyName, dataKey, nil)
+}
+
+// transitEncrypt encrypts plaintext with the Transit key keyName, deriving
+// the key from keyContext when it is set. It also returns the ID of the
+// request, as recorded in the Vault audit log.
+func transitEncrypt(client *vaultapi.Client, transitPath, keyName string, plaintext, keyContext []byte) (ciphertext, requestID string, err error) {
 	path := transitPath + "/encrypt/" + keyName
-	secret, err := client.Logical().Write(path, map[string]interface{}{
-		"plaintext": base64.StdEncoding.EncodeToString(dataKey),
-	})
+	data := map[string]interface{}{
+		"plaintext": base64.StdEncoding.EncodeToString(plaintext),
+	}
+	if keyContext != nil {
+		data["context"] = base64.StdEncoding.EncodeToString(keyContext)
+	}
+	secret, err := client.Logical().Write(path, data)
 	if err != nil {
-		return "", fmt.Errorf("vault transit encrypt (%s): %w", path, err)
+		return "", "", fmt.Errorf("vault transit encrypt (%s): %w", path, err)
 	}
 	ct, ok := secret.Data["ciphertext"].(string)
 	if !ok {
-		return "", fmt.Errorf("unexpected vault response: ciphertext not a string")
+		return "", "", fmt.Errorf("unexpected vault response: ciphertext not a string")
+	}
+	return ct, secret.RequestID, nil
+}
+
+// wrapDataKeys wraps each of dataKeys with Vault Transit and returns the
+// ciphertext blobs in the same order and the ID of the request. Several keys
+// are wrapped with a single batch encrypt request.
+func wrapDataKeys(client *vaultapi.Client, transitPath, keyName string, dataKeys [][]byte) ([]string, string, error) {
+	switch len(dataKeys) {
+	case 0:
+		return nil, "", nil
+	case 1:
+		ct, requestID, err := wrapDataKey(client, transitPath, keyName, dataKeys[0])
+		if err != nil {
+			return nil, "", err
+		}
+		return []string{ct}, requestID, nil
+	}
+	batch := make([]map[string]interface{}, len(dataKeys))
+	for i, dataKey := range dataKeys {
+		batch[i] = map[string]interface{}{"plaintext": base64.StdEncoding.EncodeToString(dataKey)}
+	}
+	path := transitPath + "/encrypt/" + keyName
+	secret, err := client.Logical().Write(path, map[string]interface{}{"batch_input": batch})
+	if err != nil {
+		return nil, "", fmt.Errorf("vault transit batch encrypt (%s): %w", path, err)
+	}
+	var results []interface{}
+	if secret != nil {
+		results, _ = secret.Data["batch_results"].([]interface{})
+	}
+	if len(results) != len(dataKeys) {
+		return nil, "", fmt.Errorf("unexpected vault response: %d batch results for %d data keys", len(results), len(dataKeys))
+	}
+	cts := make([]string, len(results))
+	for i, result := range results {
+		item, _ := result.(map[string]interface{})
+		if msg, _ := item["error"].(string); msg != "" {
+			return nil, "", fmt.Errorf("vault transit batch encrypt (%s): item %d: %s", path, i, msg)
+		}
+		ct, ok := item["ciphertext"].(string)
+		if !ok {
+			return nil, "", fmt.Errorf("unexpected vault response: ciphertext not a string")
+		}
+		cts[i] = ct
 	}
-	return ct, nil
+	return cts, secret.RequestID, nil
 }
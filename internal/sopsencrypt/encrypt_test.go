@@ -3,19 +3,31 @@ package sopsencrypt_test
 import (
 	"encoding/base64"
 	"encoding/json"
+	"encoding/pem"
+	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"filippo.io/age"
+	"github.com/getsops/sops/v3"
+	sopsjson "github.com/getsops/sops/v3/stores/json"
+	sopsyaml "github.com/getsops/sops/v3/stores/yaml"
 	vaultapi "github.com/hashicorp/vault/api"
 
 	"terraform-provider-sops/internal/sopsencrypt"
 )
 
-// mockVaultServer simulates the Vault Transit encrypt endpoint.
-// The "encrypted" payload is vault:v1:<base64(plaintext)> so tests can
+// mockVaultServer simulates the Vault Transit encrypt and decrypt endpoints,
+// including batch encryption. The "encrypted" payload is vault:v1:<base64(plaintext)> so tests can
 // verify round-trips without a real Vault instance.
 func mockVaultServer(t *testing.T) *httptest.Server {
 	t.Helper()
@@ -30,12 +42,25 @@ func mockVaultServer(t *testing.T) *httptest.Server {
 
 		if strings.Contains(r.URL.Path, "/encrypt/") {
 			var req struct {
-				Plaintext string `json:"plaintext"`
+				Plaintext  string `json:"plaintext"`
+				BatchInput []struct {
+					Plaintext string `json:"plaintext"`
+				} `json:"batch_input"`
 			}
 			if err := json.Unmarshal(body, &req); err != nil {
 				http.Error(w, err.Error(), http.StatusBadRequest)
 				return
 			}
+			if req.BatchInput != nil {
+				results := make([]map[string]interface{}, len(req.BatchInput))
+				for i, in := range req.BatchInput {
+					results[i] = map[string]interface{}{"ciphertext": "vault:v1:" + in.Plaintext}
+				}
+				json.NewEncoder(w).Encode(map[string]interface{}{ //nolint:errcheck
+					"data": map[string]interface{}{"batch_results": results},
+				})
+				return
+			}
 			json.NewEncoder(w).Encode(map[string]interface{}{ //nolint:errcheck
 				"data": map[string]interface{}{
 					"ciphertext": "vault:v1:" + req.Plaintext,
@@ -43,6 +68,21 @@ func mockVaultServer(t *testing.T) *httptest.Server {
 			})
 			return
 		}
+		if strings.Contains(r.URL.Path, "/decrypt/") {
+			var req struct {
+				Ciphertext string `json:"ciphertext"`
+			}
+			if err := json.Unmarshal(body, &req); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{ //nolint:errcheck
+				"data": map[string]interface{}{
+					"plaintext": strings.TrimPrefix(req.Ciphertext, "vault:v1:"),
+				},
+			})
+			return
+		}
 		http.NotFound(w, r)
 	}))
 }
@@ -195,6 +235,70 @@ func TestEncryptToJSON_SamePlaintextProducesDifferentCiphertexts(t *testing.T) {
 	}
 }
 
+// sopsTimestamps returns lastmodified and the hc_vault created_at of a SOPS
+// JSON document.
+func sopsTimestamps(t *testing.T, document string) (lastModified, createdAt string) {
+	t.Helper()
+	var doc struct {
+		Sops struct {
+			LastModified string `json:"lastmodified"`
+			HCVault      []struct {
+				CreatedAt string `json:"created_at"`
+			} `json:"hc_vault"`
+		} `json:"sops"`
+	}
+	if err := json.Unmarshal([]byte(document), &doc); err != nil || len(doc.Sops.HCVault) != 1 {
+		t.Fatalf("unexpected SOPS document (%v): %s", err, document)
+	}
+	return doc.Sops.LastModified, doc.Sops.HCVault[0].CreatedAt
+}
+
+func TestEncryptToJSON_FixedTimestamp(t *testing.T) {
+	srv := mockVaultServer(t)
+	defer srv.Close()
+
+	opts := sopsencrypt.EncryptOpts{Timestamp: time.Date(2024, 5, 1, 12, 30, 0, 500, time.FixedZone("CEST", 2*3600))}
+	for i := 0; i < 2; i++ {
+		out, err := sopsencrypt.EncryptToJSON(newTestClient(t, srv), "transit", "k", `{"key":"value"}`, opts)
+		if err != nil {
+			t.Fatalf("EncryptToJSON: %v", err)
+		}
+		lastModified, createdAt := sopsTimestamps(t, out)
+		if lastModified != "2024-05-01T10:30:00Z" || createdAt != "2024-05-01T10:30:00Z" {
+			t.Errorf("lastmodified %s, created_at %s, want 2024-05-01T10:30:00Z", lastModified, createdAt)
+		}
+		// The MAC is bound to lastmodified, so the document must decrypt.
+		if _, err := sopsencrypt.Decrypt(newTestClient(t, srv), out, "json"); err != nil {
+			t.Errorf("Decrypt: %v", err)
+		}
+	}
+}
+
+func TestEncryptToJSON_TimestampFromContent(t *testing.T) {
+	srv := mockVaultServer(t)
+	defer srv.Close()
+
+	opts := sopsencrypt.EncryptOpts{TimestampFromContent: true}
+	encrypt := func(content string) string {
+		out, err := sopsencrypt.EncryptToJSON(newTestClient(t, srv), "transit", "k", content, opts)
+		if err != nil {
+			t.Fatalf("EncryptToJSON: %v", err)
+		}
+		lastModified, createdAt := sopsTimestamps(t, out)
+		if lastModified != createdAt {
+			t.Errorf("lastmodified %s differs from created_at %s", lastModified, createdAt)
+		}
+		return lastModified
+	}
+	first, again, other := encrypt(`{"key":"value"}`), encrypt(`{"key":"value"}`), encrypt(`{"key":"other"}`)
+	if first != again {
+		t.Errorf("identical content got timestamps %s and %s", first, again)
+	}
+	if first == other {
+		t.Errorf("different content got the same timestamp %s", first)
+	}
+}
+
 // ── EncryptToYAML ──────────────────────────────────────────────────────────
 
 func TestEncryptToYAML_ReturnsSOPSYAML(t *testing.T) {
@@ -244,6 +348,422 @@ func TestEncryptToYAML_NestedStructure(t *testing.T) {
 	}
 }
 
+func TestEncryptToYAML_YAMLInputKeepsOrderAndComments(t *testing.T) {
+	srv := mockVaultServer(t)
+	defer srv.Close()
+
+	content := "# database settings\nzeta: 1\nalpha:\n  password: secret\n"
+	result, err := sopsencrypt.EncryptToYAML(
+		newTestClient(t, srv), "transit", "test-key", content,
+		sopsencrypt.EncryptOpts{InputYAML: true},
+	)
+	if err != nil {
+		t.Fatalf("EncryptToYAML: %v", err)
+	}
+	if !strings.Contains(result, "#ENC[") {
+		t.Errorf("comment was not kept; got:\n%s", result)
+	}
+	if zeta, alpha := strings.Index(result, "zeta:"), strings.Index(result, "alpha:"); zeta < 0 || alpha < zeta {
+		t.Errorf("key order was not kept; got:\n%s", result)
+	}
+}
+
+func TestEncryptToYAML_YAMLStream(t *testing.T) {
+	srv := mockVaultServer(t)
+	defer srv.Close()
+
+	content := "kind: Secret\nname: a\n---\nkind: Secret\nname: b\n"
+	result, err := sopsencrypt.EncryptToYAML(
+		newTestClient(t, srv), "transit", "test-key", content,
+		sopsencrypt.EncryptOpts{InputYAML: true},
+	)
+	if err != nil {
+		t.Fatalf("EncryptToYAML: %v", err)
+	}
+	docs := strings.Split(result, "\n---\n")
+	if len(docs) != 2 {
+		t.Fatalf("expected 2 documents, got %d:\n%s", len(docs), result)
+	}
+	// Every document carries the same sops metadata, so each one decrypts.
+	for i, doc := range docs {
+		if !strings.Contains(doc, "sops:") {
+			t.Errorf("document %d missing sops metadata:\n%s", i, doc)
+		}
+	}
+}
+
+func TestEncryptToYAML_Layout(t *testing.T) {
+	srv := mockVaultServer(t)
+	defer srv.Close()
+	client := newTestClient(t, srv)
+
+	content := "db:\n  host: db.example.com\n  port: 5432\n  motd: |\n    line one\n    line two\n"
+	for _, tc := range []struct {
+		style string
+		want  []string
+	}{
+		{sopsencrypt.YAMLStylePlain, []string{"\n  host: db.example.com\n", "\n  port: 5432\n", "motd: |\n", "\n  password: ENC["}},
+		{sopsencrypt.YAMLStyleDoubleQuoted, []string{`  host: "db.example.com"`, "\n  port: 5432\n", `motd: "line one\nline two\n"`, `  password: "ENC[`}},
+		{sopsencrypt.YAMLStyleSingleQuoted, []string{"  host: 'db.example.com'", "\n  port: 5432\n", "  password: 'ENC["}},
+		{sopsencrypt.YAMLStyleFolded, []string{"\n  host: db.example.com\n", "motd: >\n"}},
+	} {
+		t.Run(tc.style, func(t *testing.T) {
+			result, err := sopsencrypt.EncryptToYAML(client, "transit", "test-key", content+"  password: secret\n",
+				sopsencrypt.EncryptOpts{
+					InputYAML:        true,
+					UnencryptedRegex: "^(host|port|motd)$",
+					YAMLIndent:       2,
+					YAMLStringStyle:  tc.style,
+				})
+			if err != nil {
+				t.Fatalf("EncryptToYAML: %v", err)
+			}
+			for _, want := range tc.want {
+				if !strings.Contains(result, want) {
+					t.Errorf("output does not contain %q:\n%s", want, result)
+				}
+			}
+			// The layout leaves values and MAC intact.
+			plaintext, err := sopsencrypt.Decrypt(client, result, "yaml")
+			if err != nil {
+				t.Fatalf("Decrypt: %v", err)
+			}
+			if !strings.Contains(string(plaintext), "password: secret") {
+				t.Errorf("Decrypt = %s, want the password restored", plaintext)
+			}
+		})
+	}
+}
+
+func TestEncryptToYAML_InvalidLayout(t *testing.T) {
+	for _, opts := range []sopsencrypt.EncryptOpts{
+		{Mock: true, YAMLIndent: 1},
+		{Mock: true, YAMLIndent: 10},
+		{Mock: true, YAMLStringStyle: "literal"},
+	} {
+		if _, err := sopsencrypt.EncryptToYAML(nil, "transit", "test-key", `{"a":"b"}`, opts); err == nil {
+			t.Errorf("EncryptToYAML(%+v) succeeded, want an error", opts)
+		}
+	}
+}
+
+func TestEncrypt_LineLayout(t *testing.T) {
+	srv := mockVaultServer(t)
+	defer srv.Close()
+	client := newTestClient(t, srv)
+
+	for _, format := range []string{"json", "yaml"} {
+		t.Run(format, func(t *testing.T) {
+			result, err := sopsencrypt.Encrypt(client, "transit", "test-key", `{"password":"secret"}`, format,
+				sopsencrypt.EncryptOpts{NoTrailingNewline: true, LineEndings: sopsencrypt.LineEndingsCRLF})
+			if err != nil {
+				t.Fatalf("Encrypt: %v", err)
+			}
+			if strings.HasSuffix(result, "\n") {
+				t.Errorf("output ends with a newline:\n%q", result)
+			}
+			if strings.Count(result, "\n") != strings.Count(result, "\r\n") {
+				t.Errorf("output has bare LF line breaks:\n%q", result)
+			}
+			plaintext, err := sopsencrypt.Decrypt(client, result, format)
+			if err != nil {
+				t.Fatalf("Decrypt: %v", err)
+			}
+			if !strings.Contains(string(plaintext), "secret") {
+				t.Errorf("Decrypt = %s, want the password restored", plaintext)
+			}
+		})
+	}
+
+	if _, err := sopsencrypt.Encrypt(nil, "transit", "test-key", `{"a":"b"}`, "json",
+		sopsencrypt.EncryptOpts{Mock: true, LineEndings: "cr"}); err == nil {
+		t.Error("Encrypt with line endings \"cr\" succeeded, want an error")
+	}
+}
+
+func TestConvertEncrypted(t *testing.T) {
+	srv := mockVaultServer(t)
+	defer srv.Close()
+	client := newTestClient(t, srv)
+
+	jsonDoc, err := sopsencrypt.EncryptToJSON(client, "transit", "test-key", `{"db":{"password":"secret","port":5432}}`, sopsencrypt.EncryptOpts{})
+	if err != nil {
+		t.Fatalf("EncryptToJSON: %v", err)
+	}
+	yamlDoc, err := sopsencrypt.ConvertEncrypted(jsonDoc, "yaml", sopsencrypt.EncryptOpts{YAMLIndent: 2})
+	if err != nil {
+		t.Fatalf("ConvertEncrypted: %v", err)
+	}
+	if !strings.HasPrefix(yamlDoc, "db:\n  password: ENC[") {
+		t.Errorf("ConvertEncrypted is not indented YAML:\n%s", yamlDoc)
+	}
+	if err := sopsencrypt.CheckConsistent(jsonDoc, yamlDoc); err != nil {
+		t.Errorf("CheckConsistent: %v", err)
+	}
+	plaintext, err := sopsencrypt.DecryptFromYAML(client, yamlDoc)
+	if err != nil {
+		t.Fatalf("DecryptFromYAML: %v", err)
+	}
+	if !strings.Contains(plaintext, "password: secret") {
+		t.Errorf("DecryptFromYAML = %s, want the password restored", plaintext)
+	}
+
+	stream, err := sopsencrypt.EncryptToYAML(nil, "transit", "test-key", "a: 1\n---\nb: 2\n", sopsencrypt.EncryptOpts{Mock: true, InputYAML: true})
+	if err != nil {
+		t.Fatalf("EncryptToYAML: %v", err)
+	}
+	if _, err := sopsencrypt.ConvertEncrypted(stream, "json", sopsencrypt.EncryptOpts{}); err == nil {
+		t.Error("ConvertEncrypted of a YAML stream to JSON succeeded, want an error")
+	}
+}
+
+func TestEncryptToYAML_HeaderComment(t *testing.T) {
+	srv := mockVaultServer(t)
+	defer srv.Close()
+	client := newTestClient(t, srv)
+
+	for _, tc := range []struct {
+		name string
+		opts sopsencrypt.EncryptOpts
+	}{
+		{"every value encrypted", sopsencrypt.EncryptOpts{}},
+		{"top-level comments unencrypted", sopsencrypt.EncryptOpts{EncryptedRegex: "^password$"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.opts.HeaderComment = "Managed by Terraform — do not edit\n\n# generated\n"
+			result, err := sopsencrypt.EncryptToYAML(client, "transit", "test-key", `{"password":"secret"}`, tc.opts)
+			if err != nil {
+				t.Fatalf("EncryptToYAML: %v", err)
+			}
+			want := "# Managed by Terraform — do not edit\n#\n# generated\npassword: ENC["
+			if !strings.HasPrefix(result, want) {
+				t.Errorf("output does not start with %q:\n%s", want, result)
+			}
+			plaintext, err := sopsencrypt.Decrypt(client, result, "yaml")
+			if err != nil {
+				t.Fatalf("Decrypt: %v", err)
+			}
+			if !strings.Contains(string(plaintext), "password: secret") {
+				t.Errorf("Decrypt = %s, want the password restored", plaintext)
+			}
+		})
+	}
+}
+
+func TestEncryptToJSON_YAMLStreamIsRejected(t *testing.T) {
+	_, err := sopsencrypt.EncryptToJSON(
+		nil, "transit", "test-key", "a: 1\n---\nb: 2\n",
+		sopsencrypt.EncryptOpts{InputYAML: true, Mock: true},
+	)
+	if err == nil || !strings.Contains(err.Error(), "cannot be emitted as JSON") {
+		t.Fatalf("expected multi-document error, got %v", err)
+	}
+}
+
+func TestEncryptToJSONAndYAML_YAMLStreamHasNoJSON(t *testing.T) {
+	jsonOut, yamlOut, err := sopsencrypt.EncryptToJSONAndYAML(
+		nil, "transit", "test-key", "a: 1\n---\nb: 2\n",
+		sopsencrypt.EncryptOpts{InputYAML: true, Mock: true},
+	)
+	if err != nil {
+		t.Fatalf("EncryptToJSONAndYAML: %v", err)
+	}
+	if jsonOut != "" {
+		t.Errorf("expected no JSON output, got:\n%s", jsonOut)
+	}
+	if !strings.Contains(yamlOut, "\n---\n") {
+		t.Errorf("YAML output is not a stream:\n%s", yamlOut)
+	}
+}
+
+// ── EncryptToJSONAndYAML ───────────────────────────────────────────────────
+
+func TestEncryptToJSONAndYAML_SharesOneEncryption(t *testing.T) {
+	srv := mockVaultServer(t)
+	defer srv.Close()
+	calls := 0
+	counting := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		srv.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer counting.Close()
+
+	jsonOut, yamlOut, err := sopsencrypt.EncryptToJSONAndYAML(
+		newTestClient(t, counting), "transit", "test-key",
+		`{"password":"secret"}`, sopsencrypt.EncryptOpts{},
+	)
+	if err != nil {
+		t.Fatalf("EncryptToJSONAndYAML: %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("Vault called %d times, want 1", calls)
+	}
+
+	var doc struct {
+		Password string `json:"password"`
+		SOPS     struct {
+			MAC string `json:"mac"`
+		} `json:"sops"`
+	}
+	if err := json.Unmarshal([]byte(jsonOut), &doc); err != nil {
+		t.Fatalf("JSON output is not valid JSON: %v", err)
+	}
+	// The same encrypted tree is emitted twice, so values and MAC match.
+	for _, want := range []string{"password: " + doc.Password, "mac: " + doc.SOPS.MAC} {
+		if !strings.Contains(yamlOut, want) {
+			t.Errorf("YAML output missing %q; got:\n%s", want, yamlOut)
+		}
+	}
+}
+
+// The emitters are reimplementations of the sops stores' for speed; a file
+// the store loads and re-emits must come out unchanged.
+func TestEncryptToJSONAndYAML_MatchesSOPSStores(t *testing.T) {
+	for name, tc := range map[string]struct {
+		content string
+		opts    sopsencrypt.EncryptOpts
+	}{
+		"json": {
+			`{"a":"b","n":1,"f":1.5,"t":true,"z":null,"list":[1,"x",{"q":[]}],"o":{},"true":"x","1":"y","h":"<&>","k_unencrypted":"yes","m_unencrypted":"a\nb"}`,
+			sopsencrypt.EncryptOpts{UnencryptedSuffix: "_unencrypted"},
+		},
+		"yaml with comments": {
+			"# head\na: b # trailing\nlist:\n  # in list\n  - 1\n  - two\nobj:\n  k: v\n  # foot\nno: x\nq_unencrypted: 'quoted: yes'\n",
+			sopsencrypt.EncryptOpts{InputYAML: true, UnencryptedSuffix: "_unencrypted"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			tc.opts.AgeRecipients = []string{"age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p"}
+			jsonOut, yamlOut, err := sopsencrypt.EncryptToJSONAndYAML(nil, "", "", tc.content, tc.opts)
+			if err != nil {
+				t.Fatalf("EncryptToJSONAndYAML: %v", err)
+			}
+			for _, out := range []struct {
+				doc   string
+				store interface {
+					LoadEncryptedFile([]byte) (sops.Tree, error)
+					EmitEncryptedFile(sops.Tree) ([]byte, error)
+				}
+			}{{jsonOut, &sopsjson.Store{}}, {yamlOut, &sopsyaml.Store{}}} {
+				tree, err := out.store.LoadEncryptedFile([]byte(out.doc))
+				if err != nil {
+					t.Fatalf("LoadEncryptedFile: %v", err)
+				}
+				want, err := out.store.EmitEncryptedFile(tree)
+				if err != nil {
+					t.Fatalf("EmitEncryptedFile: %v", err)
+				}
+				if out.doc != string(want) {
+					t.Errorf("output differs from the sops store's:\n%s\n---\n%s", out.doc, want)
+				}
+			}
+		})
+	}
+}
+
+// ── EncryptBatch ───────────────────────────────────────────────────────────
+
+func TestEncryptBatch_OneVaultRequest(t *testing.T) {
+	srv := mockVaultServer(t)
+	defer srv.Close()
+	var encrypts atomic.Int64
+	counting := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Path, "/encrypt/") {
+			encrypts.Add(1)
+		}
+		srv.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer counting.Close()
+	client := newTestClient(t, counting)
+
+	documents := map[string]string{
+		"app":    `{"password":"app-secret"}`,
+		"worker": `{"password":"worker-secret"}`,
+		"db":     `{"user":"admin","password":"db-secret"}`,
+	}
+	out, err := sopsencrypt.EncryptBatch(client, "transit", "test-key", documents, sopsencrypt.EncryptOpts{})
+	if err != nil {
+		t.Fatalf("EncryptBatch: %v", err)
+	}
+	if n := encrypts.Load(); n != 1 {
+		t.Errorf("made %d Transit encrypt requests, want 1", n)
+	}
+	if len(out) != len(documents) {
+		t.Fatalf("got %d ciphertexts, want %d", len(out), len(documents))
+	}
+
+	for name, plaintext := range documents {
+		got, err := sopsencrypt.Decrypt(client, out[name], "json")
+		if err != nil {
+			t.Fatalf("Decrypt %s: %v", name, err)
+		}
+		var want, have interface{}
+		json.Unmarshal([]byte(plaintext), &want) //nolint:errcheck
+		json.Unmarshal(got, &have)               //nolint:errcheck
+		if fmt.Sprint(want) != fmt.Sprint(have) {
+			t.Errorf("%s decrypted to %s, want %s", name, got, plaintext)
+		}
+	}
+}
+
+func TestEncryptBatch_DistinctDataKeys(t *testing.T) {
+	srv := mockVaultServer(t)
+	defer srv.Close()
+
+	out, err := sopsencrypt.EncryptBatch(newTestClient(t, srv), "transit", "test-key", map[string]string{
+		"a": `{"k":"same"}`,
+		"b": `{"k":"same"}`,
+	}, sopsencrypt.EncryptOpts{})
+	if err != nil {
+		t.Fatalf("EncryptBatch: %v", err)
+	}
+	if out["a"] == out["b"] {
+		t.Fatal("identical documents produced identical ciphertexts")
+	}
+	var a, b struct {
+		K    string `json:"k"`
+		Sops struct {
+			HCVault []struct {
+				Enc string `json:"enc"`
+			} `json:"hc_vault"`
+		} `json:"sops"`
+	}
+	json.Unmarshal([]byte(out["a"]), &a) //nolint:errcheck
+	json.Unmarshal([]byte(out["b"]), &b) //nolint:errcheck
+	if len(a.Sops.HCVault) != 1 || len(b.Sops.HCVault) != 1 || a.Sops.HCVault[0].Enc == b.Sops.HCVault[0].Enc {
+		t.Errorf("documents share a wrapped data key: %+v, %+v", a.Sops.HCVault, b.Sops.HCVault)
+	}
+}
+
+func TestEncryptBatch_InvalidDocumentNamed(t *testing.T) {
+	srv := mockVaultServer(t)
+	defer srv.Close()
+
+	_, err := sopsencrypt.EncryptBatch(newTestClient(t, srv), "transit", "test-key", map[string]string{
+		"good": `{"k":"v"}`,
+		"bad":  `[1]`,
+	}, sopsencrypt.EncryptOpts{})
+	if err == nil || !strings.Contains(err.Error(), `document "bad"`) {
+		t.Fatalf("expected an error naming the bad document, got %v", err)
+	}
+}
+
+func TestEncryptBatch_BatchItemError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"data":{"batch_results":[{"ciphertext":"vault:v1:eA=="},{"error":"encryption key not found"}]}}`)) //nolint:errcheck
+	}))
+	defer srv.Close()
+
+	_, err := sopsencrypt.EncryptBatch(newTestClient(t, srv), "transit", "test-key", map[string]string{
+		"a": `{"k":"v"}`,
+		"b": `{"k":"v"}`,
+	}, sopsencrypt.EncryptOpts{})
+	if err == nil || !strings.Contains(err.Error(), "encryption key not found") {
+		t.Fatalf("expected the batch item error, got %v", err)
+	}
+}
+
 // ── NewVaultClient ─────────────────────────────────────────────────────────
 
 func TestNewVaultClient_SetsAddressAndToken(t *testing.T) {
@@ -299,3 +819,386 @@ func TestNewVaultClient_EncodedKeyInVaultRequest(t *testing.T) {
 		t.Errorf("enc payload is not valid base64: %v", err)
 	}
 }
+
+func TestVaultConfig_ProxyURL(t *testing.T) {
+	var gotHost string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHost = r.URL.Host
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{ //nolint:errcheck
+			"auth": map[string]interface{}{"client_token": "s.proxied"},
+		})
+	}))
+	defer proxy.Close()
+
+	vault := sopsencrypt.VaultConfig{Address: "http://vault.invalid:8200", ProxyURL: proxy.URL}
+	token, err := sopsencrypt.AppRoleLogin(vault, "approle", "role", "secret")
+	if err != nil {
+		t.Fatalf("AppRoleLogin: %v", err)
+	}
+	if token != "s.proxied" || gotHost != "vault.invalid:8200" {
+		t.Errorf("token = %q via host %q, want s.proxied via vault.invalid:8200", token, gotHost)
+	}
+}
+
+func TestVaultConfig_InvalidProxyURL(t *testing.T) {
+	for _, proxyURL := range []string{"ftp://proxy:21", "proxy.internal:3128", "http://"} {
+		_, err := sopsencrypt.VaultConfig{Address: "http://vault:8200", ProxyURL: proxyURL}.NewClient("")
+		if err == nil {
+			t.Errorf("NewClient accepted proxy URL %q", proxyURL)
+		}
+	}
+}
+
+func TestVaultConfig_UserAgent(t *testing.T) {
+	var got string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.UserAgent()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"data":{}}`)) //nolint:errcheck
+	}))
+	defer srv.Close()
+
+	const ua = "Terraform/1.9.0 terraform-provider-sops/0.1.0 workspace=prod"
+	client, err := sopsencrypt.VaultConfig{Address: srv.URL, UserAgent: ua}.NewClient("test-token")
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	if _, err := client.Logical().Read("transit/keys/k"); err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if got != ua {
+		t.Errorf("User-Agent = %q, want %q", got, ua)
+	}
+}
+
+func TestVaultConfig_IgnoreEnvironment(t *testing.T) {
+	var header http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"data":{}}`)) //nolint:errcheck
+	}))
+	defer srv.Close()
+	t.Setenv("VAULT_AGENT_ADDR", "http://agent.invalid:8100")
+	t.Setenv("VAULT_NAMESPACE", "ambient")
+	t.Setenv("VAULT_HEADERS", `{"X-Ambient":"1"}`)
+	t.Setenv("VAULT_MAX_RETRIES", "9")
+	t.Setenv("VAULT_PROXY_ADDR", "http://proxy.invalid:3128")
+
+	client, err := sopsencrypt.VaultConfig{Address: srv.URL, IgnoreEnvironment: true}.NewClient("test-token")
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	if client.MaxRetries() != 2 {
+		t.Errorf("MaxRetries = %d, want 2", client.MaxRetries())
+	}
+	if _, err := client.Logical().Read("transit/keys/k"); err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	for _, name := range []string{"X-Vault-Namespace", "X-Ambient"} {
+		if v := header.Get(name); v != "" {
+			t.Errorf("%s = %q, want none", name, v)
+		}
+	}
+	if header.Get("X-Vault-Request") != "true" {
+		t.Error("X-Vault-Request header missing")
+	}
+}
+
+func TestClientPool(t *testing.T) {
+	var conns atomic.Int64
+	var mu sync.Mutex
+	tokens := map[string]bool{}
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		tokens[r.Header.Get("X-Vault-Token")] = true
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"data":{}}`)) //nolint:errcheck
+	}))
+	srv.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			conns.Add(1)
+		}
+	}
+	srv.Start()
+	defer srv.Close()
+
+	pool, err := sopsencrypt.VaultConfig{Address: srv.URL}.NewClientPool()
+	if err != nil {
+		t.Fatalf("NewClientPool: %v", err)
+	}
+	for i := 0; i < 5; i++ {
+		client, err := pool.NewClient("", "token-seq")
+		if err != nil {
+			t.Fatalf("NewClient: %v", err)
+		}
+		if _, err := client.Logical().Read("transit/keys/k"); err != nil {
+			t.Fatalf("Read %d: %v", i, err)
+		}
+	}
+	if n := conns.Load(); n != 1 {
+		t.Errorf("5 clients opened %d connections, want 1 shared connection", n)
+	}
+
+	// Clients used concurrently keep their own tokens and timeouts.
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			client, err := pool.NewClient("", fmt.Sprintf("token-%d", i))
+			if err != nil {
+				t.Errorf("NewClient: %v", err)
+				return
+			}
+			client.SetClientTimeout(time.Duration(i+1) * time.Second)
+			if _, err := client.Logical().Read("transit/keys/k"); err != nil {
+				t.Errorf("Read: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	for i := 0; i < 10; i++ {
+		if !tokens[fmt.Sprintf("token-%d", i)] {
+			t.Errorf("token-%d was not sent", i)
+		}
+	}
+}
+
+func TestVaultConfig_TLS(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{ //nolint:errcheck
+			"auth": map[string]interface{}{"client_token": "s.tls"},
+		})
+	}))
+	defer srv.Close()
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	if err := os.WriteFile(caFile, ca, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	// The environment is replaced entirely by an explicit TLS config.
+	t.Setenv("VAULT_CACERT", caFile)
+
+	for _, tc := range []struct {
+		name string
+		tls  *vaultapi.TLSConfig
+		ok   bool
+	}{
+		{"environment", nil, true},
+		{"ca cert", &vaultapi.TLSConfig{CACert: caFile}, true},
+		{"skip verify", &vaultapi.TLSConfig{Insecure: true}, true},
+		{"system roots", &vaultapi.TLSConfig{}, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := sopsencrypt.AppRoleLogin(sopsencrypt.VaultConfig{Address: srv.URL, TLS: tc.tls}, "approle", "role", "secret")
+			if (err == nil) != tc.ok {
+				t.Errorf("AppRoleLogin error = %v, want ok=%v", err, tc.ok)
+			}
+		})
+	}
+}
+
+func TestVaultConfig_TLSIncompleteClientCert(t *testing.T) {
+	_, err := sopsencrypt.VaultConfig{Address: "https://vault:8200", TLS: &vaultapi.TLSConfig{ClientCert: "cert.pem"}}.NewClient("")
+	if err == nil || !strings.Contains(err.Error(), "client key") {
+		t.Errorf("NewClient error = %v, want a missing client key", err)
+	}
+}
+
+// ── Login ──────────────────────────────────────────────────────────────────
+
+func TestKubernetesLogin_PostsRoleAndJWT(t *testing.T) {
+	var gotPath string
+	var gotBody map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		json.NewDecoder(r.Body).Decode(&gotBody) //nolint:errcheck
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{ //nolint:errcheck
+			"auth": map[string]interface{}{"client_token": "s.k8s"},
+		})
+	}))
+	defer srv.Close()
+
+	token, err := sopsencrypt.KubernetesLogin(sopsencrypt.VaultConfig{Address: srv.URL}, "k8s-prod", "terraform", "eyJhbGciOi")
+	if err != nil {
+		t.Fatalf("KubernetesLogin: %v", err)
+	}
+	if token != "s.k8s" {
+		t.Errorf("token = %q, want %q", token, "s.k8s")
+	}
+	if gotPath != "/v1/auth/k8s-prod/login" {
+		t.Errorf("request path = %q, want /v1/auth/k8s-prod/login", gotPath)
+	}
+	if gotBody["role"] != "terraform" || gotBody["jwt"] != "eyJhbGciOi" {
+		t.Errorf("login body = %v, want role and jwt", gotBody)
+	}
+}
+
+func TestAppRoleLogin_EmptyAuthResponse(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{}`)) //nolint:errcheck
+	}))
+	defer srv.Close()
+
+	if _, err := sopsencrypt.AppRoleLogin(sopsencrypt.VaultConfig{Address: srv.URL}, "approle", "role", "secret"); err == nil {
+		t.Error("expected error for response without auth block")
+	}
+}
+
+// ── CheckConnection ────────────────────────────────────────────────────────
+
+// mountServer answers token lookup-self and reports a secrets engine of
+// mountType at "transit"; an empty mountType means nothing is mounted.
+func mountServer(t *testing.T, tokenStatus int, mountType string) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v1/auth/token/lookup-self":
+			w.WriteHeader(tokenStatus)
+			w.Write([]byte(`{"data":{"id":"test-token"}}`)) //nolint:errcheck
+		case "/v1/sys/internal/ui/mounts/transit":
+			if mountType == "" {
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(`{"errors":[]}`)) //nolint:errcheck
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{ //nolint:errcheck
+				"data": map[string]interface{}{"type": mountType, "path": "transit/"},
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func TestCheckConnection(t *testing.T) {
+	tests := []struct {
+		name        string
+		tokenStatus int
+		mountType   string
+		wantErr     string
+	}{
+		{"ok", http.StatusOK, "transit", ""},
+		{"invalid token", http.StatusForbidden, "transit", "token lookup-self"},
+		{"missing mount", http.StatusOK, "", "no secrets engine is mounted"},
+		{"wrong mount type", http.StatusOK, "kv", `of type "kv"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := mountServer(t, tt.tokenStatus, tt.mountType)
+			defer srv.Close()
+
+			err := sopsencrypt.CheckConnection(newTestClient(t, srv), "transit/")
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("CheckConnection: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestEncrypt_AuditRequestID(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{ //nolint:errcheck
+			"request_id": "0c6b7f3e-2b0e-4f5a-9d2c-8a1e5b7c9d10",
+			"data":       map[string]interface{}{"ciphertext": "vault:v1:d3JhcHBlZA=="},
+		})
+	}))
+	defer srv.Close()
+	client := newTestClient(t, srv)
+
+	var audit sopsencrypt.EncryptAudit
+	if _, err := sopsencrypt.EncryptToJSON(client, "transit", "app", `{"a":"b"}`, sopsencrypt.EncryptOpts{Audit: &audit}); err != nil {
+		t.Fatalf("EncryptToJSON: %v", err)
+	}
+	if audit.VaultRequestID != "0c6b7f3e-2b0e-4f5a-9d2c-8a1e5b7c9d10" {
+		t.Errorf("VaultRequestID = %q", audit.VaultRequestID)
+	}
+
+	dataKey, err := sopsencrypt.NewDataKey(client, "transit", "app", "", false)
+	if err != nil {
+		t.Fatalf("NewDataKey: %v", err)
+	}
+	audit = sopsencrypt.EncryptAudit{}
+	dataKey.RequestID = "shared-key-request"
+	if _, err := sopsencrypt.EncryptToJSON(client, "transit", "app", `{"a":"b"}`, sopsencrypt.EncryptOpts{DataKey: &dataKey, Audit: &audit}); err != nil {
+		t.Fatalf("EncryptToJSON with DataKey: %v", err)
+	}
+	if audit.VaultRequestID != "shared-key-request" {
+		t.Errorf("VaultRequestID with DataKey = %q, want the request that wrapped it", audit.VaultRequestID)
+	}
+}
+
+func TestLookupTokenIdentity(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/auth/token/lookup-self" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{ //nolint:errcheck
+			"data": map[string]interface{}{"entity_id": "entity-1", "accessor": "accessor-1"},
+		})
+	}))
+	defer srv.Close()
+
+	id, err := sopsencrypt.LookupTokenIdentity(newTestClient(t, srv))
+	if err != nil {
+		t.Fatalf("LookupTokenIdentity: %v", err)
+	}
+	if want := (sopsencrypt.TokenIdentity{EntityID: "entity-1", Accessor: "accessor-1"}); id != want {
+		t.Errorf("got %+v, want %+v", id, want)
+	}
+}
+
+// ── Benchmarks ─────────────────────────────────────────────────────────────
+
+// largeDocument returns a document of roughly size bytes: many small
+// entries plus one large base64 blob, as when a binary file is embedded.
+func largeDocument(size int, inputYAML bool) string {
+	var b strings.Builder
+	blob := base64.StdEncoding.EncodeToString(make([]byte, size/2*3/4))
+	if inputYAML {
+		for i := 0; b.Len() < size/2; i++ {
+			fmt.Fprintf(&b, "key%d:\n  user: user%d\n  password: secret%d\n", i, i, i)
+		}
+		fmt.Fprintf(&b, "blob: %s\n", blob)
+		return b.String()
+	}
+	b.WriteString("{")
+	for i := 0; b.Len() < size/2; i++ {
+		fmt.Fprintf(&b, `"key%d":{"user":"user%d","password":"secret%d"},`, i, i, i)
+	}
+	fmt.Fprintf(&b, `"blob":%q}`, blob)
+	return b.String()
+}
+
+func benchmarkEncryptLarge(b *testing.B, inputYAML bool) {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		b.Fatalf("GenerateX25519Identity: %v", err)
+	}
+	content := largeDocument(4<<20, inputYAML)
+	opts := sopsencrypt.EncryptOpts{InputYAML: inputYAML, AgeRecipients: []string{identity.Recipient().String()}}
+	b.SetBytes(int64(len(content)))
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, _, err := sopsencrypt.EncryptToJSONAndYAML(nil, "", "", content, opts); err != nil {
+			b.Fatalf("EncryptToJSONAndYAML: %v", err)
+		}
+	}
+}
+
+func BenchmarkEncryptToJSONAndYAML_LargeJSON(b *testing.B) { benchmarkEncryptLarge(b, false) }
+
+func BenchmarkEncryptToJSONAndYAML_LargeYAML(b *testing.B) { benchmarkEncryptLarge(b, true) }
@@ -0,0 +1,138 @@
+package sopsencrypt
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/getsops/sops/v3"
+)
+
+// Env flattens a plaintext document of the given input type into
+// environment variables, like `sops exec-env` but with nested values
+// allowed: the keys on the path to each leaf are converted to
+// UPPER_SNAKE_CASE and joined with "_", list elements contributing their
+// index. {"db": {"host": "x"}, "apiKeys": ["a"]} becomes DB_HOST=x and
+// API_KEYS_0=a. Booleans and numbers are formatted as in JSON and null as
+// the empty string. Two leaves mapping to the same variable are an error.
+func Env(plaintext []byte, inputType string) (map[string]string, error) {
+	store, err := storeFor(inputType)
+	if err != nil {
+		return nil, err
+	}
+	branches, err := store.LoadPlainFile(plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s document: %w", inputType, err)
+	}
+	env := map[string]string{}
+	origin := map[string]string{}
+	for _, branch := range branches {
+		if err := flattenEnv(env, origin, branch, "", ""); err != nil {
+			return nil, err
+		}
+	}
+	return env, nil
+}
+
+// flattenEnv adds the leaves of value to env. name is the variable prefix
+// so far and at the document path, which origin records per variable to
+// report collisions.
+func flattenEnv(env, origin map[string]string, value interface{}, name, at string) error {
+	switch v := value.(type) {
+	case sops.TreeBranch:
+		for _, item := range v {
+			key, ok := item.Key.(string)
+			if !ok {
+				continue
+			}
+			next := key
+			if at != "" {
+				next = at + "." + key
+			}
+			if err := flattenEnv(env, origin, item.Value, joinEnvName(name, envName(key)), next); err != nil {
+				return err
+			}
+		}
+		return nil
+	case []interface{}:
+		i := 0
+		for _, elem := range v {
+			if _, isComment := elem.(sops.Comment); isComment {
+				continue
+			}
+			index := strconv.Itoa(i)
+			if err := flattenEnv(env, origin, elem, joinEnvName(name, index), at+"["+index+"]"); err != nil {
+				return err
+			}
+			i++
+		}
+		return nil
+	case sops.Comment:
+		return nil
+	}
+
+	if name == "" {
+		return fmt.Errorf("cannot convert a top-level %T to environment variables", value)
+	}
+	if prev, ok := origin[name]; ok {
+		return fmt.Errorf("%q and %q both map to the environment variable %s", prev, at, name)
+	}
+	origin[name] = at
+	env[name] = envValue(value)
+	return nil
+}
+
+// envName converts a document key to UPPER_SNAKE_CASE: an underscore is
+// inserted where a lower-case letter or digit is followed by an upper-case
+// letter, and every character other than an ASCII letter or digit becomes an
+// underscore.
+func envName(key string) string {
+	var b strings.Builder
+	var prev rune
+	for _, r := range key {
+		switch {
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			if unicode.IsUpper(r) && (unicode.IsLower(prev) || unicode.IsDigit(prev)) {
+				b.WriteByte('_')
+			}
+			b.WriteRune(unicode.ToUpper(r))
+		default:
+			b.WriteByte('_')
+		}
+		prev = r
+	}
+	return b.String()
+}
+
+// joinEnvName appends part to the variable name prefix. A variable may not
+// start with a digit, so a leading digit is prefixed with an underscore.
+func joinEnvName(prefix, part string) string {
+	if prefix != "" {
+		return prefix + "_" + part
+	}
+	if part != "" && part[0] >= '0' && part[0] <= '9' {
+		return "_" + part
+	}
+	return part
+}
+
+// envValue formats a leaf of a plaintext sops tree.
+func envValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case int:
+		return strconv.Itoa(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case time.Time:
+		return v.Format(time.RFC3339)
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,76 @@
+package sopsencrypt_test
+
+import (
+	"maps"
+	"strings"
+	"testing"
+
+	"terraform-provider-sops/internal/sopsencrypt"
+)
+
+func TestEnv(t *testing.T) {
+	cases := []struct {
+		name      string
+		document  string
+		inputType string
+		want      map[string]string
+	}{
+		{
+			name:      "nested json",
+			document:  `{"db":{"host":"db.internal","port":5432,"tls":true},"apiKeys":["a","b"],"empty":null,"ratio":0.5}`,
+			inputType: "json",
+			want: map[string]string{
+				"DB_HOST": "db.internal", "DB_PORT": "5432", "DB_TLS": "true",
+				"API_KEYS_0": "a", "API_KEYS_1": "b", "EMPTY": "", "RATIO": "0.5",
+			},
+		},
+		{
+			name:      "yaml with comments",
+			document:  "# header\nservice-name: web # inline\nlogLevel: debug\n",
+			inputType: "yaml",
+			want:      map[string]string{"SERVICE_NAME": "web", "LOG_LEVEL": "debug"},
+		},
+		{
+			name:      "dotenv",
+			document:  "USER=admin\nPASSWORD=secret\n",
+			inputType: "dotenv",
+			want:      map[string]string{"USER": "admin", "PASSWORD": "secret"},
+		},
+		{
+			name:      "ini",
+			document:  "[db]\nuser = admin\n",
+			inputType: "ini",
+			want:      map[string]string{"DB_USER": "admin"},
+		},
+		{
+			name:      "binary",
+			document:  "opaque",
+			inputType: "binary",
+			want:      map[string]string{"DATA": "opaque"},
+		},
+		{
+			name:      "leading digit",
+			document:  `{"1password":"x"}`,
+			inputType: "json",
+			want:      map[string]string{"_1PASSWORD": "x"},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := sopsencrypt.Env([]byte(tc.document), tc.inputType)
+			if err != nil {
+				t.Fatalf("Env: %v", err)
+			}
+			if !maps.Equal(got, tc.want) {
+				t.Errorf("Env = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestEnv_Collision(t *testing.T) {
+	_, err := sopsencrypt.Env([]byte(`{"db":{"host":"a"},"db_host":"b"}`), "json")
+	if err == nil || !strings.Contains(err.Error(), `"db.host" and "db_host" both map to the environment variable DB_HOST`) {
+		t.Errorf("Env error = %v, want a collision on DB_HOST", err)
+	}
+}
@@ -0,0 +1,27 @@
+package sopsencrypt
+
+import (
+	"fmt"
+
+	sopsversion "github.com/getsops/sops/v3/version"
+	"golang.org/x/mod/semver"
+)
+
+// MinFormatVersion is the oldest sops release that understands every
+// metadata field this package writes: hc_vault and age key sources and all
+// four scope options.
+const MinFormatVersion = "3.7.0"
+
+// CheckFormatVersion reports whether version, a sops release such as 3.7.3,
+// can be recorded as the version of encrypted documents: it must be no older
+// than MinFormatVersion and no newer than the sops library in use.
+func CheckFormatVersion(version string) error {
+	v := "v" + version
+	if !semver.IsValid(v) || semver.Canonical(v) != v || semver.Prerelease(v) != "" {
+		return fmt.Errorf("invalid sops version %q: expected a release such as %s", version, MinFormatVersion)
+	}
+	if semver.Compare(v, "v"+MinFormatVersion) < 0 || semver.Compare(v, "v"+sopsversion.Version) > 0 {
+		return fmt.Errorf("unsupported sops version %s: expected %s to %s", version, MinFormatVersion, sopsversion.Version)
+	}
+	return nil
+}
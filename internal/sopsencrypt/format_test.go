@@ -0,0 +1,59 @@
+package sopsencrypt_test
+
+import (
+	"testing"
+
+	sopsversion "github.com/getsops/sops/v3/version"
+
+	"terraform-provider-sops/internal/sopsencrypt"
+)
+
+func TestCheckFormatVersion(t *testing.T) {
+	for _, tc := range []struct {
+		version string
+		ok      bool
+	}{
+		{"3.7.0", true},
+		{"3.7.3", true},
+		{"3.8.1", true},
+		{sopsversion.Version, true},
+		{"3.6.1", false},
+		{"99.0.0", false},
+		{"3.7", false},
+		{"v3.7.3", false},
+		{"3.7.3-rc.1", false},
+		{"latest", false},
+	} {
+		err := sopsencrypt.CheckFormatVersion(tc.version)
+		if (err == nil) != tc.ok {
+			t.Errorf("CheckFormatVersion(%q) = %v, want ok=%v", tc.version, err, tc.ok)
+		}
+	}
+}
+
+func TestEncryptToJSON_FormatVersion(t *testing.T) {
+	srv := mockVaultServer(t)
+	defer srv.Close()
+	client := newTestClient(t, srv)
+
+	out, err := sopsencrypt.EncryptToJSON(client, "transit", "k", `{"key":"value"}`,
+		sopsencrypt.EncryptOpts{FormatVersion: "3.7.3"})
+	if err != nil {
+		t.Fatalf("EncryptToJSON: %v", err)
+	}
+	md, err := sopsencrypt.ParseMetadata(out)
+	if err != nil {
+		t.Fatalf("ParseMetadata: %v", err)
+	}
+	if md.Version != "3.7.3" {
+		t.Errorf("version = %q, want 3.7.3", md.Version)
+	}
+	if _, err := sopsencrypt.Decrypt(client, out, "json"); err != nil {
+		t.Errorf("Decrypt: %v", err)
+	}
+
+	if _, err := sopsencrypt.EncryptToJSON(client, "transit", "k", `{"key":"value"}`,
+		sopsencrypt.EncryptOpts{FormatVersion: "3.6.0"}); err == nil {
+		t.Error("expected an error for a sops version older than 3.7.0")
+	}
+}
@@ -0,0 +1,78 @@
+package sopsencrypt
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// FetchGit reads the file at path in the commit that ref names in the git
+// repository at repoURL. ref is a branch, tag or commit SHA and defaults to
+// the remote HEAD; fetching a bare SHA needs a server that allows it, as
+// GitHub and GitLab do. Only that commit is fetched, without history.
+//
+// The git CLI does the work, so repository credentials come from the usual
+// places: SSH agent and keys, credential helpers and the git configuration.
+// Interactive prompts are disabled. Credentials embedded in repoURL are
+// removed from error messages.
+func FetchGit(ctx context.Context, repoURL, ref, path string) ([]byte, error) {
+	if repoURL == "" || path == "" {
+		return nil, errors.New("git source requires a repository URL and a path")
+	}
+	if ref == "" {
+		ref = "HEAD"
+	}
+	dir, err := os.MkdirTemp("", "terraform-provider-sops-git-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	if _, err := runGit(ctx, dir, repoURL, "init", "--quiet"); err != nil {
+		return nil, err
+	}
+	if _, err := runGit(ctx, dir, repoURL, "fetch", "--quiet", "--depth=1", "--no-tags", "--", repoURL, ref); err != nil {
+		return nil, fmt.Errorf("fetching %s from %s: %w", ref, redactURL(repoURL), err)
+	}
+	out, err := runGit(ctx, dir, repoURL, "show", "FETCH_HEAD:"+strings.TrimPrefix(path, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("reading %s at %s: %w", path, ref, err)
+	}
+	return out, nil
+}
+
+// runGit runs git with args in dir and returns its output. A failure is
+// reported with git's trimmed stderr, with repoURL redacted.
+func runGit(ctx context.Context, dir, repoURL string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "GIT_ASKPASS=")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return nil, errors.New(strings.ReplaceAll(msg, repoURL, redactURL(repoURL)))
+	}
+	return stdout.Bytes(), nil
+}
+
+// redactURL returns repoURL with the credentials in its user info masked.
+// Over HTTP a lone user name is usually a token, so it is masked as well.
+func redactURL(repoURL string) string {
+	u, err := url.Parse(repoURL)
+	if err != nil || u.User == nil {
+		return repoURL
+	}
+	if _, hasPassword := u.User.Password(); !hasPassword && (u.Scheme == "http" || u.Scheme == "https") {
+		u.User = url.User("xxxxx")
+	}
+	return u.Redacted()
+}
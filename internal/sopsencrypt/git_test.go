@@ -0,0 +1,91 @@
+package sopsencrypt_test
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"terraform-provider-sops/internal/sopsencrypt"
+)
+
+// gitRepo creates a repository with two commits of secrets/app.enc.json, the
+// first tagged v1, and returns its file:// URL.
+func gitRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+		}
+	}
+	write := func(content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Join(dir, "secrets"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "secrets", "app.enc.json"), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	git("init", "--quiet", "--initial-branch=main")
+	write("v1\n")
+	git("add", ".")
+	git("commit", "--quiet", "-m", "v1")
+	git("tag", "v1")
+	write("v2\n")
+	git("commit", "--quiet", "-am", "v2")
+	return "file://" + filepath.ToSlash(dir)
+}
+
+func TestFetchGit(t *testing.T) {
+	repo := gitRepo(t)
+
+	for ref, want := range map[string]string{"": "v2\n", "main": "v2\n", "v1": "v1\n"} {
+		got, err := sopsencrypt.FetchGit(context.Background(), repo, ref, "secrets/app.enc.json")
+		if err != nil {
+			t.Fatalf("FetchGit(ref %q): %v", ref, err)
+		}
+		if string(got) != want {
+			t.Errorf("FetchGit(ref %q) = %q, want %q", ref, got, want)
+		}
+	}
+}
+
+func TestFetchGit_Errors(t *testing.T) {
+	repo := gitRepo(t)
+
+	if _, err := sopsencrypt.FetchGit(context.Background(), repo, "main", "missing.json"); err == nil ||
+		!strings.Contains(err.Error(), "reading missing.json at main") {
+		t.Errorf("missing path: error = %v", err)
+	}
+	if _, err := sopsencrypt.FetchGit(context.Background(), repo, "nope", "secrets/app.enc.json"); err == nil ||
+		!strings.Contains(err.Error(), "fetching nope") {
+		t.Errorf("missing ref: error = %v", err)
+	}
+}
+
+func TestFetchGit_RedactsCredentials(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	_, err := sopsencrypt.FetchGit(context.Background(), "https://s3cr3t-token@127.0.0.1:1/repo.git", "main", "a.json")
+	if err == nil {
+		t.Fatal("FetchGit succeeded against a closed port")
+	}
+	if strings.Contains(err.Error(), "s3cr3t-token") {
+		t.Errorf("error leaks the token: %v", err)
+	}
+}
@@ -0,0 +1,104 @@
+package sopsencrypt
+
+import (
+	"slices"
+	"time"
+
+	"github.com/getsops/sops/v3/keys"
+	vaultapi "github.com/hashicorp/vault/api"
+)
+
+// A MasterKeySource wraps the data keys of the documents of one encryption
+// with one kind of master key. WrapDataKeys returns, for each of dataKeys,
+// the master keys that unwrap it, recording created as their creation time.
+// All master keys of a document form its single key group, so any one of
+// them decrypts it.
+type MasterKeySource interface {
+	WrapDataKeys(dataKeys [][]byte, created []time.Time) ([][]keys.MasterKey, error)
+}
+
+// keySources returns the key sources of an encryption with opts: Vault
+// Transit through client unless it is nil, the age recipients, then
+// opts.MasterKeySources. In mock mode only the placeholder Transit key is
+// used.
+func keySources(client *vaultapi.Client, transitPath, keyName string, opts EncryptOpts) []MasterKeySource {
+	if opts.Mock {
+		return []MasterKeySource{mockKeySource{client: client, transitPath: transitPath, keyName: keyName}}
+	}
+	var sources []MasterKeySource
+	if client != nil {
+		source := transitKeySource{client: client, transitPath: transitPath, keyName: keyName, createKeyType: opts.CreateKeyType, audit: opts.Audit}
+		if opts.DataKey != nil {
+			source.wrapped, source.requestID = opts.DataKey.Wrapped, opts.DataKey.RequestID
+		}
+		sources = append(sources, source)
+	}
+	if len(opts.AgeRecipients) > 0 {
+		sources = append(sources, ageKeySource(opts.AgeRecipients))
+	}
+	return append(sources, opts.MasterKeySources...)
+}
+
+// transitKeySource wraps data keys with the Vault Transit key keyName, all
+// with a single batch request. If wrapped is set, it is recorded as the
+// wrapped form of every data key instead, having been wrapped by the request
+// requestID. The ID of the request is reported to audit, if set.
+type transitKeySource struct {
+	client                 *vaultapi.Client
+	transitPath, keyName   string
+	createKeyType, wrapped string
+	requestID              string
+	audit                  *EncryptAudit
+}
+
+func (s transitKeySource) WrapDataKeys(dataKeys [][]byte, created []time.Time) ([][]keys.MasterKey, error) {
+	if s.createKeyType != "" {
+		if err := EnsureTransitKey(s.client, s.transitPath, s.keyName, s.createKeyType); err != nil {
+			return nil, err
+		}
+	}
+	encryptedKeys, requestID := slices.Repeat([]string{s.wrapped}, len(dataKeys)), s.requestID
+	if s.wrapped == "" {
+		var err error
+		if encryptedKeys, requestID, err = wrapDataKeys(s.client, s.transitPath, s.keyName, dataKeys); err != nil {
+			return nil, err
+		}
+	}
+	if s.audit != nil {
+		s.audit.VaultRequestID = requestID
+	}
+	out := make([][]keys.MasterKey, len(dataKeys))
+	for i, encryptedKey := range encryptedKeys {
+		out[i] = []keys.MasterKey{vaultMasterKey(s.client, s.transitPath, s.keyName, encryptedKey, created[i])}
+	}
+	return out, nil
+}
+
+// ageKeySource wraps data keys locally for each of its age recipients.
+type ageKeySource []string
+
+func (s ageKeySource) WrapDataKeys(dataKeys [][]byte, _ []time.Time) ([][]keys.MasterKey, error) {
+	out := make([][]keys.MasterKey, len(dataKeys))
+	for i, dataKey := range dataKeys {
+		var err error
+		if out[i], err = ageMasterKeys(s, dataKey); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// mockKeySource records mockEncryptedKey as the Transit wrapped form of
+// every data key, without contacting Vault.
+type mockKeySource struct {
+	client               *vaultapi.Client
+	transitPath, keyName string
+}
+
+func (s mockKeySource) WrapDataKeys(dataKeys [][]byte, created []time.Time) ([][]keys.MasterKey, error) {
+	out := make([][]keys.MasterKey, len(dataKeys))
+	for i := range dataKeys {
+		out[i] = []keys.MasterKey{vaultMasterKey(s.client, s.transitPath, s.keyName, mockEncryptedKey, created[i])}
+	}
+	return out, nil
+}
@@ -0,0 +1,95 @@
+package sopsencrypt_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"filippo.io/age"
+	sopsage "github.com/getsops/sops/v3/age"
+	"github.com/getsops/sops/v3/keys"
+
+	"terraform-provider-sops/internal/sopsencrypt"
+)
+
+// recordingKeySource wraps data keys for an age recipient and records the
+// batch sizes it was called with.
+type recordingKeySource struct {
+	recipient string
+	batches   *[]int
+}
+
+func (s recordingKeySource) WrapDataKeys(dataKeys [][]byte, _ []time.Time) ([][]keys.MasterKey, error) {
+	*s.batches = append(*s.batches, len(dataKeys))
+	out := make([][]keys.MasterKey, len(dataKeys))
+	for i, dataKey := range dataKeys {
+		key, err := sopsage.MasterKeyFromRecipient(s.recipient)
+		if err != nil {
+			return nil, err
+		}
+		if err := key.Encrypt(dataKey); err != nil {
+			return nil, err
+		}
+		out[i] = []keys.MasterKey{key}
+	}
+	return out, nil
+}
+
+func TestEncryptBatch_MasterKeySources(t *testing.T) {
+	srv := mockVaultServer(t)
+	defer srv.Close()
+	client := newTestClient(t, srv)
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatalf("GenerateX25519Identity: %v", err)
+	}
+
+	var batches []int
+	source := recordingKeySource{recipient: identity.Recipient().String(), batches: &batches}
+	docs, err := sopsencrypt.EncryptBatch(client, "transit", "test-key",
+		map[string]string{"a": `{"k":"1"}`, "b": `{"k":"2"}`, "c": `{"k":"3"}`},
+		sopsencrypt.EncryptOpts{MasterKeySources: []sopsencrypt.MasterKeySource{source}})
+	if err != nil {
+		t.Fatalf("EncryptBatch: %v", err)
+	}
+	if len(batches) != 1 || batches[0] != 3 {
+		t.Errorf("key source batches = %v, want a single batch of 3", batches)
+	}
+	for name, doc := range docs {
+		var meta struct {
+			SOPS struct {
+				HCVault []interface{} `json:"hc_vault"`
+				Age     []interface{} `json:"age"`
+			} `json:"sops"`
+		}
+		if err := json.Unmarshal([]byte(doc), &meta); err != nil {
+			t.Fatalf("%s: output is not JSON: %v", name, err)
+		}
+		if len(meta.SOPS.HCVault) != 1 || len(meta.SOPS.Age) != 1 {
+			t.Errorf("%s: %d hc_vault and %d age keys, want one of each", name, len(meta.SOPS.HCVault), len(meta.SOPS.Age))
+		}
+		// Vault Transit still opens the shared key group.
+		if _, err := sopsencrypt.DecryptFromJSON(client, doc); err != nil {
+			t.Errorf("%s: DecryptFromJSON: %v", name, err)
+		}
+	}
+}
+
+func TestEncrypt_OutputFormats(t *testing.T) {
+	srv := mockVaultServer(t)
+	defer srv.Close()
+	client := newTestClient(t, srv)
+
+	for _, format := range sopsencrypt.OutputFormats {
+		doc, err := sopsencrypt.Encrypt(client, "transit", "test-key", `{"k":"v"}`, format, sopsencrypt.EncryptOpts{})
+		if err != nil {
+			t.Fatalf("Encrypt(%s): %v", format, err)
+		}
+		if _, err := sopsencrypt.Decrypt(client, doc, format); err != nil {
+			t.Errorf("Decrypt(%s): %v", format, err)
+		}
+	}
+	if _, err := sopsencrypt.Encrypt(client, "transit", "test-key", `{"k":"v"}`, "toml", sopsencrypt.EncryptOpts{}); err == nil {
+		t.Error("Encrypt accepted an unsupported output format")
+	}
+}
@@ -0,0 +1,60 @@
+package sopsencrypt
+
+import (
+	"bytes"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ksopsGenerator is the Go representation of a KSOPS generator manifest, the
+// kustomize exec plugin that decrypts SOPS files for Argo CD.
+type ksopsGenerator struct {
+	APIVersion string        `yaml:"apiVersion"`
+	Kind       string        `yaml:"kind"`
+	Metadata   ksopsMetadata `yaml:"metadata"`
+	Files      []string      `yaml:"files"`
+}
+
+type ksopsMetadata struct {
+	Name        string            `yaml:"name"`
+	Annotations map[string]string `yaml:"annotations"`
+}
+
+// ksopsFunctionAnnotation tells kustomize to run KSOPS as an exec function.
+const ksopsFunctionAnnotation = "exec:\n  path: ksops\n"
+
+// GenerateKSOPSGenerator returns a KSOPS generator manifest named name that
+// decrypts the given SOPS-encrypted files. Paths are relative to the
+// kustomization that lists the generator under generators.
+func GenerateKSOPSGenerator(name string, files []string) (string, error) {
+	if name == "" {
+		return "", fmt.Errorf("generator name must not be empty")
+	}
+	if len(files) == 0 {
+		return "", fmt.Errorf("at least one file is required")
+	}
+
+	gen := ksopsGenerator{
+		APIVersion: "viaduct.ai/v1",
+		Kind:       "ksops",
+		Metadata: ksopsMetadata{
+			Name: name,
+			Annotations: map[string]string{
+				"config.kubernetes.io/function": ksopsFunctionAnnotation,
+			},
+		},
+		Files: files,
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(gen); err != nil {
+		return "", fmt.Errorf("marshaling ksops generator: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return "", fmt.Errorf("closing yaml encoder: %w", err)
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,34 @@
+package sopsencrypt_test
+
+import (
+	"testing"
+
+	"terraform-provider-sops/internal/sopsencrypt"
+)
+
+func TestGenerateKSOPSGenerator(t *testing.T) {
+	got, err := sopsencrypt.GenerateKSOPSGenerator("db-ksops", []string{"./db.enc.yaml"})
+	if err != nil {
+		t.Fatalf("GenerateKSOPSGenerator: %v", err)
+	}
+	want := `apiVersion: viaduct.ai/v1
+kind: ksops
+metadata:
+  name: db-ksops
+  annotations:
+    config.kubernetes.io/function: |
+      exec:
+        path: ksops
+files:
+  - ./db.enc.yaml
+`
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestGenerateKSOPSGenerator_RequiresFiles(t *testing.T) {
+	if _, err := sopsencrypt.GenerateKSOPSGenerator("db-ksops", nil); err == nil {
+		t.Error("expected an error without files")
+	}
+}
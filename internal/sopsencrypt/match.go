@@ -0,0 +1,143 @@
+package sopsencrypt
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// A CreationRuleMatch is the creation rule of a .sops.yaml that sops applies
+// to a file.
+type CreationRuleMatch struct {
+	// Index is the position of the rule in creation_rules.
+	Index int
+	// PathRegex is the path_regex of the rule, empty for a catch-all rule.
+	PathRegex string
+	// Keys are the keys of the rule as written: age recipients, PGP
+	// fingerprints, KMS ARNs, GCP KMS resource IDs, HuaweiCloud KMS key IDs,
+	// Azure Key Vault key URLs and Vault Transit URIs, in the order sops adds
+	// them, key group after key group.
+	Keys []string
+}
+
+// MatchCreationRule returns the creation rule of the .sops.yaml content that
+// sops applies to filePath, given relative to the directory of the config
+// file as sops compares it: the first rule that has no path_regex or whose
+// path_regex matches. ok is false when no rule matches, in which case sops
+// refuses to encrypt the file.
+func MatchCreationRule(content, filePath string) (match CreationRuleMatch, ok bool, err error) {
+	var cfg struct {
+		CreationRules []matchRule `yaml:"creation_rules"`
+	}
+	if err := yaml.Unmarshal([]byte(content), &cfg); err != nil {
+		return CreationRuleMatch{}, false, fmt.Errorf("parsing sops config: %w", err)
+	}
+	filePath = filepath.Clean(filePath)
+	for i, rule := range cfg.CreationRules {
+		if rule.PathRegex != "" {
+			re, err := regexp.Compile(rule.PathRegex)
+			if err != nil {
+				return CreationRuleMatch{}, false, fmt.Errorf("creation rule %d: invalid path_regex: %w", i, err)
+			}
+			if !re.MatchString(filePath) {
+				continue
+			}
+		}
+		return CreationRuleMatch{Index: i, PathRegex: rule.PathRegex, Keys: rule.keys()}, true, nil
+	}
+	return CreationRuleMatch{}, false, nil
+}
+
+// matchRule reads the path_regex and keys of a creation rule. Unlike
+// sopsCreationRule, which is written, it accepts every form sops reads.
+type matchRule struct {
+	PathRegex     string          `yaml:"path_regex"`
+	KMS           keyList         `yaml:"kms"`
+	GCPKMS        keyList         `yaml:"gcp_kms"`
+	HCKMS         []string        `yaml:"hckms"`
+	AzureKeyVault keyList         `yaml:"azure_keyvault"`
+	VaultURI      keyList         `yaml:"hc_vault_transit_uri"`
+	Age           keyList         `yaml:"age"`
+	PGP           keyList         `yaml:"pgp"`
+	KeyGroups     []matchKeyGroup `yaml:"key_groups"`
+}
+
+func (r matchRule) keys() []string {
+	if len(r.KeyGroups) > 0 {
+		var keys []string
+		for _, g := range r.KeyGroups {
+			keys = append(keys, g.keys()...)
+		}
+		return keys
+	}
+	var keys []string
+	for _, l := range [][]string{r.Age, r.PGP, r.KMS, r.GCPKMS, r.HCKMS, r.AzureKeyVault, r.VaultURI} {
+		keys = append(keys, l...)
+	}
+	return keys
+}
+
+// matchKeyGroup reads the keys of one entry of key_groups, including the
+// groups it merges.
+type matchKeyGroup struct {
+	Merge []matchKeyGroup `yaml:"merge"`
+	KMS   []struct {
+		ARN string `yaml:"arn"`
+	} `yaml:"kms"`
+	GCPKMS []struct {
+		ResourceID string `yaml:"resource_id"`
+	} `yaml:"gcp_kms"`
+	HCKMS []struct {
+		KeyID string `yaml:"key_id"`
+	} `yaml:"hckms"`
+	AzureKV []sopsAzureKVKey `yaml:"azure_keyvault"`
+	Vault   []string         `yaml:"hc_vault"`
+	Age     []string         `yaml:"age"`
+	PGP     []string         `yaml:"pgp"`
+}
+
+func (g matchKeyGroup) keys() []string {
+	var keys []string
+	for _, m := range g.Merge {
+		keys = append(keys, m.keys()...)
+	}
+	keys = append(keys, g.Age...)
+	keys = append(keys, g.PGP...)
+	for _, k := range g.KMS {
+		keys = append(keys, k.ARN)
+	}
+	for _, k := range g.GCPKMS {
+		keys = append(keys, k.ResourceID)
+	}
+	for _, k := range g.HCKMS {
+		keys = append(keys, k.KeyID)
+	}
+	for _, k := range g.AzureKV {
+		keys = append(keys, AzureKVKey{VaultURL: k.VaultURL, Key: k.Key, Version: k.Version}.url())
+	}
+	return append(keys, g.Vault...)
+}
+
+// keyList is a key field of a creation rule, which sops accepts as a
+// comma-separated string or as a list.
+type keyList []string
+
+func (l *keyList) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.SequenceNode {
+		return node.Decode((*[]string)(l))
+	}
+	var csv string
+	if err := node.Decode(&csv); err != nil {
+		return err
+	}
+	*l = nil
+	for _, key := range strings.Split(csv, ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			*l = append(*l, key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package sopsencrypt_test
+
+import (
+	"reflect"
+	"testing"
+
+	"terraform-provider-sops/internal/sopsencrypt"
+)
+
+func TestMatchCreationRule(t *testing.T) {
+	const config = `creation_rules:
+  - path_regex: ^prod/.*\.yaml$
+    hc_vault_transit_uri: https://vault.example.com/v1/transit/keys/prod
+  - path_regex: ^dev/
+    age: age1aaa, age1bbb
+    kms:
+      - arn:aws:kms:eu-west-1:111111111111:key/dev
+  - path_regex: ^groups/
+    key_groups:
+      - merge:
+          - hc_vault:
+              - https://vault.example.com/v1/transit/keys/shared
+        azure_keyvault:
+          - vaultUrl: https://kv.vault.azure.net/
+            key: app
+            version: v1
+        age:
+          - age1ccc
+  - hc_vault_transit_uri: https://vault.example.com/v1/transit/keys/default
+`
+	for _, tc := range []struct {
+		path string
+		want sopsencrypt.CreationRuleMatch
+	}{
+		{
+			path: "prod/db.yaml",
+			want: sopsencrypt.CreationRuleMatch{
+				PathRegex: `^prod/.*\.yaml$`,
+				Keys:      []string{"https://vault.example.com/v1/transit/keys/prod"},
+			},
+		},
+		{
+			path: "./dev/app.json",
+			want: sopsencrypt.CreationRuleMatch{
+				Index:     1,
+				PathRegex: "^dev/",
+				Keys:      []string{"age1aaa", "age1bbb", "arn:aws:kms:eu-west-1:111111111111:key/dev"},
+			},
+		},
+		{
+			path: "groups/app.yaml",
+			want: sopsencrypt.CreationRuleMatch{
+				Index:     2,
+				PathRegex: "^groups/",
+				Keys: []string{
+					"https://vault.example.com/v1/transit/keys/shared",
+					"age1ccc",
+					"https://kv.vault.azure.net/keys/app/v1",
+				},
+			},
+		},
+		{
+			path: "prod/db.json",
+			want: sopsencrypt.CreationRuleMatch{
+				Index: 3,
+				Keys:  []string{"https://vault.example.com/v1/transit/keys/default"},
+			},
+		},
+	} {
+		t.Run(tc.path, func(t *testing.T) {
+			got, ok, err := sopsencrypt.MatchCreationRule(config, tc.path)
+			if err != nil || !ok {
+				t.Fatalf("MatchCreationRule: ok %v, err %v", ok, err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+
+	if _, ok, err := sopsencrypt.MatchCreationRule("creation_rules:\n  - path_regex: ^prod/\n", "dev/app.yaml"); err != nil || ok {
+		t.Errorf("unmatched path: ok %v, err %v", ok, err)
+	}
+	if _, _, err := sopsencrypt.MatchCreationRule("creation_rules:\n  - path_regex: '('\n", "app.yaml"); err == nil {
+		t.Error("expected an error for an invalid path_regex")
+	}
+}
@@ -0,0 +1,106 @@
+package sopsencrypt
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/getsops/sops/v3"
+	sopsjson "github.com/getsops/sops/v3/stores/json"
+	sopsyaml "github.com/getsops/sops/v3/stores/yaml"
+)
+
+// Metadata is the plaintext part of a SOPS document: everything in the sops
+// block that can be read without a key.
+type Metadata struct {
+	Version           string
+	LastModified      time.Time
+	UnencryptedSuffix string
+	EncryptedSuffix   string
+	UnencryptedRegex  string
+	EncryptedRegex    string
+	KeySources        []KeySource
+}
+
+// KeySource is one master key entry that wraps the document's data key.
+type KeySource struct {
+	// Type is the SOPS metadata key for the backend, e.g. "hc_vault" or "age".
+	Type string
+	// ID identifies the key within its backend: the Transit key URI for
+	// hc_vault, the recipient for age, the ARN for kms, and so on.
+	ID string
+	// Group is the index of the key group the entry belongs to.
+	Group int
+}
+
+// ParseMetadata reads the sops block of an encrypted JSON or YAML document
+// without decrypting anything. The format is detected from the first
+// non-whitespace character: '{' means JSON, anything else YAML.
+func ParseMetadata(document string) (Metadata, error) {
+	tree, err := loadEncrypted([]byte(document))
+	if err != nil {
+		return Metadata{}, err
+	}
+	return metadataOf(tree), nil
+}
+
+// ParseMetadataAs is ParseMetadata for a document of the given input type,
+// one of InputTypes, so dotenv, INI and binary documents can be inspected too.
+func ParseMetadataAs(document, inputType string) (Metadata, error) {
+	store, err := storeFor(inputType)
+	if err != nil {
+		return Metadata{}, err
+	}
+	tree, err := store.LoadEncryptedFile([]byte(document))
+	if err != nil {
+		return Metadata{}, fmt.Errorf("parsing SOPS %s document: %w", inputType, err)
+	}
+	return metadataOf(tree), nil
+}
+
+// metadataOf collects the plaintext metadata of a loaded tree.
+func metadataOf(tree sops.Tree) Metadata {
+	md := Metadata{
+		Version:           tree.Metadata.Version,
+		LastModified:      tree.Metadata.LastModified,
+		UnencryptedSuffix: tree.Metadata.UnencryptedSuffix,
+		EncryptedSuffix:   tree.Metadata.EncryptedSuffix,
+		UnencryptedRegex:  tree.Metadata.UnencryptedRegex,
+		EncryptedRegex:    tree.Metadata.EncryptedRegex,
+	}
+	for i, group := range tree.Metadata.KeyGroups {
+		for _, key := range group {
+			md.KeySources = append(md.KeySources, KeySource{
+				Type:  key.TypeToIdentifier(),
+				ID:    key.ToString(),
+				Group: i,
+			})
+		}
+	}
+	return md
+}
+
+// loadEncrypted parses an encrypted document with the store matching its
+// format. It fails with sops.MetadataNotFound when there is no sops block.
+func loadEncrypted(document []byte) (sops.Tree, error) {
+	if bytes.HasPrefix(bytes.TrimSpace(document), []byte("{")) {
+		tree, err := (&sopsjson.Store{}).LoadEncryptedFile(document)
+		if err != nil {
+			return sops.Tree{}, fmt.Errorf("parsing SOPS JSON document: %w", err)
+		}
+		return tree, nil
+	}
+	tree, err := (&sopsyaml.Store{}).LoadEncryptedFile(document)
+	if err != nil {
+		return sops.Tree{}, fmt.Errorf("parsing SOPS YAML document: %w", err)
+	}
+	return tree, nil
+}
+
+// IsEncrypted reports whether document is a SOPS JSON or YAML document: it
+// parses, carries a sops metadata block, and names at least one key source
+// that wraps its data key.
+func IsEncrypted(document string) bool {
+	md, err := ParseMetadata(document)
+	return err == nil && len(md.KeySources) > 0
+}
@@ -0,0 +1,115 @@
+package sopsencrypt_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/getsops/sops/v3"
+	"github.com/getsops/sops/v3/stores/dotenv"
+
+	"terraform-provider-sops/internal/sopsencrypt"
+)
+
+func TestParseMetadata_JSON(t *testing.T) {
+	srv := mockVaultServer(t)
+	defer srv.Close()
+
+	doc, err := sopsencrypt.EncryptToJSON(newTestClient(t, srv), "transit", "test-key",
+		`{"password":"secret"}`, sopsencrypt.EncryptOpts{EncryptedRegex: "^password$"})
+	if err != nil {
+		t.Fatalf("EncryptToJSON: %v", err)
+	}
+
+	md, err := sopsencrypt.ParseMetadata(doc)
+	if err != nil {
+		t.Fatalf("ParseMetadata: %v", err)
+	}
+	if md.EncryptedRegex != "^password$" {
+		t.Errorf("EncryptedRegex = %q, want ^password$", md.EncryptedRegex)
+	}
+	if md.Version == "" {
+		t.Error("Version is empty")
+	}
+	if time.Since(md.LastModified) > time.Minute {
+		t.Errorf("LastModified = %v, want recent", md.LastModified)
+	}
+	if len(md.KeySources) != 1 {
+		t.Fatalf("want 1 key source, got %d", len(md.KeySources))
+	}
+	want := sopsencrypt.KeySource{Type: "hc_vault", ID: srv.URL + "/v1/transit/keys/test-key", Group: 0}
+	if md.KeySources[0] != want {
+		t.Errorf("KeySources[0] = %+v, want %+v", md.KeySources[0], want)
+	}
+}
+
+func TestParseMetadata_YAML(t *testing.T) {
+	doc, err := sopsencrypt.EncryptToYAML(nil, "transit", "k", `{"a":"b"}`,
+		sopsencrypt.EncryptOpts{Mock: true, UnencryptedSuffix: "_public"})
+	if err != nil {
+		t.Fatalf("EncryptToYAML: %v", err)
+	}
+
+	md, err := sopsencrypt.ParseMetadata(doc)
+	if err != nil {
+		t.Fatalf("ParseMetadata: %v", err)
+	}
+	if md.UnencryptedSuffix != "_public" {
+		t.Errorf("UnencryptedSuffix = %q, want _public", md.UnencryptedSuffix)
+	}
+	if len(md.KeySources) != 1 || md.KeySources[0].Type != "hc_vault" {
+		t.Errorf("KeySources = %+v, want one hc_vault entry", md.KeySources)
+	}
+}
+
+func TestParseMetadata_PlaintextDocument(t *testing.T) {
+	for _, doc := range []string{`{"password":"secret"}`, "password: secret\n"} {
+		_, err := sopsencrypt.ParseMetadata(doc)
+		if !errors.Is(err, sops.MetadataNotFound) {
+			t.Errorf("ParseMetadata(%q) error = %v, want sops.MetadataNotFound", doc, err)
+		}
+	}
+}
+
+func TestParseMetadataAs_Dotenv(t *testing.T) {
+	doc, err := sopsencrypt.EncryptToJSON(nil, "transit", "k", `{"PASSWORD":"secret"}`,
+		sopsencrypt.EncryptOpts{Mock: true, EncryptedRegex: "^PASSWORD$"})
+	if err != nil {
+		t.Fatalf("EncryptToJSON: %v", err)
+	}
+
+	md, err := sopsencrypt.ParseMetadataAs(reformat(t, doc, &dotenv.Store{}), "dotenv")
+	if err != nil {
+		t.Fatalf("ParseMetadataAs: %v", err)
+	}
+	if md.EncryptedRegex != "^PASSWORD$" {
+		t.Errorf("EncryptedRegex = %q, want ^PASSWORD$", md.EncryptedRegex)
+	}
+	if len(md.KeySources) != 1 || md.KeySources[0].Type != "hc_vault" {
+		t.Errorf("KeySources = %+v, want one hc_vault entry", md.KeySources)
+	}
+}
+
+func TestIsEncrypted(t *testing.T) {
+	encrypted, err := sopsencrypt.EncryptToJSON(nil, "transit", "k", `{"a":"b"}`, sopsencrypt.EncryptOpts{Mock: true})
+	if err != nil {
+		t.Fatalf("EncryptToJSON: %v", err)
+	}
+
+	cases := map[string]struct {
+		doc  string
+		want bool
+	}{
+		"sops json":       {encrypted, true},
+		"plain json":      {`{"password":"secret"}`, false},
+		"plain yaml":      {"password: secret\n", false},
+		"empty":           {"", false},
+		"not a document":  {"hello world", false},
+		"empty sops keys": {`{"a":"b","sops":{"version":"3.12.1","lastmodified":"2024-01-01T00:00:00Z","mac":""}}`, false},
+	}
+	for name, tc := range cases {
+		if got := sopsencrypt.IsEncrypted(tc.doc); got != tc.want {
+			t.Errorf("%s: IsEncrypted = %v, want %v", name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package sopsencrypt
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/getsops/sops/v3"
+)
+
+// mockEncryptedKey stands in for the Vault-wrapped data key in mock mode.
+const mockEncryptedKey = "vault:v0:mock"
+
+// mockSignature stands in for a Vault Transit signature in mock mode.
+const mockSignature = "vault:v0:mock-signature"
+
+// mockTimestamp is used for every timestamp in mock output so that identical
+// inputs always produce byte-identical documents.
+var mockTimestamp = time.Unix(0, 0).UTC()
+
+// mockCipher is a sops.Cipher that replaces every value with a fixed
+// placeholder carrying only the value's SOPS type. The placeholder is derived
+// from nothing but the type, so mock output never leaks plaintext and cannot
+// be decrypted.
+type mockCipher struct{}
+
+func (mockCipher) Encrypt(plaintext interface{}, _ []byte, _ string) (string, error) {
+	var typ string
+	switch v := plaintext.(type) {
+	case string:
+		if v == "" {
+			return "", nil
+		}
+		typ = "str"
+	case int:
+		typ = "int"
+	case float64:
+		typ = "float"
+	case bool:
+		typ = "bool"
+	case time.Time:
+		typ = "time"
+	case sops.Comment:
+		typ = "comment"
+	default:
+		return "", fmt.Errorf("value to encrypt has unsupported type %T", v)
+	}
+	return "ENC[MOCK,data:,iv:,tag:,type:" + typ + "]", nil
+}
+
+func (mockCipher) Decrypt(string, []byte, string) (interface{}, error) {
+	return nil, fmt.Errorf("mock ciphertext cannot be decrypted")
+}
@@ -0,0 +1,63 @@
+package sopsencrypt_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"terraform-provider-sops/internal/sopsencrypt"
+)
+
+func TestEncryptToJSON_MockIsDeterministic(t *testing.T) {
+	content := `{"password":"secret","port":5432,"enabled":true}`
+	opts := sopsencrypt.EncryptOpts{Mock: true}
+
+	r1, err := sopsencrypt.EncryptToJSON(nil, "transit", "k", content, opts)
+	if err != nil {
+		t.Fatalf("EncryptToJSON: %v", err)
+	}
+	r2, err := sopsencrypt.EncryptToJSON(nil, "transit", "k", content, opts)
+	if err != nil {
+		t.Fatalf("EncryptToJSON: %v", err)
+	}
+	if r1 != r2 {
+		t.Errorf("mock output should be identical across calls:\n%s\n---\n%s", r1, r2)
+	}
+}
+
+func TestEncryptToJSON_MockNeverContainsPlaintext(t *testing.T) {
+	result, err := sopsencrypt.EncryptToJSON(nil, "transit", "k",
+		`{"password":"hunter2"}`, sopsencrypt.EncryptOpts{Mock: true})
+	if err != nil {
+		t.Fatalf("EncryptToJSON: %v", err)
+	}
+	if strings.Contains(result, "hunter2") {
+		t.Errorf("mock output leaks plaintext:\n%s", result)
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal([]byte(result), &doc); err != nil {
+		t.Fatalf("result is not valid JSON: %v", err)
+	}
+	if pw, _ := doc["password"].(string); !strings.HasPrefix(pw, "ENC[MOCK,") {
+		t.Errorf("password = %q, want mock placeholder", pw)
+	}
+	if _, ok := doc["sops"]; !ok {
+		t.Error("mock output missing 'sops' metadata block")
+	}
+}
+
+func TestEncryptToYAML_MockHonoursScope(t *testing.T) {
+	result, err := sopsencrypt.EncryptToYAML(nil, "transit", "k",
+		`{"password":"secret","host":"db.example.com"}`,
+		sopsencrypt.EncryptOpts{Mock: true, EncryptedRegex: "^password$"})
+	if err != nil {
+		t.Fatalf("EncryptToYAML: %v", err)
+	}
+	if !strings.Contains(result, "host: db.example.com") {
+		t.Errorf("host should be left in plaintext:\n%s", result)
+	}
+	if !strings.Contains(result, "password: ENC[MOCK,") {
+		t.Errorf("password should carry the mock placeholder:\n%s", result)
+	}
+}
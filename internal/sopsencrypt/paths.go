@@ -0,0 +1,190 @@
+package sopsencrypt
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/getsops/sops/v3"
+)
+
+// pathSegment is one key of an encrypted path.
+type pathSegment struct {
+	key string
+	// any matches every key; it is written as an unescaped "*".
+	any bool
+}
+
+// parseEncryptedPath splits a path such as "database.password" or
+// "credentials.*" into its segments. A backslash escapes a following ".",
+// "*" or "\" that is part of a key name.
+func parseEncryptedPath(path string) ([]pathSegment, error) {
+	var (
+		segments []pathSegment
+		key      strings.Builder
+		escaped  bool
+		literal  bool // the key contains an escaped character
+	)
+	end := func() error {
+		if key.Len() == 0 {
+			return fmt.Errorf("invalid encrypted path %q: empty key", path)
+		}
+		segments = append(segments, pathSegment{key: key.String(), any: key.String() == "*" && !literal})
+		key.Reset()
+		literal = false
+		return nil
+	}
+	for _, r := range path {
+		switch {
+		case escaped:
+			if r != '.' && r != '*' && r != '\\' {
+				return nil, fmt.Errorf(`invalid encrypted path %q: "\" may only escape ".", "*" or "\"`, path)
+			}
+			key.WriteRune(r)
+			escaped, literal = false, true
+		case r == '\\':
+			escaped = true
+		case r == '.':
+			if err := end(); err != nil {
+				return nil, err
+			}
+		default:
+			key.WriteRune(r)
+		}
+	}
+	if escaped {
+		return nil, fmt.Errorf(`invalid encrypted path %q: trailing "\"`, path)
+	}
+	if err := end(); err != nil {
+		return nil, err
+	}
+	return segments, nil
+}
+
+// CheckEncryptedPaths reports the first of paths that is not a valid
+// EncryptOpts.EncryptedPaths entry.
+func CheckEncryptedPaths(paths []string) error {
+	for _, p := range paths {
+		if _, err := parseEncryptedPath(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// matchesPath reports whether key path matches the segments of a selector.
+func matchesPath(segments []pathSegment, keys []string) bool {
+	if len(segments) != len(keys) {
+		return false
+	}
+	for i, s := range segments {
+		if !s.any && s.key != keys[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// pathsRegex returns an encrypted_regex that makes sops encrypt exactly the
+// values under the given paths of branches.
+//
+// sops records which values are encrypted as a regex over key names at any
+// depth, and decryption fails unless the same regex selects the same values,
+// so a path can only be recorded through key names that appear nowhere
+// outside the selected subtrees. For each selected subtree, the outermost key
+// on its path that meets this condition is used. Lists are transparent, as in
+// sops: the items of a list are matched by the selectors of the list itself.
+func pathsRegex(branches sops.TreeBranches, paths []string) (string, error) {
+	selectors := make([][]pathSegment, len(paths))
+	for i, p := range paths {
+		s, err := parseEncryptedPath(p)
+		if err != nil {
+			return "", err
+		}
+		selectors[i] = s
+	}
+
+	// nodes holds the key path of every value, leaves those of the values
+	// sops encrypts.
+	var nodes, leaves [][]string
+	var walk func(v interface{}, keys []string)
+	walk = func(v interface{}, keys []string) {
+		switch v := v.(type) {
+		case sops.TreeBranch:
+			for _, item := range v {
+				key, ok := item.Key.(string)
+				if !ok {
+					continue
+				}
+				child := append(slices.Clip(keys), key)
+				nodes = append(nodes, child)
+				walk(item.Value, child)
+			}
+		case []interface{}:
+			for _, item := range v {
+				walk(item, keys)
+			}
+		case nil, sops.Comment:
+		default:
+			leaves = append(leaves, keys)
+		}
+	}
+	for _, branch := range branches {
+		walk(branch, nil)
+	}
+
+	selected := func(keys []string) bool {
+		for _, s := range selectors {
+			if len(s) <= len(keys) && matchesPath(s, keys[:len(s)]) {
+				return true
+			}
+		}
+		return false
+	}
+	// unselected returns a value outside the selected subtrees that has key
+	// in its path, if any.
+	unselected := func(key string) []string {
+		for _, leaf := range leaves {
+			if slices.Contains(leaf, key) && !selected(leaf) {
+				return leaf
+			}
+		}
+		return nil
+	}
+
+	var names []string
+	for _, node := range nodes {
+		if !slices.ContainsFunc(selectors, func(s []pathSegment) bool { return matchesPath(s, node) }) {
+			continue
+		}
+		if !slices.ContainsFunc(leaves, func(leaf []string) bool {
+			return len(leaf) >= len(node) && slices.Equal(leaf[:len(node)], node)
+		}) {
+			continue
+		}
+		i := slices.IndexFunc(node, func(key string) bool { return unselected(key) == nil })
+		if i < 0 {
+			key := node[len(node)-1]
+			return "", fmt.Errorf("encrypted paths cannot select %s: sops selects values by key name, "+
+				"and key %s also occurs at %s, which is not selected", strings.Join(node, "."), key, strings.Join(unselected(key), "."))
+		}
+		if !slices.Contains(names, node[i]) {
+			names = append(names, node[i])
+		}
+	}
+	if len(names) == 0 {
+		return "", fmt.Errorf("encrypted paths select no values in the document")
+	}
+	return keysRegex(names), nil
+}
+
+// keysRegex returns a regex that matches exactly the given key names.
+func keysRegex(keys []string) string {
+	quoted := make([]string, len(keys))
+	for i, key := range keys {
+		quoted[i] = regexp.QuoteMeta(key)
+	}
+	slices.Sort(quoted)
+	return "^(" + strings.Join(slices.Compact(quoted), "|") + ")$"
+}
@@ -0,0 +1,198 @@
+package sopsencrypt_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"terraform-provider-sops/internal/sopsencrypt"
+)
+
+func TestCheckEncryptedPaths(t *testing.T) {
+	for _, tc := range []struct {
+		path string
+		ok   bool
+	}{
+		{"database.password", true},
+		{"credentials.*", true},
+		{`app\.example\.com.token`, true},
+		{`a\*.b`, true},
+		{"", false},
+		{"database..password", false},
+		{"database.", false},
+		{`token\`, false},
+		{`to\ken`, false},
+	} {
+		err := sopsencrypt.CheckEncryptedPaths([]string{tc.path})
+		if (err == nil) != tc.ok {
+			t.Errorf("CheckEncryptedPaths(%q) = %v, want ok=%v", tc.path, err, tc.ok)
+		}
+	}
+}
+
+func TestEncryptToJSON_EncryptedPaths(t *testing.T) {
+	srv := mockVaultServer(t)
+	defer srv.Close()
+	client := newTestClient(t, srv)
+
+	const content = `{
+		"database": {"host": "db.example.com", "password": "secret"},
+		"credentials": {"user": "admin", "tokens": ["a", "b"]},
+		"cache": {"host": "cache.example.com"},
+		"servers": [{"name": "one", "key": "k1"}, {"name": "two", "key": "k2"}]
+	}`
+	result, err := sopsencrypt.EncryptToJSON(client, "transit", "test-key", content, sopsencrypt.EncryptOpts{
+		EncryptedPaths: []string{"database.password", "credentials.*", "servers.key"},
+	})
+	if err != nil {
+		t.Fatalf("EncryptToJSON: %v", err)
+	}
+
+	var doc struct {
+		Database    map[string]string
+		Credentials map[string]interface{}
+		Cache       map[string]string
+		Servers     []map[string]string
+		SOPS        struct {
+			EncryptedRegex string `json:"encrypted_regex"`
+		} `json:"sops"`
+	}
+	if err := json.Unmarshal([]byte(result), &doc); err != nil {
+		t.Fatalf("output is not JSON: %v", err)
+	}
+	if want := "^(credentials|key|password)$"; doc.SOPS.EncryptedRegex != want {
+		t.Errorf("encrypted_regex = %q, want %q", doc.SOPS.EncryptedRegex, want)
+	}
+	encrypted := map[string]string{
+		"database.password": doc.Database["password"],
+		"credentials.user":  doc.Credentials["user"].(string),
+		"servers.key":       doc.Servers[1]["key"],
+	}
+	for name, v := range encrypted {
+		if !strings.HasPrefix(v, "ENC[") {
+			t.Errorf("%s should be encrypted: %q", name, v)
+		}
+	}
+	plain := map[string]string{
+		"database.host": doc.Database["host"],
+		"cache.host":    doc.Cache["host"],
+		"servers.name":  doc.Servers[0]["name"],
+	}
+	for name, v := range plain {
+		if strings.HasPrefix(v, "ENC[") {
+			t.Errorf("%s should not be encrypted: %q", name, v)
+		}
+	}
+
+	// The recorded regex selects the same values, so sops decrypts it.
+	plaintext, err := sopsencrypt.Decrypt(client, result, "json")
+	if err != nil {
+		t.Fatalf("Decrypt: %v", err)
+	}
+	if !strings.Contains(string(plaintext), `"password": "secret"`) {
+		t.Errorf("Decrypt = %s, want the password restored", plaintext)
+	}
+}
+
+func TestEncryptToJSON_EncryptedPathsUseOutermostKey(t *testing.T) {
+	srv := mockVaultServer(t)
+	defer srv.Close()
+
+	// "password" also occurs under app, so the database subtree is
+	// recorded by its own key instead.
+	result, err := sopsencrypt.EncryptToJSON(newTestClient(t, srv), "transit", "test-key",
+		`{"database":{"password":"secret"},"app":{"password":"plain"}}`,
+		sopsencrypt.EncryptOpts{EncryptedPaths: []string{"database.password"}})
+	if err != nil {
+		t.Fatalf("EncryptToJSON: %v", err)
+	}
+	var doc struct {
+		App  map[string]string
+		SOPS struct {
+			EncryptedRegex string `json:"encrypted_regex"`
+		} `json:"sops"`
+	}
+	if err := json.Unmarshal([]byte(result), &doc); err != nil {
+		t.Fatalf("output is not JSON: %v", err)
+	}
+	if want := "^(database)$"; doc.SOPS.EncryptedRegex != want {
+		t.Errorf("encrypted_regex = %q, want %q", doc.SOPS.EncryptedRegex, want)
+	}
+	if doc.App["password"] != "plain" {
+		t.Errorf("app.password should not be encrypted: %q", doc.App["password"])
+	}
+}
+
+func TestEncryptToJSON_EncryptedPathsErrors(t *testing.T) {
+	srv := mockVaultServer(t)
+	defer srv.Close()
+	client := newTestClient(t, srv)
+
+	for _, tc := range []struct {
+		name, content string
+		opts          sopsencrypt.EncryptOpts
+		want          string
+	}{
+		{
+			name:    "key name shared with unselected value",
+			content: `{"database":{"host":"db","password":"secret"},"app":{"password":"plain"}}`,
+			opts:    sopsencrypt.EncryptOpts{EncryptedPaths: []string{"database.password"}},
+			want:    "key password also occurs at app.password",
+		},
+		{
+			name:    "nothing selected",
+			content: `{"database":{"host":"db"}}`,
+			opts:    sopsencrypt.EncryptOpts{EncryptedPaths: []string{"database.password"}},
+			want:    "select no values",
+		},
+		{
+			name:    "combined with regex",
+			content: `{"password":"secret"}`,
+			opts:    sopsencrypt.EncryptOpts{EncryptedPaths: []string{"password"}, EncryptedRegex: "^password$"},
+			want:    "cannot be combined",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := sopsencrypt.EncryptToJSON(client, "transit", "test-key", tc.content, tc.opts)
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("EncryptToJSON error = %v, want it to contain %q", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestEncryptToJSON_UnencryptedKeys(t *testing.T) {
+	srv := mockVaultServer(t)
+	defer srv.Close()
+
+	result, err := sopsencrypt.EncryptToJSON(newTestClient(t, srv), "transit", "test-key",
+		`{"host":"db.example.com","hostname":"db","port.number":5432,"password":"secret"}`,
+		sopsencrypt.EncryptOpts{UnencryptedKeys: []string{"port.number", "host"}})
+	if err != nil {
+		t.Fatalf("EncryptToJSON: %v", err)
+	}
+	var doc struct {
+		Host     string
+		Hostname string
+		Port     interface{} `json:"port.number"`
+		Password string
+		SOPS     struct {
+			UnencryptedRegex string `json:"unencrypted_regex"`
+		} `json:"sops"`
+	}
+	if err := json.Unmarshal([]byte(result), &doc); err != nil {
+		t.Fatalf("output is not JSON: %v", err)
+	}
+	if want := `^(host|port\.number)$`; doc.SOPS.UnencryptedRegex != want {
+		t.Errorf("unencrypted_regex = %q, want %q", doc.SOPS.UnencryptedRegex, want)
+	}
+	if doc.Host != "db.example.com" || doc.Port != float64(5432) {
+		t.Errorf("listed keys should stay in plaintext: host=%q port=%v", doc.Host, doc.Port)
+	}
+	// Names match exactly, not as a prefix.
+	for name, v := range map[string]string{"hostname": doc.Hostname, "password": doc.Password} {
+		if !strings.HasPrefix(v, "ENC[") {
+			t.Errorf("%s should be encrypted: %q", name, v)
+		}
+	}
+}
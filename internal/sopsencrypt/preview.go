@@ -0,0 +1,255 @@
+package sopsencrypt
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/getsops/sops/v3"
+	sopsyaml "github.com/getsops/sops/v3/stores/yaml"
+)
+
+// Placeholders of a structure preview.
+const (
+	PreviewEncrypted = "<encrypted>"
+	PreviewPlaintext = "<plaintext>"
+)
+
+// StructurePreview returns content, parsed like Encrypt parses it, with every
+// value Encrypt would encrypt under the scope options of opts replaced by
+// PreviewEncrypted and every other value by PreviewPlaintext. Keys, order and
+// nesting are kept and comments dropped, so the result shows the layout of a
+// document without any of its values. format is "json", emitted indented, or
+// "yaml". No key source is used.
+func StructurePreview(content, format string, opts EncryptOpts) (string, error) {
+	if format != "json" && format != "yaml" {
+		return "", fmt.Errorf("unsupported output format %q; expected one of %s", format, strings.Join(OutputFormats, ", "))
+	}
+	// Mock encryption applies the scope exactly as Encrypt does and marks
+	// every encrypted value with the mock prefix.
+	opts.Mock = true
+	opts.DataKey = nil
+	opts.FormatVersion = ""
+	trees, err := encryptDocuments(nil, "", "", []string{content}, nil, opts)
+	if err != nil {
+		return "", err
+	}
+	branches := trees[0].Branches
+	for i, branch := range branches {
+		branches[i] = previewValue(branch).(sops.TreeBranch)
+	}
+
+	if format == "yaml" {
+		out, err := (&sopsyaml.Store{}).EmitPlainFile(branches)
+		if err != nil {
+			return "", fmt.Errorf("emitting structure preview: %w", err)
+		}
+		return string(out), nil
+	}
+	if len(branches) != 1 {
+		return "", fmt.Errorf("a stream of %d YAML documents cannot be emitted as JSON", len(branches))
+	}
+	var compact, out bytes.Buffer
+	if err := encodeJSONValue(&compact, branches[0]); err != nil {
+		return "", fmt.Errorf("emitting structure preview: %w", err)
+	}
+	if err := json.Indent(&out, compact.Bytes(), "", "  "); err != nil {
+		return "", fmt.Errorf("emitting structure preview: %w", err)
+	}
+	out.WriteByte('\n')
+	// encoding/json escapes the angle brackets of the placeholders; the
+	// unescaped form is equivalent JSON and reads better in a plan.
+	return placeholderUnescaper.Replace(out.String()), nil
+}
+
+var placeholderUnescaper = strings.NewReplacer(
+	`"\u003cencrypted\u003e"`, `"`+PreviewEncrypted+`"`,
+	`"\u003cplaintext\u003e"`, `"`+PreviewPlaintext+`"`,
+)
+
+// previewValue returns a mock-encrypted tree value with its leaves replaced
+// by placeholders and its comments removed.
+func previewValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case sops.TreeBranch:
+		out := make(sops.TreeBranch, 0, len(v))
+		for _, item := range v {
+			if _, ok := item.Key.(sops.Comment); ok {
+				continue
+			}
+			out = append(out, sops.TreeItem{Key: item.Key, Value: previewValue(item.Value)})
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, 0, len(v))
+		for _, elem := range v {
+			if _, ok := elem.(sops.Comment); ok {
+				continue
+			}
+			out = append(out, previewValue(elem))
+		}
+		return out
+	case string:
+		if strings.HasPrefix(v, "ENC[MOCK,") {
+			return PreviewEncrypted
+		}
+	}
+	return PreviewPlaintext
+}
+
+// ValueCounts returns how many values of content Encrypt would encrypt under
+// the scope options of opts and how many values content holds in total.
+// Like StructurePreview it parses content as Encrypt does and uses no key
+// source.
+func ValueCounts(content string, opts EncryptOpts) (encrypted, total int, err error) {
+	opts.Mock = true
+	opts.DataKey = nil
+	opts.FormatVersion = ""
+	trees, err := encryptDocuments(nil, "", "", []string{content}, nil, opts)
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, branch := range trees[0].Branches {
+		countValues(branch, &encrypted, &total)
+	}
+	return encrypted, total, nil
+}
+
+// countValues adds the leaves of a mock-encrypted tree value to total and
+// those that were encrypted to encrypted. Comments are not values.
+func countValues(value interface{}, encrypted, total *int) {
+	switch v := value.(type) {
+	case sops.TreeBranch:
+		for _, item := range v {
+			if _, ok := item.Key.(sops.Comment); !ok {
+				countValues(item.Value, encrypted, total)
+			}
+		}
+	case []interface{}:
+		for _, elem := range v {
+			if _, ok := elem.(sops.Comment); !ok {
+				countValues(elem, encrypted, total)
+			}
+		}
+	default:
+		*total++
+		if s, ok := v.(string); ok && strings.HasPrefix(s, "ENC[MOCK,") {
+			*encrypted++
+		}
+	}
+}
+
+// secretKeyRegex matches key names that commonly hold secrets anywhere in
+// the name. "key" is too common a part of other words for that and is
+// matched by isSecretKey as a word of its own instead.
+var secretKeyRegex = regexp.MustCompile(`(?i)passw(or)?d|secret|token`)
+
+// isSecretKey reports whether the key name commonly holds a secret: it
+// matches secretKeyRegex or has "key" or "keys" as a word, so "apiKey" and
+// "ssh_keys" match and "keyboard", "monkey" and "turkey" do not.
+func isSecretKey(name string) bool {
+	if secretKeyRegex.MatchString(name) {
+		return true
+	}
+	for _, word := range keyWords(name) {
+		if w := strings.ToLower(word); w == "key" || w == "keys" {
+			return true
+		}
+	}
+	return false
+}
+
+// keyWords splits a key name into words at non-letters and at camel-case
+// boundaries: "api_key", "apiKey", "APIKey" and "API-KEY" all contain the
+// word "key".
+func keyWords(name string) []string {
+	var words []string
+	runes := []rune(name)
+	start := -1
+	for i, r := range runes {
+		if !unicode.IsLetter(r) {
+			if start >= 0 {
+				words = append(words, string(runes[start:i]))
+			}
+			start = -1
+			continue
+		}
+		// A word starts at an upper-case letter after a lower-case one
+		// ("apiKey"), or before a lower-case one after an acronym ("APIKey").
+		if start >= 0 && unicode.IsUpper(r) && (unicode.IsLower(runes[i-1]) ||
+			i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1])) {
+			words = append(words, string(runes[start:i]))
+			start = -1
+		}
+		if start < 0 {
+			start = i
+		}
+	}
+	if start >= 0 {
+		words = append(words, string(runes[start:]))
+	}
+	return words
+}
+
+// PlaintextSecrets returns the paths of the values of content that Encrypt
+// would leave in plaintext under the scope options of opts although their
+// key name looks like it holds a secret, such as "password", "token" or
+// "api_key". Values of list elements count under the key of their list.
+// Empty strings, booleans and nulls are not reported. Paths are written as
+// encrypted_paths takes them, without duplicates.
+func PlaintextSecrets(content string, opts EncryptOpts) ([]string, error) {
+	opts.Mock = true
+	opts.DataKey = nil
+	opts.FormatVersion = ""
+	trees, err := encryptDocuments(nil, "", "", []string{content}, nil, opts)
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, branch := range trees[0].Branches {
+		plaintextSecrets(branch, nil, &paths)
+	}
+	return paths, nil
+}
+
+// plaintextSecrets appends to paths the path of every plaintext value below
+// value, reached through keys, whose key looks like it holds a secret.
+func plaintextSecrets(value interface{}, keys []string, paths *[]string) {
+	switch v := value.(type) {
+	case sops.TreeBranch:
+		for _, item := range v {
+			if key, ok := item.Key.(string); ok {
+				plaintextSecrets(item.Value, append(keys[:len(keys):len(keys)], key), paths)
+			}
+		}
+	case []interface{}:
+		for _, elem := range v {
+			if _, ok := elem.(sops.Comment); !ok {
+				plaintextSecrets(elem, keys, paths)
+			}
+		}
+	case nil, bool:
+	default:
+		if s, ok := v.(string); ok && (s == "" || strings.HasPrefix(s, "ENC[MOCK,")) {
+			return
+		}
+		if len(keys) == 0 || !isSecretKey(keys[len(keys)-1]) {
+			return
+		}
+		escaped := make([]string, len(keys))
+		for i, key := range keys {
+			escaped[i] = pathKeyEscaper.Replace(key)
+		}
+		if path := strings.Join(escaped, "."); !slices.Contains(*paths, path) {
+			*paths = append(*paths, path)
+		}
+	}
+}
+
+// pathKeyEscaper escapes a key name for use as a segment of an encrypted
+// path.
+var pathKeyEscaper = strings.NewReplacer(`\`, `\\`, `.`, `\.`, `*`, `\*`)
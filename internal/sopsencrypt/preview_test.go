@@ -0,0 +1,171 @@
+package sopsencrypt_test
+
+import (
+	"slices"
+	"testing"
+
+	"terraform-provider-sops/internal/sopsencrypt"
+)
+
+func TestStructurePreview(t *testing.T) {
+	for _, tc := range []struct {
+		name, content, format string
+		opts                  sopsencrypt.EncryptOpts
+		want                  string
+	}{
+		{
+			name:    "json",
+			content: `{"db":{"password":"hunter2","port":5432},"hosts":["a","b"]}`,
+			format:  "json",
+			want: `{
+  "db": {
+    "password": "<encrypted>",
+    "port": "<encrypted>"
+  },
+  "hosts": [
+    "<encrypted>",
+    "<encrypted>"
+  ]
+}
+`,
+		},
+		{
+			name:    "unencrypted suffix",
+			content: `{"password":"hunter2","host_unencrypted":"db.internal"}`,
+			format:  "json",
+			opts:    sopsencrypt.EncryptOpts{UnencryptedSuffix: "_unencrypted"},
+			want: `{
+  "password": "<encrypted>",
+  "host_unencrypted": "<plaintext>"
+}
+`,
+		},
+		{
+			name:    "encrypted paths",
+			content: `{"db":{"password":"hunter2","host":"db.internal"}}`,
+			format:  "yaml",
+			opts:    sopsencrypt.EncryptOpts{EncryptedPaths: []string{"db.password"}},
+			want: `db:
+    password: <encrypted>
+    host: <plaintext>
+`,
+		},
+		{
+			name:    "yaml comments",
+			content: "# the database\npassword: hunter2 # rotate\n",
+			format:  "yaml",
+			opts:    sopsencrypt.EncryptOpts{InputYAML: true},
+			want:    "password: <encrypted>\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := sopsencrypt.StructurePreview(tc.content, tc.format, tc.opts)
+			if err != nil {
+				t.Fatalf("StructurePreview: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tc.want)
+			}
+		})
+	}
+
+	if _, err := sopsencrypt.StructurePreview("a: 1\n---\nb: 2\n", "json", sopsencrypt.EncryptOpts{InputYAML: true}); err == nil {
+		t.Error("a YAML stream should not be previewed as JSON")
+	}
+}
+
+func TestValueCounts(t *testing.T) {
+	for _, tc := range []struct {
+		name, content            string
+		opts                     sopsencrypt.EncryptOpts
+		wantEncrypted, wantTotal int
+	}{
+		{
+			name:          "default scope",
+			content:       `{"db":{"password":"hunter2","port":5432},"hosts":["a","b"]}`,
+			wantEncrypted: 4,
+			wantTotal:     4,
+		},
+		{
+			name:          "encrypted regex matching no key",
+			content:       `{"password":"hunter2","token":"abc"}`,
+			opts:          sopsencrypt.EncryptOpts{EncryptedRegex: "^secret$"},
+			wantEncrypted: 0,
+			wantTotal:     2,
+		},
+		{
+			name:          "encrypted regex",
+			content:       `{"password":"hunter2","host":"db.internal"}`,
+			opts:          sopsencrypt.EncryptOpts{EncryptedRegex: "^password$"},
+			wantEncrypted: 1,
+			wantTotal:     2,
+		},
+		{
+			name:          "yaml comments",
+			content:       "# the database\npassword: hunter2 # rotate\n",
+			opts:          sopsencrypt.EncryptOpts{InputYAML: true},
+			wantEncrypted: 1,
+			wantTotal:     1,
+		},
+		{
+			name:    "empty document",
+			content: `{}`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			encrypted, total, err := sopsencrypt.ValueCounts(tc.content, tc.opts)
+			if err != nil {
+				t.Fatalf("ValueCounts: %v", err)
+			}
+			if encrypted != tc.wantEncrypted || total != tc.wantTotal {
+				t.Errorf("got %d of %d encrypted, want %d of %d", encrypted, total, tc.wantEncrypted, tc.wantTotal)
+			}
+		})
+	}
+}
+
+func TestPlaintextSecrets(t *testing.T) {
+	for _, tc := range []struct {
+		name, content string
+		opts          sopsencrypt.EncryptOpts
+		want          []string
+	}{
+		{
+			name:    "default scope",
+			content: `{"password":"hunter2","api_key":"abc"}`,
+		},
+		{
+			name:    "encrypted regex missing secrets",
+			content: `{"db":{"password":"hunter2","host":"db.internal"},"apiKey":"abc","keyboard":"us","tokens":["a","b"]}`,
+			opts:    sopsencrypt.EncryptOpts{EncryptedRegex: "^host$"},
+			want:    []string{"db.password", "apiKey", "tokens"},
+		},
+		{
+			name:    "key as a word",
+			content: `{"API_KEY":"a","APIKey":"b","ssh-keys":["c"],"monkey":"d","turkey":"e","keyboard":"f","keynote":"g"}`,
+			opts:    sopsencrypt.EncryptOpts{EncryptedRegex: "^none$"},
+			want:    []string{"API_KEY", "APIKey", "ssh-keys"},
+		},
+		{
+			name:    "empty and boolean values",
+			content: `{"secret":"","token_enabled":true,"password":null}`,
+			opts:    sopsencrypt.EncryptOpts{EncryptedRegex: "^none$"},
+		},
+		{
+			name:    "escaped key",
+			content: `{"db.secret":"x"}`,
+			opts:    sopsencrypt.EncryptOpts{UnencryptedSuffix: "secret"},
+			want:    []string{`db\.secret`},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := sopsencrypt.PlaintextSecrets(tc.content, tc.opts)
+			if err != nil {
+				t.Fatalf("PlaintextSecrets: %v", err)
+			}
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
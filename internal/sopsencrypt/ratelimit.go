@@ -0,0 +1,114 @@
+package sopsencrypt
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	vaultapi "github.com/hashicorp/vault/api"
+	"golang.org/x/time/rate"
+)
+
+// maxRateLimitPause caps how long a rate-limit response header can hold back
+// new requests, so a misbehaving server cannot stall an apply indefinitely.
+const maxRateLimitPause = time.Minute
+
+// RateLimiter paces the Vault requests of every client created with it. It
+// starts at most RequestsPerSecond requests per second, and when Vault
+// reports that a rate-limit quota is exhausted it holds back new requests
+// until Vault allows them again. The quota is read from the Retry-After
+// header of a 429 response, or from X-Ratelimit-Remaining and
+// X-Ratelimit-Reset when the quota has enable_rate_limit_response_headers
+// set. The Vault client already waits out Retry-After before retrying the
+// request that was rejected.
+//
+// A RateLimiter is safe for concurrent use and is meant to be shared by all
+// clients of one provider instance.
+type RateLimiter struct {
+	// limiter is nil when the request rate is unlimited.
+	limiter *rate.Limiter
+
+	mu sync.Mutex
+	// until is the time before which no new request is started.
+	until time.Time
+}
+
+// NewRateLimiter returns a RateLimiter that starts at most requestsPerSecond
+// requests per second, with bursts of up to the same number of requests.
+// Zero or less leaves the rate unlimited, honouring only Vault's rate-limit
+// responses.
+func NewRateLimiter(requestsPerSecond float64) *RateLimiter {
+	l := &RateLimiter{}
+	if requestsPerSecond > 0 {
+		l.limiter = rate.NewLimiter(rate.Limit(requestsPerSecond), max(1, int(requestsPerSecond)))
+	}
+	return l
+}
+
+// configure makes clients created from cfg report their responses to l.
+func (l *RateLimiter) configure(cfg *vaultapi.Config) {
+	if l.limiter != nil {
+		cfg.Limiter = l.limiter
+	}
+	checkRetry := cfg.CheckRetry
+	if checkRetry == nil {
+		checkRetry = vaultapi.DefaultRetryPolicy
+	}
+	cfg.CheckRetry = func(ctx context.Context, resp *http.Response, err error) (bool, error) {
+		l.observe(resp, time.Now())
+		return checkRetry(ctx, resp, err)
+	}
+}
+
+// wait blocks until a rate-limit pause recorded by observe has passed, but
+// for no longer than timeout, the client timeout of the request, when it is
+// positive. The request callback that calls wait has no context, and the
+// deadline of the request has started already, so a longer pause could
+// only overrun it; the request then fails with its own timeout instead.
+func (l *RateLimiter) wait(timeout time.Duration) {
+	l.mu.Lock()
+	d := time.Until(l.until)
+	l.mu.Unlock()
+	if timeout > 0 {
+		d = min(d, timeout)
+	}
+	if d > 0 {
+		time.Sleep(d)
+	}
+}
+
+// observe records the pause that the rate-limit headers of resp ask for.
+func (l *RateLimiter) observe(resp *http.Response, now time.Time) {
+	if resp == nil {
+		return
+	}
+	pause, ok := time.Duration(0), false
+	if resp.StatusCode == http.StatusTooManyRequests {
+		pause, ok = headerSeconds(resp.Header, "Retry-After")
+	}
+	if !ok && strings.TrimSpace(resp.Header.Get("X-Ratelimit-Remaining")) == "0" {
+		pause, ok = headerSeconds(resp.Header, "X-Ratelimit-Reset")
+	}
+	if !ok || pause <= 0 {
+		return
+	}
+	until := now.Add(min(pause, maxRateLimitPause))
+	l.mu.Lock()
+	if until.After(l.until) {
+		l.until = until
+	}
+	l.mu.Unlock()
+}
+
+// headerSeconds parses header name as a whole number of seconds, the form
+// Vault uses for Retry-After and X-Ratelimit-Reset.
+func headerSeconds(h http.Header, name string) (time.Duration, bool) {
+	n, err := strconv.Atoi(strings.TrimSpace(h.Get(name)))
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	return time.Duration(n) * time.Second, true
+}
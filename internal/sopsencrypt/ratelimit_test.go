@@ -0,0 +1,129 @@
+package sopsencrypt_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	vaultapi "github.com/hashicorp/vault/api"
+	"terraform-provider-sops/internal/sopsencrypt"
+)
+
+// rateLimitedServer answers every request with an empty secret, letting
+// respond add headers or reject the n-th request (starting at 1).
+func rateLimitedServer(t *testing.T, respond func(w http.ResponseWriter, n int64) bool) *httptest.Server {
+	t.Helper()
+	var requests atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !respond(w, requests.Add(1)) {
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"data":{}}`)) //nolint:errcheck
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newLimitedClient(t *testing.T, srv *httptest.Server, limiter *sopsencrypt.RateLimiter) *vaultapi.Client {
+	t.Helper()
+	c, err := sopsencrypt.VaultConfig{Address: srv.URL, RateLimiter: limiter}.NewClient("test-token")
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	return c
+}
+
+func TestRateLimiter_RequestsPerSecond(t *testing.T) {
+	srv := rateLimitedServer(t, func(http.ResponseWriter, int64) bool { return true })
+	limiter := sopsencrypt.NewRateLimiter(4)
+
+	// Four requests pass as a burst; the next four take a quarter second each.
+	start := time.Now()
+	for i := 0; i < 8; i++ {
+		// A new client per request, as the provider creates per operation.
+		if _, err := newLimitedClient(t, srv, limiter).Logical().Read("transit/keys/k"); err != nil {
+			t.Fatalf("Read %d: %v", i, err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 900*time.Millisecond {
+		t.Errorf("8 requests at 4/s took %s, want about 1s", elapsed)
+	}
+}
+
+func TestRateLimiter_RetryAfter(t *testing.T) {
+	srv := rateLimitedServer(t, func(w http.ResponseWriter, n int64) bool {
+		if n == 1 {
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, `{"errors":["request path "transit/keys/k": rate limit quota exceeded"]}`, http.StatusTooManyRequests)
+			return false
+		}
+		return true
+	})
+
+	start := time.Now()
+	if _, err := newLimitedClient(t, srv, sopsencrypt.NewRateLimiter(0)).Logical().Read("transit/keys/k"); err != nil {
+		t.Fatalf("Read after 429: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 900*time.Millisecond {
+		t.Errorf("retry after 429 came after %s, want Retry-After of 1s honoured", elapsed)
+	}
+}
+
+func TestRateLimiter_QuotaHeadersPauseOtherClients(t *testing.T) {
+	srv := rateLimitedServer(t, func(w http.ResponseWriter, n int64) bool {
+		if n == 1 {
+			w.Header().Set("X-Ratelimit-Limit", "1")
+			w.Header().Set("X-Ratelimit-Remaining", "0")
+			w.Header().Set("X-Ratelimit-Reset", "1")
+		}
+		return true
+	})
+	limiter := sopsencrypt.NewRateLimiter(0)
+
+	if _, err := newLimitedClient(t, srv, limiter).Logical().Read("transit/keys/k"); err != nil {
+		t.Fatalf("first Read: %v", err)
+	}
+	start := time.Now()
+	if _, err := newLimitedClient(t, srv, limiter).Logical().Read("transit/keys/k"); err != nil {
+		t.Fatalf("second Read: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 900*time.Millisecond {
+		t.Errorf("request after exhausted quota started after %s, want about 1s", elapsed)
+	}
+
+	// A client without the shared limiter is not held back.
+	start = time.Now()
+	if _, err := newTestClient(t, srv).Logical().Read("transit/keys/k"); err != nil {
+		t.Fatalf("unlimited Read: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("client without limiter took %s", elapsed)
+	}
+}
+
+func TestRateLimiter_PauseEndsAtClientTimeout(t *testing.T) {
+	srv := rateLimitedServer(t, func(w http.ResponseWriter, n int64) bool {
+		if n == 1 {
+			w.Header().Set("X-Ratelimit-Remaining", "0")
+			w.Header().Set("X-Ratelimit-Reset", "30")
+		}
+		return true
+	})
+	limiter := sopsencrypt.NewRateLimiter(0)
+
+	if _, err := newLimitedClient(t, srv, limiter).Logical().Read("transit/keys/k"); err != nil {
+		t.Fatalf("first Read: %v", err)
+	}
+	client := newLimitedClient(t, srv, limiter)
+	client.SetClientTimeout(200 * time.Millisecond)
+	start := time.Now()
+	if _, err := client.Logical().Read("transit/keys/k"); err == nil {
+		t.Error("Read during a 30s pause succeeded within a 200ms client timeout")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Read during a 30s pause returned after %s, want about the 200ms client timeout", elapsed)
+	}
+}
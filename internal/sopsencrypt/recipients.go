@@ -0,0 +1,195 @@
+package sopsencrypt
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/getsops/sops/v3"
+	"github.com/getsops/sops/v3/aes"
+	"github.com/getsops/sops/v3/age"
+	"github.com/getsops/sops/v3/hcvault"
+	"github.com/getsops/sops/v3/keys"
+	vaultapi "github.com/hashicorp/vault/api"
+)
+
+// A KeyUpdate lists the master keys UpdateMasterKeys adds to and removes
+// from a document. Vault Transit keys are named by their key name under
+// TransitPath.
+type KeyUpdate struct {
+	TransitPath         string
+	AddVaultKeyNames    []string
+	AddAgeRecipients    []string
+	RemoveVaultKeyNames []string
+	RemoveAgeRecipients []string
+}
+
+// UpdateMasterKeys returns document, an encrypted SOPS JSON or YAML document
+// with a single key group, with the master keys of update added and
+// removed, as `sops updatekeys` would after a creation rule changed. The data
+// key is unwrapped with Vault Transit through client. The result keeps the
+// format of document and is emitted with the layout options of opts.
+//
+// Adding keys wraps the data key for them and leaves values, MAC and the
+// other master keys untouched; keys the document already has are not added
+// again. Removing keys also rotates the data key, since whoever held a
+// removed key may have kept the old one: the values are encrypted again with
+// a new data key, which is wrapped for every remaining and added key. That
+// fails for remaining master keys that cannot be wrapped here, such as KMS
+// or PGP keys, or Transit keys on another Vault server than client's.
+// Removed Transit keys match on engine path and key name on any server; a
+// removal that matches no key of the document fails.
+//
+// In mock mode (opts.Mock) client may be nil: mock documents have no data
+// key to unwrap or rotate, and added Transit keys record the mock
+// placeholder.
+func UpdateMasterKeys(client *vaultapi.Client, document string, update KeyUpdate, opts EncryptOpts) (string, error) {
+	store, err := outputStore(DetectInputType(document), opts)
+	if err != nil {
+		return "", err
+	}
+	tree, err := loadEncrypted([]byte(document))
+	if err != nil {
+		return "", err
+	}
+	if len(tree.Metadata.KeyGroups) != 1 {
+		return "", fmt.Errorf("the document has %d key groups; only documents with a single key group are supported", len(tree.Metadata.KeyGroups))
+	}
+	group := tree.Metadata.KeyGroups[0]
+
+	var address string
+	if client != nil {
+		address = client.Address()
+	}
+	remaining := slices.Clone(group)
+	for i, name := range update.RemoveVaultKeyNames {
+		if slices.Contains(update.RemoveVaultKeyNames[:i], name) {
+			continue
+		}
+		n := len(remaining)
+		remaining = slices.DeleteFunc(remaining, func(k keys.MasterKey) bool {
+			mk, ok := k.(*hcvault.MasterKey)
+			return ok && mk.EnginePath == update.TransitPath && mk.KeyName == name
+		})
+		if len(remaining) == n {
+			return "", fmt.Errorf("the document has no hc_vault entry for Transit key %s/keys/%s", update.TransitPath, name)
+		}
+	}
+	for i, r := range update.RemoveAgeRecipients {
+		if slices.Contains(update.RemoveAgeRecipients[:i], r) {
+			continue
+		}
+		n := len(remaining)
+		remaining = slices.DeleteFunc(remaining, func(k keys.MasterKey) bool {
+			mk, ok := k.(*age.MasterKey)
+			return ok && mk.Recipient == r
+		})
+		if len(remaining) == n {
+			return "", fmt.Errorf("the document has no age entry for recipient %q", r)
+		}
+	}
+	var addKeyNames, addRecipients []string
+	for _, name := range update.AddVaultKeyNames {
+		if !slices.Contains(addKeyNames, name) && !hasTransitKey(remaining, address, update.TransitPath, name) {
+			addKeyNames = append(addKeyNames, name)
+		}
+	}
+	for _, r := range update.AddAgeRecipients {
+		if !slices.Contains(addRecipients, r) && !hasAgeRecipient(remaining, r) {
+			addRecipients = append(addRecipients, r)
+		}
+	}
+	rotate := len(remaining) < len(group)
+	if len(remaining)+len(addKeyNames)+len(addRecipients) == 0 {
+		return "", fmt.Errorf("removing the keys would leave the document without master keys")
+	}
+	if !rotate && len(addKeyNames) == 0 && len(addRecipients) == 0 {
+		return store.EmitEncrypted(tree)
+	}
+
+	dataKey := make([]byte, 32)
+	if !opts.Mock {
+		if dataKey, err = unwrapDataKey(client, tree); err != nil {
+			return "", err
+		}
+	}
+	now := time.Now().UTC()
+	if opts.Mock {
+		now = mockTimestamp
+	}
+	if rotate && !opts.Mock {
+		if err := decryptTree(&tree, dataKey); err != nil {
+			return "", err
+		}
+		if dataKey, err = generateDataKey(); err != nil {
+			return "", err
+		}
+		if remaining, err = rewrapMasterKeys(client, remaining, dataKey, now); err != nil {
+			return "", err
+		}
+		if err := encryptTree(&tree, dataKey, aes.NewCipher(), now); err != nil {
+			return "", err
+		}
+	}
+	for _, name := range addKeyNames {
+		encryptedKey := mockEncryptedKey
+		if !opts.Mock {
+			if encryptedKey, _, err = wrapDataKey(client, update.TransitPath, name, dataKey); err != nil {
+				return "", err
+			}
+		}
+		remaining = append(remaining, vaultMasterKey(client, update.TransitPath, name, encryptedKey, now))
+	}
+	ageKeys, err := ageMasterKeys(addRecipients, dataKey)
+	if err != nil {
+		return "", err
+	}
+	tree.Metadata.KeyGroups[0] = append(remaining, ageKeys...)
+	return store.EmitEncrypted(tree)
+}
+
+// rewrapMasterKeys returns group with every master key replaced by one of
+// the same key that wraps dataKey instead, created at created.
+func rewrapMasterKeys(client *vaultapi.Client, group sops.KeyGroup, dataKey []byte, created time.Time) (sops.KeyGroup, error) {
+	out := make(sops.KeyGroup, 0, len(group))
+	for _, k := range group {
+		switch mk := k.(type) {
+		case *hcvault.MasterKey:
+			if mk.VaultAddress != client.Address() {
+				return nil, fmt.Errorf("cannot rotate the data key: Transit key %s/keys/%s is on %s, not on the provider's Vault server %s",
+					mk.EnginePath, mk.KeyName, mk.VaultAddress, client.Address())
+			}
+			encryptedKey, _, err := wrapDataKey(client, mk.EnginePath, mk.KeyName, dataKey)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, vaultMasterKey(client, mk.EnginePath, mk.KeyName, encryptedKey, created))
+		case *age.MasterKey:
+			ageKeys, err := ageMasterKeys([]string{mk.Recipient}, dataKey)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, ageKeys...)
+		default:
+			return nil, fmt.Errorf("cannot rotate the data key: %s master key %s can only be wrapped with the sops CLI", k.TypeToIdentifier(), k.ToString())
+		}
+	}
+	return out, nil
+}
+
+// hasTransitKey reports whether group has an hc_vault entry for the Transit
+// key keyName under transitPath on the Vault server at address.
+func hasTransitKey(group sops.KeyGroup, address, transitPath, keyName string) bool {
+	return slices.ContainsFunc(group, func(k keys.MasterKey) bool {
+		mk, ok := k.(*hcvault.MasterKey)
+		return ok && mk.VaultAddress == address && mk.EnginePath == transitPath && mk.KeyName == keyName
+	})
+}
+
+// hasAgeRecipient reports whether group has an age entry for recipient.
+func hasAgeRecipient(group sops.KeyGroup, recipient string) bool {
+	return slices.ContainsFunc(group, func(k keys.MasterKey) bool {
+		mk, ok := k.(*age.MasterKey)
+		return ok && mk.Recipient == recipient
+	})
+}
@@ -0,0 +1,234 @@
+package sopsencrypt_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+
+	"terraform-provider-sops/internal/sopsencrypt"
+)
+
+func TestUpdateMasterKeys(t *testing.T) {
+	srv := mockVaultServer(t)
+	defer srv.Close()
+	client := newTestClient(t, srv)
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatalf("GenerateX25519Identity: %v", err)
+	}
+
+	document, err := sopsencrypt.EncryptToJSON(client, "transit", "app", `{"password":"hunter2"}`, sopsencrypt.EncryptOpts{})
+	if err != nil {
+		t.Fatalf("EncryptToJSON: %v", err)
+	}
+	out, err := sopsencrypt.UpdateMasterKeys(client, document, sopsencrypt.KeyUpdate{
+		TransitPath:      "transit",
+		AddVaultKeyNames: []string{"app", "ops", "ops"},
+		AddAgeRecipients: []string{identity.Recipient().String()},
+	}, sopsencrypt.EncryptOpts{})
+	if err != nil {
+		t.Fatalf("UpdateMasterKeys: %v", err)
+	}
+
+	type sopsDoc struct {
+		Password string `json:"password"`
+		SOPS     struct {
+			MAC     string `json:"mac"`
+			HCVault []struct {
+				KeyName string `json:"key_name"`
+				Enc     string `json:"enc"`
+			} `json:"hc_vault"`
+			Age []struct {
+				Enc string `json:"enc"`
+			} `json:"age"`
+		} `json:"sops"`
+	}
+	var before, after sopsDoc
+	for doc, v := range map[string]*sopsDoc{document: &before, out: &after} {
+		if err := json.Unmarshal([]byte(doc), v); err != nil {
+			t.Fatalf("invalid JSON: %v\n%s", err, doc)
+		}
+	}
+	if after.Password != before.Password || after.SOPS.MAC != before.SOPS.MAC {
+		t.Error("values or MAC changed")
+	}
+	if len(after.SOPS.HCVault) != 2 || after.SOPS.HCVault[0] != before.SOPS.HCVault[0] || after.SOPS.HCVault[1].KeyName != "ops" {
+		t.Errorf("want the app entry kept and one ops entry added, got %+v", after.SOPS.HCVault)
+	}
+	if len(after.SOPS.Age) != 1 {
+		t.Fatalf("want 1 age entry, got %d", len(after.SOPS.Age))
+	}
+
+	// Every added entry wraps the data key of the document.
+	dataKey, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(before.SOPS.HCVault[0].Enc, "vault:v1:"))
+	if err != nil {
+		t.Fatalf("decoding data key: %v", err)
+	}
+	r, err := age.Decrypt(armor.NewReader(strings.NewReader(after.SOPS.Age[0].Enc)), identity)
+	if err != nil {
+		t.Fatalf("age.Decrypt: %v", err)
+	}
+	ageKey, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("reading data key: %v", err)
+	}
+	if !bytes.Equal(ageKey, dataKey) || after.SOPS.HCVault[1].Enc != before.SOPS.HCVault[0].Enc {
+		t.Error("added master keys do not wrap the data key of the document")
+	}
+	plaintext, err := sopsencrypt.DecryptFromJSON(client, out)
+	if err != nil {
+		t.Fatalf("DecryptFromJSON: %v", err)
+	}
+	var got map[string]string
+	if err := json.Unmarshal([]byte(plaintext), &got); err != nil || got["password"] != "hunter2" {
+		t.Errorf("DecryptFromJSON = %q, %v", plaintext, err)
+	}
+
+	again, err := sopsencrypt.UpdateMasterKeys(client, out, sopsencrypt.KeyUpdate{
+		TransitPath:      "transit",
+		AddVaultKeyNames: []string{"ops"},
+		AddAgeRecipients: []string{identity.Recipient().String()},
+	}, sopsencrypt.EncryptOpts{})
+	if err != nil {
+		t.Fatalf("UpdateMasterKeys: %v", err)
+	}
+	if again != out {
+		t.Error("adding keys the document already has changed it")
+	}
+}
+
+func TestUpdateMasterKeys_YAML(t *testing.T) {
+	srv := mockVaultServer(t)
+	defer srv.Close()
+	client := newTestClient(t, srv)
+
+	document, err := sopsencrypt.EncryptToYAML(client, "transit", "app", `{"password":"hunter2"}`, sopsencrypt.EncryptOpts{})
+	if err != nil {
+		t.Fatalf("EncryptToYAML: %v", err)
+	}
+	out, err := sopsencrypt.UpdateMasterKeys(client, document, sopsencrypt.KeyUpdate{TransitPath: "transit", AddVaultKeyNames: []string{"ops"}}, sopsencrypt.EncryptOpts{})
+	if err != nil {
+		t.Fatalf("UpdateMasterKeys: %v", err)
+	}
+	if !strings.Contains(out, "key_name: ops") {
+		t.Errorf("YAML output lacks the added key:\n%s", out)
+	}
+	if got, err := sopsencrypt.DecryptFromYAML(client, out); err != nil || got != "password: hunter2\n" {
+		t.Errorf("DecryptFromYAML = %q, %v", got, err)
+	}
+}
+
+func TestUpdateMasterKeys_Mock(t *testing.T) {
+	document, err := sopsencrypt.EncryptToJSON(nil, "transit", "app", `{"password":"hunter2"}`, sopsencrypt.EncryptOpts{Mock: true})
+	if err != nil {
+		t.Fatalf("EncryptToJSON: %v", err)
+	}
+	out, err := sopsencrypt.UpdateMasterKeys(nil, document, sopsencrypt.KeyUpdate{TransitPath: "transit", AddVaultKeyNames: []string{"ops"}}, sopsencrypt.EncryptOpts{Mock: true})
+	if err != nil {
+		t.Fatalf("UpdateMasterKeys: %v", err)
+	}
+	if !strings.Contains(out, `"key_name": "ops"`) {
+		t.Errorf("output lacks the added key:\n%s", out)
+	}
+}
+
+func TestUpdateMasterKeys_RemoveRotatesDataKey(t *testing.T) {
+	srv := mockVaultServer(t)
+	defer srv.Close()
+	client := newTestClient(t, srv)
+	recipient := "age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p"
+
+	document, err := sopsencrypt.EncryptToJSON(client, "transit", "app", `{"password":"hunter2"}`,
+		sopsencrypt.EncryptOpts{AgeRecipients: []string{recipient}})
+	if err != nil {
+		t.Fatalf("EncryptToJSON: %v", err)
+	}
+	document, err = sopsencrypt.UpdateMasterKeys(client, document, sopsencrypt.KeyUpdate{TransitPath: "transit", AddVaultKeyNames: []string{"ops"}}, sopsencrypt.EncryptOpts{})
+	if err != nil {
+		t.Fatalf("UpdateMasterKeys: %v", err)
+	}
+	out, err := sopsencrypt.UpdateMasterKeys(client, document, sopsencrypt.KeyUpdate{
+		TransitPath:         "transit",
+		RemoveVaultKeyNames: []string{"ops"},
+		RemoveAgeRecipients: []string{recipient},
+	}, sopsencrypt.EncryptOpts{})
+	if err != nil {
+		t.Fatalf("UpdateMasterKeys: %v", err)
+	}
+
+	type sopsDoc struct {
+		Password string `json:"password"`
+		SOPS     struct {
+			HCVault []struct {
+				KeyName string `json:"key_name"`
+				Enc     string `json:"enc"`
+			} `json:"hc_vault"`
+			Age []interface{} `json:"age"`
+		} `json:"sops"`
+	}
+	var before, after sopsDoc
+	for doc, v := range map[string]*sopsDoc{document: &before, out: &after} {
+		if err := json.Unmarshal([]byte(doc), v); err != nil {
+			t.Fatalf("invalid JSON: %v\n%s", err, doc)
+		}
+	}
+	if len(after.SOPS.Age) != 0 || len(after.SOPS.HCVault) != 1 || after.SOPS.HCVault[0].KeyName != "app" {
+		t.Fatalf("want only the app entry left, got %+v", after.SOPS)
+	}
+	if after.SOPS.HCVault[0].Enc == before.SOPS.HCVault[0].Enc || after.Password == before.Password {
+		t.Error("data key not rotated")
+	}
+	plaintext, err := sopsencrypt.DecryptFromJSON(client, out)
+	if err != nil {
+		t.Fatalf("DecryptFromJSON: %v", err)
+	}
+	var got map[string]string
+	if err := json.Unmarshal([]byte(plaintext), &got); err != nil || got["password"] != "hunter2" {
+		t.Errorf("DecryptFromJSON = %q, %v", plaintext, err)
+	}
+}
+
+func TestUpdateMasterKeys_Errors(t *testing.T) {
+	srv := mockVaultServer(t)
+	defer srv.Close()
+	client := newTestClient(t, srv)
+	document, err := sopsencrypt.EncryptToJSON(client, "transit", "app", `{"password":"hunter2"}`, sopsencrypt.EncryptOpts{})
+	if err != nil {
+		t.Fatalf("EncryptToJSON: %v", err)
+	}
+
+	for _, tc := range []struct {
+		name    string
+		update  sopsencrypt.KeyUpdate
+		wantErr string
+	}{
+		{
+			name:    "unknown Transit key",
+			update:  sopsencrypt.KeyUpdate{TransitPath: "transit", RemoveVaultKeyNames: []string{"ops"}},
+			wantErr: "no hc_vault entry for Transit key transit/keys/ops",
+		},
+		{
+			name:    "unknown age recipient",
+			update:  sopsencrypt.KeyUpdate{RemoveAgeRecipients: []string{"age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p"}},
+			wantErr: "no age entry for recipient",
+		},
+		{
+			name:    "last key",
+			update:  sopsencrypt.KeyUpdate{TransitPath: "transit", RemoveVaultKeyNames: []string{"app"}},
+			wantErr: "without master keys",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := sopsencrypt.UpdateMasterKeys(client, document, tc.update, sopsencrypt.EncryptOpts{})
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("got %v, want error containing %q", err, tc.wantErr)
+			}
+		})
+	}
+}
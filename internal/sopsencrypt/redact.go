@@ -0,0 +1,75 @@
+package sopsencrypt
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Redacted replaces plaintext removed from error messages.
+const Redacted = "(redacted)"
+
+// quotedFragment matches the tokens parsers quote in their errors: yaml.v3
+// uses backticks (cannot unmarshal !!str `secret`), encoding/json and sops
+// Go-quoted strings, HCL and others single quotes.
+var quotedFragment = regexp.MustCompile("`[^`]*`" + `|"(?:[^"\\]|\\.)*"|'[^']*'`)
+
+// minVerbatim is the shortest plaintext Redact replaces wherever it occurs in
+// a message; shorter ones cannot be told apart from the message's own words.
+const minVerbatim = 6
+
+// Redact returns msg with every quoted fragment that occurs in one of the
+// plaintexts replaced by (redacted), as well as any plaintext of at least six
+// bytes that msg contains verbatim, such as a number echoed unquoted.
+// Fragments of a single character, such as the token named by "invalid
+// character ','", are kept: they leak nothing a reader could use and are
+// what makes such an error actionable. Fragments that a parser truncated
+// with "..." are matched on their prefix.
+func Redact(msg string, plaintexts ...string) string {
+	found := func(s string) bool {
+		if len([]rune(s)) < 2 {
+			return false
+		}
+		for _, p := range plaintexts {
+			if strings.Contains(p, s) {
+				return true
+			}
+		}
+		return false
+	}
+	for _, p := range plaintexts {
+		if len(p) >= minVerbatim {
+			msg = strings.ReplaceAll(msg, p, Redacted)
+		}
+	}
+	return quotedFragment.ReplaceAllStringFunc(msg, func(q string) string {
+		inner := q[1 : len(q)-1]
+		if q[0] == '"' {
+			if s, err := strconv.Unquote(q); err == nil {
+				inner = s
+			}
+		}
+		if found(strings.TrimSuffix(inner, "...")) {
+			return q[:1] + Redacted + q[len(q)-1:]
+		}
+		return q
+	})
+}
+
+// RedactError returns err with its message passed through Redact. The result
+// still unwraps to err, so errors.Is and errors.As keep working; only its
+// message, which ends up in diagnostics and logs, is redacted.
+func RedactError(err error, plaintexts ...string) error {
+	if err == nil {
+		return nil
+	}
+	return &redactedError{msg: Redact(err.Error(), plaintexts...), err: err}
+}
+
+type redactedError struct {
+	msg string
+	err error
+}
+
+func (e *redactedError) Error() string { return e.msg }
+func (e *redactedError) Unwrap() error { return e.err }
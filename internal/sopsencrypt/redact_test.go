@@ -0,0 +1,69 @@
+package sopsencrypt_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"terraform-provider-sops/internal/sopsencrypt"
+)
+
+func TestEncrypt_ErrorsNeverContainPlaintext(t *testing.T) {
+	const secret = "hunter2secret"
+	for _, tc := range []struct {
+		name      string
+		content   string
+		inputYAML bool
+	}{
+		{"json bare value", `{"password": hunter2secret}`, false},
+		{"json string document", `"hunter2secret"`, false},
+		{"json number document", `4242424242`, false},
+		{"json truncated", `{"password": "hunter2secret"`, false},
+		{"yaml scalar document", secret, true},
+		{"yaml bad tag", "password: !!int " + secret, true},
+		{"yaml bad indentation", "a: b\n\tpassword: " + secret, true},
+		{"yaml unclosed flow", "{password: " + secret, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := sopsencrypt.EncryptToJSON(nil, "transit", "k", tc.content,
+				sopsencrypt.EncryptOpts{InputYAML: tc.inputYAML, Mock: true})
+			if err == nil {
+				t.Fatal("expected a parse error")
+			}
+			if strings.Contains(err.Error(), "hunter2") || strings.Contains(err.Error(), "4242424242") {
+				t.Errorf("error leaks plaintext: %v", err)
+			}
+		})
+	}
+}
+
+func TestRedact(t *testing.T) {
+	content := `{"password": "hunter2", "note": "it's fine"}`
+	for _, tc := range []struct{ msg, want string }{
+		{"cannot unmarshal !!str `hunter2` into int", "cannot unmarshal !!str `(redacted)` into int"},
+		{"cannot unmarshal !!str `hunte...` into int", "cannot unmarshal !!str `(redacted)` into int"},
+		{`got "hunter2" of type string`, `got "(redacted)" of type string`},
+		{"invalid character ',' after object key", "invalid character ',' after object key"},
+		{`unknown key "other"`, `unknown key "other"`},
+		{"echo: " + content, "echo: (redacted)"},
+	} {
+		if got := sopsencrypt.Redact(tc.msg, content); got != tc.want {
+			t.Errorf("Redact(%q) = %q, want %q", tc.msg, got, tc.want)
+		}
+	}
+}
+
+func TestRedactError_Unwraps(t *testing.T) {
+	base := errors.New("base")
+	err := sopsencrypt.RedactError(fmt.Errorf("value `hunter2`: %w", base), "hunter2")
+	if !errors.Is(err, base) {
+		t.Errorf("redacted error does not unwrap to its cause")
+	}
+	if strings.Contains(err.Error(), "hunter2") {
+		t.Errorf("error leaks plaintext: %v", err)
+	}
+	if sopsencrypt.RedactError(nil, "hunter2") != nil {
+		t.Errorf("RedactError(nil) should be nil")
+	}
+}
@@ -0,0 +1,83 @@
+package sopsencrypt
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"cloud.google.com/go/storage"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// FetchRemote reads the object at source, an s3://<bucket>/<key> or
+// gs://<bucket>/<object> URL such as `sops publish` writes to. Credentials
+// are taken from the environment the way the AWS and Google Cloud CLIs find
+// them. When no AWS region is configured, the bucket's region is looked up.
+func FetchRemote(ctx context.Context, source string) ([]byte, error) {
+	u, err := url.Parse(source)
+	if err != nil {
+		return nil, fmt.Errorf("invalid source URL: %w", err)
+	}
+	bucket, key := u.Host, strings.TrimPrefix(u.Path, "/")
+	if (u.Scheme == "s3" || u.Scheme == "gs") && (bucket == "" || key == "") {
+		return nil, fmt.Errorf("invalid source URL %q: expected %s://<bucket>/<object>", source, u.Scheme)
+	}
+	switch u.Scheme {
+	case "s3":
+		return fetchS3(ctx, bucket, key)
+	case "gs":
+		return fetchGCS(ctx, bucket, key)
+	}
+	return nil, fmt.Errorf("unsupported source URL %q: expected an s3:// or gs:// URL", source)
+}
+
+func fetchS3(ctx context.Context, bucket, key string) ([]byte, error) {
+	cfg, err := awsconfig.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("loading AWS configuration: %w", err)
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+		region, err := manager.GetBucketRegion(ctx, s3.NewFromConfig(cfg), bucket)
+		if err != nil {
+			return nil, fmt.Errorf("looking up region of S3 bucket %s: %w", bucket, err)
+		}
+		cfg.Region = region
+	}
+	out, err := s3.NewFromConfig(cfg).GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reading s3://%s: %w", bucket+"/"+key, err)
+	}
+	defer out.Body.Close()
+	b, err := io.ReadAll(out.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading s3://%s: %w", bucket+"/"+key, err)
+	}
+	return b, nil
+}
+
+func fetchGCS(ctx context.Context, bucket, object string) ([]byte, error) {
+	client, err := storage.NewClient(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("creating Google Cloud Storage client: %w", err)
+	}
+	defer client.Close()
+	r, err := client.Bucket(bucket).Object(object).NewReader(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("reading gs://%s: %w", bucket+"/"+object, err)
+	}
+	defer r.Close()
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("reading gs://%s: %w", bucket+"/"+object, err)
+	}
+	return b, nil
+}
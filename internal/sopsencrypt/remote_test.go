@@ -0,0 +1,39 @@
+package sopsencrypt_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"terraform-provider-sops/internal/sopsencrypt"
+)
+
+func TestFetchRemote_InvalidSource(t *testing.T) {
+	cases := map[string]string{
+		"https://example.com/secrets.enc.yaml": "unsupported source URL",
+		"secrets.enc.yaml":                     "unsupported source URL",
+		"s3://bucket":                          "expected s3://<bucket>/<object>",
+		"gs:///secrets.enc.yaml":               "expected gs://<bucket>/<object>",
+	}
+	for source, want := range cases {
+		_, err := sopsencrypt.FetchRemote(context.Background(), source)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("FetchRemote(%q) error = %v, want it to contain %q", source, err, want)
+		}
+	}
+}
+
+func TestDetectInputTypeForPath(t *testing.T) {
+	cases := []struct{ name, document, want string }{
+		{"s3://b/app.enc.env", "", "dotenv"},
+		{"gs://b/app.INI", "", "ini"},
+		{"s3://b/app.yml", "{}", "yaml"},
+		{"s3://b/app.enc", `{"a":1}`, "json"},
+		{"", "a: 1", "yaml"},
+	}
+	for _, tc := range cases {
+		if got := sopsencrypt.DetectInputTypeForPath(tc.name, tc.document); got != tc.want {
+			t.Errorf("DetectInputTypeForPath(%q) = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package sopsencrypt
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/getsops/sops/v3"
+	sopsjson "github.com/getsops/sops/v3/stores/json"
+	sopsyaml "github.com/getsops/sops/v3/stores/yaml"
+)
+
+// An InputStore parses plaintext content into the branches of a sops tree.
+type InputStore interface {
+	LoadPlain(content string) (sops.TreeBranches, error)
+}
+
+// An OutputStore serialises an encrypted sops tree as a SOPS document.
+type OutputStore interface {
+	EmitEncrypted(tree sops.Tree) (string, error)
+}
+
+// inputStores and outputStores are the document formats, by name, each
+// configured from the options of an encryption. A format added here is
+// available to every key source through Encrypt.
+var (
+	inputStores = map[string]func(EncryptOpts) InputStore{
+		"json": func(EncryptOpts) InputStore { return jsonInput{} },
+		"yaml": func(EncryptOpts) InputStore { return yamlInput{} },
+	}
+	outputStores = map[string]func(EncryptOpts) OutputStore{
+		"json": func(opts EncryptOpts) OutputStore {
+			return jsonOutput{pretty: opts.PrettyJSON, lines: opts.lineLayout()}
+		},
+		"yaml": func(opts EncryptOpts) OutputStore {
+			return yamlOutput{indent: opts.YAMLIndent, stringStyle: opts.YAMLStringStyle, header: opts.HeaderComment, lines: opts.lineLayout()}
+		},
+	}
+)
+
+// OutputFormats are the document formats Encrypt can emit, the keys of
+// outputStores.
+var OutputFormats = []string{"json", "yaml"}
+
+// inputStore returns the InputStore of the content format of opts.
+func inputStore(opts EncryptOpts) InputStore {
+	if opts.InputYAML {
+		return inputStores["yaml"](opts)
+	}
+	return inputStores["json"](opts)
+}
+
+// outputStore returns the OutputStore of format, configured from opts.
+func outputStore(format string, opts EncryptOpts) (OutputStore, error) {
+	newStore, ok := outputStores[format]
+	if !ok {
+		return nil, fmt.Errorf("unsupported output format %q; expected one of %s", format, strings.Join(OutputFormats, ", "))
+	}
+	return newStore(opts), nil
+}
+
+// ConvertEncrypted emits an encrypted SOPS JSON or YAML document in format,
+// one of OutputFormats, with the layout options of opts. Nothing is
+// decrypted: values, metadata and MAC are kept, so the result decrypts to
+// the same content. JSON has no place for comments and drops them; a YAML
+// stream of several documents has no JSON form.
+func ConvertEncrypted(document, format string, opts EncryptOpts) (string, error) {
+	store, err := outputStore(format, opts)
+	if err != nil {
+		return "", err
+	}
+	tree, err := loadEncrypted([]byte(document))
+	if err != nil {
+		return "", err
+	}
+	return store.EmitEncrypted(tree)
+}
+
+// jsonInput parses content with the sops JSON store.
+type jsonInput struct{}
+
+func (jsonInput) LoadPlain(content string) (sops.TreeBranches, error) {
+	branches, err := (&sopsjson.Store{}).LoadPlainFile([]byte(content))
+	if err != nil {
+		return nil, fmt.Errorf("parsing content as JSON: %w", err)
+	}
+	return branches, nil
+}
+
+// yamlInput parses content with the sops YAML store, keeping comments and
+// every document of a stream.
+type yamlInput struct{}
+
+func (yamlInput) LoadPlain(content string) (sops.TreeBranches, error) {
+	branches, err := (&sopsyaml.Store{}).LoadPlainFile([]byte(content))
+	if err != nil {
+		return nil, fmt.Errorf("parsing content as YAML: %w", err)
+	}
+	if len(branches) == 0 {
+		return nil, fmt.Errorf("content contains no YAML document")
+	}
+	return branches, nil
+}
+
+// Line ending styles accepted in EncryptOpts.LineEndings.
+const (
+	LineEndingsLF   = "lf"
+	LineEndingsCRLF = "crlf"
+)
+
+// LineEndingStyles are the values accepted in EncryptOpts.LineEndings.
+var LineEndingStyles = []string{LineEndingsLF, LineEndingsCRLF}
+
+// lineLayout is the trailing newline and line ending of emitted documents.
+type lineLayout struct {
+	noTrailingNewline bool
+	endings           string
+}
+
+// lineLayout returns the line layout of opts.
+func (o EncryptOpts) lineLayout() lineLayout {
+	return lineLayout{noTrailingNewline: o.NoTrailingNewline, endings: o.LineEndings}
+}
+
+// apply lays out document, which the stores emit with LF line breaks and a
+// final newline.
+func (l lineLayout) apply(document string) (string, error) {
+	if l.noTrailingNewline {
+		document = strings.TrimSuffix(document, "\n")
+	}
+	switch l.endings {
+	case "", LineEndingsLF:
+	case LineEndingsCRLF:
+		document = strings.ReplaceAll(document, "\n", "\r\n")
+	default:
+		return "", fmt.Errorf("invalid line endings %q: expected one of %s", l.endings, strings.Join(LineEndingStyles, ", "))
+	}
+	return document, nil
+}
+
+// jsonOutput emits SOPS JSON documents with emitJSON.
+type jsonOutput struct {
+	pretty bool
+	lines  lineLayout
+}
+
+func (o jsonOutput) EmitEncrypted(tree sops.Tree) (string, error) {
+	out, err := emitJSON(tree, o.pretty)
+	if err != nil {
+		return "", err
+	}
+	return o.lines.apply(out)
+}
+
+// yamlOutput emits SOPS YAML documents with emitYAML, below header as
+// comment lines.
+type yamlOutput struct {
+	indent      int
+	stringStyle string
+	header      string
+	lines       lineLayout
+}
+
+func (o yamlOutput) EmitEncrypted(tree sops.Tree) (string, error) {
+	out, err := emitYAML(tree, o.indent, o.stringStyle)
+	if err != nil {
+		return "", err
+	}
+	return o.lines.apply(yamlComment(o.header) + out)
+}
@@ -0,0 +1,87 @@
+package sopsencrypt
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/getsops/sops/v3/keyservice"
+	vaultapi "github.com/hashicorp/vault/api"
+)
+
+// TofuKeyProviderHeader is the first line an OpenTofu external key provider
+// writes, announcing the protocol version it speaks.
+const TofuKeyProviderHeader = `{"magic":"OpenTofu-External-Key-Provider","version":1}`
+
+// TofuKeyMeta is what OpenTofu stores next to state encrypted with a key of
+// the external key provider, and passes back to decrypt it.
+type TofuKeyMeta struct {
+	TransitEngine string `json:"transit_engine"`
+	KeyName       string `json:"key_name"`
+	// WrappedKey is the Transit ciphertext of the key, e.g. "vault:v1:…".
+	WrappedKey string `json:"wrapped_key"`
+}
+
+// tofuKeyInput is the input of an external key provider: null, or the
+// metadata of the state to decrypt.
+type tofuKeyInput struct {
+	ExternalData *TofuKeyMeta `json:"external_data"`
+}
+
+// tofuKeyOutput is the output of an external key provider.
+type tofuKeyOutput struct {
+	Keys struct {
+		EncryptionKey []byte `json:"encryption_key"`
+		DecryptionKey []byte `json:"decryption_key,omitempty"`
+	} `json:"keys"`
+	Meta struct {
+		ExternalData TofuKeyMeta `json:"external_data"`
+	} `json:"meta"`
+}
+
+// TofuKeys answers one request of OpenTofu's external key provider protocol
+// read from in, writing TofuKeyProviderHeader and the keys to out. Every
+// request gets a new 32-byte encryption key wrapped with the Transit key
+// keyName, recorded in the metadata. If in holds the metadata of existing
+// state, its key is unwrapped with the Transit key recorded there and
+// returned as the decryption key, so state stays readable after keyName
+// changes.
+func TofuKeys(client *vaultapi.Client, transitPath, keyName string, in io.Reader, out io.Writer) error {
+	if _, err := io.WriteString(out, TofuKeyProviderHeader+"\n"); err != nil {
+		return err
+	}
+	b, err := io.ReadAll(in)
+	if err != nil {
+		return fmt.Errorf("reading key provider input: %w", err)
+	}
+	var input tofuKeyInput
+	if b = bytes.TrimSpace(b); len(b) > 0 && !bytes.Equal(b, []byte("null")) {
+		if err := json.Unmarshal(b, &input); err != nil {
+			return fmt.Errorf("parsing key provider input: %w", err)
+		}
+		if input.ExternalData == nil {
+			return fmt.Errorf("key provider input has no external_data")
+		}
+	}
+
+	var output tofuKeyOutput
+	if meta := input.ExternalData; meta != nil {
+		if meta.TransitEngine == "" || meta.KeyName == "" || meta.WrappedKey == "" {
+			return fmt.Errorf("key provider metadata must record transit_engine, key_name and wrapped_key")
+		}
+		key, err := transitKeyService{client: client}.decrypt(
+			&keyservice.VaultKey{EnginePath: meta.TransitEngine, KeyName: meta.KeyName}, meta.WrappedKey)
+		if err != nil {
+			return fmt.Errorf("unwrapping decryption key: %w", err)
+		}
+		output.Keys.DecryptionKey = key
+	}
+	dataKey, err := NewDataKey(client, transitPath, keyName, "", false)
+	if err != nil {
+		return fmt.Errorf("creating encryption key: %w", err)
+	}
+	output.Keys.EncryptionKey = dataKey.Key
+	output.Meta.ExternalData = TofuKeyMeta{TransitEngine: transitPath, KeyName: keyName, WrappedKey: dataKey.Wrapped}
+	return json.NewEncoder(out).Encode(output)
+}
@@ -0,0 +1,69 @@
+package sopsencrypt_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	vaultapi "github.com/hashicorp/vault/api"
+
+	"terraform-provider-sops/internal/sopsencrypt"
+)
+
+type tofuKeyOutput struct {
+	Keys struct {
+		EncryptionKey []byte `json:"encryption_key"`
+		DecryptionKey []byte `json:"decryption_key"`
+	} `json:"keys"`
+	Meta json.RawMessage `json:"meta"`
+}
+
+// runTofuKeys runs one key provider request and checks the header.
+func runTofuKeys(t *testing.T, client *vaultapi.Client, input string) tofuKeyOutput {
+	t.Helper()
+	var out bytes.Buffer
+	if err := sopsencrypt.TofuKeys(client, "transit", "state-key", strings.NewReader(input), &out); err != nil {
+		t.Fatalf("TofuKeys: %v", err)
+	}
+	r := bufio.NewReader(&out)
+	header, _ := r.ReadString('\n')
+	if header != sopsencrypt.TofuKeyProviderHeader+"\n" {
+		t.Errorf("header = %q", header)
+	}
+	var output tofuKeyOutput
+	if err := json.NewDecoder(r).Decode(&output); err != nil {
+		t.Fatalf("output is not JSON: %v", err)
+	}
+	return output
+}
+
+func TestTofuKeys(t *testing.T) {
+	srv := mockVaultServer(t)
+	defer srv.Close()
+	client := newTestClient(t, srv)
+
+	first := runTofuKeys(t, client, "null\n")
+	if len(first.Keys.EncryptionKey) != 32 {
+		t.Errorf("encryption key has %d bytes, want 32", len(first.Keys.EncryptionKey))
+	}
+	if first.Keys.DecryptionKey != nil {
+		t.Errorf("decryption key without metadata: %x", first.Keys.DecryptionKey)
+	}
+
+	// OpenTofu passes the stored metadata back to decrypt the state.
+	second := runTofuKeys(t, client, string(first.Meta))
+	if !bytes.Equal(second.Keys.DecryptionKey, first.Keys.EncryptionKey) {
+		t.Errorf("decryption key %x, want the first encryption key %x", second.Keys.DecryptionKey, first.Keys.EncryptionKey)
+	}
+	if bytes.Equal(second.Keys.EncryptionKey, first.Keys.EncryptionKey) {
+		t.Error("the encryption key was reused")
+	}
+
+	var out bytes.Buffer
+	err := sopsencrypt.TofuKeys(client, "transit", "state-key", strings.NewReader(`{"external_data":{"key_name":"k"}}`), &out)
+	if err == nil || !strings.Contains(err.Error(), "wrapped_key") {
+		t.Errorf("TofuKeys with incomplete metadata: %v", err)
+	}
+}
@@ -0,0 +1,288 @@
+package sopsencrypt
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/getsops/sops/v3/hcvault"
+	vaultapi "github.com/hashicorp/vault/api"
+)
+
+// ErrTransitKeyNotFound is returned by ReadTransitKey when the key does not
+// exist.
+var ErrTransitKeyNotFound = errors.New("vault transit key not found")
+
+// TransitKeyTypes are the Transit key types that support encryption and can
+// therefore wrap SOPS data keys.
+var TransitKeyTypes = []string{"aes128-gcm96", "aes256-gcm96", "chacha20-poly1305", "rsa-2048", "rsa-3072", "rsa-4096"}
+
+// TransitKey is the metadata Vault Transit reports for a named key.
+type TransitKey struct {
+	Type                 string
+	LatestVersion        int64
+	MinDecryptionVersion int64
+	MinEncryptionVersion int64
+	DeletionAllowed      bool
+	Exportable           bool
+	SupportsEncryption   bool
+	SupportsDecryption   bool
+}
+
+// ReadTransitKey reads the metadata of keyName from the Transit engine
+// mounted at transitPath. No key material is returned by this endpoint.
+func ReadTransitKey(client *vaultapi.Client, transitPath, keyName string) (TransitKey, error) {
+	path := strings.Trim(transitPath, "/") + "/keys/" + keyName
+	secret, err := client.Logical().Read(path)
+	if err != nil {
+		return TransitKey{}, fmt.Errorf("vault transit key read (%s): %w", path, err)
+	}
+	if secret == nil || secret.Data == nil {
+		return TransitKey{}, fmt.Errorf("%w: %q at %s", ErrTransitKeyNotFound, keyName, path)
+	}
+
+	var key TransitKey
+	var ok bool
+	if key.Type, ok = secret.Data["type"].(string); !ok {
+		return TransitKey{}, fmt.Errorf("unexpected vault response: type not a string")
+	}
+	for name, dst := range map[string]*int64{
+		"latest_version":         &key.LatestVersion,
+		"min_decryption_version": &key.MinDecryptionVersion,
+		"min_encryption_version": &key.MinEncryptionVersion,
+	} {
+		// The API client decodes numbers as json.Number.
+		n, ok := secret.Data[name].(json.Number)
+		if !ok {
+			return TransitKey{}, fmt.Errorf("unexpected vault response: %s not a number", name)
+		}
+		if *dst, err = n.Int64(); err != nil {
+			return TransitKey{}, fmt.Errorf("unexpected vault response: %s: %w", name, err)
+		}
+	}
+	key.DeletionAllowed, _ = secret.Data["deletion_allowed"].(bool)
+	key.Exportable, _ = secret.Data["exportable"].(bool)
+	key.SupportsEncryption, _ = secret.Data["supports_encryption"].(bool)
+	key.SupportsDecryption, _ = secret.Data["supports_decryption"].(bool)
+	return key, nil
+}
+
+// EnsureTransitKey creates keyName with the given type on the Transit engine
+// mounted at transitPath unless it already exists. An existing key is left
+// untouched, whatever its type. The key is read first so that a token
+// without create capability works as long as the key exists.
+func EnsureTransitKey(client *vaultapi.Client, transitPath, keyName, keyType string) error {
+	_, err := ReadTransitKey(client, transitPath, keyName)
+	if !errors.Is(err, ErrTransitKeyNotFound) {
+		return err
+	}
+	path := strings.Trim(transitPath, "/") + "/keys/" + keyName
+	if _, err := client.Logical().Write(path, map[string]interface{}{"type": keyType}); err != nil {
+		return fmt.Errorf("vault transit key create (%s): %w", path, err)
+	}
+	return nil
+}
+
+// wrappedKeyVersion matches the key version prefix of a Transit ciphertext.
+var wrappedKeyVersion = regexp.MustCompile(`^vault:v(\d+):`)
+
+// RewrapDataKeys upgrades the Vault Transit wrapped data keys of a SOPS
+// document to the latest version of their Transit key, so older key versions
+// can be trimmed without invalidating the document. docs are serialisations
+// of the same encryption, e.g. its JSON and YAML forms: the first is parsed
+// for the sops metadata and every outdated wrapped key is replaced in all of
+// them. Empty entries are returned as is. The MAC does not cover the
+// metadata, so values and formatting are unaffected.
+//
+// Keys already wrapped with the latest version are left alone; Transit
+// rewrap would otherwise produce a new ciphertext on every call.
+func RewrapDataKeys(client *vaultapi.Client, docs ...string) ([]string, error) {
+	if len(docs) == 0 || docs[0] == "" {
+		return docs, nil
+	}
+	tree, err := loadEncrypted([]byte(docs[0]))
+	if err != nil {
+		return nil, err
+	}
+
+	out := slices.Clone(docs)
+	latest := map[string]int64{}
+	for _, group := range tree.Metadata.KeyGroups {
+		for _, k := range group {
+			mk, ok := k.(*hcvault.MasterKey)
+			if !ok {
+				continue
+			}
+			version, err := keyVersion(mk)
+			if err != nil {
+				return nil, err
+			}
+
+			id := mk.EnginePath + "/keys/" + mk.KeyName
+			if _, ok := latest[id]; !ok {
+				key, err := ReadTransitKey(client, mk.EnginePath, mk.KeyName)
+				if err != nil {
+					return nil, err
+				}
+				latest[id] = key.LatestVersion
+			}
+			if version >= latest[id] {
+				continue
+			}
+
+			path := strings.Trim(mk.EnginePath, "/") + "/rewrap/" + mk.KeyName
+			secret, err := client.Logical().Write(path, map[string]interface{}{"ciphertext": mk.EncryptedKey})
+			if err != nil {
+				return nil, fmt.Errorf("vault transit rewrap (%s): %w", path, err)
+			}
+			if secret == nil {
+				return nil, fmt.Errorf("unexpected vault response: empty rewrap response")
+			}
+			ct, ok := secret.Data["ciphertext"].(string)
+			if !ok {
+				return nil, fmt.Errorf("unexpected vault response: ciphertext not a string")
+			}
+			for i := range out {
+				out[i] = strings.ReplaceAll(out[i], mk.EncryptedKey, ct)
+			}
+		}
+	}
+	return out, nil
+}
+
+// WrappedKeyVersion returns the version of the Vault Transit key that wrapped
+// the data key of an encrypted document, parsed from the vault:vN: prefix of
+// its first hc_vault entry. ok is false when no Transit key wraps the data
+// key, e.g. for age-only documents.
+func WrappedKeyVersion(document string) (version int64, ok bool, err error) {
+	tree, err := loadEncrypted([]byte(document))
+	if err != nil {
+		return 0, false, err
+	}
+	for _, group := range tree.Metadata.KeyGroups {
+		for _, k := range group {
+			if mk, isVault := k.(*hcvault.MasterKey); isVault {
+				version, err := keyVersion(mk)
+				return version, err == nil, err
+			}
+		}
+	}
+	return 0, false, nil
+}
+
+// A TransitKeyRef names the Vault Transit key recorded in an hc_vault entry
+// of an encrypted document.
+type TransitKeyRef struct {
+	// Address is the Vault server; mock documents record none.
+	Address    string
+	EnginePath string
+	KeyName    string
+}
+
+// WrappingTransitKey returns the Vault Transit key of the first hc_vault
+// entry of an encrypted document, as WrappedKeyVersion reads its version. ok
+// is false when no Transit key wraps the data key.
+func WrappingTransitKey(document string) (key TransitKeyRef, ok bool, err error) {
+	tree, err := loadEncrypted([]byte(document))
+	if err != nil {
+		return TransitKeyRef{}, false, err
+	}
+	for _, group := range tree.Metadata.KeyGroups {
+		for _, k := range group {
+			if mk, isVault := k.(*hcvault.MasterKey); isVault {
+				return TransitKeyRef{Address: mk.VaultAddress, EnginePath: mk.EnginePath, KeyName: mk.KeyName}, true, nil
+			}
+		}
+	}
+	return TransitKeyRef{}, false, nil
+}
+
+// keyVersion parses the key version from the Transit ciphertext of mk.
+func keyVersion(mk *hcvault.MasterKey) (int64, error) {
+	m := wrappedKeyVersion.FindStringSubmatch(mk.EncryptedKey)
+	if m == nil {
+		return 0, fmt.Errorf("unexpected wrapped data key format for %s/keys/%s", mk.EnginePath, mk.KeyName)
+	}
+	return strconv.ParseInt(m[1], 10, 64)
+}
+
+// TransitOpts configures TransitEncrypt.
+type TransitOpts struct {
+	// Context is the key derivation context for Transit keys created with
+	// derived=true. Decrypting requires the same context.
+	Context []byte
+	// CreateKeyType, when set, creates keyName with this type first unless
+	// it exists, as for NewDataKey.
+	CreateKeyType string
+	// Mock skips Vault: client may be nil and the ciphertext is the
+	// placeholder used by mock documents.
+	Mock bool
+}
+
+// TransitEncrypt encrypts plaintext with the Vault Transit key keyName and
+// returns the Transit ciphertext, e.g. "vault:v1:…", without any SOPS
+// envelope, for consumers that call Transit decrypt themselves.
+func TransitEncrypt(client *vaultapi.Client, transitPath, keyName string, plaintext []byte, opts TransitOpts) (string, error) {
+	if opts.Mock {
+		return mockEncryptedKey, nil
+	}
+	if opts.CreateKeyType != "" {
+		if err := EnsureTransitKey(client, transitPath, keyName, opts.CreateKeyType); err != nil {
+			return "", err
+		}
+	}
+	ciphertext, _, err := transitEncrypt(client, transitPath, keyName, plaintext, opts.Context)
+	return ciphertext, err
+}
+
+// TransitDecrypt decrypts a Transit ciphertext such as one returned by
+// TransitEncrypt, e.g. "vault:v1:…", with the Vault Transit key keyName.
+// keyContext is the key derivation context the value was encrypted with, or
+// nil.
+func TransitDecrypt(client *vaultapi.Client, transitPath, keyName, ciphertext string, keyContext []byte) ([]byte, error) {
+	if _, err := TransitCiphertextVersion(ciphertext); err != nil {
+		return nil, err
+	}
+	return transitDecrypt(client, transitPath, keyName, ciphertext, keyContext)
+}
+
+// TransitSign signs input with the Vault Transit signing key keyName, e.g.
+// of type ed25519 or ecdsa-p256, and returns the signature, e.g.
+// "vault:v1:…". It can be checked with the key's verify endpoint or its
+// exported public key. With mock, client may be nil and a placeholder is
+// returned.
+func TransitSign(client *vaultapi.Client, transitPath, keyName string, input []byte, mock bool) (string, error) {
+	if mock {
+		return mockSignature, nil
+	}
+	path := strings.Trim(transitPath, "/") + "/sign/" + keyName
+	secret, err := client.Logical().Write(path, map[string]interface{}{
+		"input": base64.StdEncoding.EncodeToString(input),
+	})
+	if err != nil {
+		return "", fmt.Errorf("vault transit sign (%s): %w", path, err)
+	}
+	if secret == nil {
+		return "", errors.New("unexpected vault response: empty sign response")
+	}
+	signature, ok := secret.Data["signature"].(string)
+	if !ok {
+		return "", errors.New("unexpected vault response: signature not a string")
+	}
+	return signature, nil
+}
+
+// TransitCiphertextVersion returns the key version of a Transit ciphertext,
+// parsed from its vault:vN: prefix.
+func TransitCiphertextVersion(ciphertext string) (int64, error) {
+	m := wrappedKeyVersion.FindStringSubmatch(ciphertext)
+	if m == nil {
+		return 0, fmt.Errorf("not a Vault Transit ciphertext: missing vault:vN: prefix")
+	}
+	return strconv.ParseInt(m[1], 10, 64)
+}
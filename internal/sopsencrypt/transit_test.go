@@ -0,0 +1,331 @@
+package sopsencrypt_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+
+	"terraform-provider-sops/internal/sopsencrypt"
+)
+
+func TestReadTransitKey(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path != "/v1/transit/keys/sops" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors":[]}`)) //nolint:errcheck
+			return
+		}
+		w.Write([]byte(`{"data":{
+			"type":"aes256-gcm96","latest_version":3,"min_decryption_version":2,
+			"min_encryption_version":0,"deletion_allowed":false,"exportable":false,
+			"supports_encryption":true,"supports_decryption":true,
+			"keys":{"1":1700000000,"2":1700000001,"3":1700000002}}}`)) //nolint:errcheck
+	}))
+	defer srv.Close()
+	client := newTestClient(t, srv)
+
+	key, err := sopsencrypt.ReadTransitKey(client, "transit/", "sops")
+	if err != nil {
+		t.Fatalf("ReadTransitKey: %v", err)
+	}
+	want := sopsencrypt.TransitKey{
+		Type:                 "aes256-gcm96",
+		LatestVersion:        3,
+		MinDecryptionVersion: 2,
+		SupportsEncryption:   true,
+		SupportsDecryption:   true,
+	}
+	if key != want {
+		t.Errorf("ReadTransitKey = %+v, want %+v", key, want)
+	}
+
+	if _, err := sopsencrypt.ReadTransitKey(client, "transit", "missing"); !errors.Is(err, sopsencrypt.ErrTransitKeyNotFound) {
+		t.Errorf("expected not found error, got %v", err)
+	}
+}
+
+// transitKeysServer fakes the Transit keys and encrypt endpoints. Keys are
+// created by a write to keys/<name>; the created types are recorded in keys.
+func transitKeysServer(t *testing.T, keys map[string]string) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		name := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/v1/transit/keys/") && r.Method == http.MethodGet:
+			if typ, ok := keys[name]; ok {
+				w.Write([]byte(`{"data":{"type":"` + typ + `","latest_version":1,"min_decryption_version":1,"min_encryption_version":0}}`)) //nolint:errcheck
+				return
+			}
+		case strings.HasPrefix(r.URL.Path, "/v1/transit/keys/"):
+			var req struct {
+				Type string `json:"type"`
+			}
+			body, _ := io.ReadAll(r.Body)
+			if err := json.Unmarshal(body, &req); err != nil {
+				t.Errorf("create request: %v", err)
+			}
+			keys[name] = req.Type
+			w.WriteHeader(http.StatusNoContent)
+			return
+		case strings.HasPrefix(r.URL.Path, "/v1/transit/encrypt/"):
+			if _, ok := keys[name]; ok {
+				w.Write([]byte(`{"data":{"ciphertext":"vault:v1:wrapped"}}`)) //nolint:errcheck
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"errors":[]}`)) //nolint:errcheck
+	}))
+}
+
+func TestEnsureTransitKey(t *testing.T) {
+	keys := map[string]string{"existing": "rsa-4096"}
+	srv := transitKeysServer(t, keys)
+	defer srv.Close()
+	client := newTestClient(t, srv)
+
+	if err := sopsencrypt.EnsureTransitKey(client, "transit", "existing", "aes256-gcm96"); err != nil {
+		t.Fatalf("EnsureTransitKey(existing): %v", err)
+	}
+	if keys["existing"] != "rsa-4096" {
+		t.Errorf("existing key changed to %q", keys["existing"])
+	}
+
+	if err := sopsencrypt.EnsureTransitKey(client, "transit", "new", "chacha20-poly1305"); err != nil {
+		t.Fatalf("EnsureTransitKey(new): %v", err)
+	}
+	if keys["new"] != "chacha20-poly1305" {
+		t.Errorf("new key type = %q, want chacha20-poly1305", keys["new"])
+	}
+}
+
+func TestEncryptToJSON_CreateKeyType(t *testing.T) {
+	keys := map[string]string{}
+	srv := transitKeysServer(t, keys)
+	defer srv.Close()
+	client := newTestClient(t, srv)
+
+	if _, err := sopsencrypt.EncryptToJSON(client, "transit", "app", `{"a":"b"}`, sopsencrypt.EncryptOpts{}); err == nil {
+		t.Fatal("expected an error for a missing key without CreateKeyType")
+	}
+	if _, err := sopsencrypt.EncryptToJSON(client, "transit", "app", `{"a":"b"}`, sopsencrypt.EncryptOpts{CreateKeyType: "aes256-gcm96"}); err != nil {
+		t.Fatalf("EncryptToJSON: %v", err)
+	}
+	if keys["app"] != "aes256-gcm96" {
+		t.Errorf("key type = %q, want aes256-gcm96", keys["app"])
+	}
+}
+
+func TestRewrapDataKeys(t *testing.T) {
+	var rewraps int
+	latest := 1
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v1/transit/keys/app":
+			fmt.Fprintf(w, `{"data":{"type":"aes256-gcm96","latest_version":%d,"min_decryption_version":1,"min_encryption_version":0}}`, latest)
+		case "/v1/transit/encrypt/app":
+			w.Write([]byte(`{"data":{"ciphertext":"vault:v1:wrapped"}}`)) //nolint:errcheck
+		case "/v1/transit/rewrap/app":
+			rewraps++
+			fmt.Fprintf(w, `{"data":{"ciphertext":"vault:v%d:rewrapped"}}`, latest)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors":[]}`)) //nolint:errcheck
+		}
+	}))
+	defer srv.Close()
+	client := newTestClient(t, srv)
+
+	jsonOut, yamlOut, err := sopsencrypt.EncryptToJSONAndYAML(client, "transit", "app", `{"password":"s3cret"}`, sopsencrypt.EncryptOpts{PrettyJSON: true})
+	if err != nil {
+		t.Fatalf("EncryptToJSONAndYAML: %v", err)
+	}
+
+	// Already wrapped with the latest version: nothing to do.
+	docs, err := sopsencrypt.RewrapDataKeys(client, jsonOut, yamlOut)
+	if err != nil {
+		t.Fatalf("RewrapDataKeys: %v", err)
+	}
+	if rewraps != 0 || docs[0] != jsonOut || docs[1] != yamlOut {
+		t.Errorf("expected documents unchanged without rotation, got %d rewraps", rewraps)
+	}
+
+	latest = 2
+	docs, err = sopsencrypt.RewrapDataKeys(client, jsonOut, yamlOut, "")
+	if err != nil {
+		t.Fatalf("RewrapDataKeys: %v", err)
+	}
+	if rewraps != 1 {
+		t.Errorf("rewraps = %d, want 1", rewraps)
+	}
+	for i, doc := range docs[:2] {
+		if strings.Contains(doc, "vault:v1:") || !strings.Contains(doc, "vault:v2:rewrapped") {
+			t.Errorf("document %d not rewrapped:\n%s", i, doc)
+		}
+	}
+	if docs[2] != "" {
+		t.Errorf("empty document changed to %q", docs[2])
+	}
+	// Only the wrapped key changes; values, MAC and formatting are kept.
+	if want := strings.ReplaceAll(jsonOut, "vault:v1:wrapped", "vault:v2:rewrapped"); docs[0] != want {
+		t.Errorf("JSON document changed beyond the wrapped key:\n%s", docs[0])
+	}
+}
+
+func TestWrappedKeyVersion(t *testing.T) {
+	srv := mockVaultServer(t)
+	defer srv.Close()
+	client := newTestClient(t, srv)
+
+	doc, err := sopsencrypt.EncryptToJSON(client, "transit", "app", `{"a":"b"}`, sopsencrypt.EncryptOpts{})
+	if err != nil {
+		t.Fatalf("EncryptToJSON: %v", err)
+	}
+	// mockVaultServer wraps with vault:v1:.
+	if version, ok, err := sopsencrypt.WrappedKeyVersion(doc); err != nil || !ok || version != 1 {
+		t.Errorf("WrappedKeyVersion = %d, %v, %v; want 1, true, nil", version, ok, err)
+	}
+
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatalf("GenerateX25519Identity: %v", err)
+	}
+	doc, err = sopsencrypt.EncryptToYAML(nil, "", "", `{"a":"b"}`,
+		sopsencrypt.EncryptOpts{AgeRecipients: []string{identity.Recipient().String()}})
+	if err != nil {
+		t.Fatalf("EncryptToYAML: %v", err)
+	}
+	if _, ok, err := sopsencrypt.WrappedKeyVersion(doc); err != nil || ok {
+		t.Errorf("WrappedKeyVersion(age-only) = %v, %v; want false, nil", ok, err)
+	}
+}
+
+func TestWrappingTransitKey(t *testing.T) {
+	srv := mockVaultServer(t)
+	defer srv.Close()
+	client := newTestClient(t, srv)
+
+	doc, err := sopsencrypt.EncryptToYAML(client, "secrets/transit", "app", `{"a":"b"}`, sopsencrypt.EncryptOpts{})
+	if err != nil {
+		t.Fatalf("EncryptToYAML: %v", err)
+	}
+	want := sopsencrypt.TransitKeyRef{Address: srv.URL, EnginePath: "secrets/transit", KeyName: "app"}
+	if key, ok, err := sopsencrypt.WrappingTransitKey(doc); err != nil || !ok || key != want {
+		t.Errorf("WrappingTransitKey = %+v, %v, %v; want %+v, true, nil", key, ok, err, want)
+	}
+	if _, _, err := sopsencrypt.WrappingTransitKey(`{"a":"b"}`); err == nil {
+		t.Error("WrappingTransitKey of a plaintext document succeeded, want an error")
+	}
+}
+
+func TestTransitEncrypt(t *testing.T) {
+	var contexts []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Plaintext string `json:"plaintext"`
+			Context   string `json:"context"`
+		}
+		json.NewDecoder(r.Body).Decode(&req) //nolint:errcheck
+		contexts = append(contexts, req.Context)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{ //nolint:errcheck
+			"data": map[string]interface{}{"ciphertext": "vault:v3:" + req.Plaintext},
+		})
+	}))
+	defer srv.Close()
+	client := newTestClient(t, srv)
+
+	ct, err := sopsencrypt.TransitEncrypt(client, "transit", "app", []byte("s3cret"), sopsencrypt.TransitOpts{})
+	if err != nil {
+		t.Fatalf("TransitEncrypt: %v", err)
+	}
+	if want := "vault:v3:" + base64.StdEncoding.EncodeToString([]byte("s3cret")); ct != want {
+		t.Errorf("ciphertext = %q, want %q", ct, want)
+	}
+	if version, err := sopsencrypt.TransitCiphertextVersion(ct); err != nil || version != 3 {
+		t.Errorf("TransitCiphertextVersion = %d, %v; want 3", version, err)
+	}
+
+	if _, err := sopsencrypt.TransitEncrypt(client, "transit", "app", []byte("s3cret"), sopsencrypt.TransitOpts{Context: []byte("tenant-1")}); err != nil {
+		t.Fatalf("TransitEncrypt with context: %v", err)
+	}
+	if want := []string{"", base64.StdEncoding.EncodeToString([]byte("tenant-1"))}; !slices.Equal(contexts, want) {
+		t.Errorf("contexts = %q, want %q", contexts, want)
+	}
+
+	mock, err := sopsencrypt.TransitEncrypt(nil, "transit", "app", []byte("s3cret"), sopsencrypt.TransitOpts{Mock: true})
+	if err != nil || mock != "vault:v0:mock" {
+		t.Errorf("mock TransitEncrypt = %q, %v", mock, err)
+	}
+	if _, err := sopsencrypt.TransitCiphertextVersion("s3cret"); err == nil {
+		t.Error("TransitCiphertextVersion: expected an error without a vault:vN: prefix")
+	}
+}
+
+func TestTransitDecrypt(t *testing.T) {
+	srv := mockVaultServer(t)
+	defer srv.Close()
+	client := newTestClient(t, srv)
+
+	ct, err := sopsencrypt.TransitEncrypt(client, "transit", "app", []byte("s3cret"), sopsencrypt.TransitOpts{})
+	if err != nil {
+		t.Fatalf("TransitEncrypt: %v", err)
+	}
+	plaintext, err := sopsencrypt.TransitDecrypt(client, "transit", "app", ct, nil)
+	if err != nil {
+		t.Fatalf("TransitDecrypt: %v", err)
+	}
+	if string(plaintext) != "s3cret" {
+		t.Errorf("plaintext = %q, want s3cret", plaintext)
+	}
+	if _, err := sopsencrypt.TransitDecrypt(client, "transit", "app", "s3cret", nil); err == nil {
+		t.Error("expected an error for a value without a vault:vN: prefix")
+	}
+}
+
+func TestTransitSign(t *testing.T) {
+	var gotPath, gotInput string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Input string `json:"input"`
+		}
+		json.NewDecoder(r.Body).Decode(&req) //nolint:errcheck
+		gotPath, gotInput = r.URL.Path, req.Input
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{ //nolint:errcheck
+			"data": map[string]interface{}{"signature": "vault:v2:c2lnbmF0dXJl"},
+		})
+	}))
+	defer srv.Close()
+	client := newTestClient(t, srv)
+
+	sig, err := sopsencrypt.TransitSign(client, "transit/", "gitops-signer", []byte("ciphertext"), false)
+	if err != nil {
+		t.Fatalf("TransitSign: %v", err)
+	}
+	if sig != "vault:v2:c2lnbmF0dXJl" {
+		t.Errorf("signature = %q", sig)
+	}
+	if gotPath != "/v1/transit/sign/gitops-signer" {
+		t.Errorf("path = %q, want /v1/transit/sign/gitops-signer", gotPath)
+	}
+	if want := base64.StdEncoding.EncodeToString([]byte("ciphertext")); gotInput != want {
+		t.Errorf("input = %q, want %q", gotInput, want)
+	}
+
+	if mock, err := sopsencrypt.TransitSign(nil, "transit", "gitops-signer", []byte("ciphertext"), true); err != nil || mock != "vault:v0:mock-signature" {
+		t.Errorf("mock TransitSign = %q, %v", mock, err)
+	}
+}
@@ -0,0 +1,310 @@
+package sopsencrypt
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/getsops/sops/v3"
+	vaultapi "github.com/hashicorp/vault/api"
+)
+
+// Validation is the outcome of Validate. Each check has a result and, when
+// it fails, a reason; reasons never contain document values.
+type Validation struct {
+	// Decryptable reports whether the data key could be unwrapped with Vault
+	// Transit.
+	Decryptable       bool
+	DecryptableReason string
+	// MACValid reports whether the decrypted values match the MAC. It is
+	// false when the data key could not be unwrapped.
+	MACValid  bool
+	MACReason string
+	// ScopeConsistent reports whether at most one scope option is recorded
+	// and every value is encrypted exactly when that option selects it.
+	ScopeConsistent bool
+	ScopeReason     string
+}
+
+// Valid reports whether every check passed.
+func (v Validation) Valid() bool {
+	return v.Decryptable && v.MACValid && v.ScopeConsistent
+}
+
+// Reasons returns the reasons of the failed checks, in check order.
+func (v Validation) Reasons() []string {
+	var reasons []string
+	for _, r := range []string{v.DecryptableReason, v.MACReason, v.ScopeReason} {
+		if r != "" {
+			reasons = append(reasons, r)
+		}
+	}
+	return reasons
+}
+
+// Validate checks a SOPS document of the given input type without returning
+// any plaintext: that its data key can be unwrapped with Vault Transit
+// through client, that its MAC matches its values, and that its scope
+// metadata agrees with which values are encrypted. A nil client fails the
+// decryptability and MAC checks but still checks the scope. An error is
+// returned only when the document cannot be parsed.
+func Validate(client *vaultapi.Client, document, inputType string) (Validation, error) {
+	store, err := storeFor(inputType)
+	if err != nil {
+		return Validation{}, err
+	}
+	tree, err := store.LoadEncryptedFile([]byte(document))
+	if err != nil {
+		return Validation{}, fmt.Errorf("parsing SOPS %s document: %w", inputType, err)
+	}
+
+	var v Validation
+	if err := checkScope(tree); err != nil {
+		v.ScopeReason = err.Error()
+	} else {
+		v.ScopeConsistent = true
+	}
+
+	dataKey, err := unwrapDataKey(client, tree)
+	if err != nil {
+		v.DecryptableReason = err.Error()
+		v.MACReason = "MAC not verified: the data key could not be unwrapped"
+		return v, nil
+	}
+	v.Decryptable = true
+	if err := decryptTree(&tree, dataKey); err != nil {
+		v.MACReason = err.Error()
+	} else {
+		v.MACValid = true
+	}
+	return v, nil
+}
+
+// CheckConsistent checks, without a key, that documents are SOPS JSON or
+// YAML documents from a single encryption, such as the serialisations
+// returned by EncryptToJSONAndYAML: that each parses and that all share the
+// MAC, lastmodified timestamp and values of the first. Empty documents are
+// skipped. Rewrapping the data key, as RewrapDataKeys does, keeps them
+// consistent.
+func CheckConsistent(documents ...string) error {
+	var first *sops.Tree
+	var firstValues []string
+	for i, document := range documents {
+		if document == "" {
+			continue
+		}
+		tree, err := loadEncrypted([]byte(document))
+		if err != nil {
+			return fmt.Errorf("document %d: %w", i, err)
+		}
+		values := leafValues(tree.Branches)
+		if first == nil {
+			first, firstValues = &tree, values
+			continue
+		}
+		switch {
+		case tree.Metadata.MessageAuthenticationCode != first.Metadata.MessageAuthenticationCode:
+			return fmt.Errorf("document %d: MAC differs from document 0", i)
+		case !tree.Metadata.LastModified.Equal(first.Metadata.LastModified):
+			return fmt.Errorf("document %d: lastmodified differs from document 0", i)
+		case !slices.Equal(values, firstValues):
+			return fmt.Errorf("document %d: values differ from document 0", i)
+		}
+	}
+	return nil
+}
+
+// leafValues returns the leaf values of branches in document order, without
+// comments, formatted so that JSON and YAML serialisations of one tree
+// compare equal.
+func leafValues(branches sops.TreeBranches) []string {
+	var values []string
+	var walk func(value interface{})
+	walk = func(value interface{}) {
+		switch v := value.(type) {
+		case sops.TreeBranch:
+			for _, item := range v {
+				if _, ok := item.Key.(sops.Comment); !ok {
+					walk(item.Value)
+				}
+			}
+		case []interface{}:
+			for _, elem := range v {
+				walk(elem)
+			}
+		case sops.Comment:
+		default:
+			values = append(values, fmt.Sprint(v))
+		}
+	}
+	for _, branch := range branches {
+		walk(branch)
+	}
+	return values
+}
+
+// checkScope verifies that the scope options of tree are usable and that
+// every value is encrypted exactly when sops would encrypt it. Documents
+// scoped by comment regexes are only checked for conflicting options, as
+// their scope depends on comments the stores do not all preserve.
+func checkScope(tree sops.Tree) error {
+	md := tree.Metadata
+	var set []string
+	for _, opt := range []struct{ name, value string }{
+		{"unencrypted_suffix", md.UnencryptedSuffix},
+		{"encrypted_suffix", md.EncryptedSuffix},
+		{"unencrypted_regex", md.UnencryptedRegex},
+		{"encrypted_regex", md.EncryptedRegex},
+		{"unencrypted_comment_regex", md.UnencryptedCommentRegex},
+		{"encrypted_comment_regex", md.EncryptedCommentRegex},
+	} {
+		if opt.value != "" {
+			set = append(set, opt.name)
+		}
+	}
+	if len(set) > 1 {
+		return fmt.Errorf("conflicting scope options %s: at most one may be set", strings.Join(set, ", "))
+	}
+	if md.UnencryptedCommentRegex != "" || md.EncryptedCommentRegex != "" {
+		return nil
+	}
+
+	selects := func([]string) bool { return true }
+	switch {
+	case md.UnencryptedSuffix != "":
+		selects = func(keys []string) bool {
+			return !slices.ContainsFunc(keys, func(k string) bool { return strings.HasSuffix(k, md.UnencryptedSuffix) })
+		}
+	case md.EncryptedSuffix != "":
+		selects = func(keys []string) bool {
+			return slices.ContainsFunc(keys, func(k string) bool { return strings.HasSuffix(k, md.EncryptedSuffix) })
+		}
+	case md.UnencryptedRegex != "":
+		re, err := regexp.Compile(md.UnencryptedRegex)
+		if err != nil {
+			return fmt.Errorf("invalid unencrypted_regex: %w", err)
+		}
+		selects = func(keys []string) bool { return !slices.ContainsFunc(keys, re.MatchString) }
+	case md.EncryptedRegex != "":
+		re, err := regexp.Compile(md.EncryptedRegex)
+		if err != nil {
+			return fmt.Errorf("invalid encrypted_regex: %w", err)
+		}
+		selects = func(keys []string) bool { return slices.ContainsFunc(keys, re.MatchString) }
+	}
+
+	for _, branch := range tree.Branches {
+		if err := checkScopeValue(branch, nil, "", selects); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkScopeValue walks value, whose map keys from the document root are
+// keys and whose display path is at, and fails on the first leaf whose
+// encryption disagrees with selects.
+func checkScopeValue(value interface{}, keys []string, at string, selects func([]string) bool) error {
+	switch v := value.(type) {
+	case sops.TreeBranch:
+		for _, item := range v {
+			key, ok := item.Key.(string)
+			if !ok {
+				continue
+			}
+			next := key
+			if at != "" {
+				next = at + "." + key
+			}
+			if err := checkScopeValue(item.Value, append(keys[:len(keys):len(keys)], key), next, selects); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for i, elem := range v {
+			if err := checkScopeValue(elem, keys, at+"["+strconv.Itoa(i)+"]", selects); err != nil {
+				return err
+			}
+		}
+	case sops.Comment, nil:
+	default:
+		s, isString := v.(string)
+		if isString && s == "" {
+			return nil
+		}
+		encrypted := isString && strings.HasPrefix(s, "ENC[")
+		if want := selects(keys); encrypted != want {
+			if want {
+				return fmt.Errorf("value at %q is not encrypted although the scope options select it", at)
+			}
+			return fmt.Errorf("value at %q is encrypted although the scope options exclude it", at)
+		}
+	}
+	return nil
+}
+
+// ErrAlreadyEncrypted is wrapped by the errors of CheckPlaintext for content
+// that is already a SOPS document.
+var ErrAlreadyEncrypted = errors.New("the document is already SOPS-encrypted")
+
+// encryptedValueRegex matches a value encrypted by SOPS, such as
+// "ENC[AES256_GCM,data:...,type:str]", including mock encryption.
+var encryptedValueRegex = regexp.MustCompile(`^ENC\[[A-Z0-9_]+,data:`)
+
+// CheckPlaintext fails when content, parsed as Encrypt parses it, is already
+// a SOPS document: it has a top-level "sops" key or holds an encrypted
+// value. Encrypting it again would nest SOPS metadata and ciphertext in a
+// document that decrypts only to the first encryption. Such errors wrap
+// ErrAlreadyEncrypted and name the offending path, never a value.
+func CheckPlaintext(content string, inputYAML bool) error {
+	branches, err := inputStore(EncryptOpts{InputYAML: inputYAML}).LoadPlain(content)
+	if err != nil {
+		return err
+	}
+	for _, branch := range branches {
+		for _, item := range branch {
+			if item.Key == "sops" {
+				return fmt.Errorf(`%w: it has a top-level "sops" key`, ErrAlreadyEncrypted)
+			}
+		}
+		if err := checkPlaintextValue(branch, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkPlaintextValue fails on the first SOPS-encrypted leaf of value, whose
+// display path is at.
+func checkPlaintextValue(value interface{}, at string) error {
+	switch v := value.(type) {
+	case sops.TreeBranch:
+		for _, item := range v {
+			key, ok := item.Key.(string)
+			if !ok {
+				continue
+			}
+			next := key
+			if at != "" {
+				next = at + "." + key
+			}
+			if err := checkPlaintextValue(item.Value, next); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for i, elem := range v {
+			if err := checkPlaintextValue(elem, at+"["+strconv.Itoa(i)+"]"); err != nil {
+				return err
+			}
+		}
+	case string:
+		if encryptedValueRegex.MatchString(v) {
+			return fmt.Errorf("%w: the value at %q is a SOPS ciphertext", ErrAlreadyEncrypted, at)
+		}
+	}
+	return nil
+}
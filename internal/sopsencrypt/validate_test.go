@@ -0,0 +1,163 @@
+package sopsencrypt_test
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+
+	"terraform-provider-sops/internal/sopsencrypt"
+)
+
+func TestValidate(t *testing.T) {
+	srv := mockVaultServer(t)
+	defer srv.Close()
+	client := newTestClient(t, srv)
+
+	doc, err := sopsencrypt.EncryptToJSON(client, "transit", "test-key", `{"a":"x","b":"y"}`,
+		sopsencrypt.EncryptOpts{EncryptedRegex: "^a$"})
+	if err != nil {
+		t.Fatalf("EncryptToJSON: %v", err)
+	}
+	encA := regexp.MustCompile(`"a": ("ENC\[[^"]+")`).FindStringSubmatch(doc)[1]
+
+	cases := []struct {
+		name     string
+		document string
+		client   bool
+		want     sopsencrypt.Validation
+	}{
+		{
+			name:     "valid",
+			document: doc,
+			client:   true,
+			want:     sopsencrypt.Validation{Decryptable: true, MACValid: true, ScopeConsistent: true},
+		},
+		{
+			name:     "tampered",
+			document: strings.Replace(doc, `"b": "y"`, `"b": "z"`, 1),
+			client:   true,
+			want: sopsencrypt.Validation{Decryptable: true, ScopeConsistent: true,
+				MACReason: "MAC mismatch: the document was modified after encryption"},
+		},
+		{
+			name:     "scope",
+			document: strings.Replace(doc, `"b": "y"`, `"b": `+encA, 1),
+			client:   true,
+			want: sopsencrypt.Validation{Decryptable: true,
+				MACReason:   "MAC mismatch: the document was modified after encryption",
+				ScopeReason: `value at "b" is encrypted although the scope options exclude it`},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := sopsencrypt.Validate(client, tc.document, "json")
+			if err != nil {
+				t.Fatalf("Validate: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("Validate = %+v, want %+v", got, tc.want)
+			}
+			if got.Valid() != (len(got.Reasons()) == 0) {
+				t.Errorf("Valid = %v but Reasons = %q", got.Valid(), got.Reasons())
+			}
+		})
+	}
+}
+
+func TestValidate_NoClient(t *testing.T) {
+	doc, err := sopsencrypt.EncryptToJSON(nil, "transit", "k", `{"a":"x"}`, sopsencrypt.EncryptOpts{Mock: true})
+	if err != nil {
+		t.Fatalf("EncryptToJSON: %v", err)
+	}
+
+	got, err := sopsencrypt.Validate(nil, doc, "json")
+	if err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if got.Decryptable || got.MACValid || !got.ScopeConsistent {
+		t.Errorf("Validate = %+v, want only the scope check to pass", got)
+	}
+	if len(got.Reasons()) != 2 {
+		t.Errorf("Reasons = %q, want 2", got.Reasons())
+	}
+}
+
+func TestValidate_NotSOPS(t *testing.T) {
+	if _, err := sopsencrypt.Validate(nil, `{"a":"x"}`, "json"); err == nil {
+		t.Error("Validate succeeded on a plaintext document")
+	}
+}
+
+func TestCheckConsistent(t *testing.T) {
+	srv := mockVaultServer(t)
+	defer srv.Close()
+	client := newTestClient(t, srv)
+
+	jsonDoc, yamlDoc, err := sopsencrypt.EncryptToJSONAndYAML(client, "transit", "test-key",
+		`{"a":"x","n":1,"list":[true,"y"]}`, sopsencrypt.EncryptOpts{UnencryptedSuffix: "_plain"})
+	if err != nil {
+		t.Fatalf("EncryptToJSONAndYAML: %v", err)
+	}
+	if err := sopsencrypt.CheckConsistent(jsonDoc, yamlDoc, ""); err != nil {
+		t.Errorf("serialisations of one encryption: %v", err)
+	}
+
+	other, err := sopsencrypt.EncryptToYAML(client, "transit", "test-key", `{"a":"x","n":1,"list":[true,"y"]}`, sopsencrypt.EncryptOpts{})
+	if err != nil {
+		t.Fatalf("EncryptToYAML: %v", err)
+	}
+	encA := regexp.MustCompile(`(?m)^a: (ENC\[.+\])$`)
+	swapped := encA.ReplaceAllString(yamlDoc, "a: "+encA.FindStringSubmatch(other)[1])
+	for name, docs := range map[string][]string{
+		"other encryption": {jsonDoc, other},
+		"swapped value":    {jsonDoc, swapped},
+		"not sops":         {jsonDoc, `{"a":"x"}`},
+	} {
+		if err := sopsencrypt.CheckConsistent(docs...); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestCheckPlaintext(t *testing.T) {
+	for _, tc := range []struct {
+		name, content string
+		inputYAML     bool
+		wantErr       string
+	}{
+		{name: "plaintext", content: `{"password":"hunter2","note":"ENC is short for encrypted"}`},
+		{
+			name:    "sops block",
+			content: `{"password":"hunter2","sops":{"version":"3.12.1"}}`,
+			wantErr: `top-level "sops" key`,
+		},
+		{
+			name:    "encrypted value",
+			content: `{"db":{"hosts":["a","ENC[AES256_GCM,data:Zm9v,iv:YmFy,tag:YmF6,type:str]"]}}`,
+			wantErr: `value at "db.hosts[1]" is a SOPS ciphertext`,
+		},
+		{
+			name:      "yaml stream",
+			content:   "a: 1\n---\ntoken: ENC[AES256_GCM,data:Zm9v,iv:YmFy,tag:YmF6,type:str]\n",
+			inputYAML: true,
+			wantErr:   `value at "token" is a SOPS ciphertext`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := sopsencrypt.CheckPlaintext(tc.content, tc.inputYAML)
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Fatalf("CheckPlaintext: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, sopsencrypt.ErrAlreadyEncrypted) || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("got %v, want error containing %q", err, tc.wantErr)
+			}
+			if strings.Contains(err.Error(), "Zm9v") {
+				t.Errorf("error contains the ciphertext: %v", err)
+			}
+		})
+	}
+}
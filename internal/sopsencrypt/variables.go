@@ -0,0 +1,45 @@
+package sopsencrypt
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// Variables returns the top-level keys of a plaintext document of the given
+// input type, like the variables of a .tfvars.json file. Values are decoded
+// from their JSON encoding: objects become map[string]any, arrays []any and
+// numbers json.Number. The document must be a single mapping; a
+// multi-document YAML file is an error.
+func Variables(plaintext []byte, inputType string) (map[string]any, error) {
+	store, err := storeFor(inputType)
+	if err != nil {
+		return nil, err
+	}
+	branches, err := store.LoadPlainFile(plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s document: %w", inputType, err)
+	}
+	if len(branches) != 1 {
+		return nil, fmt.Errorf("expected a single document, got %d", len(branches))
+	}
+	vars := make(map[string]any, len(branches[0]))
+	for _, item := range branches[0] {
+		key, ok := item.Key.(string)
+		if !ok {
+			continue
+		}
+		var buf bytes.Buffer
+		if err := encodeJSONValue(&buf, item.Value); err != nil {
+			return nil, fmt.Errorf("encoding value of %q: %w", key, err)
+		}
+		dec := json.NewDecoder(&buf)
+		dec.UseNumber()
+		var v any
+		if err := dec.Decode(&v); err != nil {
+			return nil, fmt.Errorf("encoding value of %q: %w", key, err)
+		}
+		vars[key] = v
+	}
+	return vars, nil
+}
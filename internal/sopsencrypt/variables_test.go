@@ -0,0 +1,54 @@
+package sopsencrypt_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"terraform-provider-sops/internal/sopsencrypt"
+)
+
+func TestVariables(t *testing.T) {
+	cases := []struct {
+		name      string
+		document  string
+		inputType string
+		want      map[string]any
+	}{
+		{
+			name:      "json",
+			document:  `{"db_password":"hunter2","port":5432,"tls":true,"tags":["a",1],"nested":{"x":null}}`,
+			inputType: "json",
+			want: map[string]any{
+				"db_password": "hunter2",
+				"port":        json.Number("5432"),
+				"tls":         true,
+				"tags":        []any{"a", json.Number("1")},
+				"nested":      map[string]any{"x": nil},
+			},
+		},
+		{
+			name:      "yaml with comments",
+			document:  "# header\nregion: eu-west-1 # inline\nreplicas: 3\n",
+			inputType: "yaml",
+			want:      map[string]any{"region": "eu-west-1", "replicas": json.Number("3")},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := sopsencrypt.Variables([]byte(tc.document), tc.inputType)
+			if err != nil {
+				t.Fatalf("Variables: %v", err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got %#v, want %#v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestVariablesMultiDocument(t *testing.T) {
+	if _, err := sopsencrypt.Variables([]byte("a: 1\n---\nb: 2\n"), "yaml"); err == nil {
+		t.Fatal("expected an error for a multi-document YAML file")
+	}
+}
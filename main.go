@@ -3,19 +3,39 @@ package main
 import (
 	"context"
 	"flag"
+	"fmt"
 	"log"
+	"os"
 
 	"github.com/hashicorp/terraform-plugin-framework/providerserver"
 	"terraform-provider-sops/internal/provider"
+	"terraform-provider-sops/internal/sopsencrypt"
 )
 
 var version = "dev"
 
 func main() {
-	var debug bool
+	var (
+		debug         bool
+		tofuKeys      bool
+		keyName       string
+		transitEngine string
+	)
 	flag.BoolVar(&debug, "debug", false, "run the provider with support for debuggers like delve")
+	flag.BoolVar(&tofuKeys, "tofu-key-provider", false, "act as an OpenTofu external key provider for state encryption, "+
+		"wrapping keys with Vault Transit at VAULT_ADDR using VAULT_TOKEN")
+	flag.StringVar(&keyName, "vault-key-name", "", "Vault Transit key that wraps the state keys, with -tofu-key-provider")
+	flag.StringVar(&transitEngine, "vault-transit-engine", "transit", "Vault Transit mount path, with -tofu-key-provider")
 	flag.Parse()
 
+	if tofuKeys {
+		if err := tofuKeyProvider(keyName, transitEngine); err != nil {
+			fmt.Fprintln(os.Stderr, "terraform-provider-sops:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	opts := providerserver.ServeOpts{
 		Address: "registry.opentofu.org/syoc/sops",
 		Debug:   debug,
@@ -24,3 +44,21 @@ func main() {
 		log.Fatal(err)
 	}
 }
+
+// tofuKeyProvider answers an OpenTofu external key provider request on
+// stdin and stdout. Vault is configured by the environment variables the
+// Vault CLI reads.
+func tofuKeyProvider(keyName, transitEngine string) error {
+	if keyName == "" {
+		return fmt.Errorf("-vault-key-name is required")
+	}
+	address, token := os.Getenv("VAULT_ADDR"), os.Getenv("VAULT_TOKEN")
+	if address == "" || token == "" {
+		return fmt.Errorf("VAULT_ADDR and VAULT_TOKEN must be set")
+	}
+	client, err := sopsencrypt.VaultConfig{Address: address, UserAgent: "terraform-provider-sops/" + version}.NewClient(token)
+	if err != nil {
+		return err
+	}
+	return sopsencrypt.TofuKeys(client, transitEngine, keyName, os.Stdin, os.Stdout)
+}